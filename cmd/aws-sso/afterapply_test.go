@@ -23,6 +23,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
 )
 
 func TestAfterApply(t *testing.T) {
@@ -31,7 +32,9 @@ func TestAfterApply(t *testing.T) {
 		apply    func(*RunContext) error
 		wantAuth CommandAuth
 	}{
+		{"AgentCmd", AgentCmd{}.AfterApply, AUTH_REQUIRED},
 		{"CacheCmd", CacheCmd{}.AfterApply, AUTH_REQUIRED},
+		{"ChainExplainCmd", ChainExplainCmd{}.AfterApply, AUTH_SKIP},
 		{"CompleteCmd", CompleteCmd{}.AfterApply, AUTH_NO_CONFIG},
 		{"ConsoleCmd", ConsoleCmd{}.AfterApply, AUTH_REQUIRED},
 		{"CredentialsCmd", CredentialsCmd{}.AfterApply, AUTH_REQUIRED},
@@ -39,9 +42,11 @@ func TestAfterApply(t *testing.T) {
 		{"EcsAuthCmd", EcsAuthCmd{}.AfterApply, AUTH_SKIP},
 		{"EcsListCmd", EcsListCmd{}.AfterApply, AUTH_SKIP},
 		{"EcsLoadCmd", EcsLoadCmd{}.AfterApply, AUTH_REQUIRED},
+		{"EcsMTLSCmd", EcsMTLSCmd{}.AfterApply, AUTH_SKIP},
 		{"EcsProfileCmd", EcsProfileCmd{}.AfterApply, AUTH_SKIP},
 		{"EcsSSLCmd", EcsSSLCmd{}.AfterApply, AUTH_SKIP},
 		{"EcsUnloadCmd", EcsUnloadCmd{}.AfterApply, AUTH_NO_CONFIG},
+		{"EcsWatchCmd", EcsWatchCmd{}.AfterApply, AUTH_SKIP},
 		{"ExecCmd", ExecCmd{}.AfterApply, AUTH_REQUIRED},
 		{"ListCmd", ListCmd{}.AfterApply, AUTH_SKIP},
 		{"ListSSORolesCmd", ListSSORolesCmd{}.AfterApply, AUTH_SKIP},
@@ -50,6 +55,12 @@ func TestAfterApply(t *testing.T) {
 		{"ProcessCmd", ProcessCmd{}.AfterApply, AUTH_REQUIRED},
 		{"SetupProfilesCmd", SetupProfilesCmd{}.AfterApply, AUTH_REQUIRED},
 		{"SetupWizardCmd", SetupWizardCmd{}.AfterApply, AUTH_SKIP},
+		{"StaticAddCmd", StaticAddCmd{}.AfterApply, AUTH_SKIP},
+		{"StaticDeleteCmd", StaticDeleteCmd{}.AfterApply, AUTH_SKIP},
+		{"StaticListCmd", StaticListCmd{}.AfterApply, AUTH_SKIP},
+		{"StaticRotateCmd", StaticRotateCmd{}.AfterApply, AUTH_SKIP},
+		{"StoreMigrateCmd", StoreMigrateCmd{}.AfterApply, AUTH_SKIP},
+		{"StatusCmd", StatusCmd{}.AfterApply, AUTH_SKIP},
 		{"TagsCmd", TagsCmd{}.AfterApply, AUTH_SKIP},
 		{"TimeCmd", TimeCmd{}.AfterApply, AUTH_SKIP},
 	}
@@ -61,3 +72,31 @@ func TestAfterApply(t *testing.T) {
 		})
 	}
 }
+
+func TestAfterApplyAgent(t *testing.T) {
+	t.Setenv(agent.ENV_AGENT_SOCK, "/tmp/agent.sock")
+
+	cases := []struct {
+		name     string
+		apply    func(*RunContext) error
+		cli      CLI
+		wantAuth CommandAuth
+	}{
+		{"ProcessCmd", ProcessCmd{}.AfterApply, CLI{}, AUTH_NO_CONFIG},
+		{"EvalCmd", EvalCmd{}.AfterApply, CLI{}, AUTH_NO_CONFIG},
+		{"EvalCmdClear", EvalCmd{}.AfterApply, CLI{Eval: EvalCmd{Clear: true}}, AUTH_SKIP},
+		{"ExecCmd", ExecCmd{Profile: "foo"}.AfterApply, CLI{}, AUTH_NO_CONFIG},
+		{"ExecCmdArn", ExecCmd{Arn: "arn:aws:iam::123456789012:role/foo"}.AfterApply, CLI{}, AUTH_NO_CONFIG},
+		{"ExecCmdAccountRole", ExecCmd{AccountId: 123456789012, Role: "foo"}.AfterApply, CLI{}, AUTH_NO_CONFIG},
+		// interactive role selection needs the cache
+		{"ExecCmdPrompt", ExecCmd{}.AfterApply, CLI{}, AUTH_REQUIRED},
+		{"ExecCmdRoleOnly", ExecCmd{Role: "foo"}.AfterApply, CLI{}, AUTH_REQUIRED},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rctx := &RunContext{Cli: &tc.cli}
+			require.NoError(t, tc.apply(rctx))
+			assert.Equal(t, tc.wantAuth, rctx.Auth)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+type AgentCmd struct {
+	Socket string `kong:"help='Path of the Unix socket to listen on',default='${AGENT_SOCK_FILE}',predict='allFiles'"`
+}
+
+// AfterApply agent command requires a valid SSO auth token
+func (a AgentCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_REQUIRED
+	return nil
+}
+
+func (cc *AgentCmd) Run(ctx *RunContext) error {
+	ssoName, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
+
+	sockPath := fileutils.GetHomePath(ctx.Cli.Agent.Socket)
+	s, err := agent.NewAgentServer(sockPath, newAgentProvider(ctx, ssoName))
+	if err != nil {
+		return err
+	}
+
+	// Shut down gracefully when the context is cancelled (handles SIGINT/SIGTERM from main).
+	go func() {
+		<-ctx.Ctx.Done()
+		s.Close()
+	}()
+
+	log.Info("Agent listening", "sso", ssoName, "socket", sockPath)
+	log.Info(fmt.Sprintf("Set %s=%s to use the agent", agent.ENV_AGENT_SOCK, sockPath))
+
+	if err := s.Serve(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// agentProvider looks up credentials on behalf of the aws-sso agent using the
+// Settings and SecureStorage loaded at startup
+type agentProvider struct {
+	ctx     *RunContext
+	ssoName string
+	lock    sync.Mutex
+}
+
+func newAgentProvider(ctx *RunContext, ssoName string) *agentProvider {
+	return &agentProvider{
+		ctx:     ctx,
+		ssoName: ssoName,
+	}
+}
+
+// SSOName implements agent.CredentialsProvider
+func (p *agentProvider) SSOName() string {
+	return p.ssoName
+}
+
+// GetCredentials implements agent.CredentialsProvider.  Requests are serialized
+// because neither our Settings nor the SecureStorage are thread safe.
+func (p *agentProvider) GetCredentials(req agent.CredentialsRequest) (*agent.CredentialsResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	sci := NewSelectCliArgs(req.Arn, req.AccountId, req.RoleName, req.Profile)
+	if err := sci.Update(p.ctx); errors.Is(err, &NoRoleSelectedError{}) {
+		return nil, fmt.Errorf("please specify --arn, --profile or --account and --role")
+	} else if err != nil {
+		return nil, err
+	}
+
+	as := initAwsSSO(p.ctx)
+	arn := credentialsARN(p.ctx.Settings.Cache.GetSSO().Roles, as.Partition(), sci.AccountId, sci.RoleName)
+	saveCache := req.AddHistory
+
+	var creds *storage.RoleCredentials
+	var ok bool
+	if !req.STSRefresh {
+		creds, ok = cachedRoleCredentials(p.ctx, arn)
+	}
+
+	if ok {
+		auditCredentials(p.ctx, newCredentialsEvent(p.ctx, arn, "cache", sci.AccountId, sci.RoleName), creds, nil)
+	} else {
+		// the SSO token is silently renewed via the refresh token if possible
+		if !awsparse.IsUserARN(arn) && !as.ValidAuthToken(p.ctx.Ctx) {
+			return nil, fmt.Errorf("AWS SSO token for %s has expired.  Please run 'aws-sso login' and restart the agent", p.ssoName)
+		}
+
+		var err error
+		creds, err = fetchRoleCredentials(p.ctx, as, arn, false)
+		auditCredentials(p.ctx, newCredentialsEvent(p.ctx, arn, "aws", sci.AccountId, sci.RoleName), creds, err)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get role credentials for %s: %w", arn, err)
+		}
+		saveCache = true // new expiration time
+	}
+
+	if req.AddHistory {
+		p.ctx.Settings.Cache.AddHistory(p.ctx.Settings, arn)
+	}
+
+	if saveCache {
+		if err := p.ctx.Settings.Cache.Save(false); err != nil {
+			log.Warn("Unable to update cache", "error", err.Error())
+		}
+	}
+
+	return newCredentialsResponse(p.ctx, creds), nil
+}
+
+// agentSocket returns the path of the aws-sso agent socket if our commands
+// should get their credentials from the agent
+func agentSocket() string {
+	return os.Getenv(agent.ENV_AGENT_SOCK)
+}
+
+// agentCredentials requests the role creds from the aws-sso agent
+func agentCredentials(ctx *RunContext, req agent.CredentialsRequest) (*agent.CredentialsResponse, error) {
+	req.SSO = ctx.Cli.SSO
+
+	c := agent.NewAgentClient(fileutils.GetHomePath(agentSocket()))
+	resp, err := c.GetCredentials(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Creds.Expired() {
+		return nil, fmt.Errorf("aws-sso agent returned expired credentials for %s", resp.Creds.RoleArn())
+	}
+	return resp, nil
+}
+
+// agentRegion returns the region to use for the role creds returned by the
+// aws-sso agent.  Mirrors Settings.GetDefaultRegion() since the agent can't
+// see our environment.
+func agentRegion(resp *agent.CredentialsResponse, noRegion, overwriteEnv bool) string {
+	if noRegion || (!overwriteEnv && sso.UserManagedRegion()) {
+		return ""
+	}
+	return resp.DefaultRegion
+}
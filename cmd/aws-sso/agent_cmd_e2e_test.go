@@ -0,0 +1,147 @@
+//go:build e2etests
+
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
+)
+
+// startE2EAgent runs an aws-sso agent backed by the given e2eSetup and points
+// AWS_SSO_AGENT_SOCK at it
+func startE2EAgent(t *testing.T, setup *e2eSetup) {
+	t.Helper()
+	sockPath := filepath.Join(setup.TempDir, "agent.sock")
+
+	s, err := agent.NewAgentServer(sockPath, newAgentProvider(newRunContext(setup, AUTH_REQUIRED), setup.SSOName))
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- s.Serve() }()
+	t.Cleanup(func() {
+		s.Close()
+		assert.ErrorIs(t, <-done, http.ErrServerClosed)
+	})
+
+	t.Setenv(agent.ENV_AGENT_SOCK, sockPath)
+}
+
+// newAgentRunContext returns a RunContext like main() builds for AUTH_NO_CONFIG
+func newAgentRunContext() *RunContext {
+	return &RunContext{
+		Cli:  &CLI{},
+		Auth: AUTH_NO_CONFIG,
+		Ctx:  context.Background(),
+	}
+}
+
+// TestE2EAgent verifies that process, eval and exec get their credentials from
+// the agent without loading the config, cache or SecureStore themselves.
+func TestE2EAgent(t *testing.T) {
+	for _, v := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE"} {
+		unsetEnvForTest(t, v)
+	}
+	t.Setenv("SHELL", "/bin/bash")
+
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server) // only queued once; later requests must hit the agent's cache
+	startE2EAgent(t, setup)
+
+	ctx := newAgentRunContext()
+	ctx.Cli.Process = ProcessCmd{
+		AccountId: 123456789012,
+		Role:      "ReadOnly",
+	}
+	output := captureStdout(func() {
+		require.NoError(t, (&ctx.Cli.Process).Run(ctx))
+	})
+
+	var cpo CredentialProcessOutput
+	require.NoError(t, json.Unmarshal([]byte(output), &cpo))
+	assert.Equal(t, "AKIDTEST12345", cpo.AccessKeyId)
+	assert.Equal(t, "SECRETTEST12345", cpo.SecretAccessKey)
+	assert.Equal(t, "TOKENTEST12345", cpo.SessionToken)
+
+	ctx = newAgentRunContext()
+	ctx.Cli.Eval = EvalCmd{
+		Arn: "arn:aws:iam::123456789012:role/ReadOnly",
+	}
+	output = captureStdout(func() {
+		require.NoError(t, (&ctx.Cli.Eval).Run(ctx))
+	})
+	assert.Contains(t, output, `export AWS_ACCESS_KEY_ID="AKIDTEST12345"`)
+	assert.Contains(t, output, `export AWS_SSO="Default"`)
+	assert.Contains(t, output, `export AWS_SSO_PROFILE="123456789012:ReadOnly"`)
+
+	ctx = newAgentRunContext()
+	ctx.Cli.Exec = ExecCmd{
+		Profile: "123456789012:ReadOnly",
+		Cmd:     "/bin/sh",
+		Args:    []string{"-c", "echo KEY=$AWS_ACCESS_KEY_ID"},
+	}
+	output = captureStdout(func() {
+		require.NoError(t, (&ctx.Cli.Exec).Run(ctx))
+	})
+	assert.Contains(t, output, "KEY=AKIDTEST12345")
+
+	// exec updates the history in the agent's cache
+	cache := setup.Settings.Cache.GetSSO()
+	assert.Equal(t, []string{"arn:aws:iam::123456789012:role/ReadOnly"}, cache.History)
+}
+
+// TestE2EAgentErrors verifies that agent errors are reported to the client
+func TestE2EAgentErrors(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	startE2EAgent(t, setup)
+
+	ctx := newAgentRunContext()
+	ctx.Cli.Process = ProcessCmd{Profile: "NoSuchProfile"}
+	err := (&ctx.Cli.Process).Run(ctx)
+	assert.ErrorContains(t, err, "Invalid --profile NoSuchProfile")
+
+	ctx = newAgentRunContext()
+	ctx.Cli.SSO = "Other"
+	ctx.Cli.Process = ProcessCmd{Arn: "arn:aws:iam::123456789012:role/ReadOnly"}
+	err = (&ctx.Cli.Process).Run(ctx)
+	assert.ErrorContains(t, err, "agent is serving the Default AWS SSO instance, not Other")
+
+	ctx = newAgentRunContext()
+	ctx.Cli.Process = ProcessCmd{Role: "ReadOnly"}
+	err = (&ctx.Cli.Process).Run(ctx)
+	assert.ErrorContains(t, err, "please specify --arn or --account and --role")
+
+	t.Setenv(agent.ENV_AGENT_SOCK, filepath.Join(setup.TempDir, "missing.sock"))
+	ctx = newAgentRunContext()
+	ctx.Cli.Process = ProcessCmd{Arn: "arn:aws:iam::123456789012:role/ReadOnly"}
+	err = (&ctx.Cli.Process).Run(ctx)
+	assert.ErrorContains(t, err, "unable to contact aws-sso agent")
+}
@@ -0,0 +1,256 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kong"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/config"
+	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/gotable"
+)
+
+type AuditCmd struct {
+	Role    string `kong:"short='r',help='Only show events for this role name, ARN or profile'"`
+	Command string `kong:"short='c',help='Only show events generated by this aws-sso command (ie: exec, ecs server)'"`
+	Action  string `kong:"short='a',help='Only show events of this type [credentials|ecs-credentials|console|login|logout]'"`
+	Since   string `kong:"short='s',help='Only show events after this time (RFC3339, YYYY-MM-DD or a duration like 24h)'"`
+	Until   string `kong:"short='u',help='Only show events before this time (RFC3339, YYYY-MM-DD or a duration like 1h)'"`
+	Json    bool   `kong:"help='Print the matching events as JSON lines'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (a AuditCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *AuditCmd) Run(ctx *RunContext) error {
+	filter, err := cc.filter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	a := ctx.Settings.Audit
+	logger := audit.NewLogger(auditLogFile(ctx.Settings), a.MaxSize, a.MaxFiles)
+	if !a.Enabled {
+		log.Warn("Audit log is disabled.  Set Audit.Enabled in config.yaml to enable")
+	}
+
+	events, err := logger.Query(filter)
+	if err != nil {
+		return err
+	}
+
+	if cc.Json {
+		enc := json.NewEncoder(os.Stdout)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(events) == 0 {
+		fmt.Printf("No matching audit events in %s\n", logger.File())
+		return nil
+	}
+
+	ts := []gotable.TableStruct{}
+	for _, e := range events {
+		ts = append(ts, newAuditRow(e))
+	}
+	fields := []string{"Time", "Action", "Command", "Pid", "SSO", "Arn", "Profile", "Remote", "Error"}
+	err = gotable.GenerateTable(ts, fields)
+	if err != nil {
+		fmt.Printf("\n")
+	}
+	return err
+}
+
+// filter returns the audit.Filter for our flags
+func (cc *AuditCmd) filter(now time.Time) (audit.Filter, error) {
+	var err error
+	f := audit.Filter{
+		Role:    cc.Role,
+		Command: cc.Command,
+	}
+
+	if cc.Action != "" {
+		if f.Action, err = audit.NewAction(cc.Action); err != nil {
+			return f, err
+		}
+	}
+	if f.Since, err = parseAuditTime(cc.Since, now); err != nil {
+		return f, fmt.Errorf("invalid --since: %w", err)
+	}
+	if f.Until, err = parseAuditTime(cc.Until, now); err != nil {
+		return f, fmt.Errorf("invalid --until: %w", err)
+	}
+	if !f.Since.IsZero() && !f.Until.IsZero() && f.Until.Before(f.Since) {
+		return f, fmt.Errorf("--until must be after --since")
+	}
+	return f, nil
+}
+
+// parseAuditTime parses an RFC3339 time, a YYYY-MM-DD date in local time or a
+// duration before now.  An empty string returns the zero time.
+func parseAuditTime(t string, now time.Time) (time.Time, error) {
+	if t == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(t); err == nil {
+		return now.Add(-d), nil
+	}
+	if ts, err := time.Parse(time.RFC3339, t); err == nil {
+		return ts, nil
+	}
+	if ts, err := time.ParseInLocation(time.DateOnly, t, time.Local); err == nil {
+		return ts, nil
+	}
+	return time.Time{}, fmt.Errorf("unable to parse %s", t)
+}
+
+// auditRow is a row of the `audit` table
+type auditRow struct {
+	Time    string `header:"Time"`
+	Action  string `header:"Action"`
+	Command string `header:"Command"`
+	Pid     int    `header:"PID"`
+	SSO     string `header:"SSO"`
+	Arn     string `header:"ARN"`
+	Profile string `header:"Profile"`
+	Remote  string `header:"Remote"`
+	Error   string `header:"Error"`
+}
+
+func newAuditRow(e audit.Event) auditRow {
+	remote := e.Remote
+	if e.Slot != "" {
+		remote = fmt.Sprintf("%s (%s)", e.Remote, e.Slot)
+	}
+	return auditRow{
+		Time:    e.Time.Local().Format(time.DateTime),
+		Action:  string(e.Action),
+		Command: e.Command,
+		Pid:     e.Pid,
+		SSO:     e.SSO,
+		Arn:     e.Arn,
+		Profile: e.Profile,
+		Remote:  remote,
+		Error:   e.Error,
+	}
+}
+
+// GetHeader is required for GenerateTable()
+func (r auditRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+// auditLogFile returns the path of our audit log
+func auditLogFile(s *sso.Settings) string {
+	if s.Audit.File != "" {
+		return fileutils.GetHomePath(s.Audit.File)
+	}
+	return config.AuditLogFile(true)
+}
+
+// newAuditLogger returns the audit.Logger for the command being run or nil
+// if the audit log is disabled
+func newAuditLogger(s *sso.Settings, kctx *kong.Context) *audit.Logger {
+	if !s.Audit.Enabled {
+		return nil
+	}
+	l := audit.NewLogger(auditLogFile(s), s.Audit.MaxSize, s.Audit.MaxFiles)
+	l.SetCommand(auditCommand(kctx))
+	return l
+}
+
+// auditCommand returns the name of the aws-sso command without any arguments
+func auditCommand(kctx *kong.Context) string {
+	if kctx == nil {
+		return ""
+	}
+	words := []string{}
+	for _, w := range strings.Fields(kctx.Command()) {
+		if !strings.HasPrefix(w, "<") {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// auditEvent records the event in our audit log, if enabled
+func auditEvent(ctx *RunContext, e audit.Event) {
+	if err := ctx.Audit.Log(e); err != nil {
+		log.Warn("Unable to write audit log", "error", err.Error())
+	}
+}
+
+// auditSSOName returns the name of the selected AWS SSO instance for our audit log
+func auditSSOName(ctx *RunContext) string {
+	ssoName, _ := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	return ssoName
+}
+
+// newCredentialsEvent returns the audit.Event for the role credentials of the
+// selected AWS SSO instance retrieved from source
+func newCredentialsEvent(ctx *RunContext, arn, source string, accountId int64, role string) audit.Event {
+	return audit.Event{
+		SSO:       auditSSOName(ctx),
+		Arn:       arn,
+		AccountId: accountId,
+		RoleName:  role,
+		Source:    source,
+	}
+}
+
+// auditCredentials records the result of fetching the role credentials
+// described by e.  The Profile, Expires and Error are filled in for us.
+func auditCredentials(ctx *RunContext, e audit.Event, creds *storage.RoleCredentials, err error) {
+	if ctx.Audit == nil {
+		return
+	}
+
+	e.Action = audit.Credentials
+	if cache, ok := ctx.Settings.Cache.SSO[e.SSO]; ok && cache.Roles != nil {
+		if rFlat, err := cache.Roles.GetRole(e.AccountId, e.RoleName); err == nil {
+			if p, err := rFlat.ProfileName(ctx.Settings); err == nil {
+				e.Profile = p
+			}
+		}
+	}
+	if err != nil {
+		e.Error = err.Error()
+	} else if creds != nil {
+		e.Expires = creds.ExpireString()
+	}
+	auditEvent(ctx, e)
+}
@@ -0,0 +1,107 @@
+//go:build e2etests
+
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
+)
+
+// TestE2EAudit verifies that credentials and logouts are recorded in the audit
+// log and that `aws-sso audit` can query them.
+func TestE2EAudit(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+
+	setup.Settings.Audit = sso.AuditConfig{
+		Enabled: true,
+		File:    filepath.Join(t.TempDir(), "audit.jsonl"),
+	}
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Audit = newAuditLogger(setup.Settings, nil)
+	ctx.Audit.SetCommand("process")
+	ctx.Cli.Process = ProcessCmd{
+		AccountId: 123456789012,
+		Role:      "ReadOnly",
+	}
+
+	// first from AWS, then from the SecureStore
+	for i := 0; i < 2; i++ {
+		captureStdout(func() {
+			require.NoError(t, (&ProcessCmd{}).Run(ctx))
+		})
+	}
+
+	ctx.Audit.SetCommand("logout")
+	require.NoError(t, setup.Store.DeleteCreateTokenResponse(ctx.Ctx, AwsSSO.StoreKey()))
+	assert.Error(t, (&LogoutCmd{}).Run(ctx))
+
+	query := func(cc AuditCmd) []audit.Event {
+		cc.Json = true
+		output := captureStdout(func() {
+			require.NoError(t, cc.Run(ctx))
+		})
+		events := []audit.Event{}
+		for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+			if line == "" {
+				continue
+			}
+			e := audit.Event{}
+			require.NoError(t, json.Unmarshal([]byte(line), &e))
+			events = append(events, e)
+		}
+		return events
+	}
+
+	events := query(AuditCmd{})
+	require.Len(t, events, 3)
+
+	events = query(AuditCmd{Role: "ReadOnly"})
+	require.Len(t, events, 2)
+	assert.Equal(t, "aws", events[0].Source)
+	assert.Equal(t, "cache", events[1].Source)
+	for _, e := range events {
+		assert.Equal(t, audit.Credentials, e.Action)
+		assert.Equal(t, "process", e.Command)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/ReadOnly", e.Arn)
+		assert.Equal(t, int64(123456789012), e.AccountId)
+		assert.NotEmpty(t, e.Profile)
+		assert.NotEmpty(t, e.Expires)
+		assert.Empty(t, e.Error)
+	}
+
+	events = query(AuditCmd{Action: "logout"})
+	require.Len(t, events, 1)
+	assert.Equal(t, "logout", events[0].Command)
+	assert.NotEmpty(t, events[0].Error)
+
+	assert.Empty(t, query(AuditCmd{Command: "console"}))
+	assert.Empty(t, query(AuditCmd{Since: "-1h"})) // in the future
+}
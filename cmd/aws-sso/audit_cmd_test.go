@@ -0,0 +1,114 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/config"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
+)
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+
+	ts, err := parseAuditTime("", now)
+	assert.NoError(t, err)
+	assert.True(t, ts.IsZero())
+
+	ts, err = parseAuditTime("90m", now)
+	assert.NoError(t, err)
+	assert.Equal(t, now.Add(-90*time.Minute), ts)
+
+	ts, err = parseAuditTime("2026-03-14T08:30:00Z", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 14, 8, 30, 0, 0, time.UTC), ts)
+
+	ts, err = parseAuditTime("2026-03-14", now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, 3, 14, 0, 0, 0, 0, time.Local), ts)
+
+	_, err = parseAuditTime("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestAuditCmdFilter(t *testing.T) {
+	now := time.Now()
+	cc := AuditCmd{
+		Role:    "FooBar",
+		Command: "exec",
+		Action:  "ecs-credentials",
+		Since:   "2h",
+		Until:   "1h",
+	}
+	f, err := cc.filter(now)
+	require.NoError(t, err)
+	assert.Equal(t, audit.Filter{
+		Role:    "FooBar",
+		Command: "exec",
+		Action:  audit.EcsCredentials,
+		Since:   now.Add(-2 * time.Hour),
+		Until:   now.Add(-1 * time.Hour),
+	}, f)
+
+	_, err = (&AuditCmd{Action: "foo"}).filter(now)
+	assert.ErrorContains(t, err, "invalid audit action")
+	_, err = (&AuditCmd{Since: "foo"}).filter(now)
+	assert.ErrorContains(t, err, "--since")
+	_, err = (&AuditCmd{Until: "foo"}).filter(now)
+	assert.ErrorContains(t, err, "--until")
+	_, err = (&AuditCmd{Since: "1h", Until: "2h"}).filter(now)
+	assert.ErrorContains(t, err, "must be after")
+}
+
+func TestAuditCommand(t *testing.T) {
+	assert.Equal(t, "", auditCommand(nil))
+
+	ctx := &RunContext{Cli: &CLI{}, Auth: AUTH_UNKNOWN}
+	parseArgsFrom(ctx, []string{"ecs", "server"})
+	assert.Equal(t, "ecs server", auditCommand(ctx.Kctx))
+
+	ctx = &RunContext{Cli: &CLI{}, Auth: AUTH_UNKNOWN}
+	parseArgsFrom(ctx, []string{"exec", "--profile", "foo", "--", "ls", "-l"})
+	assert.Equal(t, "exec", auditCommand(ctx.Kctx))
+}
+
+func TestNewAuditLogger(t *testing.T) {
+	s := &sso.Settings{}
+	assert.Nil(t, newAuditLogger(s, nil))
+	assert.Equal(t, config.AuditLogFile(true), auditLogFile(s))
+
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	s.Audit = sso.AuditConfig{
+		Enabled:  true,
+		File:     file,
+		MaxSize:  1,
+		MaxFiles: 2,
+	}
+	l := newAuditLogger(s, nil)
+	require.NotNil(t, l)
+	assert.Equal(t, file, l.File())
+}
+
+func TestNewAuditRow(t *testing.T) {
+	e := audit.Event{
+		Time:    time.Now(),
+		Action:  audit.EcsCredentials,
+		Command: "ecs server",
+		Pid:     1234,
+		Arn:     "arn:aws:iam::123456789012:role/FooBar",
+		Remote:  "127.0.0.1:5555",
+		Slot:    "default",
+	}
+	row := newAuditRow(e)
+	assert.Equal(t, "ecs-credentials", row.Action)
+	assert.Equal(t, "127.0.0.1:5555 (default)", row.Remote)
+	assert.Equal(t, e.Time.Local().Format(time.DateTime), row.Time)
+
+	header, err := row.GetHeader("Pid")
+	assert.NoError(t, err)
+	assert.Equal(t, "PID", header)
+}
@@ -0,0 +1,165 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
+)
+
+type ChainCmd struct {
+	Explain ChainExplainCmd `kong:"cmd,help='Explain how credentials for a role chain are retrieved'"`
+}
+
+type ChainExplainCmd struct {
+	Profile string `kong:"short='p',required,help='Name of AWS Profile to explain',predictor='profile'"`
+	DryRun  bool   `kong:"help='Print the AWS API calls which would be made'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (c ChainExplainCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *ChainExplainCmd) Run(ctx *RunContext) error {
+	cache := ctx.Settings.Cache.GetSSO()
+	rFlat, err := cache.Roles.GetRoleByProfile(ctx.Cli.Chain.Explain.Profile, ctx.Settings)
+	if err != nil {
+		return err
+	}
+
+	s, err := ctx.Settings.GetSelectedSSO(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
+
+	hops, err := roles.NewChainResolver(s, cache.Roles).Resolve(rFlat.Arn)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Role chain for %s:\n\n", ctx.Cli.Chain.Explain.Profile)
+	if ctx.Cli.Chain.Explain.DryRun {
+		return printChainCalls(os.Stdout, hops)
+	}
+	printChainHops(os.Stdout, hops)
+	return nil
+}
+
+// printChainHops writes each hop of the role chain to w
+func printChainHops(w io.Writer, hops []roles.ChainHop) {
+	for i, hop := range hops {
+		fmt.Fprintf(w, "%d. %s\n", i+1, hop.Arn)
+		fmt.Fprintf(w, "   Credential Source: %s\n", hop.CredentialSource)
+		if hop.Via != "" {
+			fmt.Fprintf(w, "   Via:               %s\n", hop.Via)
+		}
+		if id := hop.ExternalId(); id != "" {
+			fmt.Fprintf(w, "   ExternalId:        %s\n", id)
+		}
+		if id := hop.SourceIdentity(); id != "" {
+			fmt.Fprintf(w, "   SourceIdentity:    %s\n", id)
+		}
+		if hop.Role != nil && hop.Role.MfaSerial != "" {
+			fmt.Fprintf(w, "   MfaSerial:         %s\n", hop.Role.MfaSerial)
+		}
+	}
+}
+
+// printChainCalls writes the AWS API calls needed to retrieve the credentials
+// for the role chain to w without making them
+func printChainCalls(w io.Writer, hops []roles.ChainHop) error {
+	step := 1
+	var prevAccount, prevName string
+
+	for _, hop := range hops {
+		accountId, name, err := awsparse.ParseRoleARN(hop.Arn)
+		if err != nil {
+			return err
+		}
+		aId, err := awsparse.AccountIdToString(accountId)
+		if err != nil {
+			return err
+		}
+
+		switch hop.CredentialSource {
+		case roles.CredentialSourceIAMUser:
+			fmt.Fprintf(w, "   Load StaticCredentials for %s from the SecureStore\n", hop.Arn)
+
+		case roles.CredentialSourceSSO:
+			fmt.Fprintf(w, "%d. sso:GetRoleCredentials\n", step)
+			fmt.Fprintf(w, "   AccountId:         %s\n", aId)
+			fmt.Fprintf(w, "   RoleName:          %s\n", name)
+			step++
+
+		case roles.CredentialSourceAssumeRole:
+			role := hop.Role
+			if role == nil {
+				role = &ssoconfig.SSORole{}
+			}
+			sessionName, err := role.GetRoleSessionName(ssoconfig.RoleSessionNameData{
+				AccountId:       prevAccount,
+				RoleName:        prevName,
+				TargetAccountId: aId,
+				TargetRoleName:  name,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(w, "%d. sts:AssumeRole using the credentials for %s\n", step, hop.Via)
+			fmt.Fprintf(w, "   RoleArn:           %s\n", hop.Arn)
+			fmt.Fprintf(w, "   RoleSessionName:   %s\n", sessionName)
+			if role.Duration > 0 {
+				fmt.Fprintf(w, "   DurationSeconds:   %d\n", role.Duration*60)
+			}
+			if role.ExternalId != "" {
+				fmt.Fprintf(w, "   ExternalId:        %s\n", role.ExternalId)
+			}
+			if role.SourceIdentity != "" {
+				fmt.Fprintf(w, "   SourceIdentity:    %s\n", role.SourceIdentity)
+			}
+			if role.MfaSerial != "" {
+				fmt.Fprintf(w, "   SerialNumber:      %s (prompts for TokenCode)\n", role.MfaSerial)
+			}
+			for _, k := range role.GetSessionTagKeys() {
+				fmt.Fprintf(w, "   Tag:               %s=%s\n", k, role.SessionTags[k])
+			}
+			if len(role.TransitiveTagKeys) > 0 {
+				fmt.Fprintf(w, "   TransitiveTagKeys: %s\n", strings.Join(role.TransitiveTagKeys, ", "))
+			}
+			for _, arn := range role.PolicyArns {
+				fmt.Fprintf(w, "   PolicyArn:         %s\n", arn)
+			}
+			if role.Policy != "" {
+				fmt.Fprintf(w, "   Policy:            %s\n", role.Policy)
+			}
+			step++
+		}
+		prevAccount, prevName = aId, name
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
+)
+
+func testChainHops() []roles.ChainHop {
+	return []roles.ChainHop{
+		{
+			Arn:              "arn:aws:iam::111111111111:role/Base",
+			CredentialSource: roles.CredentialSourceSSO,
+		},
+		{
+			Arn:              "arn:aws:iam::222222222222:role/Target",
+			CredentialSource: roles.CredentialSourceAssumeRole,
+			Via:              "arn:aws:iam::111111111111:role/Base",
+			Role: &ssoconfig.SSORole{
+				ARN:               "arn:aws:iam::222222222222:role/Target",
+				Via:               "arn:aws:iam::111111111111:role/Base",
+				ExternalId:        "ext-id",
+				SourceIdentity:    "alice",
+				MfaSerial:         "arn:aws:iam::111111111111:mfa/alice",
+				Duration:          60,
+				SessionTags:       map[string]string{"Team": "ops", "Env": "prod"},
+				TransitiveTagKeys: []string{"Team"},
+				PolicyArns:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+			},
+		},
+	}
+}
+
+func TestPrintChainHops(t *testing.T) {
+	buf := &bytes.Buffer{}
+	printChainHops(buf, testChainHops())
+	out := buf.String()
+
+	assert.Contains(t, out, "1. arn:aws:iam::111111111111:role/Base\n   Credential Source: AWS SSO\n2.")
+	assert.Contains(t, out, "2. arn:aws:iam::222222222222:role/Target\n   Credential Source: sts:AssumeRole\n")
+	assert.Contains(t, out, "   Via:               arn:aws:iam::111111111111:role/Base\n")
+	assert.Contains(t, out, "   ExternalId:        ext-id\n")
+	assert.Contains(t, out, "   SourceIdentity:    alice\n")
+	assert.Contains(t, out, "   MfaSerial:         arn:aws:iam::111111111111:mfa/alice\n")
+}
+
+func TestPrintChainCalls(t *testing.T) {
+	buf := &bytes.Buffer{}
+	require.NoError(t, printChainCalls(buf, testChainHops()))
+	out := buf.String()
+
+	assert.Contains(t, out, "1. sso:GetRoleCredentials\n   AccountId:         111111111111\n   RoleName:          Base\n")
+	assert.Contains(t, out, "2. sts:AssumeRole using the credentials for arn:aws:iam::111111111111:role/Base\n")
+	assert.Contains(t, out, "   RoleSessionName:   Base@111111111111\n")
+	assert.Contains(t, out, "   DurationSeconds:   3600\n")
+	assert.Contains(t, out, "   SerialNumber:      arn:aws:iam::111111111111:mfa/alice (prompts for TokenCode)\n")
+	assert.Contains(t, out, "   Tag:               Env=prod\n   Tag:               Team=ops\n")
+	assert.Contains(t, out, "   TransitiveTagKeys: Team\n")
+	assert.Contains(t, out, "   PolicyArn:         arn:aws:iam::aws:policy/ReadOnlyAccess\n")
+
+	// IAM Users don't need an API call
+	hops := testChainHops()
+	hops[0] = roles.ChainHop{
+		Arn:              "arn:aws:iam::111111111111:user/breakglass",
+		CredentialSource: roles.CredentialSourceIAMUser,
+	}
+	hops[1].Via = hops[0].Arn
+	buf.Reset()
+	require.NoError(t, printChainCalls(buf, hops))
+	out = buf.String()
+	assert.NotContains(t, out, "sso:GetRoleCredentials")
+	assert.Contains(t, out, "Load StaticCredentials for arn:aws:iam::111111111111:user/breakglass")
+	assert.Contains(t, out, "1. sts:AssumeRole using the credentials for arn:aws:iam::111111111111:user/breakglass\n")
+	assert.Contains(t, out, "   RoleSessionName:   breakglass@111111111111\n")
+}
@@ -36,8 +36,10 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	// "github.com/davecgh/go-spew/spew"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
 	"github.com/synfinatic/aws-sso-cli/internal/awsendpoint"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
 )
@@ -48,7 +50,7 @@ type ConsoleCmd struct {
 	Prompt     bool   `kong:"short='P',help='Force interactive prompt to select role'"`
 	Region     string `kong:"help='AWS Region',env='AWS_DEFAULT_REGION',predictor='region'"`
 	STSRefresh bool   `kong:"help='Force refresh of STS Token Credentials'"`
-	UrlAction  string `kong:"short='u',help='How to handle URLs [clip|exec|open|print|printurl|granted-containers|open-url-in-container|ansi-osc52] (default: open)',predictor='urlAction'"`
+	UrlAction  string `kong:"short='u',help='How to handle URLs [clip|exec|open|print|printurl|qrcode|granted-containers|open-url-in-container|ansi-osc52] (default: open)',predictor='urlAction'"`
 
 	Arn       string    `kong:"short='a',help='ARN of role to assume',env='AWS_SSO_ROLE_ARN',predictor='arn'"`
 	AccountId AccountID `kong:"name='account',short='A',help='AWS AccountID of role to assume',env='AWS_SSO_ACCOUNT_ID',predictor='accountId'"`
@@ -244,7 +246,7 @@ func openConsole(ctx *RunContext, accountid int64, role string) error {
 		duration = ctx.Cli.Console.Duration
 	}
 
-	ctx.Settings.Cache.AddHistory(ctx.Settings, awsparse.MakeRoleARN(accountid, role))
+	ctx.Settings.Cache.AddHistory(ctx.Settings, roleARN(ctx, accountid, role))
 	if err := ctx.Settings.Cache.Save(false); err != nil {
 		log.Warn("Unable to update cache", "error", err.Error())
 	}
@@ -269,17 +271,17 @@ func openConsoleAccessKey(ctx *RunContext, creds *storage.RoleCredentials,
 	signinUrl := signin.GetUrl(creds.RoleChaining)
 	resp, err := http.Get(signinUrl) // nolint: gosec
 	if err != nil {
-		log.Debug("http get", "url", signinUrl, "error", err.Error())
 		// sanitize error and remove sensitive URL from normal output
 		r := regexp.MustCompile(`Get "[^"]+": `)
 		e := r.ReplaceAllString(err.Error(), "")
+		log.Debug("http get", "url", logger.RedactURL(signinUrl), "error", e)
 		return fmt.Errorf("unable to login to AWS: %s", e)
 	}
 
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		log.Debug("http get", "url", signinUrl, "status", resp.StatusCode, "chaining", creds.RoleChaining)
+		log.Debug("http get", "url", logger.RedactURL(signinUrl), "status", resp.StatusCode, "chaining", creds.RoleChaining)
 		return fmt.Errorf("unable to login to AWS: %s", resp.Status)
 	}
 
@@ -321,12 +323,24 @@ func openConsoleAccessKey(ctx *RunContext, creds *storage.RoleCredentials,
 
 	urlOpener.ContainerSettings(containerParams(ctx, accountId, role))
 
-	return urlOpener.Open()
+	e := audit.Event{
+		Action:    audit.Console,
+		SSO:       auditSSOName(ctx),
+		Arn:       roleARN(ctx, accountId, role),
+		AccountId: accountId,
+		RoleName:  role,
+		Region:    region,
+	}
+	if err = urlOpener.Open(); err != nil {
+		e.Error = err.Error()
+	}
+	auditEvent(ctx, e)
+	return err
 }
 
 // containerParams generates the name, color, icon for the Firefox container plugin
 func containerParams(ctx *RunContext, accountId int64, role string) (string, string, string) {
-	rFlat, _ := ctx.Settings.Cache.GetRole(awsparse.MakeRoleARN(accountId, role))
+	rFlat, _ := ctx.Settings.Cache.GetRole(roleARN(ctx, accountId, role))
 	profile, err := rFlat.ProfileName(ctx.Settings)
 	if err != nil && strings.Contains(profile, "&") {
 		profile = fmt.Sprintf("%d:%s", accountId, role)
@@ -19,15 +19,21 @@ package main
  */
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
-	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs/client"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/gotable"
 )
 
@@ -40,7 +46,7 @@ type EcsLoadCmd struct {
 	STSRefresh bool      `kong:"help='Force refresh of STS Token Credentials'"`
 
 	// Other params
-	Server  string `kong:"help='Endpoint of aws-sso ECS Server',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
+	Server  string `kong:"help='Endpoint of aws-sso ECS Server (host:port or unix:///path/to/socket)',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
 	Slotted bool   `kong:"short='s',help='Load credentials in a unique slot using the ProfileName as the key'"`
 }
 
@@ -64,7 +70,7 @@ func (cc *EcsLoadCmd) Run(ctx *RunContext) error {
 }
 
 type EcsProfileCmd struct {
-	Server string `kong:"help='URL endpoint of aws-sso ECS Server',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
+	Server string `kong:"help='Endpoint of aws-sso ECS Server (host:port or unix:///path/to/socket)',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -111,17 +117,22 @@ func ecsLoadCmd(ctx *RunContext, accountId int64, role string) error {
 	}
 
 	// save history
-	ctx.Settings.Cache.AddHistory(ctx.Settings, awsparse.MakeRoleARN(rFlat.AccountId, rFlat.RoleName))
+	ctx.Settings.Cache.AddHistory(ctx.Settings, roleARN(ctx, rFlat.AccountId, rFlat.RoleName))
 	if err := ctx.Settings.Cache.Save(false); err != nil {
 		log.Warn("Unable to update cache", "error", err.Error())
 	}
 
-	log.Debug("role", "dump", spew.Sdump(rFlat))
-	return c.SubmitCreds(creds, rFlat.Profile, ctx.Cli.Ecs.Load.Slotted)
+	ssoName, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
+
+	log.Debug("role", "dump", logger.Sdump(rFlat))
+	return c.SubmitCreds(creds, rFlat.Profile, ssoName, ctx.Cli.Ecs.Load.Slotted)
 }
 
 type EcsListCmd struct {
-	Server string `kong:"help='Endpoint of aws-sso ECS Server',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
+	Server string `kong:"help='Endpoint of aws-sso ECS Server (host:port or unix:///path/to/socket)',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -147,7 +158,7 @@ func (cc *EcsListCmd) Run(ctx *RunContext) error {
 
 type EcsUnloadCmd struct {
 	Profile string `kong:"short='p',help='Slot of AWS Profile to unload',predictor='profile'"`
-	Server  string `kong:"help='Endpoint of aws-sso ECS Server',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
+	Server  string `kong:"help='Endpoint of aws-sso ECS Server (host:port or unix:///path/to/socket)',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -162,6 +173,83 @@ func (cc *EcsUnloadCmd) Run(ctx *RunContext) error {
 	return c.Delete(ctx.Cli.Ecs.Unload.Profile)
 }
 
+type EcsWatchCmd struct {
+	Server string   `kong:"help='Endpoint of aws-sso ECS Server (host:port or unix:///path/to/socket)',env='AWS_SSO_ECS_SERVER',default='localhost:4144'"`
+	Json   bool     `kong:"help='Print each event as JSON'"`
+	Cmd    string   `kong:"arg,optional,name='command',help='Command to run for each event instead of printing it'"`
+	Args   []string `kong:"arg,optional,passthrough,name='args',help='Associated arguments for the command'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (e EcsWatchCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *EcsWatchCmd) Run(ctx *RunContext) error {
+	c := newClient(ctx.Cli.Ecs.Watch.Server, ctx)
+
+	return c.WatchEvents(ctx.Ctx, func(e ecs.SlotEvent) error {
+		if ctx.Cli.Ecs.Watch.Cmd != "" {
+			// a failing hook shouldn't stop us from watching
+			if err := runEventHook(ctx.Cli.Ecs.Watch.Cmd, ctx.Cli.Ecs.Watch.Args, e); err != nil {
+				log.Error("event hook failed", "event", e.Type, "slot", e.Slot, "error", err.Error())
+			}
+			return nil
+		}
+		return printSlotEvent(os.Stdout, e, ctx.Cli.Ecs.Watch.Json)
+	})
+}
+
+// printSlotEvent writes the event to w as either a line of text or JSON
+func printSlotEvent(w io.Writer, e ecs.SlotEvent, asJson bool) error {
+	if asJson {
+		return json.NewEncoder(w).Encode(e)
+	}
+
+	slot := e.Slot
+	if slot == "" {
+		slot = "(default)"
+	}
+	line := fmt.Sprintf("%s %-9s slot=%s", time.Unix(e.Time, 0).Format(time.DateTime), e.Type, slot)
+	if e.ProfileName != "" {
+		line += fmt.Sprintf(" profile=%s", e.ProfileName)
+	}
+	if e.Expiration > 0 {
+		line += fmt.Sprintf(" expires=%s", time.Unix(e.Expiration, 0).Format(time.DateTime))
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// slotEventEnv returns the environment variables describing the event for our hook command
+func slotEventEnv(e ecs.SlotEvent) []string {
+	return []string{
+		"AWS_SSO_ECS_EVENT=" + string(e.Type),
+		"AWS_SSO_ECS_SLOT=" + e.Slot,
+		"AWS_SSO_ECS_PROFILE=" + e.ProfileName,
+		"AWS_SSO_ECS_ACCOUNT_ID=" + e.AccountId,
+		"AWS_SSO_ECS_ROLE_NAME=" + e.RoleName,
+		"AWS_SSO_ECS_EXPIRATION=" + strconv.FormatInt(e.Expiration, 10),
+	}
+}
+
+// runEventHook runs the command for the event with the event details in the
+// environment and the event JSON on stdin
+func runEventHook(command string, args []string, e ecs.SlotEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(command, args...) // #nosec
+	cmd.Env = append(os.Environ(), slotEventEnv(e)...)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
 func listProfiles(profiles []ecs.ListProfilesResponse) error {
 	// sort our results
 	sort.Slice(profiles, func(i, j int) bool {
@@ -191,5 +279,21 @@ func newClient(server string, ctx *RunContext) *client.ECSClient {
 	if err != nil {
 		log.Fatal("Unable to get ECS bearer token", "error", err)
 	}
-	return client.NewECSClient(server, bearerToken, certChain)
+	c := client.NewECSClient(server, bearerToken, certChain)
+
+	// present our client certificate to servers which require mutual TLS
+	clientCert, err := ctx.Store.GetEcsClientCert()
+	if err != nil {
+		log.Fatal("Unable to get ECS client cert", "error", err.Error())
+	}
+	if clientCert != "" && certChain != "" && !strings.HasPrefix(server, ecs.UNIX_SOCKET_PREFIX) {
+		clientKey, err := ctx.Store.GetEcsClientKey()
+		if err != nil {
+			log.Fatal("Unable to get ECS client key", "error", err.Error())
+		}
+		if err := c.SetClientCert(clientCert, clientKey); err != nil {
+			log.Fatal("Unable to load ECS client cert", "error", err.Error())
+		}
+	}
+	return c
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+func TestPrintSlotEvent(t *testing.T) {
+	now := time.Now()
+	e := ecs.SlotEvent{
+		Type:        ecs.EVENT_EXPIRING,
+		Time:        now.Unix(),
+		Slot:        "000001234567:FooBar",
+		ProfileName: "000001234567:FooBar",
+		AccountId:   "000001234567",
+		RoleName:    "FooBar",
+		Expiration:  now.Add(5 * time.Minute).Unix(),
+	}
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, printSlotEvent(buf, e, false))
+	assert.Equal(t, now.Format(time.DateTime)+" expiring  slot=000001234567:FooBar profile=000001234567:FooBar expires="+
+		now.Add(5*time.Minute).Format(time.DateTime)+"\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printSlotEvent(buf, ecs.SlotEvent{Type: ecs.EVENT_UNLOADED, Time: now.Unix()}, false))
+	assert.Equal(t, now.Format(time.DateTime)+" unloaded  slot=(default)\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, printSlotEvent(buf, e, true))
+	e2 := ecs.SlotEvent{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &e2))
+	assert.Equal(t, e, e2)
+}
+
+func TestSlotEventEnv(t *testing.T) {
+	e := ecs.SlotEvent{
+		Type:        ecs.EVENT_LOADED,
+		Slot:        "FooBar",
+		ProfileName: "000001234567:FooBar",
+		AccountId:   "000001234567",
+		RoleName:    "FooBar",
+		Expiration:  1700000000,
+	}
+	assert.Equal(t, []string{
+		"AWS_SSO_ECS_EVENT=loaded",
+		"AWS_SSO_ECS_SLOT=FooBar",
+		"AWS_SSO_ECS_PROFILE=000001234567:FooBar",
+		"AWS_SSO_ECS_ACCOUNT_ID=000001234567",
+		"AWS_SSO_ECS_ROLE_NAME=FooBar",
+		"AWS_SSO_ECS_EXPIRATION=1700000000",
+	}, slotEventEnv(e))
+}
+
+func TestRunEventHook(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	e := ecs.SlotEvent{Type: ecs.EVENT_REFRESHED, Slot: "FooBar"}
+
+	// event details are in the environment and the JSON is on stdin
+	script := `echo "$AWS_SSO_ECS_EVENT $AWS_SSO_ECS_SLOT" > "$1" && cat >> "$1"`
+	require.NoError(t, runEventHook("/bin/sh", []string{"-c", script, "hook", out}, e))
+
+	data, err := os.ReadFile(out) // #nosec
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "refreshed FooBar\n")
+	assert.Contains(t, string(data), `"Type":"refreshed"`)
+
+	assert.Error(t, runEventHook("/bin/sh", []string{"-c", "exit 1"}, e))
+}
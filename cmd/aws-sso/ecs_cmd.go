@@ -30,6 +30,7 @@ type EcsCmd struct {
 	List    EcsListCmd    `kong:"cmd,help='List profiles loaded in the ECS Server'"`
 	Unload  EcsUnloadCmd  `kong:"cmd,help='Unload the current IAM Role credentials from the ECS Server'"`
 	Profile EcsProfileCmd `kong:"cmd,help='Get the current role profile name in the default slot'"`
+	Watch   EcsWatchCmd   `kong:"cmd,help='Print or run a command for every slot change in the ECS Server'"`
 	// login required commands
 	Load EcsLoadCmd `kong:"cmd,help='Load new IAM Role credentials into the ECS Server',group='login-required'"`
 }
@@ -27,12 +27,20 @@ import (
 	"io"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/awsmock"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs/server"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	"golang.org/x/net/nettest"
 )
 
@@ -64,9 +72,9 @@ func TestE2EEcsServerDefault(t *testing.T) {
 	err = setServerDefaultProfile(ctx, s, "123456789012:ReadOnly")
 	require.NoError(t, err, "setServerDefaultProfile should succeed with a populated cache")
 
-	assert.Equal(t, "123456789012:ReadOnly", s.DefaultCreds.ProfileName,
+	assert.Equal(t, "123456789012:ReadOnly", s.GetDefaultCreds().ProfileName,
 		"default slot profile name should match the requested profile")
-	assert.Equal(t, "AKIDTEST12345", s.DefaultCreds.Creds.AccessKeyId,
+	assert.Equal(t, "AKIDTEST12345", s.GetDefaultCreds().Creds.AccessKeyId,
 		"default slot should hold the queued test access key")
 
 	// Start the HTTP server in the background; Close() shuts it down on cleanup.
@@ -553,3 +561,337 @@ func TestE2EEcsServerRunWithAuth(t *testing.T) {
 	cancel()
 	assert.NoError(t, <-done, "Run() should return nil on context cancellation")
 }
+
+// getEcsCreds does a GET against the ECS server and returns the decoded credentials
+func getEcsCreds(t *testing.T, url string) map[string]string {
+	t.Helper()
+	resp, err := http.Get(url) // nolint:gosec,noctx
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var got map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	return got
+}
+
+// TestE2EEcsServerAutoRefreshViaChain verifies that with auto-refresh enabled the
+// server re-fetches soon to expire default credentials itself:
+//  1. The SSO access token has expired, so it is renewed via the refresh token.
+//  2. TargetRole is fetched via BaseRole (GetRoleCredentials + AssumeRole).
+//  3. Subsequent requests are served from the refreshed credentials.
+func TestE2EEcsServerAutoRefreshViaChain(t *testing.T) {
+	setup := newE2ESetupRoleChain(t)
+	preAuthExpired(t, setup, "seeded-refresh-token")
+
+	setup.Server.SSOOIDC.QueueCreateToken(awsmock.OIDCTokenResponse{
+		AccessToken:  "refreshed-access-token",
+		ExpiresIn:    3600,
+		RefreshToken: "rotated-refresh-token",
+		TokenType:    "Bearer",
+	})
+	setup.Server.SSO.QueueGetRoleCredentials(awsmock.GetRoleCredentialsResponse{
+		RoleCredentials: awsmock.RoleCredentials{
+			AccessKeyID:     "AKID-BASE",
+			SecretAccessKey: "SECRET-BASE",
+			SessionToken:    "TOKEN-BASE",
+			Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+		},
+	})
+	setup.Server.STS.QueueAssumeRole(awsmock.AssumeRoleResult{
+		AccessKeyID:     "AKID-TARGET",
+		SecretAccessKey: "SECRET-TARGET",
+		SessionToken:    "TOKEN-TARGET",
+		Expiration:      time.Now().Add(1 * time.Hour),
+		RoleARN:         "arn:aws:iam::123456789012:role/TargetRole",
+		SessionName:     "BaseRole@123456789012",
+	})
+
+	s, addr := newEcsServerForTest(t)
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	s.EnableRefresh(newEcsCredsFetcher(ctx, &sync.Mutex{}), server.DEFAULT_REFRESH_WINDOW)
+
+	// pushed creds which expire inside of the refresh window
+	s.SetDefaultCreds(&ecs.ECSClientRequest{
+		ProfileName: "123456789012:TargetRole",
+		SSOName:     setup.SSOName,
+		Creds: &storage.RoleCredentials{
+			AccountId:       123456789012,
+			RoleName:        "TargetRole",
+			AccessKeyId:     "AKID-OLD",
+			SecretAccessKey: "SECRET-OLD",
+			SessionToken:    "TOKEN-OLD",
+			Expiration:      time.Now().Add(5 * time.Minute).UnixMilli(),
+		},
+	})
+
+	got := getEcsCreds(t, fmt.Sprintf("http://%s/", addr))
+	assert.Equal(t, "AKID-TARGET", got["AccessKeyId"])
+	assert.Equal(t, "SECRET-TARGET", got["SecretAccessKey"])
+	assert.Equal(t, "TOKEN-TARGET", got["Token"])
+
+	assert.Equal(t, []string{string(storage.GrantTypeRefreshToken)}, setup.Server.SSOOIDC.TokenGrants(),
+		"expired SSO token should be renewed via the refresh token")
+
+	// nothing else is queued, so this must be served from the refreshed creds
+	got = getEcsCreds(t, fmt.Sprintf("http://%s/", addr))
+	assert.Equal(t, "AKID-TARGET", got["AccessKeyId"])
+}
+
+// TestE2EEcsServerAutoRefreshSlotted verifies slotted credentials are refreshed
+// and that fresh credentials are left untouched.
+func TestE2EEcsServerAutoRefreshSlotted(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	queueRoleCredentials(setup.Server)
+
+	s, addr := newEcsServerForTest(t)
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	s.EnableRefresh(newEcsCredsFetcher(ctx, &sync.Mutex{}), 10*time.Minute)
+
+	newSlot := func(profile, role string, expires time.Duration) *ecs.ECSClientRequest {
+		return &ecs.ECSClientRequest{
+			ProfileName: profile,
+			SSOName:     setup.SSOName,
+			Creds: &storage.RoleCredentials{
+				AccountId:       123456789012,
+				RoleName:        role,
+				AccessKeyId:     "AKID-" + role,
+				SecretAccessKey: "SECRET-" + role,
+				SessionToken:    "TOKEN-" + role,
+				Expiration:      time.Now().Add(expires).UnixMilli(),
+			},
+		}
+	}
+	require.NoError(t, s.PutSlottedCreds(newSlot("Stale", "ReadOnly", 5*time.Minute)))
+	require.NoError(t, s.PutSlottedCreds(newSlot("Fresh", "PowerUser", 1*time.Hour)))
+
+	got := getEcsCreds(t, fmt.Sprintf("http://%s/slot/Stale", addr))
+	assert.Equal(t, "AKIDTEST12345", got["AccessKeyId"])
+
+	got = getEcsCreds(t, fmt.Sprintf("http://%s/slot/Fresh", addr))
+	assert.Equal(t, "AKID-PowerUser", got["AccessKeyId"])
+}
+
+// TestE2EEcsServerRunUnixSocket verifies that `ecs server --unix-socket` only
+// listens on an owner-only socket and that `ecs load/list` can talk to it.
+func TestE2EEcsServerRunUnixSocket(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+
+	cctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	path := filepath.Join(t.TempDir(), "ecs.sock")
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Ctx = cctx
+	ctx.Cli.Ecs.Server = EcsServerCmd{UnixSocket: path}
+	cc := &ctx.Cli.Ecs.Server
+
+	done := make(chan error, 1)
+	go func() { done <- cc.Run(ctx) }()
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unix", path)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	addr := ecs.UNIX_SOCKET_PREFIX + path
+	ctx.Cli.Ecs.Load.Server = addr
+	ctx.Cli.Ecs.Load.Slotted = true
+	require.NoError(t, ecsLoadCmd(ctx, 123456789012, "ReadOnly"))
+
+	ctx.Cli.Ecs.List.Server = addr
+	output := captureStdout(func() {
+		assert.NoError(t, (&EcsListCmd{}).Run(ctx))
+	})
+	assert.Contains(t, output, "ReadOnly")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// TestE2EEcsServerRunClientCerts verifies that `ecs server --require-client-cert`
+// rejects clients without a certificate signed by the client CA in the SecureStore.
+func TestE2EEcsServerRunClientCerts(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+
+	readFile := func(name string) []byte {
+		b, err := os.ReadFile("../../internal/ecs/server/testdata/" + name)
+		require.NoError(t, err)
+		return b
+	}
+	bg := context.Background()
+	require.NoError(t, setup.Store.SaveEcsSslKeyPair(bg, readFile("localhost.key"), readFile("localhost.crt")))
+	require.NoError(t, setup.Store.SaveEcsClientCA(bg, readFile("client-ca.crt")))
+
+	cctx, cancel := context.WithCancel(bg)
+	t.Cleanup(cancel)
+
+	port := freePort(t)
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Ctx = cctx
+	ctx.Cli.Ecs.Server = EcsServerCmd{
+		BindIP:      "127.0.0.1",
+		Port:        port,
+		ClientCerts: true,
+	}
+	cc := &ctx.Cli.Ecs.Server
+
+	done := make(chan error, 1)
+	go func() { done <- cc.Run(ctx) }()
+
+	addr := fmt.Sprintf("localhost:%d", port)
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// no client cert in the SecureStore
+	ctx.Cli.Ecs.List.Server = addr
+	assert.Error(t, (&EcsListCmd{}).Run(ctx))
+
+	require.NoError(t, setup.Store.SaveEcsClientKeyPair(bg, readFile("client.key"), readFile("client.crt")))
+	queueRoleCredentials(setup.Server)
+	ctx.Cli.Ecs.Load.Server = addr
+	require.NoError(t, ecsLoadCmd(ctx, 123456789012, "ReadOnly"))
+
+	ctx.Cli.Ecs.Profile.Server = addr
+	output := captureStdout(func() {
+		assert.NoError(t, (&EcsProfileCmd{}).Run(ctx))
+	})
+	assert.Contains(t, output, "ReadOnly")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// TestE2EEcsServerRunMetrics verifies that `ecs server --metrics --metrics-token`
+// protects /metrics with its own token and counts credential requests.
+func TestE2EEcsServerRunMetrics(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+	require.NoError(t, setup.Store.SaveEcsBearerToken(context.Background(), "AuthToken"))
+
+	cctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	port := freePort(t)
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Ctx = cctx
+	ctx.Cli.Ecs.Server = EcsServerCmd{
+		BindIP:       "127.0.0.1",
+		Port:         port,
+		DisableSSL:   true,
+		Default:      "123456789012:ReadOnly",
+		Metrics:      true,
+		MetricsToken: "MetricsToken",
+	}
+	cc := &ctx.Cli.Ecs.Server
+
+	done := make(chan error, 1)
+	go func() { done <- cc.Run(ctx) }()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	require.NoError(t, waitForEcsServerUp("http", addr, "", 5*time.Second))
+
+	get := func(path, token string) (int, string) {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("http://%s%s", addr, path), nil) // nolint:noctx
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	code, _ := get("/", "AuthToken")
+	assert.Equal(t, http.StatusOK, code)
+
+	code, _ = get(server.METRICS_ROUTE, "AuthToken")
+	assert.Equal(t, http.StatusForbidden, code, "credential token must not grant access to metrics")
+
+	code, body := get(server.METRICS_ROUTE, "MetricsToken")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, `aws_sso_ecs_credentials_vended_total{slot="default"} 1`)
+	assert.Contains(t, body, `aws_sso_ecs_auth_failures_total 1`)
+	assert.Contains(t, body, `aws_sso_ecs_credentials_expiry_seconds{slot="default",profile="123456789012:ReadOnly"}`)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+// TestE2EEcsWatch exercises `ecs watch` with a hook command:
+//  1. Start the watch command against a running server.
+//  2. Load credentials into a slot until the hook sees the loaded event.
+//  3. Unload them and verify the hook sees the unloaded event.
+func TestE2EEcsWatch(t *testing.T) {
+	setup := newE2ESetup(t)
+	_, addr := newEcsServerForTest(t)
+
+	cctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	out := filepath.Join(t.TempDir(), "events")
+	ctx := newRunContext(setup, AUTH_SKIP)
+	ctx.Ctx = cctx
+	ctx.Cli.Ecs.Watch = EcsWatchCmd{
+		Server: addr,
+		Cmd:    "/bin/sh",
+		Args:   []string{"-c", `echo "$AWS_SSO_ECS_EVENT $AWS_SSO_ECS_SLOT" >> "$1"`, "hook", out},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- (&EcsWatchCmd{}).Run(ctx) }()
+
+	events := func() string {
+		data, _ := os.ReadFile(out) // #nosec
+		return string(data)
+	}
+
+	c := newClient(addr, ctx)
+	creds := &storage.RoleCredentials{ // nolint:gosec
+		AccountId:       123456789012,
+		RoleName:        "ReadOnly",
+		AccessKeyId:     "AccessKeyId",
+		SecretAccessKey: "SecretAccessKey",
+		SessionToken:    "SessionToken",
+		Expiration:      time.Now().Add(time.Hour).UnixMilli(),
+	}
+	// the watch may not be connected yet, so keep loading until it sees one
+	assert.Eventually(t, func() bool {
+		require.NoError(t, c.SubmitCreds(creds, "123456789012:ReadOnly", "Default", true))
+		return events() != ""
+	}, 5*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, c.Delete("123456789012:ReadOnly"))
+	assert.Eventually(t, func() bool {
+		return slices.Contains(strings.Split(events(), "\n"), "unloaded 123456789012:ReadOnly")
+	}, 5*time.Second, 50*time.Millisecond)
+	assert.Equal(t, "loaded 123456789012:ReadOnly", strings.Split(events(), "\n")[0])
+
+	cancel()
+	assert.NoError(t, <-done)
+}
@@ -32,6 +32,7 @@ import (
 	dockerclient "github.com/moby/moby/client"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	ecsclient "github.com/synfinatic/aws-sso-cli/internal/ecs/client"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	// "github.com/davecgh/go-spew/spew"
 )
 
@@ -45,17 +46,22 @@ type EcsDockerCmd struct {
 }
 
 type EcsDockerStartCmd struct {
-	DisableAuth bool   `kong:"help='Disable HTTP Auth for the ECS Docker Server'"`
-	DisableSSL  bool   `kong:"help='Disable SSL/TLS for the ECS Docker Server'"`
-	BindIP      string `kong:"help='Host IP address to bind to the ECS Server',default='127.0.0.1'"`
-	Port        string `kong:"help='Host port to bind to the ECS Server',default='4144'"`
-	Image       string `kong:"help='ECS Server docker image',default='synfinatic/aws-sso-cli-ecs-server'"`
-	Version     string `kong:"help='ECS Server docker image version',default='v${VERSION}'"`
-	Default     string `kong:"short='d',help='Profile name to load as default credentials on start',predictor='profile'"`
+	DisableAuth     bool   `kong:"help='Disable HTTP Auth for the ECS Docker Server'"`
+	DisableSSL      bool   `kong:"help='Disable SSL/TLS for the ECS Docker Server'"`
+	BindIP          string `kong:"help='Host IP address to bind to the ECS Server',default='127.0.0.1'"`
+	Port            string `kong:"help='Host port to bind to the ECS Server',default='4144'"`
+	Image           string `kong:"help='ECS Server docker image',default='synfinatic/aws-sso-cli-ecs-server'"`
+	Version         string `kong:"help='ECS Server docker image version',default='v${VERSION}'"`
+	Default         string `kong:"short='d',help='Profile name to load as default credentials on start',predictor='profile'"`
+	Persist         bool   `kong:"help='Save loaded credentials in ~/.aws-sso/mnt and restore them when the container restarts'"`
+	PersistPassword string `kong:"help='Password used to encrypt the credentials saved by --persist',env='AWS_SSO_ECS_PERSIST_PASSWORD'"` // nolint:gosec
 }
 
 // AfterApply determines if SSO auth token is required
 func (e EcsDockerStartCmd) AfterApply(runCtx *RunContext) error {
+	if e.Persist && e.PersistPassword == "" {
+		return fmt.Errorf("--persist requires --persist-password or $AWS_SSO_ECS_PERSIST_PASSWORD")
+	}
 	if e.Default != "" {
 		runCtx.Auth = AUTH_REQUIRED
 	} else {
@@ -118,8 +124,12 @@ func (cc *EcsDockerStartCmd) Run(ctx *RunContext) error {
 		},
 		User: fmt.Sprintf("%d:%d", os.Getuid(), os.Getgid()),
 	}
-	if ctx.Cli.LogLevel == "debug" || ctx.Cli.LogLevel == "trace" {
-		config.Entrypoint = []string{"./aws-sso", "ecs", "server", "--level", string(ctx.Cli.LogLevel), "--docker"}
+	if entrypoint := dockerEntrypoint(string(ctx.Cli.LogLevel), cc.Persist); entrypoint != nil {
+		config.Entrypoint = entrypoint
+	}
+	if cc.Persist {
+		// encrypts the file SecureStore in the container
+		config.Env = append(config.Env, storage.ENV_SSO_FILE_PASSWORD+"="+cc.PersistPassword)
 	}
 
 	portBinding := network.PortBinding{
@@ -200,6 +210,22 @@ func (cc *EcsDockerStartCmd) Run(ctx *RunContext) error {
 	return nil
 }
 
+// dockerEntrypoint returns the entrypoint of the ECS Server container or nil to
+// use the default of the image
+func dockerEntrypoint(logLevel string, persist bool) []string {
+	args := []string{"./aws-sso", "ecs", "server"}
+	if logLevel == "debug" || logLevel == "trace" {
+		args = append(args, "--level", logLevel)
+	} else if !persist {
+		return nil
+	}
+	args = append(args, "--docker")
+	if persist {
+		args = append(args, "--persist")
+	}
+	return args
+}
+
 // writeAndCloseSecurityFile writes the ECS security config and closes the file
 // synchronously before the container starts, ensuring the data is visible on the
 // shared filesystem (e.g. VirtioFS) before the container process reads it.
@@ -289,8 +315,12 @@ func loadProfileToEcsServer(ctx *RunContext, profileName, serverAddr string) err
 	if p, err := rFlat.ProfileName(ctx.Settings); err == nil {
 		rFlat.Profile = p
 	}
+	ssoName, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
 	c := newClient(serverAddr, ctx)
-	return c.SubmitCreds(creds, rFlat.Profile, false)
+	return c.SubmitCreds(creds, rFlat.Profile, ssoName, false)
 }
 
 type EcsDockerStopCmd struct {
@@ -47,6 +47,11 @@ func TestEcsDockerStartCmdAfterApply(t *testing.T) {
 			cmd:      EcsDockerStartCmd{},
 			wantAuth: AUTH_SKIP,
 		},
+		{
+			name:     "Persist with a password",
+			cmd:      EcsDockerStartCmd{Persist: true, PersistPassword: "password"},
+			wantAuth: AUTH_SKIP,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -58,6 +63,21 @@ func TestEcsDockerStartCmdAfterApply(t *testing.T) {
 	}
 }
 
+func TestEcsDockerStartCmdAfterApplyPersist(t *testing.T) {
+	err := EcsDockerStartCmd{Persist: true}.AfterApply(&RunContext{})
+	assert.ErrorContains(t, err, "--persist requires")
+}
+
+func TestDockerEntrypoint(t *testing.T) {
+	assert.Nil(t, dockerEntrypoint("info", false))
+	assert.Equal(t, []string{"./aws-sso", "ecs", "server", "--level", "debug", "--docker"},
+		dockerEntrypoint("debug", false))
+	assert.Equal(t, []string{"./aws-sso", "ecs", "server", "--docker", "--persist"},
+		dockerEntrypoint("info", true))
+	assert.Equal(t, []string{"./aws-sso", "ecs", "server", "--level", "trace", "--docker", "--persist"},
+		dockerEntrypoint("trace", true))
+}
+
 func TestWaitForEcsHealthcheck_Success(t *testing.T) {
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -25,26 +25,66 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs/server"
+	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
+// warn when the ECS Server SSL certificate expires within this window
+const ECS_SSL_EXPIRY_WARNING = 30 * 24 * time.Hour
+
 type EcsServerCmd struct {
 	BindIP  string `kong:"help='Bind address for ECS Server',default='127.0.0.1'"`
 	Port    int    `kong:"help='TCP port to listen on',default=4144"`
 	Default string `kong:"short='d',help='Profile name to load as default credentials on start',predictor='profile'"`
 	// hidden flags are for internal use only when running in a docker container
-	Docker      bool `kong:"hidden"`
-	DisableAuth bool `kong:"help='Disable HTTP Auth for the ECS Server'"`
-	DisableSSL  bool `kong:"help='Disable SSL/TLS for the ECS Server'"`
+	Docker       bool          `kong:"hidden"`
+	DisableAuth  bool          `kong:"help='Disable HTTP Auth for the ECS Server'"`
+	DisableSSL   bool          `kong:"help='Disable SSL/TLS for the ECS Server'"`
+	AutoRefresh  bool          `kong:"help='Re-fetch credentials from AWS SSO before they expire'"`
+	RefreshTime  time.Duration `kong:"help='How long before expiration to re-fetch credentials',default='15m'"`
+	Persist      bool          `kong:"help='Save loaded credentials in the SecureStore and restore them on restart'"`
+	IMDS         bool          `kong:"name='imds',help='Also emulate the EC2 Instance Metadata Service (IMDSv2) credential endpoints'"`
+	IMDSSlot     []string      `kong:"name='imds-slot',help='Slot which IMDS may also serve (default: only the default credentials)',predictor='profile'"`
+	UnixSocket   string        `kong:"name='unix-socket',help='Listen on a Unix domain socket at this path instead of TCP',type='path'"`
+	ClientCerts  bool          `kong:"name='require-client-cert',help='Require client certificates signed by the CA in the SecureStore (mutual TLS)'"`
+	Metrics      bool          `kong:"help='Expose Prometheus metrics at /metrics'"`
+	MetricsToken string        `kong:"help='Bearer token required to read /metrics (separate from the credential routes)',env='AWS_SSO_ECS_METRICS_TOKEN'"`
 }
 
 // AfterApply determines if SSO auth token is required
 func (e EcsServerCmd) AfterApply(runCtx *RunContext) error {
+	if e.UnixSocket != "" && e.ClientCerts {
+		return fmt.Errorf("--require-client-cert can not be used with --unix-socket")
+	}
+	if len(e.IMDSSlot) > 0 && !e.IMDS {
+		return fmt.Errorf("--imds-slot requires --imds")
+	}
 	if e.Docker {
+		if e.AutoRefresh {
+			return fmt.Errorf("--auto-refresh is not supported when running in a docker container")
+		}
+		if e.UnixSocket != "" {
+			return fmt.Errorf("--unix-socket is not supported when running in a docker container")
+		}
+		if e.ClientCerts {
+			return fmt.Errorf("--require-client-cert is not supported when running in a docker container")
+		}
+		if e.Metrics {
+			return fmt.Errorf("--metrics is not supported when running in a docker container")
+		}
+		if e.IMDS {
+			// the container listens on all interfaces & IMDS has no HTTP Auth
+			return fmt.Errorf("--imds is not supported when running in a docker container")
+		}
 		runCtx.Auth = AUTH_NO_CONFIG
-	} else if e.Default != "" {
+	} else if e.Default != "" || e.AutoRefresh {
 		runCtx.Auth = AUTH_REQUIRED
 	} else {
 		runCtx.Auth = AUTH_SKIP
@@ -59,29 +99,29 @@ func (cc *EcsServerCmd) Run(ctx *RunContext) error {
 		// if running in a docker container, bind to all interfaces
 		bindIP = "0.0.0.0"
 	}
-	l, err := net.Listen("tcp", fmt.Sprintf("%s:%d", bindIP, ctx.Cli.Ecs.Server.Port))
+	var l net.Listener
+	var err error
+	if cc.UnixSocket != "" {
+		l, err = server.NewUnixListener(cc.UnixSocket)
+	} else {
+		l, err = net.Listen("tcp", fmt.Sprintf("%s:%d", bindIP, ctx.Cli.Ecs.Server.Port))
+	}
 	if err != nil {
 		return err
 	}
 
+	// the SecureStore we persist slots in
+	store := ctx.Store
 	var bearerToken, privateKey, certChain string
 	if ctx.Cli.Ecs.Server.Docker {
-		// fetch the creds from our temporary file mounted in the docker container
-		f, err := ecs.OpenSecurityFile(ecs.READ_ONLY)
-		if err != nil {
-			log.Warn("Failed to open ECS credentials file", "error", err.Error())
-		} else {
-			creds, err := ecs.ReadSecurityConfig(f)
-			if err != nil {
+		if cc.Persist {
+			// the container has no access to our SecureStore, so use an encrypted one in the mounted directory
+			if store, err = openDockerStore(ctx.Ctx, ecs.CONTAINER_STORE_DIR); err != nil {
 				return err
 			}
-			// have to manually close since defer won't work in this case
-			f.Close()
-			os.Remove(f.Name()) // nolint:gosec
-
-			bearerToken = creds.BearerToken
-			privateKey = creds.PrivateKey
-			certChain = creds.CertChain
+		}
+		if bearerToken, privateKey, certChain, err = dockerSecurityConfig(ctx.Ctx, store, cc.Persist); err != nil {
+			return err
 		}
 	} else {
 		if bearerToken, err = ctx.Store.GetEcsBearerToken(); err != nil {
@@ -104,18 +144,23 @@ func (cc *EcsServerCmd) Run(ctx *RunContext) error {
 		certChain = ""
 	}
 
-	if ctx.Cli.Ecs.Server.DisableAuth {
-		bearerToken = ""
+	// access to the socket is restricted by the file permissions instead
+	if cc.UnixSocket != "" {
+		privateKey = ""
+		certChain = ""
 	}
 
-	if bearerToken == "" && !ctx.Cli.Ecs.Server.DisableAuth {
-		log.Warn("HTTP Auth: disabled. Use 'aws-sso setup ecs auth' to enable")
-	} else {
-		log.Info("HTTP Auth: enabled")
+	if ctx.Cli.Ecs.Server.DisableAuth {
+		bearerToken = ""
 	}
 
 	if privateKey != "" && certChain != "" {
 		log.Info("SSL/TLS: enabled")
+		if expiring, err := ecs.CertificateExpiresWithin([]byte(certChain), ECS_SSL_EXPIRY_WARNING); err == nil && expiring {
+			log.Warn("SSL certificate expires soon.  Use 'aws-sso setup ecs ssl --generate' to rotate it")
+		}
+	} else if cc.UnixSocket != "" {
+		log.Info("SSL/TLS: disabled for Unix socket", "path", cc.UnixSocket)
 	} else if !ctx.Cli.Ecs.Server.DisableSSL {
 		log.Warn("SSL/TLS: disabled.  Use 'aws-sso setup ecs ssl' to enable")
 	}
@@ -124,12 +169,67 @@ func (cc *EcsServerCmd) Run(ctx *RunContext) error {
 	if err != nil {
 		return err
 	}
+	if !cc.Docker && !cc.DisableAuth {
+		if err := addEcsTokens(ctx.Store, s); err != nil {
+			return err
+		}
+	}
+
+	if !s.AuthEnabled() && !ctx.Cli.Ecs.Server.DisableAuth {
+		log.Warn("HTTP Auth: disabled. Use 'aws-sso setup ecs auth' to enable")
+	} else if s.AuthEnabled() {
+		log.Info("HTTP Auth: enabled")
+	}
+	// the SecureStore isn't safe for concurrent use by the server & credentials fetcher
+	storeLock := &sync.Mutex{}
+	if cc.Persist {
+		s.EnablePersistence(store, storeLock)
+	}
+	s.EnableAudit(ctx.Audit)
+
+	if cc.ClientCerts {
+		caCert, err := ctx.Store.GetEcsClientCA()
+		if err != nil {
+			return err
+		}
+		if caCert == "" {
+			return fmt.Errorf("no client CA certificate found.  Use 'aws-sso setup ecs mtls' to load one")
+		}
+		if err := s.RequireClientCerts(caCert); err != nil {
+			return err
+		}
+		log.Info("Mutual TLS: enabled")
+	}
+
 	if cc.Default != "" && !cc.Docker {
 		if err := setServerDefaultProfile(ctx, s, cc.Default); err != nil {
 			return err
 		}
 	}
 
+	if cc.IMDS {
+		// IMDS clients can't send our bearer token
+		if err = s.EnableIMDS(cc.IMDSSlot); err != nil {
+			return err
+		}
+		log.Warn("IMDSv2: enabled without HTTP Auth", "AWS_EC2_METADATA_SERVICE_ENDPOINT", s.BaseURL(),
+			"slots", cc.IMDSSlot)
+	}
+
+	if cc.Metrics {
+		if cc.MetricsToken == "" {
+			log.Warn("Metrics: enabled without HTTP Auth", "url", s.BaseURL()+server.METRICS_ROUTE)
+		} else {
+			log.Info("Metrics: enabled", "url", s.BaseURL()+server.METRICS_ROUTE)
+		}
+		s.EnableMetrics(cc.MetricsToken)
+	}
+
+	if cc.AutoRefresh {
+		log.Info("Credential auto-refresh: enabled")
+		s.EnableRefresh(newEcsCredsFetcher(ctx, storeLock), cc.RefreshTime)
+	}
+
 	// Shut down gracefully when the context is cancelled (handles SIGINT/SIGTERM from main).
 	go func() {
 		<-ctx.Ctx.Done()
@@ -142,6 +242,87 @@ func (cc *EcsServerCmd) Run(ctx *RunContext) error {
 	return nil
 }
 
+// openDockerStore opens the file SecureStore in the given directory, which is
+// encrypted with the password passed into the docker container via $AWS_SSO_FILE_PASSWORD
+func openDockerStore(ctx context.Context, dir string) (storage.SecureStorage, error) {
+	if os.Getenv(storage.ENV_SSO_FILE_PASSWORD) == "" {
+		return nil, fmt.Errorf("--persist requires $%s in a docker container", storage.ENV_SSO_FILE_PASSWORD)
+	}
+	cfg, err := storage.NewKeyringConfig("file", dir, "")
+	if err != nil {
+		return nil, err
+	}
+	log.Info("Persisting slots", "dir", cfg.FileDir)
+	return storage.OpenKeyring(ctx, cfg)
+}
+
+// dockerSecurityConfig returns the bearer token, private key and certificate chain
+// from the temporary file mounted in the docker container.  If persist is true, they
+// are saved in store so that we keep using them when the container is restarted and
+// the file no longer exists.
+func dockerSecurityConfig(ctx context.Context, store storage.SecureStorage, persist bool) (string, string, string, error) {
+	f, err := ecs.OpenSecurityFile(ecs.READ_ONLY)
+	if err != nil {
+		if !persist {
+			log.Warn("Failed to open ECS credentials file", "error", err.Error())
+			return "", "", "", nil
+		}
+		log.Info("Using persisted ECS credentials")
+		bearerToken, err := store.GetEcsBearerToken()
+		if err != nil {
+			return "", "", "", err
+		}
+		privateKey, err := store.GetEcsSslKey()
+		if err != nil {
+			return "", "", "", err
+		}
+		certChain, err := store.GetEcsSslCert()
+		return bearerToken, privateKey, certChain, err
+	}
+
+	creds, err := ecs.ReadSecurityConfig(f)
+	if err != nil {
+		return "", "", "", err
+	}
+	// have to manually close since defer won't work in this case
+	f.Close()
+	os.Remove(f.Name()) // nolint:gosec
+
+	if persist {
+		if err = store.SaveEcsBearerToken(ctx, creds.BearerToken); err != nil {
+			return "", "", "", err
+		}
+		if creds.PrivateKey != "" && creds.CertChain != "" {
+			err = store.SaveEcsSslKeyPair(ctx, []byte(creds.PrivateKey), []byte(creds.CertChain))
+		} else {
+			err = store.DeleteEcsSslKeyPair(ctx)
+		}
+		if err != nil {
+			return "", "", "", err
+		}
+	}
+	return creds.BearerToken, creds.PrivateKey, creds.CertChain, nil
+}
+
+// addEcsTokens adds the named bearer tokens in the SecureStore to the ECS Server
+func addEcsTokens(store storage.SecureStorage, s *server.EcsServer) error {
+	for _, name := range store.ListEcsTokens() {
+		token := storage.EcsToken{}
+		if err := store.GetEcsToken(name, &token); err != nil {
+			return err
+		}
+		scope := server.TokenScope{
+			Admin: token.Admin,
+			Slot:  token.Slot,
+		}
+		if err := s.AddToken(token.Token, scope); err != nil {
+			return fmt.Errorf("invalid ECS bearer token %s: %w", name, err)
+		}
+		log.Debug("added ECS bearer token", "name", name, "admin", token.Admin, "slot", token.Slot)
+	}
+	return nil
+}
+
 // setServerDefaultProfile resolves a profile name to credentials and injects them
 // directly into the server's default slot before Serve() is called.
 func setServerDefaultProfile(ctx *RunContext, s *server.EcsServer, profileName string) error {
@@ -154,9 +335,98 @@ func setServerDefaultProfile(ctx *RunContext, s *server.EcsServer, profileName s
 	if p, err := rFlat.ProfileName(ctx.Settings); err == nil {
 		rFlat.Profile = p
 	}
-	s.DefaultCreds = &ecs.ECSClientRequest{
+	ssoName, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
+	s.SetDefaultCreds(&ecs.ECSClientRequest{
 		Creds:       creds,
 		ProfileName: rFlat.Profile,
-	}
+		SSOName:     ssoName,
+	})
 	return nil
 }
+
+// ecsCredsFetcher re-fetches role credentials from AWS SSO on behalf of the ECS Server
+type ecsCredsFetcher struct {
+	ctx       *RunContext
+	lock      sync.Mutex
+	storeLock *sync.Mutex // shared with the ECS Server which persists slots in the same SecureStore
+	awsSSOs   map[string]*ssoauth.AWSSSO
+}
+
+func newEcsCredsFetcher(ctx *RunContext, storeLock *sync.Mutex) *ecsCredsFetcher {
+	return &ecsCredsFetcher{
+		ctx:       ctx,
+		storeLock: storeLock,
+		awsSSOs:   map[string]*ssoauth.AWSSSO{},
+	}
+}
+
+// GetRoleCredentials implements server.CredentialsFetcher.  The SSO token is
+// silently renewed via the refresh token if it has expired.
+func (f *ecsCredsFetcher) GetRoleCredentials(ssoName string, accountId int64, role string) (*storage.RoleCredentials, error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	// renewing the SSO token & fetching role chains use the SecureStore too
+	f.storeLock.Lock()
+	defer f.storeLock.Unlock()
+
+	as, err := f.getAWSSSO(ssoName)
+	if err != nil {
+		return nil, err
+	}
+
+	if ssoName == "" {
+		if ssoName, err = f.ctx.Settings.GetSelectedSSOName(f.ctx.Cli.SSO); err != nil {
+			return nil, err
+		}
+	}
+	arn := credentialsARN(f.ctx.Settings.Cache.GetSSOByName(ssoName).Roles, as.Partition(), accountId, role)
+
+	// IAM Users with StaticCredentials don't need AWS SSO
+	if !awsparse.IsUserARN(arn) && !as.ValidAuthToken(f.ctx.Ctx) {
+		return nil, fmt.Errorf("AWS SSO token for %s has expired and can not be refreshed.  Please run 'aws-sso login'", ssoName)
+	}
+
+	// the credentials we are replacing are about to expire, so don't get them back
+	// from a cached MFA session
+	creds, err := as.RefreshCredentials(arn)
+	auditCredentials(f.ctx, audit.Event{
+		SSO:       ssoName,
+		Arn:       arn,
+		AccountId: accountId,
+		RoleName:  role,
+		Source:    "aws",
+	}, &creds, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := f.ctx.Store.SaveRoleCredentials(f.ctx.Ctx, arn, creds); err != nil {
+		log.Warn("Unable to cache role credentials in secure store", "error", err.Error())
+	}
+	return &creds, nil
+}
+
+// getAWSSSO returns the AWSSSO for the given SSO instance name
+func (f *ecsCredsFetcher) getAWSSSO(ssoName string) (*ssoauth.AWSSSO, error) {
+	selected, err := f.ctx.Settings.GetSelectedSSOName(f.ctx.Cli.SSO)
+	if err != nil {
+		return nil, err
+	}
+	if ssoName == "" || ssoName == selected {
+		return initAwsSSO(f.ctx), nil
+	}
+
+	if as, ok := f.awsSSOs[ssoName]; ok {
+		return as, nil
+	}
+
+	s, err := f.ctx.Settings.GetSelectedSSO(ssoName)
+	if err != nil {
+		return nil, err
+	}
+	f.awsSSOs[ssoName] = newAWSSSO(f.ctx, s)
+	return f.awsSSOs[ssoName], nil
+}
@@ -19,11 +19,17 @@ package main
  */
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs/server"
 	ssocache "github.com/synfinatic/aws-sso-cli/internal/sso/cache"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"golang.org/x/net/nettest"
 )
 
 func TestEcsServerCmdAfterApply(t *testing.T) {
@@ -58,6 +64,67 @@ func TestEcsServerCmdAfterApply(t *testing.T) {
 	}
 }
 
+func TestEcsServerCmdAfterApplyErrors(t *testing.T) {
+	runCtx := &RunContext{}
+	assert.ErrorContains(t, EcsServerCmd{Docker: true, UnixSocket: "/tmp/ecs.sock"}.AfterApply(runCtx), "--unix-socket")
+	assert.ErrorContains(t, EcsServerCmd{Docker: true, ClientCerts: true}.AfterApply(runCtx), "--require-client-cert")
+	assert.ErrorContains(t, EcsServerCmd{Docker: true, Metrics: true}.AfterApply(runCtx), "--metrics")
+	assert.ErrorContains(t, EcsServerCmd{Docker: true, IMDS: true}.AfterApply(runCtx), "--imds")
+	assert.ErrorContains(t, EcsServerCmd{IMDSSlot: []string{"foo"}}.AfterApply(runCtx), "requires --imds")
+	assert.ErrorContains(t, EcsServerCmd{UnixSocket: "/tmp/ecs.sock", ClientCerts: true}.AfterApply(runCtx), "can not be used with")
+}
+
+func TestDockerSecurityConfig(t *testing.T) {
+	ctx := context.Background()
+	store := openTestStore(t)
+	require.NoError(t, store.SaveEcsBearerToken(ctx, "Bearer Token"))
+
+	// no security file in the container and nothing persisted
+	token, key, cert, err := dockerSecurityConfig(ctx, store, false)
+	require.NoError(t, err)
+	assert.Empty(t, token)
+	assert.Empty(t, key)
+	assert.Empty(t, cert)
+
+	// a restarted container keeps using what it persisted
+	token, key, cert, err = dockerSecurityConfig(ctx, store, true)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer Token", token)
+	assert.Empty(t, key)
+	assert.Empty(t, cert)
+}
+
+func TestOpenDockerStore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	t.Setenv(storage.ENV_SSO_FILE_PASSWORD, "")
+	_, err := openDockerStore(ctx, dir)
+	assert.ErrorContains(t, err, storage.ENV_SSO_FILE_PASSWORD)
+
+	t.Setenv(storage.ENV_SSO_FILE_PASSWORD, "password")
+	store, err := openDockerStore(ctx, dir)
+	require.NoError(t, err)
+	require.NoError(t, store.SaveEcsBearerToken(ctx, "Bearer Secret"))
+
+	// a restarted container can read it back
+	store, err = openDockerStore(ctx, dir)
+	require.NoError(t, err)
+	token, err := store.GetEcsBearerToken()
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer Secret", token)
+
+	// but it is never written in plain text
+	files, err := os.ReadDir(filepath.Join(dir, "secure"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+	for _, f := range files {
+		data, err := os.ReadFile(filepath.Join(dir, "secure", f.Name()))
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "Bearer Secret")
+	}
+}
+
 func TestSetServerDefaultProfileNotFound(t *testing.T) {
 	// Build a RunContext with an empty SSO cache so GetRoleByProfile returns "not found".
 	c := &ssocache.Cache{SSO: map[string]*ssocache.SSOCache{}}
@@ -70,3 +137,22 @@ func TestSetServerDefaultProfileNotFound(t *testing.T) {
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "nonexistent-profile")
 }
+
+func TestAddEcsTokens(t *testing.T) {
+	store := openTestStore(t)
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	s, err := server.NewEcsServer(context.Background(), "", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.NoError(t, addEcsTokens(store, s))
+	assert.False(t, s.AuthEnabled())
+
+	require.NoError(t, store.SaveEcsToken(context.Background(), "container", storage.EcsToken{Token: "token", Slot: "FooBar"}))
+	assert.NoError(t, addEcsTokens(store, s))
+	assert.True(t, s.AuthEnabled())
+
+	require.NoError(t, store.SaveEcsToken(context.Background(), "broken", storage.EcsToken{Token: "token2"}))
+	assert.ErrorContains(t, addEcsTokens(store, s), "invalid ECS bearer token broken")
+}
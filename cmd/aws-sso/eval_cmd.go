@@ -24,6 +24,7 @@ import (
 	"runtime"
 	"strings"
 
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 )
 
@@ -43,10 +44,13 @@ type EvalCmd struct {
 
 // AfterApply determines if SSO auth token is required
 func (e EvalCmd) AfterApply(runCtx *RunContext) error {
-	if !runCtx.Cli.Eval.Clear {
-		runCtx.Auth = AUTH_REQUIRED
-	} else {
+	if runCtx.Cli.Eval.Clear {
 		runCtx.Auth = AUTH_SKIP
+	} else if agentSocket() != "" {
+		// the aws-sso agent takes care of everything
+		runCtx.Auth = AUTH_NO_CONFIG
+	} else {
+		runCtx.Auth = AUTH_REQUIRED
 	}
 	return nil
 }
@@ -61,6 +65,10 @@ func (cc *EvalCmd) Run(ctx *RunContext) error {
 		return unsetEnvVars(ctx)
 	}
 
+	if agentSocket() != "" {
+		return evalAgent(ctx)
+	}
+
 	// refreshing?
 	if ctx.Cli.Eval.Refresh {
 		if ctx.Cli.Eval.EnvArn == "" {
@@ -93,7 +101,41 @@ func (cc *EvalCmd) Run(ctx *RunContext) error {
 	}
 	region := ctx.Settings.GetDefaultRegion(accountid, role, ctx.Cli.Eval.NoRegion, ctx.Cli.Eval.OverwriteRegion)
 
-	for k, v := range execShellEnvs(ctx, accountid, role, region) {
+	return printEnvVars(execShellEnvs(ctx, accountid, role, region))
+}
+
+// evalAgent prints the environment variables using the role creds from the aws-sso agent
+func evalAgent(ctx *RunContext) error {
+	req := agent.CredentialsRequest{}
+
+	if ctx.Cli.Eval.Refresh {
+		if ctx.Cli.Eval.EnvArn == "" {
+			return fmt.Errorf("unable to determine current IAM role")
+		}
+		req.Arn = ctx.Cli.Eval.EnvArn
+	} else if ctx.Cli.Eval.Profile != "" {
+		req.Profile = ctx.Cli.Eval.Profile
+	} else if ctx.Cli.Eval.Arn != "" {
+		req.Arn = ctx.Cli.Eval.Arn
+	} else if ctx.Cli.Eval.Role != "" && ctx.Cli.Eval.AccountId != 0 {
+		req.AccountId = int64(ctx.Cli.Eval.AccountId)
+		req.RoleName = ctx.Cli.Eval.Role
+	} else {
+		return fmt.Errorf("please specify --refresh, --clear, --arn, or --account and --role")
+	}
+
+	resp, err := agentCredentials(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	region := agentRegion(resp, ctx.Cli.Eval.NoRegion, ctx.Cli.Eval.OverwriteRegion)
+	return printEnvVars(shellEnvs(resp, region))
+}
+
+// printEnvVars prints the commands to set the environment variables in the user's shell
+func printEnvVars(shellVars map[string]string) error {
+	for k, v := range shellVars {
 		if isBashLike() {
 			if len(v) == 0 {
 				fmt.Printf("unset %s\n", k)
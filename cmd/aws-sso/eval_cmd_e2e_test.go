@@ -119,6 +119,99 @@ func TestE2EEval_RoleChain(t *testing.T) {
 	assert.Contains(t, output, `export AWS_SESSION_TOKEN="TOKEN-TARGET"`)
 }
 
+// TestE2EEval_RoleChainParameters verifies that the sts:AssumeRole parameters
+// configured on a role with Via are passed to STS.
+func TestE2EEval_RoleChainParameters(t *testing.T) {
+	accountsYAML := `"123456789012":
+  Roles:
+    BaseRole: {}
+    TargetRole:
+      Via: arn:aws:iam::123456789012:role/BaseRole
+      Duration: 30
+      SessionTags:
+        Project: aws-sso
+        Team: infra
+      TransitiveTagKeys:
+        - Project
+      PolicyArns:
+        - arn:aws:iam::aws:policy/ReadOnlyAccess
+      Policy: '{"Version": "2012-10-17", "Statement": [{"Effect": "Allow", "Action": "s3:*", "Resource": "*"}]}'
+      RoleSessionName: "{{ .RoleName }}-to-{{ .TargetRoleName }}"`
+	setup := newE2ESetupWithDefaults(t, "Default", nil, "device_code", accountsYAML)
+	preAuth(t, setup)
+
+	setup.Server.SSO.QueueGetRoleCredentials(awsmock.GetRoleCredentialsResponse{
+		RoleCredentials: awsmock.RoleCredentials{
+			AccessKeyID:     "AKID-BASE",
+			SecretAccessKey: "SECRET-BASE",
+			SessionToken:    "TOKEN-BASE",
+			Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+		},
+	})
+	setup.Server.STS.QueueAssumeRole(awsmock.AssumeRoleResult{
+		AccessKeyID:     "AKID-TARGET",
+		SecretAccessKey: "SECRET-TARGET",
+		SessionToken:    "TOKEN-TARGET",
+		Expiration:      time.Now().Add(30 * time.Minute),
+		RoleARN:         "arn:aws:iam::123456789012:role/TargetRole",
+		SessionName:     "BaseRole-to-TargetRole",
+	})
+
+	creds, err := AwsSSO.GetRoleCredentials(123456789012, "TargetRole")
+	require.NoError(t, err)
+	assert.Equal(t, "AKID-TARGET", creds.AccessKeyId)
+
+	requests := setup.Server.STS.AssumeRoleRequests()
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, "AssumeRole", req.Get("Action"))
+	assert.Equal(t, "arn:aws:iam::123456789012:role/TargetRole", req.Get("RoleArn"))
+	assert.Equal(t, "BaseRole-to-TargetRole", req.Get("RoleSessionName"))
+	assert.Equal(t, "1800", req.Get("DurationSeconds"))
+	assert.Equal(t, "Project", req.Get("Tags.member.1.Key"))
+	assert.Equal(t, "aws-sso", req.Get("Tags.member.1.Value"))
+	assert.Equal(t, "Team", req.Get("Tags.member.2.Key"))
+	assert.Equal(t, "infra", req.Get("Tags.member.2.Value"))
+	assert.Equal(t, "Project", req.Get("TransitiveTagKeys.member.1"))
+	assert.Empty(t, req.Get("TransitiveTagKeys.member.2"))
+	assert.Equal(t, "arn:aws:iam::aws:policy/ReadOnlyAccess", req.Get("PolicyArns.member.1.arn"))
+	assert.Contains(t, req.Get("Policy"), `"Action": "s3:*"`)
+}
+
+// TestE2EEval_RoleChainDefaultParameters verifies that without any AssumeRole
+// parameters configured we only send the RoleArn and default RoleSessionName.
+func TestE2EEval_RoleChainDefaultParameters(t *testing.T) {
+	setup := newE2ESetupRoleChain(t)
+	preAuth(t, setup)
+
+	setup.Server.SSO.QueueGetRoleCredentials(awsmock.GetRoleCredentialsResponse{
+		RoleCredentials: awsmock.RoleCredentials{
+			AccessKeyID:     "AKID-BASE",
+			SecretAccessKey: "SECRET-BASE",
+			SessionToken:    "TOKEN-BASE",
+			Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+		},
+	})
+	setup.Server.STS.QueueAssumeRole(awsmock.AssumeRoleResult{
+		AccessKeyID:     "AKID-TARGET",
+		SecretAccessKey: "SECRET-TARGET",
+		SessionToken:    "TOKEN-TARGET",
+		Expiration:      time.Now().Add(1 * time.Hour),
+		RoleARN:         "arn:aws:iam::123456789012:role/TargetRole",
+	})
+
+	_, err := AwsSSO.GetRoleCredentials(123456789012, "TargetRole")
+	require.NoError(t, err)
+
+	requests := setup.Server.STS.AssumeRoleRequests()
+	require.Len(t, requests, 1)
+	req := requests[0]
+	assert.Equal(t, "BaseRole@123456789012", req.Get("RoleSessionName"))
+	for _, param := range []string{"DurationSeconds", "Tags.member.1.Key", "TransitiveTagKeys.member.1", "PolicyArns.member.1.arn", "Policy"} {
+		assert.False(t, req.Has(param), param)
+	}
+}
+
 // TestE2EMultipleSSO_Selection verifies that commands respect both explicit
 // --sso flag overrides and the DefaultSSO setting when multiple SSO instances
 // are configured.
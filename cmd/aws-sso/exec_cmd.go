@@ -21,13 +21,20 @@ package main
 import (
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
 	"os"
 	"os/exec"
 	"runtime"
 	"strings"
+	"sync"
 
-	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs/server"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/sso"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
 type ExecCmd struct {
@@ -39,6 +46,12 @@ type ExecCmd struct {
 	NoRegion     bool      `kong:"short='n',help='Do not set AWS_DEFAULT_REGION/AWS_REGION from config.yaml'"`
 	STSRefresh   bool      `kong:"help='Force refresh of STS Token Credentials'"`
 	OverwriteEnv bool      `kong:"short='O',help='Force overwriting existing AWS_* environment variables'"`
+	Endpoint     bool      `kong:"name='credentials-endpoint',help='Serve credentials to the command via a local endpoint which refreshes them before they expire'"`
+
+	// Multi-role Params
+	Tag     map[string]string `kong:"short='t',help='Run the command in every role matching the tags (key=value;key2=value2)'"`
+	Threads int               `kong:"help='Number of roles to run the command in at once with --tag (default: Threads in config.yaml)'"`
+	Collect bool              `kong:"help='Print the output of each role after its command completes instead of prefixing each line with the profile'"`
 
 	// Exec Params
 	Cmd  string   `kong:"arg,optional,name='command',help='Command to execute',env='SHELL'"`
@@ -47,7 +60,12 @@ type ExecCmd struct {
 
 // AfterApply determines if SSO auth token is required
 func (e ExecCmd) AfterApply(runCtx *RunContext) error {
-	runCtx.Auth = AUTH_REQUIRED
+	if e.useAgent() {
+		// the aws-sso agent takes care of everything
+		runCtx.Auth = AUTH_NO_CONFIG
+	} else {
+		runCtx.Auth = AUTH_REQUIRED
+	}
 	return nil
 }
 
@@ -69,6 +87,17 @@ func (cc *ExecCmd) Run(ctx *RunContext) error {
 		}
 	}
 
+	if len(ctx.Cli.Exec.Tag) > 0 {
+		if ctx.Cli.Exec.Endpoint {
+			return fmt.Errorf("--tag can not be used with --credentials-endpoint")
+		}
+		return execMulti(ctx)
+	}
+
+	if ctx.Cli.Exec.useAgent() {
+		return execAgent(ctx)
+	}
+
 	sci := NewSelectCliArgs(ctx.Cli.Exec.Arn, int64(ctx.Cli.Exec.AccountId), ctx.Cli.Exec.Role, ctx.Cli.Exec.Profile)
 	if err := sci.Update(ctx); err == nil {
 		// successful lookup?
@@ -85,17 +114,100 @@ func (cc *ExecCmd) Run(ctx *RunContext) error {
 func execCmd(ctx *RunContext, accountid int64, role string) error {
 	region := ctx.Settings.GetDefaultRegion(accountid, role, ctx.Cli.Exec.NoRegion, ctx.Cli.Exec.OverwriteEnv)
 
-	ctx.Settings.Cache.AddHistory(ctx.Settings, awsparse.MakeRoleARN(accountid, role))
+	ctx.Settings.Cache.AddHistory(ctx.Settings, roleARN(ctx, accountid, role))
 	if err := ctx.Settings.Cache.Save(false); err != nil {
 		log.Warn("Unable to update cache", "error", err.Error())
 	}
 
+	if ctx.Cli.Exec.Endpoint {
+		return execEndpoint(ctx, accountid, role, region)
+	}
+	return runCmd(ctx, execShellEnvs(ctx, accountid, role, region))
+}
+
+// execEndpoint executes Cmd+Args with an ephemeral ECS Server providing the role creds
+// so that long running commands are not limited by the lifetime of the creds
+func execEndpoint(ctx *RunContext, accountid int64, role, region string) error {
+	creds := GetRoleCredentials(ctx, AwsSSO, ctx.Cli.Exec.STSRefresh, accountid, role)
+	resp := newCredentialsResponse(ctx, creds)
+
+	profile := resp.Profile
+	if profile == "" {
+		profile = creds.RoleArn()
+	}
+	s, err := server.NewEphemeralServer(ctx.Ctx, &ecs.ECSClientRequest{
+		Creds:       creds,
+		ProfileName: profile,
+		SSOName:     resp.SSO,
+	}, newEcsCredsFetcher(ctx, &sync.Mutex{}))
+	if err != nil {
+		return fmt.Errorf("unable to start credentials endpoint: %w", err)
+	}
+	defer s.Close()
+	s.EnableAudit(ctx.Audit)
+
+	go func() {
+		if err := s.Serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("credentials endpoint failed", "error", err.Error())
+		}
+	}()
+	log.Debug("Started credentials endpoint", "url", s.BaseURL())
+
+	return runCmd(ctx, endpointShellEnvs(shellEnvs(resp, region), s.ContainerEnv()))
+}
+
+// endpointShellEnvs replaces the static role creds in shellVars with the
+// variables for the credentials endpoint.  Static creds take precedence over the
+// endpoint in the AWS SDKs, so they must be removed.
+func endpointShellEnvs(shellVars, containerEnv map[string]string) map[string]string {
+	for _, k := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_SSO_SESSION_EXPIRATION"} {
+		delete(shellVars, k)
+	}
+	maps.Copy(shellVars, containerEnv)
+	return shellVars
+}
+
+// execAgent executes Cmd+Args using the role creds from the aws-sso agent
+func execAgent(ctx *RunContext) error {
+	resp, err := agentCredentials(ctx, agent.CredentialsRequest{
+		Arn:        ctx.Cli.Exec.Arn,
+		AccountId:  int64(ctx.Cli.Exec.AccountId),
+		RoleName:   ctx.Cli.Exec.Role,
+		Profile:    ctx.Cli.Exec.Profile,
+		STSRefresh: ctx.Cli.Exec.STSRefresh,
+		AddHistory: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	region := agentRegion(resp, ctx.Cli.Exec.NoRegion, ctx.Cli.Exec.OverwriteEnv)
+	return runCmd(ctx, shellEnvs(resp, region))
+}
+
+// useAgent returns true if we should get our creds from the aws-sso agent.
+// We can only do so when the role is selected via the CLI and we are not
+// running a credentials endpoint.
+func (e ExecCmd) useAgent() bool {
+	return agentSocket() != "" && !e.Endpoint && (e.Arn != "" || e.Profile != "" || (e.AccountId != 0 && e.Role != ""))
+}
+
+// runCmd runs Cmd+Args with the given AWS environment variables
+func runCmd(ctx *RunContext, shellVars map[string]string) error {
 	// ready our command and connect everything up
-	cmd := exec.Command(ctx.Cli.Exec.Cmd, ctx.Cli.Exec.Args...) // #nosec
+	cmd := newCmd(ctx, shellVars)
 	cmd.Stderr = os.Stderr
 	cmd.Stdout = os.Stdout
 	cmd.Stdin = os.Stdin
 
+	// just do it!
+	return cmd.Run()
+}
+
+// newCmd returns Cmd+Args with the given AWS environment variables
+func newCmd(ctx *RunContext, shellVars map[string]string) *exec.Cmd {
+	cmd := exec.Command(ctx.Cli.Exec.Cmd, ctx.Cli.Exec.Args...) // #nosec
+
 	// Filter out the old AWS_ environment variables if IgnoreEnv is set,
 	// otherwise copy our current environment to the executor
 	if ctx.Cli.Exec.OverwriteEnv {
@@ -111,12 +223,11 @@ func execCmd(ctx *RunContext, accountid int64, role string) error {
 
 	// add the variables we need for AWS to the executor without polluting our
 	// own process
-	for k, v := range execShellEnvs(ctx, accountid, role, region) {
-		log.Debug("Setting", "variable", k, "value", v)
+	for k, v := range shellVars {
+		log.Debug("Setting", "variable", k, "value", logger.RedactEnv(k, v))
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, v))
 	}
-	// just do it!
-	return cmd.Run()
+	return cmd
 }
 
 // setRegionVars populates region-related env vars in shellVars. When region is
@@ -135,40 +246,62 @@ func setRegionVars(shellVars map[string]string, region string) {
 }
 
 func execShellEnvs(ctx *RunContext, accountid int64, role, region string) map[string]string {
-	var err error
-	credsPtr := GetRoleCredentials(ctx, AwsSSO, ctx.Cli.Exec.STSRefresh, accountid, role)
-	creds := *credsPtr
+	creds := GetRoleCredentials(ctx, AwsSSO, ctx.Cli.Exec.STSRefresh, accountid, role)
+	return shellEnvs(newCredentialsResponse(ctx, creds), region)
+}
 
+// newCredentialsResponse returns the role creds along with the info from our
+// config & cache needed to generate the environment variables for the role
+func newCredentialsResponse(ctx *RunContext, creds *storage.RoleCredentials) *agent.CredentialsResponse {
+	var err error
 	ssoName, _ := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
-	shellVars := map[string]string{
-		"AWS_ACCESS_KEY_ID":          creds.AccessKeyId,
-		"AWS_SECRET_ACCESS_KEY":      creds.SecretAccessKey,
-		"AWS_SESSION_TOKEN":          creds.SessionToken,
-		"AWS_SSO_ACCOUNT_ID":         creds.AccountIdStr(),
-		"AWS_SSO_ROLE_NAME":          creds.RoleName,
-		"AWS_SSO_SESSION_EXPIRATION": creds.ExpireString(),
-		"AWS_SSO_ROLE_ARN":           awsparse.MakeRoleARN(creds.AccountId, creds.RoleName),
-		"AWS_SSO":                    ssoName,
+	resp := &agent.CredentialsResponse{
+		SSO:           ssoName,
+		Creds:         *creds,
+		DefaultRegion: ctx.Settings.GetDefaultRegion(creds.AccountId, creds.RoleName, false, true),
 	}
 
-	setRegionVars(shellVars, region)
-
 	// Set the AWS_SSO_PROFILE env var using our template
 	cache := ctx.Settings.Cache.GetSSO()
 	var roleInfo *sso.AWSRoleFlat
-	if roleInfo, err = cache.Roles.GetRole(accountid, role); err != nil {
+	if roleInfo, err = cache.Roles.GetRole(creds.AccountId, creds.RoleName); err != nil {
 		// this error should never happen
 		log.Error("Unable to find role in cache.  Unable to set AWS_SSO_PROFILE")
 	} else {
-		shellVars["AWS_SSO_PROFILE"], err = roleInfo.ProfileName(ctx.Settings)
+		resp.Profile, err = roleInfo.ProfileName(ctx.Settings)
 		if err != nil {
 			log.Error("Unable to generate AWS_SSO_PROFILE", "error", err.Error())
 		}
 
 		// and any EnvVarTags
-		for k, v := range roleInfo.GetEnvVarTags(ctx.Settings) {
-			shellVars[k] = v
-		}
+		resp.EnvVarTags = roleInfo.GetEnvVarTags(ctx.Settings)
+	}
+
+	return resp
+}
+
+// shellEnvs returns the environment variables for the role creds
+func shellEnvs(resp *agent.CredentialsResponse, region string) map[string]string {
+	creds := resp.Creds
+	shellVars := map[string]string{
+		"AWS_ACCESS_KEY_ID":          creds.AccessKeyId,
+		"AWS_SECRET_ACCESS_KEY":      creds.SecretAccessKey,
+		"AWS_SESSION_TOKEN":          creds.SessionToken,
+		"AWS_SSO_ACCOUNT_ID":         creds.AccountIdStr(),
+		"AWS_SSO_ROLE_NAME":          creds.RoleName,
+		"AWS_SSO_SESSION_EXPIRATION": creds.ExpireString(),
+		"AWS_SSO_ROLE_ARN":           creds.RoleArn(),
+		"AWS_SSO":                    resp.SSO,
+	}
+
+	setRegionVars(shellVars, region)
+
+	if resp.Profile != "" {
+		shellVars["AWS_SSO_PROFILE"] = resp.Profile
+	}
+
+	for k, v := range resp.EnvVarTags {
+		shellVars[k] = v
 	}
 
 	return shellVars
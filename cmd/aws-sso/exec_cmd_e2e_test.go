@@ -23,6 +23,7 @@ package main
 import (
 	"bytes"
 	"io"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -314,3 +315,121 @@ func TestE2EMultipleSSO_Exec(t *testing.T) {
 			"exec should succeed using Secondary SSO from DefaultSSO config")
 	})
 }
+
+// TestE2EExecTag verifies that --tag runs the command once in every matching role
+// with each line of output prefixed by the profile.
+func TestE2EExecTag(t *testing.T) {
+	for _, v := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE"} {
+		unsetEnvForTest(t, v)
+	}
+
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+	queueRoleCredentials(setup.Server)
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Cli.Exec = ExecCmd{
+		Tag:  map[string]string{"AccountID": "123456789012"},
+		Cmd:  "/bin/sh",
+		Args: []string{"-c", "echo ROLE=$AWS_SSO_ROLE_NAME KEY=$AWS_ACCESS_KEY_ID"},
+	}
+
+	output := captureStdout(func() {
+		err := (&ctx.Cli.Exec).Run(ctx)
+		require.NoError(t, err)
+	})
+
+	assert.Contains(t, output, "] ROLE=PowerUser KEY=AKIDTEST12345\n")
+	assert.Contains(t, output, "] ROLE=ReadOnly KEY=AKIDTEST12345\n")
+	assert.Contains(t, output, "ExitCode")
+}
+
+// TestE2EExecTag_Failure verifies that --tag returns an error when the command
+// fails in any role and that --collect groups the output by role.
+func TestE2EExecTag_Failure(t *testing.T) {
+	for _, v := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE"} {
+		unsetEnvForTest(t, v)
+	}
+
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+	queueRoleCredentials(setup.Server)
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Cli.Exec = ExecCmd{
+		Tag:     map[string]string{"AccountID": "123456789012"},
+		Threads: 1,
+		Collect: true,
+		Cmd:     "/bin/sh",
+		Args:    []string{"-c", "echo ROLE=$AWS_SSO_ROLE_NAME; test $AWS_SSO_ROLE_NAME = ReadOnly || exit 4"},
+	}
+
+	var err error
+	output := captureStdout(func() {
+		err = (&ctx.Cli.Exec).Run(ctx)
+	})
+
+	assert.ErrorContains(t, err, "command failed in 1 of 2 roles")
+	assert.Contains(t, output, "(exit code 4) <==\nROLE=PowerUser\n")
+	assert.Contains(t, output, "(exit code 0) <==\nROLE=ReadOnly\n")
+}
+
+// TestE2EExecTag_NoMatch verifies that --tag returns an error when no roles match
+func TestE2EExecTag_NoMatch(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Cli.Exec = ExecCmd{
+		Tag:          map[string]string{"Env": "prod"},
+		OverwriteEnv: true,
+		Cmd:          "/bin/sh",
+	}
+	assert.ErrorContains(t, (&ctx.Cli.Exec).Run(ctx), "no roles match the tags: Env=prod")
+
+	ctx.Cli.Exec.Profile = "foo"
+	assert.ErrorContains(t, (&ctx.Cli.Exec).Run(ctx), "--tag can not be used with")
+}
+
+// TestE2EExecCredentialsEndpoint verifies that --credentials-endpoint serves the role
+// credentials to the subprocess instead of setting them in the environment and shuts
+// down the endpoint when the subprocess exits.
+func TestE2EExecCredentialsEndpoint(t *testing.T) {
+	for _, v := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_PROFILE"} {
+		unsetEnvForTest(t, v)
+	}
+
+	setup := newE2ESetup(t)
+	preAuth(t, setup)
+	populateCache(t, setup)
+	queueRoleCredentials(setup.Server)
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Cli.Exec = ExecCmd{
+		AccountId: AccountID(123456789012),
+		Role:      "ReadOnly",
+		Endpoint:  true,
+		Cmd:       "/bin/sh",
+		Args: []string{"-c", "echo URI=$AWS_CONTAINER_CREDENTIALS_FULL_URI; " +
+			"echo AUTH=$AWS_CONTAINER_AUTHORIZATION_TOKEN; echo KEY=$AWS_ACCESS_KEY_ID"},
+	}
+
+	output := captureStdout(func() {
+		err := (&ctx.Cli.Exec).Run(ctx)
+		require.NoError(t, err)
+	})
+
+	assert.Regexp(t, `URI=http://127\.0\.0\.1:\d+/\n`, output)
+	assert.Regexp(t, `AUTH=Bearer \S+\n`, output)
+	assert.Contains(t, output, "KEY=\n", "static credentials must not be set")
+
+	// the endpoint is shut down once the command exits
+	uri := strings.TrimPrefix(strings.Split(output, "\n")[0], "URI=")
+	_, err := http.Get(uri)
+	assert.Error(t, err)
+}
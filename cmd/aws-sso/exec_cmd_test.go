@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
+	"github.com/synfinatic/flexlog"
 )
 
 // unsetEnvForTest unsets key for the duration of the test, restoring it afterward.
@@ -21,6 +25,34 @@ func unsetEnvForTest(t *testing.T, key string) {
 	})
 }
 
+func TestNewCmdRedactsLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	oldLogger := log
+	log = logger.WithRedaction(flexlog.NewLogger(flexlog.NewJSON, buf, false, slog.LevelDebug, false))
+	defer func() { log = oldLogger }()
+
+	ctx := &RunContext{Cli: &CLI{}}
+	ctx.Cli.Exec.Cmd = "true"
+	cmd := newCmd(ctx, map[string]string{
+		"AWS_ACCESS_KEY_ID":                 "access-key-id",
+		"AWS_SECRET_ACCESS_KEY":             "secret-access-key",
+		"AWS_SESSION_TOKEN":                 "session-token",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN": "bearer-token",
+	})
+
+	// the command still gets the real values
+	assert.Contains(t, cmd.Env, "AWS_SECRET_ACCESS_KEY=secret-access-key")
+	assert.Contains(t, cmd.Env, "AWS_SESSION_TOKEN=session-token")
+	assert.Contains(t, cmd.Env, "AWS_CONTAINER_AUTHORIZATION_TOKEN=bearer-token")
+
+	output := buf.String()
+	assert.Contains(t, output, "AWS_SECRET_ACCESS_KEY")
+	assert.Contains(t, output, "access-key-id")
+	for _, secret := range []string{"secret-access-key", "session-token", "bearer-token"} {
+		assert.NotContains(t, output, secret)
+	}
+}
+
 func TestSetRegionVars(t *testing.T) {
 	t.Run("sets AWS_DEFAULT_REGION, AWS_REGION and sentinel when region is non-empty", func(t *testing.T) {
 		shellVars := map[string]string{}
@@ -163,3 +195,26 @@ func TestCheckAwsEnvironment(t *testing.T) {
 		assert.Contains(t, err.Error(), "AWS_PROFILE")
 	})
 }
+
+func TestEndpointShellEnvs(t *testing.T) {
+	shellVars := map[string]string{
+		"AWS_ACCESS_KEY_ID":          "AKIDTEST",
+		"AWS_SECRET_ACCESS_KEY":      "secret",
+		"AWS_SESSION_TOKEN":          "token",
+		"AWS_SSO_SESSION_EXPIRATION": "2026-01-01 00:00:00 -0000 UTC",
+		"AWS_SSO_ROLE_ARN":           "arn:aws:iam::123456789012:role/ReadOnly",
+		"AWS_DEFAULT_REGION":         "us-east-1",
+	}
+	containerEnv := map[string]string{
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": "http://127.0.0.1:1234/",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "Bearer token",
+	}
+
+	env := endpointShellEnvs(shellVars, containerEnv)
+	assert.Equal(t, map[string]string{
+		"AWS_SSO_ROLE_ARN":                   "arn:aws:iam::123456789012:role/ReadOnly",
+		"AWS_DEFAULT_REGION":                 "us-east-1",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI": "http://127.0.0.1:1234/",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN":  "Bearer token",
+	}, env)
+}
@@ -0,0 +1,344 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
+	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/gotable"
+)
+
+// execMultiJob is a role to run the command in
+type execMultiJob struct {
+	index int
+	role  *sso.AWSRoleFlat
+}
+
+// execMultiResult is the outcome of running the command in a single role
+type execMultiResult struct {
+	index    int
+	Profile  string
+	Arn      string
+	ExitCode int
+	Err      error
+}
+
+// execMultiRunner runs Cmd+Args in many roles at once
+type execMultiRunner struct {
+	ctx    *RunContext
+	awssso *ssoauth.AWSSSO
+	stdout io.Writer
+	stderr io.Writer
+	lock   sync.Mutex // protects our SecureStore & cache
+	output sync.Mutex // protects stdout & stderr
+}
+
+// execMulti runs Cmd+Args once in every role matching the --tag filters
+func execMulti(ctx *RunContext) error {
+	e := ctx.Cli.Exec
+	if e.Arn != "" || e.AccountId != 0 || e.Role != "" || e.Profile != "" {
+		return fmt.Errorf("--tag can not be used with --arn, --account, --role or --profile")
+	}
+
+	roles := ctx.Settings.Cache.GetSSO().Roles.MatchingRoles(e.Tag)
+	if len(roles) == 0 {
+		return fmt.Errorf("no roles match the tags: %s", formatTags(e.Tag))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return strings.Compare(roles[i].Arn, roles[j].Arn) < 0
+	})
+
+	workers := ctx.Settings.GetThreads()
+	if e.Threads > 0 {
+		workers = e.Threads
+	}
+	workers = max(min(workers, len(roles)), 1)
+
+	runner := &execMultiRunner{
+		ctx:    ctx,
+		awssso: AwsSSO,
+		stdout: os.Stdout,
+		stderr: os.Stderr,
+	}
+
+	tasks := make(chan execMultiJob, len(roles))
+	results := make(chan execMultiResult, len(roles))
+	for i, role := range roles {
+		tasks <- execMultiJob{index: i, role: role}
+	}
+	close(tasks)
+
+	log.Debug("Running command", "roles", len(roles), "workers", workers)
+	for w := 1; w <= workers; w++ {
+		go func() {
+			for job := range tasks {
+				results <- runner.run(job)
+			}
+		}()
+	}
+
+	summary := make([]execMultiResult, len(roles))
+	for range roles {
+		r := <-results
+		summary[r.index] = r
+	}
+	close(results)
+
+	if err := ctx.Settings.Cache.Save(false); err != nil {
+		log.Warn("Unable to update cache", "error", err.Error())
+	}
+	return printExecMultiSummary(summary)
+}
+
+// run runs Cmd+Args in the given role
+func (r *execMultiRunner) run(job execMultiJob) execMultiResult {
+	role := job.role
+	result := execMultiResult{
+		index:   job.index,
+		Profile: role.Arn,
+		Arn:     role.Arn,
+	}
+	if profile, err := role.ProfileName(r.ctx.Settings); err == nil && profile != "" {
+		result.Profile = profile
+	}
+
+	shellVars, err := r.shellVars(role.AccountId, role.RoleName)
+	if err != nil {
+		result.ExitCode = -1
+		result.Err = err
+		return result
+	}
+
+	cmd := newCmd(r.ctx, shellVars)
+	var stdout, stderr *prefixWriter
+	var outBuf, errBuf bytes.Buffer
+	if r.ctx.Cli.Exec.Collect {
+		cmd.Stdout = &outBuf
+		cmd.Stderr = &errBuf
+	} else {
+		prefix := fmt.Sprintf("[%s] ", result.Profile)
+		stdout = newPrefixWriter(r.stdout, prefix, &r.output)
+		stderr = newPrefixWriter(r.stderr, prefix, &r.output)
+		cmd.Stdout = stdout
+		cmd.Stderr = stderr
+	}
+
+	result.Err = cmd.Run()
+	result.ExitCode = exitCode(result.Err)
+
+	if r.ctx.Cli.Exec.Collect {
+		r.output.Lock()
+		fmt.Fprintf(r.stdout, "==> %s (exit code %d) <==\n", result.Profile, result.ExitCode)
+		_, _ = r.stdout.Write(outBuf.Bytes())
+		_, _ = r.stderr.Write(errBuf.Bytes())
+		r.output.Unlock()
+	} else {
+		_ = stdout.Flush()
+		_ = stderr.Flush()
+	}
+	return result
+}
+
+// shellVars returns the environment variables for the given role
+func (r *execMultiRunner) shellVars(accountId int64, role string) (map[string]string, error) {
+	creds, err := r.roleCredentials(accountId, role)
+	if err != nil {
+		return map[string]string{}, err
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	region := r.ctx.Settings.GetDefaultRegion(accountId, role, r.ctx.Cli.Exec.NoRegion, r.ctx.Cli.Exec.OverwriteEnv)
+	return shellEnvs(newCredentialsResponse(r.ctx, creds), region), nil
+}
+
+// roleCredentials is a goroutine safe version of GetRoleCredentials which returns
+// an error instead of exiting
+func (r *execMultiRunner) roleCredentials(accountId int64, role string) (*storage.RoleCredentials, error) {
+	r.lock.Lock()
+	arn := credentialsARN(r.ctx.Settings.Cache.GetSSO().Roles, r.awssso.Partition(), accountId, role)
+	if !r.ctx.Cli.Exec.STSRefresh {
+		if creds, ok := cachedRoleCredentials(r.ctx, arn); ok {
+			auditCredentials(r.ctx, newCredentialsEvent(r.ctx, arn, "cache", accountId, role), creds, nil)
+			r.lock.Unlock()
+			return creds, nil
+		}
+	}
+	r.lock.Unlock()
+
+	fetch := r.awssso.GetCredentials
+	if r.ctx.Cli.Exec.STSRefresh {
+		fetch = r.awssso.RefreshCredentials
+	}
+
+	var creds storage.RoleCredentials
+	var err error
+	configRole, _ := r.awssso.SSOConfig.GetRole(accountId, role)
+	if configRole.Via == "" && !awsparse.IsUserARN(arn) {
+		// AWS SSO roles don't touch our SecureStore so can be fetched in parallel
+		creds, err = fetch(arn)
+	} else {
+		// role chains & IAM Users read and write our SecureStore and may prompt
+		// for an MFA token, so fetch them one at a time
+		r.lock.Lock()
+		creds, err = fetch(arn)
+		r.lock.Unlock()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	auditCredentials(r.ctx, newCredentialsEvent(r.ctx, arn, "aws", accountId, role), &creds, err)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get role credentials for %s: %w", arn, err)
+	}
+	saveRoleCredentials(r.ctx, arn, creds)
+	return &creds, nil
+}
+
+// exitCode returns the exit code of the command given the error from Run().
+// Commands which could not be started return -1.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// printExecMultiSummary prints the exit code of the command in each role and
+// returns an error if any of them failed
+func printExecMultiSummary(results []execMultiResult) error {
+	failed := 0
+	ts := []gotable.TableStruct{}
+	for _, r := range results {
+		row := execMultiRow{
+			Profile:  r.Profile,
+			Arn:      r.Arn,
+			ExitCode: fmt.Sprintf("%d", r.ExitCode),
+		}
+		if r.Err != nil {
+			failed++
+			var exitErr *exec.ExitError
+			if !errors.As(r.Err, &exitErr) {
+				row.Error = r.Err.Error()
+			}
+		}
+		ts = append(ts, row)
+	}
+
+	fmt.Printf("\n")
+	if err := gotable.GenerateTable(ts, []string{"Profile", "Arn", "ExitCode", "Error"}); err != nil {
+		return err
+	}
+	fmt.Printf("\n")
+
+	if failed > 0 {
+		return fmt.Errorf("command failed in %d of %d roles", failed, len(results))
+	}
+	return nil
+}
+
+// execMultiRow is a row of the `exec --tag` summary table
+type execMultiRow struct {
+	Profile  string `header:"Profile"`
+	Arn      string `header:"ARN"`
+	ExitCode string `header:"ExitCode"`
+	Error    string `header:"Error"`
+}
+
+// GetHeader is required for GenerateTable()
+func (r execMultiRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+// formatTags returns the tags as a sorted list of key=value pairs
+func formatTags(tags map[string]string) string {
+	ret := []string{}
+	for k, v := range tags {
+		ret = append(ret, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+// prefixWriter writes each line to the underlying io.Writer with a prefix
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	lock   *sync.Mutex // shared by all writers of w so lines are not interleaved
+	buf    []byte
+}
+
+func newPrefixWriter(w io.Writer, prefix string, lock *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		w:      w,
+		prefix: prefix,
+		lock:   lock,
+	}
+}
+
+// Write buffers any partial line until the rest of it is written or Flush() is called
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := p.writeLine(p.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+// Flush writes any remaining partial line
+func (p *prefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	line := append(p.buf, '\n')
+	p.buf = nil
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	_, err := fmt.Fprintf(p.w, "%s%s", p.prefix, line)
+	return err
+}
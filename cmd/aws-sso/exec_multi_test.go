@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lock := &sync.Mutex{}
+	p := newPrefixWriter(buf, "[foo] ", lock)
+
+	n, err := p.Write([]byte("line one\nline "))
+	require.NoError(t, err)
+	assert.Equal(t, 14, n)
+	assert.Equal(t, "[foo] line one\n", buf.String())
+
+	_, err = p.Write([]byte("two\npartial"))
+	require.NoError(t, err)
+	assert.Equal(t, "[foo] line one\n[foo] line two\n", buf.String())
+
+	require.NoError(t, p.Flush())
+	assert.Equal(t, "[foo] line one\n[foo] line two\n[foo] partial\n", buf.String())
+
+	// nothing left to flush
+	require.NoError(t, p.Flush())
+	assert.Equal(t, "[foo] line one\n[foo] line two\n[foo] partial\n", buf.String())
+}
+
+func TestPrefixWriterConcurrent(t *testing.T) {
+	buf := &bytes.Buffer{}
+	lock := &sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p := newPrefixWriter(buf, fmt.Sprintf("[%d] ", i), lock)
+			for j := 0; j < 100; j++ {
+				_, _ = p.Write([]byte(fmt.Sprintf("%d\n", i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	// every line must be intact
+	for _, line := range bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n")) {
+		var a, b int
+		_, err := fmt.Sscanf(string(line), "[%d] %d", &a, &b)
+		require.NoError(t, err)
+		assert.Equal(t, a, b)
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 0, exitCode(nil))
+	assert.Equal(t, -1, exitCode(errors.New("not started")))
+
+	err := exec.Command("/bin/sh", "-c", "exit 3").Run()
+	assert.Equal(t, 3, exitCode(err))
+}
+
+func TestFormatTags(t *testing.T) {
+	assert.Equal(t, "", formatTags(map[string]string{}))
+	assert.Equal(t, "Env=prod, Team=ops", formatTags(map[string]string{"Team": "ops", "Env": "prod"}))
+}
+
+func TestPrintExecMultiSummary(t *testing.T) {
+	results := []execMultiResult{
+		{Profile: "a", Arn: "arn:aws:iam::111111111111:role/a"},
+		{Profile: "b", Arn: "arn:aws:iam::111111111111:role/b"},
+	}
+	assert.NoError(t, printExecMultiSummary(results))
+
+	results[1].ExitCode = 2
+	results[1].Err = exec.Command("/bin/sh", "-c", "exit 2").Run()
+	assert.ErrorContains(t, printExecMultiSummary(results), "command failed in 1 of 2 roles")
+}
@@ -19,14 +19,28 @@ package main
  */
 
 import (
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
 	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/cache"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
+	"github.com/synfinatic/gotable"
 )
 
 type LoginCmd struct {
-	UrlAction string `kong:"short='u',help='How to handle URLs [clip|exec|open|print|printurl|granted-containers|open-url-in-container|ansi-osc52] (default: open)',predictor='urlAction'"`
-	Threads   int    `kong:"help='Override number of threads for talking to AWS',default=${DEFAULT_THREADS}"`
-	Force     bool   `kong:"short='f',help='End the current SSO session and start a new one, resetting the session duration'"`
+	UrlAction string        `kong:"short='u',help='How to handle URLs [clip|exec|open|print|printurl|qrcode|granted-containers|open-url-in-container|ansi-osc52] (default: open)',predictor='urlAction'"`
+	Threads   int           `kong:"help='Override number of threads for talking to AWS',default=${DEFAULT_THREADS}"`
+	Force     bool          `kong:"short='f',help='End the current SSO session and start a new one, resetting the session duration'"`
+	KeepAlive bool          `kong:"name='keepalive',help='Keep running and refresh the SSO token before it expires'"`
+	Refresh   time.Duration `kong:"name='refresh-time',help='How long before expiration to refresh the SSO token with --keepalive',default='15m'"`
+	All       bool          `kong:"short='a',help='Log into every AWS SSO instance in turn'"`
+	Match     string        `kong:"short='m',help='Log into every AWS SSO instance whose name matches the glob pattern'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -36,7 +50,29 @@ func (l LoginCmd) AfterApply(runCtx *RunContext) error {
 }
 
 func (cc *LoginCmd) Run(ctx *RunContext) error {
+	if ctx.Cli.Login.All || ctx.Cli.Login.Match != "" {
+		if ctx.Cli.Login.KeepAlive {
+			return fmt.Errorf("--keepalive can not be used with --all or --match")
+		}
+		return loginAll(ctx, map[string]*ssoauth.AWSSSO{})
+	}
+
 	doAuth(ctx)
+	if ctx.Cli.Login.KeepAlive {
+		return keepAlive(ctx, AwsSSO, ctx.Cli.Login.Refresh)
+	}
+	return nil
+}
+
+// keepAlive refreshes the SSO token before it expires until we are interrupted
+// or the refresh token lapses
+func keepAlive(ctx *RunContext, as *ssoauth.AWSSSO, refreshBefore time.Duration) error {
+	log.Info("Refreshing the AWS SSO token before it expires.  Press Ctrl-C to exit.",
+		"storeKey", as.StoreKey(), "refreshTime", refreshBefore.String())
+	if err := as.KeepAlive(ctx.Ctx, refreshBefore); err != nil {
+		return err
+	}
+	log.Info("Stopped refreshing the AWS SSO token", "storeKey", as.StoreKey())
 	return nil
 }
 
@@ -48,7 +84,7 @@ func initAwsSSO(ctx *RunContext) *ssoauth.AWSSSO {
 			log.Fatal("unable to select SSO", "sso", ctx.Cli.SSO, err.Error())
 		}
 
-		AwsSSO = ssoauth.NewAWSSSO(s, ctx.Store)
+		AwsSSO = newAWSSSO(ctx, s)
 	}
 
 	return AwsSSO
@@ -87,6 +123,18 @@ func endCurrentSession(ctx *RunContext, as *ssoauth.AWSSSO) {
 	log.Debug("Ended the current SSO session", "storeKey", as.StoreKey())
 }
 
+// loginUrlAction returns how to handle the login URL for the given AWS SSO instance
+func loginUrlAction(ctx *RunContext, s *ssoconfig.SSOConfig) (uri.Action, error) {
+	if len(ctx.Cli.Login.UrlAction) > 0 {
+		// CLI override
+		return uri.NewAction(ctx.Cli.Login.UrlAction)
+	} else if s.AuthUrlAction != uri.Undef {
+		// Auth specific override
+		return s.AuthUrlAction, nil
+	}
+	return ctx.Settings.UrlAction, nil // global default
+}
+
 // doAuth creates a singleton AWSSO object post authentication
 func doAuth(ctx *RunContext) {
 	as := initAwsSSO(ctx)
@@ -99,19 +147,19 @@ func doAuth(ctx *RunContext) {
 		return
 	}
 
-	var err error
-	action := ctx.Settings.UrlAction // global default
-	if len(ctx.Cli.Login.UrlAction) > 0 {
-		// CLI override
-		action, err = uri.NewAction(ctx.Cli.Login.UrlAction)
-		if err != nil {
-			log.Fatal("Invalid --url-action", "action", ctx.Cli.Login.UrlAction)
-		}
-	} else if AwsSSO.SSOConfig.AuthUrlAction != uri.Undef {
-		// Auth specific override
-		action = AwsSSO.SSOConfig.AuthUrlAction
+	action, err := loginUrlAction(ctx, AwsSSO.SSOConfig)
+	if err != nil {
+		log.Fatal("Invalid --url-action", "action", ctx.Cli.Login.UrlAction)
 	}
 	err = AwsSSO.Authenticate(ctx.Ctx, action, ctx.Settings.Browser)
+	e := audit.Event{
+		Action: audit.Login,
+		SSO:    auditSSOName(ctx),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	auditEvent(ctx, e)
 	if err != nil {
 		log.Fatal("Unable to authenticate", "error", err.Error())
 	}
@@ -141,3 +189,154 @@ func doAuth(ctx *RunContext) {
 		}
 	}
 }
+
+// loginAllRow is the result of logging into an AWS SSO instance via --all
+type loginAllRow struct {
+	SSO   string `header:"SSO"`
+	Login string `header:"Login"`
+	Cache string `header:"Cache"`
+	Error string `header:"Error"`
+}
+
+// GetHeader is required for GenerateTable()
+func (r loginAllRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+// selectSSONames returns the sorted names which match the glob pattern.
+// An empty pattern matches every name.
+func selectSSONames(names []string, pattern string) ([]string, error) {
+	selected := []string{}
+	for _, name := range names {
+		if pattern != "" {
+			match, err := path.Match(pattern, name)
+			if err != nil {
+				return selected, fmt.Errorf("invalid --match pattern %s: %w", pattern, err)
+			}
+			if !match {
+				continue
+			}
+		}
+		selected = append(selected, name)
+	}
+
+	if len(selected) == 0 {
+		return selected, fmt.Errorf("no AWS SSO instances match %s", pattern)
+	}
+	sort.Strings(selected)
+	return selected, nil
+}
+
+// loginAll logs into each of the selected AWS SSO instances in turn and then
+// refreshes their caches in parallel.  awsSSOs holds any AWSSSO we should use
+// instead of creating a new one.
+func loginAll(ctx *RunContext, awsSSOs map[string]*ssoauth.AWSSSO) error {
+	names, err := selectSSONames(ctx.Settings.GetSSONames(), ctx.Cli.Login.Match)
+	if err != nil {
+		return err
+	}
+
+	selected, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]loginAllRow, len(names))
+	jobs := []cache.RefreshJob{}
+	jobRows := []int{}
+	for i, name := range names {
+		rows[i].SSO = name
+		as, ok := awsSSOs[name]
+		if !ok {
+			if name == selected {
+				as = initAwsSSO(ctx)
+			} else {
+				as = newAWSSSO(ctx, ctx.Settings.SSO[name])
+			}
+			awsSSOs[name] = as
+		}
+
+		rows[i].Login, err = loginSSO(ctx, name, as)
+		if err != nil {
+			rows[i].Cache = "Skipped"
+			rows[i].Error = err.Error()
+			continue
+		}
+
+		jobs = append(jobs, cache.RefreshJob{
+			SSO:     as,
+			Config:  ctx.Settings.SSO[name],
+			SSOName: name,
+		})
+		jobRows = append(jobRows, i)
+	}
+
+	if len(jobs) > 0 {
+		log.Info("Refreshing AWS SSO role caches, please wait...", "count", len(jobs))
+		results := ctx.Settings.Cache.RefreshAll(jobs, ctx.Cli.Login.Threads, ctx.Settings)
+		for j, r := range results {
+			row := &rows[jobRows[j]]
+			if r.Err != nil {
+				row.Cache = "Failed"
+				row.Error = r.Err.Error()
+				continue
+			}
+			row.Cache = fmt.Sprintf("Updated (+%d/-%d)", len(r.Added), len(r.Deleted))
+		}
+
+		// RefreshAll has already set the LastUpdate of each refreshed cache
+		if err = ctx.Settings.Cache.Save(false); err != nil {
+			log.Error("Unable to save cache", "error", err.Error())
+		}
+	}
+
+	failed := 0
+	ts := []gotable.TableStruct{}
+	for _, row := range rows {
+		if row.Error != "" {
+			failed++
+		}
+		ts = append(ts, row)
+	}
+	if err = gotable.GenerateTable(ts, []string{"SSO", "Login", "Cache", "Error"}); err != nil {
+		fmt.Printf("\n")
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("unable to log into %d of %d AWS SSO instances", failed, len(names))
+	}
+	return nil
+}
+
+// loginSSO logs into a single AWS SSO instance for loginAll().  Every login
+// uses the same browser so the session with the identity provider is reused.
+// Returns the status of the login.
+func loginSSO(ctx *RunContext, ssoName string, as *ssoauth.AWSSSO) (string, error) {
+	if ctx.Cli.Login.Force {
+		endCurrentSession(ctx, as)
+	} else if as.ValidAuthToken(ctx.Ctx) {
+		return "Already logged in", nil
+	}
+
+	action, err := loginUrlAction(ctx, as.SSOConfig)
+	if err != nil {
+		return "Failed", err
+	}
+
+	log.Info("Logging into AWS SSO", "sso", ssoName)
+	err = as.Authenticate(ctx.Ctx, action, ctx.Settings.Browser)
+	e := audit.Event{
+		Action: audit.Login,
+		SSO:    ssoName,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	auditEvent(ctx, e)
+	if err != nil {
+		return "Failed", err
+	}
+	return "Logged in", nil
+}
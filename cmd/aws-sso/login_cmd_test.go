@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectSSONames(t *testing.T) {
+	names := []string{"Prod", "Default", "ProdEU", "Dev"}
+
+	selected, err := selectSSONames(names, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Default", "Dev", "Prod", "ProdEU"}, selected)
+
+	selected, err = selectSSONames(names, "Prod*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Prod", "ProdEU"}, selected)
+
+	selected, err = selectSSONames(names, "D?v")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Dev"}, selected)
+
+	_, err = selectSSONames(names, "Staging*")
+	assert.ErrorContains(t, err, "no AWS SSO instances match Staging*")
+
+	_, err = selectSSONames(names, "[")
+	assert.ErrorContains(t, err, "invalid --match pattern")
+}
@@ -21,12 +21,14 @@ package main
  */
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/synfinatic/aws-sso-cli/internal/awsmock"
+	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
@@ -381,3 +383,144 @@ func TestE2ELogin_PKCE(t *testing.T) {
 	assert.Equal(t, "pkce-access-token", ctr.AccessToken)
 	assert.False(t, ctr.Expired(), "token should not be expired after PKCE login")
 }
+
+// TestE2ELogin_KeepAlive verifies --keepalive keeps refreshing the token before
+// it expires and exits with an error once the refresh token has lapsed.
+func TestE2ELogin_KeepAlive(t *testing.T) {
+	setup := newE2ESetup(t)
+	preAuthExpired(t, setup, "seeded-refresh-token")
+
+	// tokens which expire in a second are due for refresh almost immediately
+	setup.Server.SSOOIDC.QueueCreateToken(awsmock.OIDCTokenResponse{
+		AccessToken:  "refreshed-access-token",
+		ExpiresIn:    1,
+		RefreshToken: "rotated-refresh-token",
+		TokenType:    "Bearer",
+	})
+	setup.Server.SSOOIDC.QueueCreateToken(awsmock.OIDCTokenResponse{
+		AccessToken: "keepalive-access-token",
+		ExpiresIn:   1,
+		TokenType:   "Bearer",
+	})
+	setup.Server.SSOOIDC.QueueCreateTokenError(http.StatusBadRequest,
+		`{"__type":"InvalidGrantException","error":"invalid_grant"}`)
+
+	ctx := newRunContext(setup, AUTH_SKIP)
+	ctx.Cli.Login = LoginCmd{UrlAction: "print", Threads: 1, KeepAlive: true, Refresh: ssoauth.DEFAULT_KEEPALIVE_REFRESH}
+
+	cmd := &LoginCmd{}
+	err := cmd.Run(ctx)
+	assert.ErrorIs(t, err, ssoauth.ErrRefreshTokenExpired)
+
+	assert.Equal(t, []string{
+		string(storage.GrantTypeRefreshToken),
+		string(storage.GrantTypeRefreshToken),
+		string(storage.GrantTypeRefreshToken),
+	}, setup.Server.SSOOIDC.TokenGrants())
+
+	var ctr storage.CreateTokenResponse
+	require.NoError(t, setup.Store.GetCreateTokenResponse(AwsSSO.StoreKey(), &ctr))
+	assert.Equal(t, "keepalive-access-token", ctr.AccessToken)
+	// AWS didn't rotate the refresh token, so we keep using the old one
+	assert.Equal(t, "rotated-refresh-token", ctr.RefreshToken)
+}
+
+// queueLoginAllRefresh queues the ListAccounts/ListAccountRoles responses for
+// the parallel cache refresh of count AWS SSO instances
+func queueLoginAllRefresh(setup *e2eSetup, count int) {
+	for i := 0; i < count; i++ {
+		setup.Server.SSO.QueueListAccounts(awsmock.ListAccountsResponse{
+			AccountList: []awsmock.AccountInfo{
+				{AccountID: "123456789012", AccountName: "TestAccount", EmailAddress: "admin@example.com"},
+			},
+		})
+		setup.Server.SSO.QueueListAccountRoles(awsmock.ListAccountRolesResponse{
+			RoleList: []awsmock.RoleInfo{
+				{AccountID: "123456789012", RoleName: "ReadOnly"},
+			},
+		})
+	}
+}
+
+// TestE2ELogin_All logs into every AWS SSO instance, skipping the one which
+// already has a valid token, and refreshes each of their caches.
+func TestE2ELogin_All(t *testing.T) {
+	setup := newE2ESetupMultiSSO(t, "Default")
+	preAuth(t, setup)
+
+	secondaryConf, err := setup.Settings.GetSelectedSSO("Secondary")
+	require.NoError(t, err)
+	secondary := ssoauth.NewAWSSSOForTest(secondaryConf, setup.Store, setup.Server.URL())
+
+	setup.Server.SSOOIDC.QueueRegisterClient(awsmock.RegisterClientResponse{
+		ClientID:              "secondary-client-id",
+		ClientSecret:          "secondary-client-secret",
+		ClientIDIssuedAt:      time.Now().Unix(),
+		ClientSecretExpiresAt: time.Now().Add(30 * 24 * time.Hour).Unix(),
+	})
+	setup.Server.SSOOIDC.QueueDeviceAuth(awsmock.DeviceAuthResponse{
+		DeviceCode:              "device-code",
+		UserCode:                "CODE-1234",
+		VerificationURI:         "https://verify.example.com",
+		VerificationURIComplete: "https://verify.example.com?user_code=CODE-1234",
+		ExpiresIn:               600,
+		Interval:                0,
+	})
+	setup.Server.SSOOIDC.QueueCreateToken(awsmock.OIDCTokenResponse{
+		AccessToken: "secondary-access-token",
+		ExpiresIn:   3600,
+		TokenType:   "Bearer",
+	})
+	queueLoginAllRefresh(setup, 2)
+
+	ctx := newRunContext(setup, AUTH_SKIP)
+	ctx.Cli.Login = LoginCmd{UrlAction: "print", Threads: 1, All: true}
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = loginAll(ctx, map[string]*ssoauth.AWSSSO{"Secondary": secondary})
+	})
+	require.NoError(t, runErr)
+	assert.Contains(t, out, "Already logged in")
+	assert.Contains(t, out, "Logged in")
+	assert.Equal(t, []string{string(storage.GrantTypeDeviceCode)}, setup.Server.SSOOIDC.TokenGrants())
+
+	var ctr storage.CreateTokenResponse
+	require.NoError(t, setup.Store.GetCreateTokenResponse(secondary.StoreKey(), &ctr))
+	assert.Equal(t, "secondary-access-token", ctr.AccessToken)
+
+	for _, name := range []string{"Default", "Secondary"} {
+		c := setup.Settings.Cache.GetSSOByName(name)
+		assert.NotZero(t, c.LastUpdate, name)
+		assert.Contains(t, c.Roles.Accounts[123456789012].Roles, "ReadOnly", name)
+	}
+}
+
+// TestE2ELogin_AllFailure reports the AWS SSO instances we could not log
+// into and still refreshes the cache of the rest.
+func TestE2ELogin_AllFailure(t *testing.T) {
+	setup := newE2ESetupMultiSSO(t, "Default")
+	preAuth(t, setup)
+
+	secondaryConf, err := setup.Settings.GetSelectedSSO("Secondary")
+	require.NoError(t, err)
+	secondary := ssoauth.NewAWSSSOForTest(secondaryConf, setup.Store, setup.Server.URL())
+
+	setup.Server.SSOOIDC.QueueRegisterClientError(http.StatusBadRequest,
+		`{"__type":"InvalidClientMetadataException","error":"invalid_client_metadata"}`)
+	queueLoginAllRefresh(setup, 1)
+
+	ctx := newRunContext(setup, AUTH_SKIP)
+	ctx.Cli.Login = LoginCmd{UrlAction: "print", Threads: 1, Match: "*"}
+
+	var runErr error
+	out := captureStdout(func() {
+		runErr = loginAll(ctx, map[string]*ssoauth.AWSSSO{"Secondary": secondary})
+	})
+	assert.ErrorContains(t, runErr, "unable to log into 1 of 2 AWS SSO instances")
+	assert.Contains(t, out, "Failed")
+	assert.Contains(t, out, "Skipped")
+
+	assert.NotZero(t, setup.Settings.Cache.GetSSOByName("Default").LastUpdate)
+	assert.Zero(t, setup.Settings.Cache.GetSSOByName("Secondary").LastUpdate)
+}
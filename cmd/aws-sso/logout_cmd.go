@@ -18,6 +18,7 @@ package main
  * along with this program.  If not, see <http://www.gnu.org/licenses/>.
  */
 import (
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
 	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
 )
 
@@ -41,7 +42,17 @@ func (cc *LogoutCmd) Run(ctx *RunContext) error {
 	awssso := ssoauth.NewAWSSSO(s, ctx.Store)
 
 	flushSts(ctx, awssso)
-	return awssso.Logout(ctx.Ctx) // invalidate our AccessToken
+	err = awssso.Logout(ctx.Ctx) // invalidate our AccessToken
+
+	e := audit.Event{
+		Action: audit.Logout,
+		SSO:    auditSSOName(ctx),
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	auditEvent(ctx, e)
+	return err
 }
 
 // flushSts flushes our IAM STS Role credentials from the secure store
@@ -54,6 +65,7 @@ func flushSts(ctx *RunContext, awssso *ssoauth.AWSSSO) {
 			}
 		}
 	}
+	awssso.DeleteMfaSessions(ctx.Ctx)
 	if err := ctx.Settings.Cache.MarkRolesExpired(); err != nil {
 		log.Error("failed to mark roles expired", "error", err.Error())
 	} else {
@@ -19,6 +19,7 @@ package main
  */
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -32,14 +33,19 @@ import (
 
 	// "github.com/davecgh/go-spew/spew"
 
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 	"github.com/synfinatic/aws-sso-cli/internal/config"
 	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
 	"github.com/synfinatic/aws-sso-cli/internal/predictor"
 	"github.com/synfinatic/aws-sso-cli/internal/sso"
 	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
+	ssocache "github.com/synfinatic/aws-sso-cli/internal/sso/cache"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	"github.com/willabides/kongplete"
+	"golang.org/x/term"
 )
 
 // These variables are defined in the Makefile
@@ -84,6 +90,7 @@ type RunContext struct {
 	Store    storage.SecureStorage
 	Auth     CommandAuth
 	Ctx      context.Context
+	Audit    *audit.Logger // nil if the audit log is disabled
 }
 
 const (
@@ -109,6 +116,8 @@ var DEFAULT_CONFIG map[string]interface{} = map[string]interface{}{ // #nosec G1
 	"PromptColors.SelectedSuggestionTextColor":  "White",
 	"PromptColors.SuggestionBGColor":            "Cyan",
 	"PromptColors.SuggestionTextColor":          "White",
+	"Audit.MaxFiles":                            audit.DEFAULT_MAX_FILES,
+	"Audit.MaxSize":                             audit.DEFAULT_MAX_SIZE, // MB
 	"AutoConfigCheck":                           false,
 	"AutoLogin":                                 false,
 	"CacheRefresh":                              168, // 7 days in hours
@@ -146,19 +155,25 @@ type CLI struct {
 	LogLevel   LogLevelType `kong:"short='L',name='level',help='Logging level [error|warn|info|debug|trace] (default: info)'"`
 	Lines      bool         `kong:"help='Print line number in logs'"`
 	SSO        string       `kong:"short='S',help='Override default AWS SSO Instance',env='AWS_SSO',predictor='sso'"`
+	MfaToken   string       `kong:"name='mfa-token',help='MFA code for roles with an MfaSerial',env='AWS_SSO_MFA_TOKEN'"`
 
 	// Commands
 	Default      DefaultCmd      `kong:"cmd,hidden,default='1'"` // list command without args
+	Audit        AuditCmd        `kong:"cmd,help='Query the audit log of credentials and logins'"`
+	Chain        ChainCmd        `kong:"cmd,help='Explain role chains configured with Via'"`
 	Ecs          EcsCmd          `kong:"cmd,help='ECS server/client commands'"`
 	List         ListCmd         `kong:"cmd,help='List all accounts / roles (default command)'"`
 	Login        LoginCmd        `kong:"cmd,help='Login to an AWS Identity Center instance'"`
 	ListSSORoles ListSSORolesCmd `kong:"cmd,hidden,help='List AWS SSO Roles (debugging)'"`
 	Setup        SetupCmd        `kong:"cmd,help='Setup Wizard, Completions, Profiles, etc'"`
+	Static       StaticCmd       `kong:"cmd,help='Manage long-lived IAM User credentials'"`
+	Status       StatusCmd       `kong:"cmd,help='Print the state of your AWS SSO sessions, credentials and cache'"`
 	Tags         TagsCmd         `kong:"cmd,help='List tags'"`
 	Time         TimeCmd         `kong:"cmd,help='Print how much time before current STS Token expires'"`
 	Version      VersionCmd      `kong:"cmd,help='Print version and exit'"`
 
 	// Login Commands
+	Agent       AgentCmd       `kong:"cmd,help='Run an agent which serves AWS credentials over a Unix socket',group='login-required'"`
 	Cache       CacheCmd       `kong:"cmd,help='Force reload of cached AWS SSO role info and config.yaml',group='login-required'"`
 	Console     ConsoleCmd     `kong:"cmd,help='Open AWS Console using specificed AWS role/profile',group='login-required'"`
 	Credentials CredentialsCmd `kong:"cmd,help='Generate static AWS credentials for use with AWS CLI',group='login-required'"`
@@ -242,13 +257,19 @@ func main() {
 	cacheFile := config.InsecureCacheFile(true)
 
 	if runCtx.Settings, err = sso.LoadSettings(runCtx.Cli.ConfigFile, cacheFile, DEFAULT_CONFIG, override); err != nil {
-		log.Fatal(err.Error())
+		if !errors.Is(err, roles.ErrMissingHop) || !fixesMissingHops(runCtx.Kctx.Command()) {
+			log.Fatal(err.Error())
+		}
+		// our cache may just be out of date
+		log.Warn(err.Error())
 	}
+	runCtx.Audit = newAuditLogger(runCtx.Settings, runCtx.Kctx)
 
 	switch runCtx.Auth {
 	case AUTH_REQUIRED:
 		// make sure we have authenticated via AWS SSO and init SecureStore
 		loadSecureStore(&runCtx)
+		syncStaticUsers(&runCtx)
 		if !checkAuth(&runCtx) {
 			if !runCtx.Settings.AutoLogin {
 				log.Fatal(fmt.Sprintf("Must run `aws-sso login` before running `aws-sso %s`", runCtx.Kctx.Command()))
@@ -266,7 +287,8 @@ func main() {
 		}
 
 		loadSecureStore(c)
-		AwsSSO = ssoauth.NewAWSSSO(s, c.Store)
+		syncStaticUsers(c)
+		AwsSSO = newAWSSSO(c, s)
 	case AUTH_UNKNOWN:
 		log.Fatal("Internal error: AUTH_UNKNOWN, please open a bug report")
 	}
@@ -277,35 +299,106 @@ func main() {
 	}
 }
 
+// fixesMissingHops returns true if the command updates the roles and IAM Users
+// in our cache, which may fix a role chain with a missing hop
+func fixesMissingHops(command string) bool {
+	return command == "cache" || command == "login" || command == "static add"
+}
+
 // loadSecureStore loads our secure store data for future access
 func loadSecureStore(ctx *RunContext) {
 	var err error
-	switch ctx.Settings.SecureStore {
+	if ctx.Store, err = openSecureStore(ctx, ctx.Settings.SecureStore); err != nil {
+		log.Fatal(err.Error())
+	}
+}
+
+// newAWSSSO returns a new AWSSSO for the given AWS SSO instance
+func newAWSSSO(ctx *RunContext, s *ssoconfig.SSOConfig) *ssoauth.AWSSSO {
+	as := ssoauth.NewAWSSSO(s, ctx.Store)
+	as.SetMfaTokenFunc(func(mfaSerial string) (string, error) {
+		return mfaToken(ctx, mfaSerial)
+	})
+	return as
+}
+
+// mfaToken returns the value of --mfa-token or prompts the user for the
+// current code of the given MFA device
+func mfaToken(ctx *RunContext, mfaSerial string) (string, error) {
+	token := ctx.Cli.MfaToken
+	if token == "" {
+		if !term.IsTerminal(int(os.Stdin.Fd())) {
+			return "", fmt.Errorf("MFA code required for %s: use --mfa-token or $AWS_SSO_MFA_TOKEN", mfaSerial)
+		}
+		fmt.Fprintf(os.Stderr, "Enter MFA code for %s: ", mfaSerial)
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		token = strings.TrimSpace(line)
+	}
+
+	if len(token) != 6 || strings.Trim(token, "0123456789") != "" {
+		return "", fmt.Errorf("invalid MFA code for %s: must be 6 digits", mfaSerial)
+	}
+	return token, nil
+}
+
+// syncStaticUsers updates the cache with the IAM Users in our SecureStore
+func syncStaticUsers(ctx *RunContext) {
+	ssoName, err := ctx.Settings.GetSelectedSSOName(ctx.Cli.SSO)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	users := []storage.StaticCredentials{}
+	for _, arn := range ctx.Store.ListStaticCredentials() {
+		user := storage.StaticCredentials{}
+		if err := ctx.Store.GetStaticCredentials(arn, &user); err != nil {
+			log.Warn("Unable to load static credentials", "arn", arn, "error", err.Error())
+			continue
+		}
+		users = append(users, user)
+	}
+
+	if ctx.Settings.Cache.SetStaticUsers(ssoName, users) {
+		if err := ctx.Settings.Cache.Save(false); err != nil {
+			log.Warn("Unable to update cache", "error", err.Error())
+		}
+	}
+}
+
+// openSecureStore opens the given type of SecureStore using the settings in our config
+func openSecureStore(ctx *RunContext, storeType string) (storage.SecureStorage, error) {
+	switch storeType {
 	case "json":
 		sfile := config.JsonStoreFile(true)
 		if ctx.Settings.JsonStore != "" {
 			sfile = fileutils.GetHomePath(ctx.Settings.JsonStore)
 		}
-		ctx.Store, err = storage.OpenJsonStore(ctx.Ctx, sfile)
+		store, err := storage.OpenJsonStore(ctx.Ctx, sfile)
 		if err != nil {
-			log.Fatal("Unable to open JsonStore", "file", sfile, "error", err.Error())
+			return nil, fmt.Errorf("unable to open JsonStore %s: %w", sfile, err)
 		}
 		log.Warn("Using insecure json file for SecureStore", "file", sfile)
+		return store, nil
 	case "1password":
 		op := ctx.Settings.OnePassword
-		ctx.Store, err = storage.OpenOnePasswordStore(ctx.Ctx, op.AuthType, op.Vault, op.Account)
+		store, err := storage.OpenOnePasswordStore(ctx.Ctx, op.AuthType, op.Vault, op.Account)
 		if err != nil {
-			log.Fatal("Unable to open 1Password SecureStore", "error", err.Error())
+			return nil, fmt.Errorf("unable to open 1Password SecureStore: %w", err)
 		}
+		return store, nil
 	default:
-		cfg, err := storage.NewKeyringConfig(ctx.Settings.SecureStore, config.ConfigDir(true), ctx.Settings.SecretServiceCollection)
+		cfg, err := storage.NewKeyringConfig(storeType, config.ConfigDir(true), ctx.Settings.SecretServiceCollection)
 		if err != nil {
-			log.Fatal("Unable to create SecureStore", "error", err.Error())
+			return nil, fmt.Errorf("unable to create SecureStore: %w", err)
 		}
-		ctx.Store, err = storage.OpenKeyring(ctx.Ctx, cfg)
+		store, err := storage.OpenKeyring(ctx.Ctx, cfg)
 		if err != nil {
-			log.Fatal("Unable to open SecureStore", "file", ctx.Settings.SecureStore, "error", err.Error())
+			return nil, fmt.Errorf("unable to open SecureStore %s: %w", storeType, err)
 		}
+		return store, nil
 	}
 }
 
@@ -318,8 +411,41 @@ func parseArgs(ctx *RunContext) sso.OverrideSettings {
 func parseArgsFrom(ctx *RunContext, args []string) sso.OverrideSettings {
 	var err error
 
+	cli := ctx.Cli
+	parser := newParser(ctx)
+
+	p := predictor.NewPredictor(config.InsecureCacheFile(true), config.ConfigFile(true))
+
+	kongplete.Complete(parser,
+		kongplete.WithPredictors(p.KongpletePredictor()),
+	)
+
+	ctx.Kctx, err = parser.Parse(args)
+	parser.FatalIfErrorf(err)
+
+	threads := 0
+	if cli.Cache.Threads != DEFAULT_THREADS {
+		threads = cli.Cache.Threads
+	} else if cli.Login.Threads != DEFAULT_THREADS {
+		threads = cli.Login.Threads
+	}
+
+	override := sso.OverrideSettings{
+		Browser:    cli.Browser,
+		DefaultSSO: cli.SSO,
+		LogLevel:   string(cli.LogLevel),
+		LogLines:   cli.Lines,
+		Threads:    threads, // must be > 0 to override config
+	}
+
+	return override
+}
+
+// newParser returns our kong CLI parser for ctx.Cli
+func newParser(ctx *RunContext) *kong.Kong {
 	// need to pass in the variables for defaults
 	vars := kong.Vars{
+		"AGENT_SOCK_FILE": config.AgentSockFile(false),
 		"CONFIG_DIR":      config.ConfigDir(false),
 		"CONFIG_FILE":     config.ConfigFile(false),
 		"DEFAULT_STORE":   DEFAULT_STORE,
@@ -341,12 +467,14 @@ func parseArgsFrom(ctx *RunContext, args []string) sso.OverrideSettings {
 			Title: "Add SSL Certificate/Key:",
 			Key:   "add-ssl",
 		},
+		{
+			Title: "Generate SSL Certificate/Key:",
+			Key:   "generate-ssl",
+		},
 	}
 
-	cli := ctx.Cli
-
-	parser := kong.Must(
-		cli,
+	return kong.Must(
+		ctx.Cli,
 		kong.Name("aws-sso"),
 		kong.Description("Securely manage temporary AWS API Credentials issued via AWS SSO"),
 		kong.ConfigureHelp(help),
@@ -354,32 +482,6 @@ func parseArgsFrom(ctx *RunContext, args []string) sso.OverrideSettings {
 		kong.ExplicitGroups(groups),
 		kong.Bind(ctx),
 	)
-
-	p := predictor.NewPredictor(config.InsecureCacheFile(true), config.ConfigFile(true))
-
-	kongplete.Complete(parser,
-		kongplete.WithPredictors(p.KongpletePredictor()),
-	)
-
-	ctx.Kctx, err = parser.Parse(args)
-	parser.FatalIfErrorf(err)
-
-	threads := 0
-	if cli.Cache.Threads != DEFAULT_THREADS {
-		threads = cli.Cache.Threads
-	} else if cli.Login.Threads != DEFAULT_THREADS {
-		threads = cli.Login.Threads
-	}
-
-	override := sso.OverrideSettings{
-		Browser:    cli.Browser,
-		DefaultSSO: cli.SSO,
-		LogLevel:   string(cli.LogLevel),
-		LogLines:   cli.Lines,
-		Threads:    threads, // must be > 0 to override config
-	}
-
-	return override
 }
 
 type VersionCmd struct{} // takes no arguments
@@ -400,47 +502,91 @@ func (cc *VersionCmd) Run(ctx *RunContext) error {
 	return nil
 }
 
+// roleARN returns the ARN of the role, or IAM User with StaticCredentials, in the
+// partition of the selected AWS SSO instance
+func roleARN(ctx *RunContext, accountId int64, role string) string {
+	partition := awsparse.PARTITION_AWS
+	if s, err := ctx.Settings.GetSelectedSSO(ctx.Cli.SSO); err == nil {
+		partition = s.Partition()
+	}
+	return credentialsARN(ctx.Settings.Cache.GetSSO().Roles, partition, accountId, role)
+}
+
 // Get our RoleCredentials from the secure store or from AWS SSO
 func GetRoleCredentials(ctx *RunContext, awssso *ssoauth.AWSSSO, refreshSTS bool, accountid int64, role string) *storage.RoleCredentials {
-	creds := storage.RoleCredentials{}
-
 	// First look for our creds in the secure store, if we're not forcing a refresh
-	arn := awsparse.MakeRoleARN(accountid, role)
+	arn := credentialsARN(ctx.Settings.Cache.GetSSO().Roles, awssso.Partition(), accountid, role)
 	log.Debug("Getting role credentials", "arn", arn)
 	if !refreshSTS {
-		if roleFlat, err := ctx.Settings.Cache.GetRole(arn); err == nil {
-			if !roleFlat.IsExpired() {
-				if err := ctx.Store.GetRoleCredentials(arn, &creds); err == nil {
-					if !creds.Expired() {
-						log.Debug("Retrieved role credentials from the SecureStore")
-						return &creds
-					}
-				}
-			}
+		if creds, ok := cachedRoleCredentials(ctx, arn); ok {
+			auditCredentials(ctx, newCredentialsEvent(ctx, arn, "cache", accountid, role), creds, nil)
+			return creds
 		}
 	} else {
 		log.Info("Forcing STS refresh", "arn", arn)
 	}
 
+	creds, err := fetchRoleCredentials(ctx, awssso, arn, refreshSTS)
+	auditCredentials(ctx, newCredentialsEvent(ctx, arn, "aws", accountid, role), creds, err)
+	if err != nil {
+		log.Fatal("Unable to get role credentials", "arn", arn, "error", err.Error())
+	}
+	return creds
+}
+
+// cachedRoleCredentials returns our RoleCredentials from the secure store if they have not expired
+func cachedRoleCredentials(ctx *RunContext, arn string) (*storage.RoleCredentials, bool) {
+	creds := storage.RoleCredentials{}
+	if roleFlat, err := ctx.Settings.Cache.GetRole(arn); err == nil {
+		if !roleFlat.IsExpired() {
+			if err := ctx.Store.GetRoleCredentials(arn, &creds); err == nil {
+				if !creds.Expired() {
+					log.Debug("Retrieved role credentials from the SecureStore")
+					return &creds, true
+				}
+			}
+		}
+	}
+	return nil, false
+}
+
+// credentialsARN returns the ARN to get credentials for: the IAM Role or, if it
+// is in the cache, the IAM User with StaticCredentials of the same name
+func credentialsARN(roles *ssocache.Roles, partition string, accountId int64, role string) string {
+	if flat, err := roles.GetRole(accountId, role); err == nil && awsparse.IsUserARN(flat.Arn) {
+		return flat.Arn
+	}
+	return awsparse.MakeRoleARN(partition, accountId, role)
+}
+
+// fetchRoleCredentials gets our RoleCredentials from AWS and caches them.  If refresh
+// is true, any MFA session for the role is not reused.
+func fetchRoleCredentials(ctx *RunContext, awssso *ssoauth.AWSSSO, arn string, refresh bool) (*storage.RoleCredentials, error) {
 	log.Debug("Fetching STS token from AWS SSO")
 
-	// If we didn't use our secure store ask AWS SSO
-	var err error
-	creds, err = awssso.GetRoleCredentials(accountid, role)
+	fetch := awssso.GetCredentials
+	if refresh {
+		fetch = awssso.RefreshCredentials
+	}
+	creds, err := fetch(arn)
 	if err != nil {
-		log.Fatal("Unable to get role credentials", "arn", arn, "error", err.Error())
+		return nil, err
 	}
 
 	log.Debug("Retrieved role credentials from AWS SSO")
 
-	// Cache our creds
+	saveRoleCredentials(ctx, arn, creds)
+	return &creds, nil
+}
+
+// saveRoleCredentials caches our RoleCredentials in the secure store and updates
+// the expiration time in our cache
+func saveRoleCredentials(ctx *RunContext, arn string, creds storage.RoleCredentials) {
 	if err := ctx.Store.SaveRoleCredentials(ctx.Ctx, arn, creds); err != nil {
 		log.Warn("Unable to cache role credentials in secure store", "error", err.Error())
 	}
 
-	// Update the cache
 	if err := ctx.Settings.Cache.SetRoleExpires(arn, creds.ExpireEpoch()); err != nil {
 		log.Warn("Unable to update cache", "error", err.Error())
 	}
-	return &creds
 }
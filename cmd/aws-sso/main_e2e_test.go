@@ -22,9 +22,11 @@ package main
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/awsmock"
 )
 
 // TestGetRoleCredentials_CacheMiss verifies that when no credentials exist in
@@ -88,3 +90,71 @@ func TestGetRoleCredentials_ForceRefresh(t *testing.T) {
 	require.NotNil(t, second)
 	assert.Equal(t, "AKIDTEST12345", second.AccessKeyId)
 }
+
+// TestGetRoleCredentials_ForceRefreshMfa verifies that --sts-refresh calls
+// sts:AssumeRole again for a role with an MfaSerial instead of returning the
+// credentials of the cached MFA session.
+func TestGetRoleCredentials_ForceRefreshMfa(t *testing.T) {
+	accountsYAML := `"123456789012":
+  Roles:
+    BaseRole: {}
+    TargetRole:
+      Via: arn:aws:iam::123456789012:role/BaseRole
+      MfaSerial: arn:aws:iam::123456789012:mfa/alice`
+	setup := newE2ESetupWithDefaults(t, "Default", nil, "device_code", accountsYAML)
+	preAuth(t, setup)
+
+	setup.Server.SSO.QueueListAccounts(awsmock.ListAccountsResponse{
+		AccountList: []awsmock.AccountInfo{
+			{AccountID: "123456789012", AccountName: "TestAccount", EmailAddress: "admin@example.com"},
+		},
+	})
+	setup.Server.SSO.QueueListAccountRoles(awsmock.ListAccountRolesResponse{
+		RoleList: []awsmock.RoleInfo{
+			{AccountID: "123456789012", RoleName: "BaseRole"},
+			{AccountID: "123456789012", RoleName: "TargetRole"},
+		},
+	})
+	_, _, err := setup.Settings.Cache.Refresh(AwsSSO, setup.SSOConf, setup.SSOName, 1, setup.Settings)
+	require.NoError(t, err)
+
+	prompts := 0
+	AwsSSO.SetMfaTokenFunc(func(mfaSerial string) (string, error) {
+		prompts++
+		return "123456", nil
+	})
+
+	for _, key := range []string{"AKID-FIRST", "AKID-REFRESH"} {
+		setup.Server.SSO.QueueGetRoleCredentials(awsmock.GetRoleCredentialsResponse{
+			RoleCredentials: awsmock.RoleCredentials{
+				AccessKeyID:     "AKID-BASE",
+				SecretAccessKey: "SECRET-BASE",
+				SessionToken:    "TOKEN-BASE",
+				Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+			},
+		})
+		setup.Server.STS.QueueAssumeRole(awsmock.AssumeRoleResult{
+			AccessKeyID:     key,
+			SecretAccessKey: "SECRET-TARGET",
+			SessionToken:    "TOKEN-TARGET",
+			Expiration:      time.Now().Add(1 * time.Hour),
+			RoleARN:         "arn:aws:iam::123456789012:role/TargetRole",
+			SessionName:     "BaseRole@123456789012",
+		})
+	}
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	creds := GetRoleCredentials(ctx, AwsSSO, false, 123456789012, "TargetRole")
+	assert.Equal(t, "AKID-FIRST", creds.AccessKeyId)
+
+	// served from the cache
+	creds = GetRoleCredentials(ctx, AwsSSO, false, 123456789012, "TargetRole")
+	assert.Equal(t, "AKID-FIRST", creds.AccessKeyId)
+	assert.Len(t, setup.Server.STS.AssumeRoleRequests(), 1)
+	assert.Equal(t, 1, prompts)
+
+	creds = GetRoleCredentials(ctx, AwsSSO, true, 123456789012, "TargetRole")
+	assert.Equal(t, "AKID-REFRESH", creds.AccessKeyId)
+	assert.Len(t, setup.Server.STS.AssumeRoleRequests(), 2)
+	assert.Equal(t, 2, prompts)
+}
@@ -326,3 +326,31 @@ func TestLoadSecureStoreJSON(t *testing.T) {
 	loadSecureStore(ctx)
 	assert.NotNil(t, ctx.Store)
 }
+
+func TestMfaToken(t *testing.T) {
+	serial := "arn:aws:iam::123456789012:mfa/alice"
+	ctx := &RunContext{Cli: &CLI{MfaToken: "012345"}}
+
+	token, err := mfaToken(ctx, serial)
+	require.NoError(t, err)
+	assert.Equal(t, "012345", token)
+
+	for _, bad := range []string{"12345", "1234567", "12345a"} {
+		ctx.Cli.MfaToken = bad
+		_, err = mfaToken(ctx, serial)
+		assert.ErrorContains(t, err, "must be 6 digits")
+	}
+
+	// stdin is not a terminal under go test
+	ctx.Cli.MfaToken = ""
+	_, err = mfaToken(ctx, serial)
+	assert.ErrorContains(t, err, "use --mfa-token")
+}
+
+func TestFixesMissingHops(t *testing.T) {
+	assert.True(t, fixesMissingHops("cache"))
+	assert.True(t, fixesMissingHops("login"))
+	assert.True(t, fixesMissingHops("static add"))
+	assert.False(t, fixesMissingHops("exec"))
+	assert.False(t, fixesMissingHops("static list"))
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 
 	// log "github.com/sirupsen/logrus"
+	"github.com/synfinatic/aws-sso-cli/internal/agent"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
@@ -38,13 +39,22 @@ type ProcessCmd struct {
 
 // AfterApply list command requires a valid SSO auth token
 func (p ProcessCmd) AfterApply(runCtx *RunContext) error {
-	runCtx.Auth = AUTH_REQUIRED
+	if agentSocket() != "" {
+		// the aws-sso agent takes care of everything
+		runCtx.Auth = AUTH_NO_CONFIG
+	} else {
+		runCtx.Auth = AUTH_REQUIRED
+	}
 	return nil
 }
 
 func (cc *ProcessCmd) Run(ctx *RunContext) error {
 	var err error
 
+	if agentSocket() != "" {
+		return agentCredentialProcess(ctx)
+	}
+
 	role := ctx.Cli.Process.Role
 	account := ctx.Cli.Process.AccountId
 
@@ -101,7 +111,30 @@ func (cpo *CredentialProcessOutput) Output() (string, error) {
 
 func credentialProcess(ctx *RunContext, accountId int64, role string) error {
 	creds := GetRoleCredentials(ctx, AwsSSO, ctx.Cli.Process.STSRefresh, accountId, role)
+	return printCredentialProcess(creds)
+}
+
+// agentCredentialProcess prints the role creds from the aws-sso agent
+func agentCredentialProcess(ctx *RunContext) error {
+	if ctx.Cli.Process.Arn == "" && ctx.Cli.Process.Profile == "" &&
+		(ctx.Cli.Process.Role == "" || ctx.Cli.Process.AccountId == 0) {
+		return fmt.Errorf("please specify --arn or --account and --role")
+	}
+
+	resp, err := agentCredentials(ctx, agent.CredentialsRequest{
+		Arn:        ctx.Cli.Process.Arn,
+		AccountId:  ctx.Cli.Process.AccountId,
+		RoleName:   ctx.Cli.Process.Role,
+		Profile:    ctx.Cli.Process.Profile,
+		STSRefresh: ctx.Cli.Process.STSRefresh,
+	})
+	if err != nil {
+		return err
+	}
+	return printCredentialProcess(&resp.Creds)
+}
 
+func printCredentialProcess(creds *storage.RoleCredentials) error {
 	cpo := NewCredentialsProcessOutput(creds)
 	out, err := cpo.Output()
 	if err != nil {
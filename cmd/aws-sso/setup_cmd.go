@@ -3,7 +3,14 @@ package main
 import (
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/gotable"
 )
 
 /*
@@ -29,16 +36,23 @@ type SetupCmd struct {
 	Wizard      SetupWizardCmd   `kong:"cmd,help='Run the configuration wizard'"`
 	Profiles    SetupProfilesCmd `kong:"cmd,help='Update ~/.aws/config with AWS SSO profiles from the cache'"`
 	Ecs         SetupEcsCmd      `kong:"cmd,help='Manage ECS Server secrets'"`
+	Store       SetupStoreCmd    `kong:"cmd,help='Manage the SecureStore'"`
 }
 
 type SetupEcsCmd struct {
 	Auth EcsAuthCmd `kong:"cmd,help='Configure HTTP Authentication for the ECS Server'"`
 	SSL  EcsSSLCmd  `kong:"cmd,help='Load SSL cert/key for the ECS Server'"`
+	MTLS EcsMTLSCmd `kong:"cmd,name='mtls',help='Load the client CA and client cert/key for ECS Server mutual TLS'"`
 }
 
 type EcsAuthCmd struct {
 	BearerToken string `kong:"short=t,help='Bearer token value to use for ECS Server',xor='flag'"` // nolint:gosec
+	Generate    bool   `kong:"short=g,help='Generate a random bearer token and print it',xor='flag'"`
 	Delete      bool   `kong:"short=d,help='Delete the current bearer token',xor='flag'"`
+	List        bool   `kong:"short=l,help='List the named bearer tokens',xor='flag'"`
+	Name        string `kong:"short=n,help='Manage the named bearer token instead of the default token'"`
+	Admin       bool   `kong:"help='Named token can load, unload and read the credentials in every slot',xor='scope'"`
+	Slot        string `kong:"help='Named token can only read the credentials in this slot',xor='scope'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -48,27 +62,129 @@ func (e EcsAuthCmd) AfterApply(runCtx *RunContext) error {
 }
 
 func (cc *EcsAuthCmd) Run(ctx *RunContext) error {
+	auth := ctx.Cli.Setup.Ecs.Auth
+	if auth.List {
+		return listEcsTokens(ctx)
+	}
+
+	if auth.Name == "" && (auth.Admin || auth.Slot != "") {
+		return fmt.Errorf("--admin and --slot require --name")
+	}
+
 	// Delete the token
-	if ctx.Cli.Setup.Ecs.Auth.Delete {
+	if auth.Delete {
+		if auth.Name != "" {
+			return ctx.Store.DeleteEcsToken(ctx.Ctx, auth.Name)
+		}
 		return ctx.Store.DeleteEcsBearerToken(ctx.Ctx)
 	}
 
 	// Or store the token in the SecureStore
-	if ctx.Cli.Setup.Ecs.Auth.BearerToken == "" {
+	token := auth.BearerToken
+	if auth.Generate {
+		var err error
+		if token, err = ecs.NewBearerToken(); err != nil {
+			return err
+		}
+	}
+	if token == "" {
 		return fmt.Errorf("no bearer token provided")
 	}
-	if strings.HasPrefix(ctx.Cli.Setup.Ecs.Auth.BearerToken, "Bearer ") {
+	if strings.HasPrefix(token, "Bearer ") {
 		return fmt.Errorf("token should not start with 'Bearer '")
 	}
-	return ctx.Store.SaveEcsBearerToken(ctx.Ctx, ctx.Cli.Setup.Ecs.Auth.BearerToken)
+
+	var err error
+	if auth.Name != "" {
+		if !auth.Admin && auth.Slot == "" {
+			return fmt.Errorf("named tokens require either --admin or --slot")
+		}
+		err = ctx.Store.SaveEcsToken(ctx.Ctx, auth.Name, storage.EcsToken{
+			Token: token,
+			Admin: auth.Admin,
+			Slot:  auth.Slot,
+		})
+	} else {
+		err = ctx.Store.SaveEcsBearerToken(ctx.Ctx, token)
+	}
+	if err != nil {
+		return err
+	}
+
+	// this is the only time the user gets to see a generated token
+	if auth.Generate {
+		fmt.Println(token)
+	}
+	return nil
+}
+
+// ecsTokenRow is a row of the `setup ecs auth --list` table
+type ecsTokenRow struct {
+	Name  string `header:"Name"`
+	Scope string `header:"Scope"`
+}
+
+// GetHeader is required for GenerateTable()
+func (r ecsTokenRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+// newEcsTokenRow returns the table row for the named token
+func newEcsTokenRow(name string, token storage.EcsToken) ecsTokenRow {
+	scope := fmt.Sprintf("read slot %s", token.Slot)
+	if token.Admin {
+		scope = "admin"
+	}
+	return ecsTokenRow{
+		Name:  name,
+		Scope: scope,
+	}
+}
+
+// listEcsTokens prints the scope of the default & named bearer tokens, but never the tokens
+func listEcsTokens(ctx *RunContext) error {
+	ts := []gotable.TableStruct{}
+
+	if token, err := ctx.Store.GetEcsBearerToken(); err != nil {
+		return err
+	} else if token != "" {
+		ts = append(ts, ecsTokenRow{Name: "(default)", Scope: "admin"})
+	}
+
+	names := ctx.Store.ListEcsTokens()
+	sort.Strings(names)
+	for _, name := range names {
+		token := storage.EcsToken{}
+		if err := ctx.Store.GetEcsToken(name, &token); err != nil {
+			return err
+		}
+		ts = append(ts, newEcsTokenRow(name, token))
+	}
+
+	if len(ts) == 0 {
+		fmt.Println("No ECS Server bearer tokens.  Use 'aws-sso setup ecs auth' to add one")
+		return nil
+	}
+
+	err := gotable.GenerateTable(ts, []string{"Name", "Scope"})
+	if err != nil {
+		fmt.Printf("\n")
+	}
+	return err
 }
 
 type EcsSSLCmd struct {
-	Delete      bool   `kong:"short=d,help='Disable SSL and delete the current SSL cert/key',xor='flag,cert,key'"`
-	Print       bool   `kong:"short=p,help='Print the current SSL certificate',xor='flag,cert,key'"`
-	Certificate string `kong:"short=c,type='existingfile',help='Path to certificate chain PEM file',predictor='allFiles',group='add-ssl',xor='cert'"`
-	PrivateKey  string `kong:"short=k,type='existingfile',help='Path to private key file PEM file',predictor='allFiles',group='add-ssl',xor='key'"` // nolint:gosec
-	Force       bool   `kong:"hidden,help='Force loading the certificate'"`
+	Delete      bool     `kong:"short=d,help='Disable SSL and delete the current SSL cert/key and CA',xor='flag,cert,key,export'"`
+	Print       bool     `kong:"short=p,help='Print the current SSL certificate',xor='flag,cert,key,export'"`
+	Certificate string   `kong:"short=c,type='existingfile',help='Path to certificate chain PEM file',predictor='allFiles',group='add-ssl',xor='cert,export'"`
+	PrivateKey  string   `kong:"short=k,type='existingfile',help='Path to private key file PEM file',predictor='allFiles',group='add-ssl',xor='key,export'"` // nolint:gosec
+	Generate    bool     `kong:"short=g,help='Generate an SSL cert/key signed by a local CA',xor='flag,cert,key',group='generate-ssl'"`
+	Hostname    []string `kong:"help='Additional host name or IP address for the generated certificate',group='generate-ssl'"`
+	Days        int      `kong:"help='Number of days the generated certificate is valid',default=365,group='generate-ssl'"`
+	RenewDays   int      `kong:"help='Only generate a new certificate if the current one expires within this many days',group='generate-ssl'"`
+	ExportCA    string   `kong:"name='export-ca',type='path',help='Write the local CA certificate to this file',predictor='allFiles',xor='export'"`
+	Force       bool     `kong:"hidden,help='Force loading the certificate'"`
 }
 
 // AfterApply determines if SSO auth token is required
@@ -79,7 +195,12 @@ func (e EcsSSLCmd) AfterApply(runCtx *RunContext) error {
 
 func (cc *EcsSSLCmd) Run(ctx *RunContext) error {
 	if ctx.Cli.Setup.Ecs.SSL.Delete {
+		if err := ctx.Store.DeleteEcsCAKeyPair(ctx.Ctx); err != nil {
+			return err
+		}
 		return ctx.Store.DeleteEcsSslKeyPair(ctx.Ctx)
+	} else if ctx.Cli.Setup.Ecs.SSL.ExportCA != "" && !ctx.Cli.Setup.Ecs.SSL.Generate {
+		return exportEcsCA(ctx, ctx.Cli.Setup.Ecs.SSL.ExportCA)
 	} else if ctx.Cli.Setup.Ecs.SSL.Print {
 		cert, err := ctx.Store.GetEcsSslCert()
 		if err != nil {
@@ -101,6 +222,10 @@ func (cc *EcsSSLCmd) Run(ctx *RunContext) error {
 		log.Fatal("Use `--force` to continue anyways.")
 	}
 
+	if ctx.Cli.Setup.Ecs.SSL.Generate {
+		return generateEcsSslKeyPair(ctx)
+	}
+
 	certChain, err = os.ReadFile(ctx.Cli.Setup.Ecs.SSL.Certificate)
 	if err != nil {
 		return fmt.Errorf("failed to read certificate chain file: %w", err)
@@ -115,3 +240,155 @@ func (cc *EcsSSLCmd) Run(ctx *RunContext) error {
 
 	return ctx.Store.SaveEcsSslKeyPair(ctx.Ctx, privateKey, certChain)
 }
+
+// generateEcsSslKeyPair creates a new SSL cert/key for the ECS Server signed by
+// our local CA, creating the CA first if necessary
+func generateEcsSslKeyPair(ctx *RunContext) error {
+	args := ctx.Cli.Setup.Ecs.SSL
+	if args.Days < 1 {
+		return fmt.Errorf("--days must be at least 1")
+	}
+	lifetime := time.Duration(args.Days) * 24 * time.Hour
+
+	if args.RenewDays > 0 {
+		cert, err := ctx.Store.GetEcsSslCert()
+		if err != nil {
+			return err
+		}
+		if cert != "" {
+			expiring, err := ecs.CertificateExpiresWithin([]byte(cert), time.Duration(args.RenewDays)*24*time.Hour)
+			if err != nil {
+				return err
+			}
+			if !expiring {
+				log.Info("SSL certificate is not due for renewal")
+				return nil
+			}
+		}
+	}
+
+	caCert, err := ctx.Store.GetEcsCACert()
+	if err != nil {
+		return err
+	}
+	caKey, err := ctx.Store.GetEcsCAKey()
+	if err != nil {
+		return err
+	}
+
+	newCA := caCert == "" || caKey == ""
+	if !newCA {
+		if newCA, err = ecs.CertificateExpiresWithin([]byte(caCert), lifetime); err != nil {
+			return err
+		}
+	}
+	if newCA {
+		c, k, err := ecs.GenerateCA(ecs.CA_LIFETIME)
+		if err != nil {
+			return fmt.Errorf("unable to generate CA: %w", err)
+		}
+		if err := ctx.Store.SaveEcsCAKeyPair(ctx.Ctx, k, c); err != nil {
+			return err
+		}
+		caCert, caKey = string(c), string(k)
+		log.Info("Generated a new local CA.  Clients which do not use this SecureStore will need to trust it")
+	}
+
+	hosts := append(append([]string{}, ecs.DefaultServerHosts...), args.Hostname...)
+	cert, key, err := ecs.GenerateServerCert([]byte(caCert), []byte(caKey), hosts, lifetime)
+	if err != nil {
+		return fmt.Errorf("unable to generate SSL certificate: %w", err)
+	}
+
+	// include our CA so clients using the chain trust it
+	certChain := append(cert, []byte(caCert)...)
+	if err := ctx.Store.SaveEcsSslKeyPair(ctx.Ctx, key, certChain); err != nil {
+		return err
+	}
+	log.Info("Generated SSL certificate", "hosts", strings.Join(hosts, ","),
+		"expires", time.Now().Add(lifetime).Format(time.DateOnly))
+
+	if args.ExportCA != "" {
+		return exportEcsCA(ctx, args.ExportCA)
+	}
+	return nil
+}
+
+// exportEcsCA writes our local CA certificate to the given file
+func exportEcsCA(ctx *RunContext, filename string) error {
+	caCert, err := ctx.Store.GetEcsCACert()
+	if err != nil {
+		return err
+	}
+	if caCert == "" {
+		return fmt.Errorf("no CA certificate found.  Use 'aws-sso setup ecs ssl --generate' to create one")
+	}
+	if err := os.WriteFile(filename, []byte(caCert), 0644); err != nil { // nolint:gosec
+		return fmt.Errorf("unable to write CA certificate: %w", err)
+	}
+	log.Info("Exported CA certificate", "file", filename)
+	return nil
+}
+
+type EcsMTLSCmd struct {
+	Delete            bool   `kong:"short=d,help='Disable mutual TLS and delete the client CA and client cert/key',xor='flag'"`
+	Print             bool   `kong:"short=p,help='Print the current client CA certificate',xor='flag'"`
+	CACertificate     string `kong:"name='ca-certificate',type='existingfile',help='Path to CA certificate PEM file used by the ECS Server to verify clients',predictor='allFiles',xor='export'"`
+	ClientCertificate string `kong:"name='client-certificate',type='existingfile',help='Path to client certificate chain PEM file presented by the ECS client commands',predictor='allFiles',and='client'"`
+	ClientPrivateKey  string `kong:"name='client-private-key',type='existingfile',help='Path to client private key PEM file',predictor='allFiles',and='client'"` // nolint:gosec
+}
+
+// AfterApply determines if SSO auth token is required
+func (e EcsMTLSCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *EcsMTLSCmd) Run(ctx *RunContext) error {
+	args := ctx.Cli.Setup.Ecs.MTLS
+	if args.Delete {
+		if err := ctx.Store.DeleteEcsClientCA(ctx.Ctx); err != nil {
+			return err
+		}
+		return ctx.Store.DeleteEcsClientKeyPair(ctx.Ctx)
+	} else if args.Print {
+		cert, err := ctx.Store.GetEcsClientCA()
+		if err != nil {
+			return err
+		}
+		if cert == "" {
+			return fmt.Errorf("no client CA certificate found")
+		}
+		fmt.Println(cert)
+		return nil
+	}
+
+	if args.CACertificate == "" && args.ClientCertificate == "" {
+		return fmt.Errorf("no certificates provided")
+	}
+
+	if args.CACertificate != "" {
+		caCert, err := os.ReadFile(args.CACertificate)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate file: %w", err)
+		}
+		if err := ctx.Store.SaveEcsClientCA(ctx.Ctx, caCert); err != nil {
+			return err
+		}
+	}
+
+	if args.ClientCertificate != "" {
+		certChain, err := os.ReadFile(args.ClientCertificate)
+		if err != nil {
+			return fmt.Errorf("failed to read client certificate chain file: %w", err)
+		}
+		privateKey, err := os.ReadFile(args.ClientPrivateKey)
+		if err != nil {
+			return fmt.Errorf("failed to read client private key file: %w", err)
+		}
+		if err := ctx.Store.SaveEcsClientKeyPair(ctx.Ctx, privateKey, certChain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
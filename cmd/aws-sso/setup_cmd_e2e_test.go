@@ -193,3 +193,86 @@ func TestE2EEcsServerSSL(t *testing.T) {
 	cancel()
 	assert.NoError(t, <-done, "Run() should return nil on context cancellation")
 }
+
+// TestE2ESetupEcsSSLGenerate exercises `setup ecs ssl --generate`:
+//  1. A local CA and server cert are created and stored in the SecureStore.
+//  2. The exported CA is enough for a client to trust the ECS Server.
+//  3. --renew-days skips a cert which is not about to expire.
+//  4. Generating again rotates the server cert but keeps the CA.
+func TestE2ESetupEcsSSLGenerate(t *testing.T) {
+	setup := newE2ESetup(t)
+	ctx := newRunContext(setup, AUTH_SKIP)
+
+	// nothing to export yet
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{ExportCA: caFile}
+	assert.ErrorContains(t, (&EcsSSLCmd{}).Run(ctx), "no CA certificate found")
+
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{Generate: true, Force: true, Days: 30, ExportCA: caFile}
+	require.NoError(t, (&EcsSSLCmd{}).Run(ctx))
+
+	caCert, err := setup.Store.GetEcsCACert()
+	require.NoError(t, err)
+	exported, err := os.ReadFile(caFile)
+	require.NoError(t, err)
+	assert.Equal(t, caCert, string(exported))
+
+	certChain, err := setup.Store.GetEcsSslCert()
+	require.NoError(t, err)
+	assert.Contains(t, certChain, caCert, "cert chain should include the CA")
+	privateKey, err := setup.Store.GetEcsSslKey()
+	require.NoError(t, err)
+
+	// serve with the generated cert and connect trusting only the CA
+	cctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	ctx.Ctx = cctx
+
+	port := freePort(t)
+	ctx.Cli.Ecs.Server = EcsServerCmd{
+		BindIP:      "127.0.0.1",
+		Port:        port,
+		DisableAuth: true,
+	}
+	cc := &ctx.Cli.Ecs.Server
+	done := make(chan error, 1)
+	go func() { done <- cc.Run(ctx) }()
+
+	for _, host := range []string{"127.0.0.1", "localhost"} {
+		addr := fmt.Sprintf("%s:%d", host, port)
+		require.NoError(t, waitForEcsServerUp("https", addr, caCert, 5*time.Second), host)
+	}
+	cancel()
+	assert.NoError(t, <-done)
+	ctx.Ctx = context.Background()
+
+	// not due for renewal
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{Generate: true, Force: true, Days: 30, RenewDays: 7}
+	require.NoError(t, (&EcsSSLCmd{}).Run(ctx))
+	sameChain, err := setup.Store.GetEcsSslCert()
+	require.NoError(t, err)
+	assert.Equal(t, certChain, sameChain)
+
+	// rotate
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{Generate: true, Force: true, Days: 30, RenewDays: 60}
+	require.NoError(t, (&EcsSSLCmd{}).Run(ctx))
+	newChain, err := setup.Store.GetEcsSslCert()
+	require.NoError(t, err)
+	newKey, err := setup.Store.GetEcsSslKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, certChain, newChain)
+	assert.NotEqual(t, privateKey, newKey)
+	sameCA, err := setup.Store.GetEcsCACert()
+	require.NoError(t, err)
+	assert.Equal(t, caCert, sameCA, "CA should be reused when rotating")
+
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{Generate: true, Force: true}
+	assert.ErrorContains(t, (&EcsSSLCmd{}).Run(ctx), "--days")
+
+	// --delete also removes the CA
+	ctx.Cli.Setup.Ecs.SSL = EcsSSLCmd{Delete: true}
+	require.NoError(t, (&EcsSSLCmd{}).Run(ctx))
+	caCert, err = setup.Store.GetEcsCACert()
+	require.NoError(t, err)
+	assert.Empty(t, caCert)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/sso"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
@@ -25,6 +26,51 @@ func TestEcsSSLCmdAfterApply(t *testing.T) {
 	assert.Equal(t, AUTH_SKIP, ctx.Auth)
 }
 
+func TestEcsSSLCmdParse(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name: "generate and export the CA",
+			args: []string{"--generate", "--export-ca", caFile},
+		},
+		{
+			name: "only export the CA",
+			args: []string{"--export-ca", caFile},
+		},
+		{
+			name:    "delete and export the CA",
+			args:    []string{"--delete", "--export-ca", caFile},
+			wantErr: "can't be used together",
+		},
+		{
+			name:    "print and export the CA",
+			args:    []string{"--print", "--export-ca", caFile},
+			wantErr: "can't be used together",
+		},
+		{
+			name:    "delete and generate",
+			args:    []string{"--delete", "--generate"},
+			wantErr: "can't be used together",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &RunContext{Cli: &CLI{}, Auth: AUTH_UNKNOWN}
+			_, err := newParser(ctx).Parse(append([]string{"setup", "ecs", "ssl"}, tt.args...))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, caFile, ctx.Cli.Setup.Ecs.SSL.ExportCA)
+		})
+	}
+}
+
 func TestEcsSSLCmdRun_Delete(t *testing.T) {
 	store := openTestStore(t)
 	ctx := &RunContext{
@@ -114,3 +160,190 @@ func TestEcsAuthCmdRun_Delete(t *testing.T) {
 	// Nothing stored to delete; should return nil.
 	assert.NoError(t, cmd.Run(ctx))
 }
+
+func TestEcsAuthCmdRun_Generate(t *testing.T) {
+	store := openTestStore(t)
+	ctx := &RunContext{
+		Cli:   &CLI{},
+		Store: store,
+		Ctx:   context.Background(),
+	}
+	ctx.Cli.Setup.Ecs.Auth.Generate = true
+
+	cmd := &EcsAuthCmd{}
+	assert.NoError(t, cmd.Run(ctx))
+	token, err := store.GetEcsBearerToken()
+	assert.NoError(t, err)
+	assert.Len(t, token, 43)
+}
+
+func TestEcsAuthCmdRun_NamedToken(t *testing.T) {
+	store := openTestStore(t)
+	ctx := &RunContext{
+		Cli:   &CLI{},
+		Store: store,
+		Ctx:   context.Background(),
+	}
+	cmd := &EcsAuthCmd{}
+
+	// scope requires a name
+	ctx.Cli.Setup.Ecs.Auth.Slot = "FooBar"
+	ctx.Cli.Setup.Ecs.Auth.BearerToken = "slot-token"
+	assert.ErrorContains(t, cmd.Run(ctx), "require --name")
+
+	// and a name requires a scope
+	ctx.Cli.Setup.Ecs.Auth = EcsAuthCmd{Name: "container", BearerToken: "slot-token"}
+	assert.ErrorContains(t, cmd.Run(ctx), "either --admin or --slot")
+
+	ctx.Cli.Setup.Ecs.Auth.Slot = "FooBar"
+	assert.NoError(t, cmd.Run(ctx))
+	ctx.Cli.Setup.Ecs.Auth = EcsAuthCmd{Name: "admin", Admin: true, Generate: true}
+	assert.NoError(t, cmd.Run(ctx))
+
+	token := storage.EcsToken{}
+	assert.NoError(t, store.GetEcsToken("container", &token))
+	assert.Equal(t, storage.EcsToken{Token: "slot-token", Slot: "FooBar"}, token)
+	assert.NoError(t, store.GetEcsToken("admin", &token))
+	assert.True(t, token.Admin)
+	assert.Len(t, token.Token, 43)
+
+	// the default token is untouched
+	bearer, err := store.GetEcsBearerToken()
+	assert.NoError(t, err)
+	assert.Empty(t, bearer)
+
+	ctx.Cli.Setup.Ecs.Auth = EcsAuthCmd{List: true}
+	assert.NoError(t, cmd.Run(ctx))
+
+	ctx.Cli.Setup.Ecs.Auth = EcsAuthCmd{Name: "container", Delete: true}
+	assert.NoError(t, cmd.Run(ctx))
+	assert.Equal(t, []string{"admin"}, store.ListEcsTokens())
+	assert.Error(t, cmd.Run(ctx))
+}
+
+func TestNewEcsTokenRow(t *testing.T) {
+	assert.Equal(t, ecsTokenRow{Name: "admin", Scope: "admin"},
+		newEcsTokenRow("admin", storage.EcsToken{Token: "token", Admin: true}))
+	assert.Equal(t, ecsTokenRow{Name: "container", Scope: "read slot FooBar"},
+		newEcsTokenRow("container", storage.EcsToken{Token: "token", Slot: "FooBar"}))
+}
+
+func TestEcsMTLSCmdRun(t *testing.T) {
+	store := openTestStore(t)
+	ctx := &RunContext{
+		Cli:   &CLI{},
+		Store: store,
+		Ctx:   context.Background(),
+	}
+	cmd := &EcsMTLSCmd{}
+
+	// nothing to load or print
+	assert.ErrorContains(t, cmd.Run(ctx), "no certificates provided")
+	ctx.Cli.Setup.Ecs.MTLS.Print = true
+	assert.Error(t, cmd.Run(ctx))
+	ctx.Cli.Setup.Ecs.MTLS.Print = false
+
+	ctx.Cli.Setup.Ecs.MTLS.CACertificate = "../../internal/ecs/server/testdata/client-ca.crt"
+	ctx.Cli.Setup.Ecs.MTLS.ClientCertificate = "../../internal/ecs/server/testdata/client.crt"
+	ctx.Cli.Setup.Ecs.MTLS.ClientPrivateKey = "../../internal/ecs/server/testdata/client.key"
+	require.NoError(t, cmd.Run(ctx))
+
+	ca, err := store.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Contains(t, ca, "BEGIN CERTIFICATE")
+	cert, err := store.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Contains(t, cert, "BEGIN CERTIFICATE")
+
+	// key must match the certificate
+	ctx.Cli.Setup.Ecs.MTLS.ClientPrivateKey = "../../internal/ecs/server/testdata/localhost.key"
+	assert.Error(t, cmd.Run(ctx))
+
+	ctx.Cli.Setup.Ecs.MTLS = EcsMTLSCmd{Delete: true}
+	require.NoError(t, cmd.Run(ctx))
+	ca, err = store.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+	cert, err = store.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+}
+
+func TestSecureStoreTypeValidate(t *testing.T) {
+	assert.NoError(t, SecureStoreType("").Validate())
+	assert.NoError(t, SecureStoreType("json").Validate())
+	assert.NoError(t, SecureStoreType("1password").Validate())
+	assert.Error(t, SecureStoreType("foobar").Validate())
+}
+
+func TestStoreMigrateCmdRun_SameStore(t *testing.T) {
+	ctx := &RunContext{
+		Cli:      &CLI{},
+		Settings: &sso.Settings{SecureStore: "json"},
+		Store:    openTestStore(t),
+		Ctx:      context.Background(),
+	}
+	ctx.Cli.Setup.Store.Migrate.To = "json"
+
+	cmd := &StoreMigrateCmd{}
+	assert.Error(t, cmd.Run(ctx))
+
+	ctx.Cli.Setup.Store.Migrate.From = "file"
+	ctx.Cli.Setup.Store.Migrate.To = "file"
+	assert.Error(t, cmd.Run(ctx))
+}
+
+func TestMigrateSecureStore(t *testing.T) {
+	ctx := &RunContext{Ctx: context.Background()}
+	dir := t.TempDir()
+	src, err := storage.OpenJsonStore(ctx.Ctx, filepath.Join(dir, "src.json"))
+	require.NoError(t, err)
+	dst, err := storage.OpenJsonStore(ctx.Ctx, filepath.Join(dir, "dst.json"))
+	require.NoError(t, err)
+	reopen := func() (storage.SecureStorage, error) {
+		return storage.OpenJsonStore(ctx.Ctx, filepath.Join(dir, "dst.json"))
+	}
+
+	arn := "arn:aws:iam::123456789012:role/ReadOnly"
+	creds := storage.RoleCredentials{
+		RoleName:        "ReadOnly",
+		AccountId:       123456789012,
+		AccessKeyId:     "ASIAEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "token",
+		Expiration:      1234567890000,
+	}
+	require.NoError(t, src.SaveRoleCredentials(ctx.Ctx, arn, creds))
+	require.NoError(t, src.SaveEcsBearerToken(ctx.Ctx, "my-secret-token"))
+
+	// copy but leave the source alone
+	require.NoError(t, migrateSecureStore(ctx, src, dst, reopen, false))
+	check, err := reopen()
+	require.NoError(t, err)
+	got := storage.RoleCredentials{}
+	require.NoError(t, check.GetRoleCredentials(arn, &got))
+	assert.Equal(t, creds, got)
+	token, err := check.GetEcsBearerToken()
+	require.NoError(t, err)
+	assert.Equal(t, "my-secret-token", token)
+	assert.Equal(t, []string{arn}, src.ListRoleCredentials())
+
+	// copy again and wipe the source
+	require.NoError(t, migrateSecureStore(ctx, src, dst, reopen, true))
+	assert.Empty(t, src.ListRoleCredentials())
+	token, err = src.GetEcsBearerToken()
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	// verification failure leaves the source untouched
+	require.NoError(t, src.SaveRoleCredentials(ctx.Ctx, arn, creds))
+	broken := func() (storage.SecureStorage, error) {
+		return storage.OpenJsonStore(ctx.Ctx, filepath.Join(dir, "empty.json"))
+	}
+	empty, err := broken()
+	require.NoError(t, err)
+	// dst gets the record but the "re-opened" store is a different, empty file
+	assert.Error(t, migrateSecureStore(ctx, src, dst, broken, true))
+	assert.Empty(t, empty.ListRoleCredentials())
+	assert.Equal(t, []string{arn}, src.ListRoleCredentials())
+}
@@ -0,0 +1,134 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+var VALID_SECURE_STORES = []string{
+	"file", "keychain", "kwallet", "pass", "secret-service", "wincred", "json", "1password",
+}
+
+type SecureStoreType string
+
+func (store SecureStoreType) Validate() error {
+	if slices.Contains(VALID_SECURE_STORES, string(store)) || store == "" {
+		return nil
+	}
+	return fmt.Errorf("invalid value: %s.  Must be one of: %s", store, strings.Join(VALID_SECURE_STORES, ", "))
+}
+
+type SetupStoreCmd struct {
+	Migrate StoreMigrateCmd `kong:"cmd,help='Copy all secrets to a different SecureStore'"`
+}
+
+type StoreMigrateCmd struct {
+	From SecureStoreType `kong:"short=f,help='SecureStore to copy secrets from (default: SecureStore in config.yaml)'"`
+	To   SecureStoreType `kong:"short=t,required,help='SecureStore to copy secrets to'"`
+	Wipe bool            `kong:"help='Delete all secrets from the source SecureStore after a successful migration'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (s StoreMigrateCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StoreMigrateCmd) Run(ctx *RunContext) error {
+	from := string(ctx.Cli.Setup.Store.Migrate.From)
+	if from == "" {
+		from = ctx.Settings.SecureStore
+	}
+	to := string(ctx.Cli.Setup.Store.Migrate.To)
+	if from == to {
+		return fmt.Errorf("--from and --to must be different SecureStores")
+	}
+
+	src := ctx.Store
+	if from != ctx.Settings.SecureStore {
+		var err error
+		if src, err = openSecureStore(ctx, from); err != nil {
+			return err
+		}
+	}
+
+	dst, err := openSecureStore(ctx, to)
+	if err != nil {
+		return err
+	}
+
+	reopen := func() (storage.SecureStorage, error) {
+		return openSecureStore(ctx, to)
+	}
+	if err = migrateSecureStore(ctx, src, dst, reopen, ctx.Cli.Setup.Store.Migrate.Wipe); err != nil {
+		return err
+	}
+
+	if to != ctx.Settings.SecureStore {
+		log.Info("Update your config.yaml to use the new SecureStore", "SecureStore", to)
+	}
+	return nil
+}
+
+// migrateSecureStore copies all the secrets from src to dst and verifies them
+// against a fresh copy of dst returned by reopen.  The src is only wiped if
+// requested and the verification succeeds.
+func migrateSecureStore(ctx *RunContext, src, dst storage.SecureStorage,
+	reopen func() (storage.SecureStorage, error), wipe bool) error {
+	result, err := storage.Migrate(ctx.Ctx, src, dst)
+	if err != nil {
+		return fmt.Errorf("unable to migrate SecureStore: %w", err)
+	}
+	log.Info("Copied secrets", "total", result.Total(),
+		"RegisterClientData", result.RegisterClientData,
+		"CreateTokenResponse", result.CreateTokenResponse,
+		"RoleCredentials", result.RoleCredentials,
+		"StaticCredentials", result.StaticCredentials,
+		"EcsSlots", result.EcsSlots,
+		"EcsTokens", result.EcsTokens,
+		"EcsBearerToken", result.EcsBearerToken,
+		"EcsSslKeyPair", result.EcsSslKeyPair,
+		"EcsCAKeyPair", result.EcsCAKeyPair,
+		"EcsClientCA", result.EcsClientCA,
+		"EcsClientKeyPair", result.EcsClientKeyPair)
+
+	// verify against what was actually persisted, not what is cached in memory
+	check, err := reopen()
+	if err != nil {
+		return err
+	}
+	if err = storage.VerifyMigration(src, check); err != nil {
+		return fmt.Errorf("unable to verify migrated secrets, source SecureStore is untouched: %w", err)
+	}
+	log.Info("Verified all secrets in the new SecureStore")
+
+	if !wipe {
+		return nil
+	}
+	if err = storage.Wipe(ctx.Ctx, src); err != nil {
+		return fmt.Errorf("unable to wipe source SecureStore: %w", err)
+	}
+	log.Info("Deleted all secrets from the source SecureStore")
+	return nil
+}
@@ -360,6 +360,10 @@ func promptUrlAction(defaultValue uri.Action) uri.Action {
 			Name:  "Print only the URL",
 			Value: "printurl",
 		},
+		{
+			Name:  "Print a QR code with the URL",
+			Value: "qrcode",
+		},
 	}
 
 	// only valid on localhost
@@ -0,0 +1,279 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/synfinatic/aws-sso-cli/internal/iamuser"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/gotable"
+	"golang.org/x/term"
+)
+
+const (
+	ENV_STATIC_ACCESS_KEY_ID     = "AWS_SSO_STATIC_ACCESS_KEY_ID"     // nolint:gosec
+	ENV_STATIC_SECRET_ACCESS_KEY = "AWS_SSO_STATIC_SECRET_ACCESS_KEY" // nolint:gosec
+)
+
+type StaticCmd struct {
+	Add    StaticAddCmd    `kong:"cmd,help='Add long-lived IAM User credentials'"`
+	List   StaticListCmd   `kong:"cmd,help='List IAM Users with stored credentials'"`
+	Rotate StaticRotateCmd `kong:"cmd,help='Replace the access key of an IAM User'"`
+	Delete StaticDeleteCmd `kong:"cmd,help='Delete the stored credentials of an IAM User'"`
+}
+
+type StaticAddCmd struct {
+	Profile string            `kong:"short='p',help='Profile name for the IAM User (default: ProfileFormat in config.yaml)'"`
+	Tag     map[string]string `kong:"short='t',help='Tags for the IAM User (key=value;key2=value2)'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (s StaticAddCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StaticAddCmd) Run(ctx *RunContext) error {
+	accessKeyId, err := readStaticSecret("AWS Access Key ID", ENV_STATIC_ACCESS_KEY_ID, false)
+	if err != nil {
+		return err
+	}
+	secretAccessKey, err := readStaticSecret("AWS Secret Access Key", ENV_STATIC_SECRET_ACCESS_KEY, true)
+	if err != nil {
+		return err
+	}
+
+	client, err := staticClient(ctx)
+	if err != nil {
+		return err
+	}
+	creds, err := client.Identify(ctx.Ctx, accessKeyId, secretAccessKey)
+	if err != nil {
+		return fmt.Errorf("unable to validate access key: %w", err)
+	}
+	creds.Profile = ctx.Cli.Static.Add.Profile
+	creds.Tags = ctx.Cli.Static.Add.Tag
+
+	if err = ctx.Store.SaveStaticCredentials(ctx.Ctx, creds.UserArn(), creds); err != nil {
+		return err
+	}
+	log.Info("Added IAM User", "arn", creds.UserArn())
+	syncStaticUsers(ctx)
+	return nil
+}
+
+type StaticListCmd struct{}
+
+// AfterApply determines if SSO auth token is required
+func (s StaticListCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StaticListCmd) Run(ctx *RunContext) error {
+	users := []storage.StaticCredentials{}
+	for _, arn := range ctx.Store.ListStaticCredentials() {
+		user := storage.StaticCredentials{}
+		if err := ctx.Store.GetStaticCredentials(arn, &user); err != nil {
+			return fmt.Errorf("unable to load static credentials for %s: %w", arn, err)
+		}
+		users = append(users, user)
+	}
+
+	if len(users) == 0 {
+		fmt.Printf("No IAM User credentials are stored.\n")
+		return nil
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return strings.Compare(users[i].UserArn(), users[j].UserArn()) < 0
+	})
+
+	ts := []gotable.TableStruct{}
+	for _, user := range users {
+		tags := []string{}
+		for k, v := range user.Tags {
+			tags = append(tags, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(tags)
+		ts = append(ts, staticListRow{
+			Arn:         user.UserArn(),
+			Profile:     user.Profile,
+			AccessKeyId: user.AccessKeyId,
+			Tags:        strings.Join(tags, ", "),
+		})
+	}
+
+	// never print the secrets
+	fields := []string{"Arn", "Profile", "AccessKeyId", "Tags"}
+	err := gotable.GenerateTable(ts, fields)
+	if err != nil {
+		fmt.Printf("\n")
+	}
+	return err
+}
+
+// staticListRow is a row of the `static list` table
+type staticListRow struct {
+	Arn         string `header:"ARN"`
+	Profile     string `header:"Profile"`
+	AccessKeyId string `header:"AccessKeyId"`
+	Tags        string `header:"Tags"`
+}
+
+// GetHeader is required for GenerateTable()
+func (r staticListRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+type StaticRotateCmd struct {
+	User string `kong:"arg,help='ARN, UserName or Profile of the IAM User'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (s StaticRotateCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StaticRotateCmd) Run(ctx *RunContext) error {
+	creds, err := findStaticCredentials(ctx.Store, ctx.Cli.Static.Rotate.User)
+	if err != nil {
+		return err
+	}
+
+	client, err := staticClient(ctx)
+	if err != nil {
+		return err
+	}
+	newCreds, err := client.CreateAccessKey(ctx.Ctx, creds)
+	if err != nil {
+		return fmt.Errorf("unable to create new access key: %w", err)
+	}
+
+	// save the new key before deleting the old one so we never lose access
+	if err = ctx.Store.SaveStaticCredentials(ctx.Ctx, newCreds.UserArn(), newCreds); err != nil {
+		return fmt.Errorf("unable to save new access key %s: %w", newCreds.AccessKeyId, err)
+	}
+
+	if err = client.DeleteAccessKey(ctx.Ctx, newCreds, creds.AccessKeyId); err != nil {
+		log.Warn("Unable to delete old access key, please delete it manually",
+			"accessKeyId", creds.AccessKeyId, "error", err.Error())
+	}
+	log.Info("Rotated access key", "arn", newCreds.UserArn(), "accessKeyId", newCreds.AccessKeyId)
+	return nil
+}
+
+type StaticDeleteCmd struct {
+	User string `kong:"arg,help='ARN, UserName or Profile of the IAM User'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (s StaticDeleteCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StaticDeleteCmd) Run(ctx *RunContext) error {
+	creds, err := findStaticCredentials(ctx.Store, ctx.Cli.Static.Delete.User)
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Store.DeleteStaticCredentials(ctx.Ctx, creds.UserArn()); err != nil {
+		return err
+	}
+	log.Info("Deleted IAM User", "arn", creds.UserArn())
+	syncStaticUsers(ctx)
+	return nil
+}
+
+// findStaticCredentials returns the StaticCredentials in the store which
+// match the given ARN, UserName or Profile
+func findStaticCredentials(store storage.SecureStorage, user string) (storage.StaticCredentials, error) {
+	matches := []storage.StaticCredentials{}
+	for _, arn := range store.ListStaticCredentials() {
+		creds := storage.StaticCredentials{}
+		if err := store.GetStaticCredentials(arn, &creds); err != nil {
+			return creds, fmt.Errorf("unable to load static credentials for %s: %w", arn, err)
+		}
+		if arn == user || creds.UserArn() == user {
+			return creds, nil
+		}
+		if creds.UserName == user || (creds.Profile != "" && creds.Profile == user) {
+			matches = append(matches, creds)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return storage.StaticCredentials{}, fmt.Errorf("unable to find IAM User: %s", user)
+	case 1:
+		return matches[0], nil
+	default:
+		return storage.StaticCredentials{}, fmt.Errorf("%s matches multiple IAM Users, please use the ARN", user)
+	}
+}
+
+// staticClient returns an iamuser.Client for the region of the selected AWS SSO instance
+func staticClient(ctx *RunContext) (*iamuser.Client, error) {
+	s, err := ctx.Settings.GetSelectedSSO(ctx.Cli.SSO)
+	if err != nil {
+		return nil, err
+	}
+	return iamuser.NewClient(s.SSORegion, ""), nil
+}
+
+// readStaticSecret returns the value of the given environment variable or
+// prompts the user for it
+func readStaticSecret(prompt, envVar string, hidden bool) (string, error) {
+	if v := os.Getenv(envVar); v != "" {
+		return v, nil
+	}
+
+	var value string
+	fmt.Fprintf(os.Stderr, "%s: ", prompt)
+	if hidden {
+		b, err := term.ReadPassword(int(os.Stdin.Fd())) // #nosec
+		fmt.Fprintf(os.Stderr, "\n")
+		if err != nil {
+			return "", err
+		}
+		value = string(b)
+	} else {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		value = line
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%s is required", prompt)
+	}
+	return value, nil
+}
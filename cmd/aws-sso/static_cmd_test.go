@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+func saveTestStaticCredentials(t *testing.T, store storage.SecureStorage) {
+	t.Helper()
+	users := []storage.StaticCredentials{
+		{Profile: "admin", UserName: "alice", AccountId: 123456789012, AccessKeyId: "AKIAALICE", SecretAccessKey: "alice-secret"},
+		{UserName: "bob", AccountId: 123456789012, AccessKeyId: "AKIABOB", SecretAccessKey: "bob-secret"},
+		{UserName: "bob", AccountId: 210987654321, AccessKeyId: "AKIABOB2", SecretAccessKey: "bob2-secret"},
+	}
+	for _, user := range users {
+		require.NoError(t, store.SaveStaticCredentials(context.Background(), user.UserArn(), user))
+	}
+}
+
+func TestFindStaticCredentials(t *testing.T) {
+	store := openTestStore(t)
+	saveTestStaticCredentials(t, store)
+
+	creds, err := findStaticCredentials(store, "arn:aws:iam::123456789012:user/bob")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIABOB", creds.AccessKeyId)
+
+	creds, err = findStaticCredentials(store, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIAALICE", creds.AccessKeyId)
+
+	creds, err = findStaticCredentials(store, "admin")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", creds.UserName)
+
+	_, err = findStaticCredentials(store, "bob")
+	assert.ErrorContains(t, err, "matches multiple IAM Users")
+
+	_, err = findStaticCredentials(store, "carol")
+	assert.ErrorContains(t, err, "unable to find IAM User")
+}
+
+func TestStaticListCmdRun(t *testing.T) {
+	store := openTestStore(t)
+	ctx := &RunContext{
+		Cli:   &CLI{},
+		Store: store,
+		Ctx:   context.Background(),
+	}
+
+	cmd := &StaticListCmd{}
+	assert.NoError(t, cmd.Run(ctx))
+
+	saveTestStaticCredentials(t, store)
+	assert.NoError(t, cmd.Run(ctx))
+}
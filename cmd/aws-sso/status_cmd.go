@@ -0,0 +1,231 @@
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	ssoauth "github.com/synfinatic/aws-sso-cli/internal/sso/auth"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/cache"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/aws-sso-cli/internal/timeutils"
+	"github.com/synfinatic/gotable"
+)
+
+type StatusCmd struct {
+	Json bool `kong:"help='Print the status as JSON'"`
+}
+
+// AfterApply determines if SSO auth token is required
+func (s StatusCmd) AfterApply(runCtx *RunContext) error {
+	runCtx.Auth = AUTH_SKIP
+	return nil
+}
+
+func (cc *StatusCmd) Run(ctx *RunContext) error {
+	names := []string{}
+	for name := range ctx.Settings.SSO {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	now := time.Now()
+	status := []ssoStatus{}
+	for _, name := range names {
+		status = append(status, newSsoStatus(ctx.Settings.SSO[name], ctx.Store, ctx.Settings.Cache, now))
+	}
+
+	if cc.Json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+
+	ts := []gotable.TableStruct{}
+	for _, s := range status {
+		ts = append(ts, newStatusRow(s, now))
+	}
+	fields := []string{"SSO", "AuthWorkflow", "Token", "RefreshToken", "Client", "GrantTypes", "Credentials", "CacheAge", "CacheRefresh"}
+	err := gotable.GenerateTable(ts, fields)
+	if err != nil {
+		fmt.Printf("\n")
+	}
+	return err
+}
+
+// ssoStatus is the health of the session, client registration, credentials
+// and cache of an AWS SSO instance
+type ssoStatus struct {
+	SSO                       string   `json:"SSO"`
+	StartUrl                  string   `json:"StartUrl"`
+	AuthWorkflow              string   `json:"AuthWorkflow"`
+	HasToken                  bool     `json:"HasToken"`
+	TokenExpiresAt            int64    `json:"TokenExpiresAt,omitempty"`
+	TokenExpired              bool     `json:"TokenExpired"`
+	HasRefreshToken           bool     `json:"HasRefreshToken"`
+	Registered                bool     `json:"Registered"`
+	ClientExpiresAt           int64    `json:"ClientExpiresAt,omitempty"`
+	ClientExpired             bool     `json:"ClientExpired"`
+	GrantTypes                []string `json:"GrantTypes,omitempty"`
+	SupportsAuthorizationCode bool     `json:"SupportsAuthorizationCode"`
+	SupportsDeviceCode        bool     `json:"SupportsDeviceCode"`
+	SupportsRefreshToken      bool     `json:"SupportsRefreshToken"`
+	ValidCredentials          int      `json:"ValidCredentials"`
+	CacheLastUpdate           int64    `json:"CacheLastUpdate,omitempty"`
+	CacheRefresh              int64    `json:"CacheRefresh"` // hours, <= 0 disables refresh
+	CacheExpired              bool     `json:"CacheExpired"`
+}
+
+// newSsoStatus returns the ssoStatus of the given SSO instance.  It only reads
+// the SecureStore & cache and never talks to AWS.
+func newSsoStatus(s *ssoconfig.SSOConfig, store storage.SecureStorage, c *cache.Cache, now time.Time) ssoStatus {
+	name := s.GetKey()
+	status := ssoStatus{
+		SSO:          name,
+		StartUrl:     s.StartUrl,
+		AuthWorkflow: string(ssoauth.NewAWSSSO(s, store).AuthWorkflow()),
+		CacheRefresh: s.CacheRefresh,
+	}
+
+	token := storage.CreateTokenResponse{}
+	if err := store.GetCreateTokenResponse(name, &token); err == nil {
+		status.HasToken = true
+		status.TokenExpiresAt = token.ExpiresAt
+		status.TokenExpired = token.ExpiresAt <= now.Unix()
+		status.HasRefreshToken = token.RefreshToken != ""
+	}
+
+	client := storage.RegisterClientData{}
+	if err := store.GetRegisterClientData(name, &client); err == nil {
+		status.Registered = true
+		status.ClientExpiresAt = client.ClientSecretExpiresAt
+		status.ClientExpired = client.ClientSecretExpiresAt <= now.Unix()
+		for _, gt := range client.GrantTypes {
+			status.GrantTypes = append(status.GrantTypes, string(gt))
+		}
+		status.SupportsAuthorizationCode = client.SupportsAuthorizationCode()
+		status.SupportsDeviceCode = client.SupportsDeviceCode()
+		status.SupportsRefreshToken = client.SupportsRefreshToken()
+	}
+
+	ssoCache, ok := c.SSO[name]
+	if !ok {
+		return status
+	}
+	status.CacheLastUpdate = ssoCache.LastUpdate
+	if s.CacheRefresh > 0 {
+		ttl := time.Duration(s.CacheRefresh) * time.Hour
+		status.CacheExpired = time.Unix(ssoCache.LastUpdate, 0).Add(ttl).Before(now)
+	}
+
+	// only count the role credentials which belong to this SSO instance
+	arns := map[string]bool{}
+	if ssoCache.Roles != nil {
+		for _, role := range ssoCache.Roles.GetAllRoles() {
+			arns[role.Arn] = true
+		}
+	}
+	for _, arn := range store.ListRoleCredentials() {
+		creds := storage.RoleCredentials{}
+		if !arns[arn] || store.GetRoleCredentials(arn, &creds) != nil {
+			continue
+		}
+		if creds.ExpireEpoch() > now.Unix() {
+			status.ValidCredentials++
+		}
+	}
+	return status
+}
+
+// statusRow is a row of the `status` table
+type statusRow struct {
+	SSO          string `header:"SSO"`
+	AuthWorkflow string `header:"Workflow"`
+	Token        string `header:"Token Expires"`
+	RefreshToken string `header:"Refresh Token"`
+	Client       string `header:"Client Expires"`
+	GrantTypes   string `header:"Grant Types"`
+	Credentials  int    `header:"Valid Creds"`
+	CacheAge     string `header:"Cache Age"`
+	CacheRefresh string `header:"Cache Refresh"`
+}
+
+func newStatusRow(s ssoStatus, now time.Time) statusRow {
+	row := statusRow{
+		SSO:          s.SSO,
+		AuthWorkflow: s.AuthWorkflow,
+		Token:        "None",
+		RefreshToken: "No",
+		Client:       "None",
+		Credentials:  s.ValidCredentials,
+		CacheAge:     "Never",
+		CacheRefresh: "Disabled",
+	}
+
+	// the device code grant type is a URN, so just show the short names
+	grantTypes := []string{}
+	for _, gt := range s.GrantTypes {
+		grantTypes = append(grantTypes, gt[strings.LastIndex(gt, ":")+1:])
+	}
+	row.GrantTypes = strings.Join(grantTypes, ", ")
+
+	if s.HasToken {
+		row.Token, _ = timeutils.TimeRemain(s.TokenExpiresAt, false)
+	}
+	if s.HasRefreshToken {
+		row.RefreshToken = "Yes"
+	}
+	if s.Registered {
+		row.Client = time.Unix(s.ClientExpiresAt, 0).Local().Format(time.DateTime)
+		if s.ClientExpired {
+			row.Client = "Expired"
+		}
+	}
+	if s.CacheLastUpdate > 0 {
+		row.CacheAge = formatAge(now.Sub(time.Unix(s.CacheLastUpdate, 0)))
+		if s.CacheExpired {
+			row.CacheAge += " (stale)"
+		}
+	}
+	if s.CacheRefresh > 0 {
+		row.CacheRefresh = fmt.Sprintf("%dh", s.CacheRefresh)
+	}
+	return row
+}
+
+// GetHeader is required for GenerateTable()
+func (r statusRow) GetHeader(fieldName string) (string, error) {
+	v := reflect.ValueOf(r)
+	return gotable.GetHeaderTag(v, fieldName)
+}
+
+// formatAge returns the duration in the HHhMMm format used by TimeRemain()
+func formatAge(d time.Duration) string {
+	if d < time.Minute {
+		return "< 1m"
+	}
+	return strings.TrimSuffix(d.Truncate(time.Minute).String(), "0s")
+}
@@ -0,0 +1,77 @@
+//go:build e2etests
+
+package main
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestE2EStatus verifies `aws-sso status` reports every SSO instance without
+// talking to AWS.
+func TestE2EStatus(t *testing.T) {
+	setup := newE2ESetupMultiSSO(t, "Default")
+	preAuth(t, setup)
+	populateCache(t, setup)
+	require.NoError(t, setup.Settings.Cache.Save(true)) // record the LastUpdate
+	queueRoleCredentials(setup.Server)
+
+	ctx := newRunContext(setup, AUTH_REQUIRED)
+	ctx.Cli.Process = ProcessCmd{
+		AccountId: 123456789012,
+		Role:      "ReadOnly",
+	}
+	captureStdout(func() {
+		require.NoError(t, (&ProcessCmd{}).Run(ctx))
+	})
+
+	output := captureStdout(func() {
+		require.NoError(t, (&StatusCmd{Json: true}).Run(ctx))
+	})
+	status := []ssoStatus{}
+	require.NoError(t, json.Unmarshal([]byte(output), &status))
+	require.Len(t, status, 2)
+
+	assert.Equal(t, "Default", status[0].SSO)
+	assert.Equal(t, "device_code", status[0].AuthWorkflow)
+	assert.True(t, status[0].HasToken)
+	assert.False(t, status[0].TokenExpired)
+	assert.False(t, status[0].HasRefreshToken)
+	assert.True(t, status[0].Registered)
+	assert.True(t, status[0].SupportsRefreshToken)
+	assert.Equal(t, 1, status[0].ValidCredentials)
+	assert.NotZero(t, status[0].CacheLastUpdate)
+
+	assert.Equal(t, "Secondary", status[1].SSO)
+	assert.False(t, status[1].HasToken)
+	assert.False(t, status[1].Registered)
+	assert.Zero(t, status[1].ValidCredentials)
+
+	output = captureStdout(func() {
+		require.NoError(t, (&StatusCmd{}).Run(ctx))
+	})
+	assert.Contains(t, output, "Default")
+	assert.Contains(t, output, "Secondary")
+	assert.Contains(t, output, "Never")
+}
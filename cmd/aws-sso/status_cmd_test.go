@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/cache"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+func newStatusTestSSO(name string) *ssoconfig.SSOConfig {
+	s := &ssoconfig.SSOConfig{
+		SSORegion:    "us-east-1",
+		StartUrl:     "https://testing.awsapps.com/start",
+		AuthWorkflow: oidc.AuthWorkflowDeviceCode,
+		CacheRefresh: 24,
+	}
+	s.SetKey(name)
+	return s
+}
+
+func TestNewSsoStatus(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	store := openTestStore(t)
+	s := newStatusTestSSO("Default")
+
+	const validArn = "arn:aws:iam::123456789012:role/Valid"
+	const expiredArn = "arn:aws:iam::123456789012:role/Expired"
+	const otherArn = "arn:aws:iam::210987654321:role/OtherSSO"
+
+	c := &cache.Cache{
+		SSO: map[string]*cache.SSOCache{
+			"Default": {
+				LastUpdate: now.Add(-25 * time.Hour).Unix(),
+				Roles: &cache.Roles{
+					Accounts: map[int64]*cache.AWSAccount{
+						123456789012: {
+							Roles: map[string]*cache.AWSRole{
+								"Valid":   {Arn: validArn},
+								"Expired": {Arn: expiredArn},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("nothing stored", func(t *testing.T) {
+		status := newSsoStatus(s, store, &cache.Cache{SSO: map[string]*cache.SSOCache{}}, now)
+		assert.Equal(t, ssoStatus{
+			SSO:          "Default",
+			StartUrl:     "https://testing.awsapps.com/start",
+			AuthWorkflow: "device_code",
+			CacheRefresh: 24,
+		}, status)
+	})
+
+	require.NoError(t, store.SaveCreateTokenResponse(ctx, "Default", storage.CreateTokenResponse{
+		AccessToken:  "access-token",
+		ExpiresAt:    now.Add(time.Hour).Unix(),
+		RefreshToken: "refresh-token",
+	}))
+	require.NoError(t, store.SaveRegisterClientData(ctx, "Default", storage.RegisterClientData{
+		ClientId:              "cid",
+		ClientSecretExpiresAt: now.Add(-time.Hour).Unix(),
+		GrantTypes:            []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken},
+	}))
+	for _, arn := range []string{validArn, otherArn} {
+		require.NoError(t, store.SaveRoleCredentials(ctx, arn, storage.RoleCredentials{
+			Expiration: now.Add(time.Hour).UnixMilli(),
+		}))
+	}
+	require.NoError(t, store.SaveRoleCredentials(ctx, expiredArn, storage.RoleCredentials{
+		Expiration: now.Add(-time.Hour).UnixMilli(),
+	}))
+
+	t.Run("stored", func(t *testing.T) {
+		status := newSsoStatus(s, store, c, now)
+		assert.True(t, status.HasToken)
+		assert.False(t, status.TokenExpired)
+		assert.Equal(t, now.Add(time.Hour).Unix(), status.TokenExpiresAt)
+		assert.True(t, status.HasRefreshToken)
+
+		assert.True(t, status.Registered)
+		assert.True(t, status.ClientExpired)
+		assert.Equal(t, []string{string(storage.GrantTypeDeviceCode), "refresh_token"}, status.GrantTypes)
+		assert.True(t, status.SupportsDeviceCode)
+		assert.True(t, status.SupportsRefreshToken)
+		assert.False(t, status.SupportsAuthorizationCode)
+
+		// the expired creds and those of other SSO instances are not counted
+		assert.Equal(t, 1, status.ValidCredentials)
+
+		assert.Equal(t, now.Add(-25*time.Hour).Unix(), status.CacheLastUpdate)
+		assert.True(t, status.CacheExpired)
+	})
+
+	t.Run("cache refresh disabled", func(t *testing.T) {
+		s := newStatusTestSSO("Default")
+		s.CacheRefresh = 0
+		status := newSsoStatus(s, store, c, now)
+		assert.False(t, status.CacheExpired)
+	})
+}
+
+func TestNewStatusRow(t *testing.T) {
+	now := time.Now()
+
+	row := newStatusRow(ssoStatus{SSO: "Default", AuthWorkflow: "pkce"}, now)
+	assert.Equal(t, statusRow{
+		SSO:          "Default",
+		AuthWorkflow: "pkce",
+		Token:        "None",
+		RefreshToken: "No",
+		Client:       "None",
+		CacheAge:     "Never",
+		CacheRefresh: "Disabled",
+	}, row)
+
+	row = newStatusRow(ssoStatus{
+		SSO:              "Default",
+		AuthWorkflow:     "device_code",
+		HasToken:         true,
+		TokenExpiresAt:   now.Add(-time.Minute).Unix(),
+		TokenExpired:     true,
+		HasRefreshToken:  true,
+		Registered:       true,
+		ClientExpired:    true,
+		GrantTypes:       []string{string(storage.GrantTypeDeviceCode), "refresh_token"},
+		ValidCredentials: 3,
+		CacheLastUpdate:  now.Add(-26*time.Hour - 5*time.Minute).Unix(),
+		CacheRefresh:     24,
+		CacheExpired:     true,
+	}, now)
+	assert.Equal(t, "Expired", row.Token)
+	assert.Equal(t, "Yes", row.RefreshToken)
+	assert.Equal(t, "Expired", row.Client)
+	assert.Equal(t, "device_code, refresh_token", row.GrantTypes)
+	assert.Equal(t, 3, row.Credentials)
+	assert.Equal(t, "26h5m (stale)", row.CacheAge)
+	assert.Equal(t, "24h", row.CacheRefresh)
+}
+
+func TestFormatAge(t *testing.T) {
+	assert.Equal(t, "< 1m", formatAge(30*time.Second))
+	assert.Equal(t, "5m", formatAge(5*time.Minute+10*time.Second))
+	assert.Equal(t, "2h0m", formatAge(2*time.Hour))
+	assert.Equal(t, "26h5m", formatAge(26*time.Hour+5*time.Minute))
+}
@@ -0,0 +1,76 @@
+package agent
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/flexlog"
+)
+
+var log flexlog.FlexLogger
+
+func init() {
+	log = logger.GetLogger()
+}
+
+const (
+	// ENV_AGENT_SOCK is the environment variable which tells our commands
+	// to fetch credentials from the agent listening on the given socket
+	ENV_AGENT_SOCK = "AWS_SSO_AGENT_SOCK"
+
+	PING_ROUTE        = "/"
+	CREDENTIALS_ROUTE = "/credentials"
+
+	CHARSET_JSON = "application/json; charset=utf-8"
+)
+
+// CredentialsRequest asks the agent for the credentials of a role.  The role
+// is selected via the Profile, the Arn or the AccountId and RoleName.
+type CredentialsRequest struct {
+	SSO        string `json:"sso,omitempty"`
+	Profile    string `json:"profile,omitempty"`
+	Arn        string `json:"arn,omitempty"`
+	AccountId  int64  `json:"accountId,omitempty"`
+	RoleName   string `json:"roleName,omitempty"`
+	STSRefresh bool   `json:"stsRefresh,omitempty"`
+	AddHistory bool   `json:"addHistory,omitempty"`
+}
+
+// CredentialsResponse is everything a client needs to generate the
+// environment for the role
+type CredentialsResponse struct {
+	SSO           string                  `json:"sso"`
+	Creds         storage.RoleCredentials `json:"creds"`
+	Profile       string                  `json:"profile,omitempty"`
+	DefaultRegion string                  `json:"defaultRegion,omitempty"`
+	EnvVarTags    map[string]string       `json:"envVarTags,omitempty"`
+}
+
+// PingResponse identifies the running agent
+type PingResponse struct {
+	SSO string `json:"sso"`
+	Pid int    `json:"pid"`
+}
+
+// Message is returned by the agent on error
+type Message struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,191 @@
+package agent
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+type testProvider struct {
+	lock     sync.Mutex
+	requests []CredentialsRequest
+}
+
+func (p *testProvider) SSOName() string {
+	return "Default"
+}
+
+func (p *testProvider) GetCredentials(req CredentialsRequest) (*CredentialsResponse, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.requests = append(p.requests, req)
+
+	if req.RoleName == "Missing" {
+		return nil, fmt.Errorf("unable to find role %d:%s", req.AccountId, req.RoleName)
+	}
+
+	return &CredentialsResponse{
+		SSO: p.SSOName(),
+		Creds: storage.RoleCredentials{
+			RoleName:        req.RoleName,
+			AccountId:       req.AccountId,
+			AccessKeyId:     "AccessKeyId",
+			SecretAccessKey: "SecretAccessKey",
+			SessionToken:    "SessionToken",
+			Expiration:      time.Now().Add(time.Hour).UnixMilli(),
+		},
+		Profile:       fmt.Sprintf("%d:%s", req.AccountId, req.RoleName),
+		DefaultRegion: "us-west-2",
+		EnvVarTags:    map[string]string{"FOO": "bar"},
+	}, nil
+}
+
+// startTestAgent starts an agent in a temp directory and returns its socket path
+func startTestAgent(t *testing.T, p CredentialsProvider) (*AgentServer, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	sockPath := filepath.Join(dir, "sock", "agent.sock")
+	a, err := NewAgentServer(sockPath, p)
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- a.Serve() }()
+	t.Cleanup(func() {
+		a.Close()
+		assert.ErrorIs(t, <-done, http.ErrServerClosed)
+	})
+	return a, sockPath
+}
+
+func TestAgentCredentials(t *testing.T) {
+	p := &testProvider{}
+	a, sockPath := startTestAgent(t, p)
+	assert.Equal(t, sockPath, a.SocketPath())
+
+	c := NewAgentClient(sockPath)
+	ping, err := c.Ping()
+	assert.NoError(t, err)
+	assert.Equal(t, "Default", ping.SSO)
+	assert.Equal(t, os.Getpid(), ping.Pid)
+
+	req := CredentialsRequest{
+		SSO:        "Default",
+		AccountId:  123456789012,
+		RoleName:   "FooBar",
+		STSRefresh: true,
+		AddHistory: true,
+	}
+	resp, err := c.GetCredentials(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "Default", resp.SSO)
+	assert.Equal(t, "SecretAccessKey", resp.Creds.SecretAccessKey)
+	assert.Equal(t, "arn:aws:iam::123456789012:role/FooBar", resp.Creds.RoleArn())
+	assert.False(t, resp.Creds.Expired())
+	assert.Equal(t, "123456789012:FooBar", resp.Profile)
+	assert.Equal(t, "us-west-2", resp.DefaultRegion)
+	assert.Equal(t, map[string]string{"FOO": "bar"}, resp.EnvVarTags)
+	assert.Equal(t, []CredentialsRequest{req}, p.requests)
+
+	// SSO defaults to whatever the agent serves
+	_, err = c.GetCredentials(CredentialsRequest{Profile: "foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", p.requests[1].Profile)
+}
+
+func TestAgentErrors(t *testing.T) {
+	p := &testProvider{}
+	_, sockPath := startTestAgent(t, p)
+	c := NewAgentClient(sockPath)
+
+	_, err := c.GetCredentials(CredentialsRequest{AccountId: 1, RoleName: "Missing"})
+	assert.ErrorContains(t, err, "unable to find role 1:Missing")
+
+	_, err = c.GetCredentials(CredentialsRequest{SSO: "Other", AccountId: 1, RoleName: "FooBar"})
+	assert.ErrorContains(t, err, "agent is serving the Default AWS SSO instance, not Other")
+	assert.Len(t, p.requests, 1) // never made it to the provider
+
+	err = c.do(http.MethodGet, CREDENTIALS_ROUTE, nil, &CredentialsResponse{})
+	assert.ErrorContains(t, err, "Bad request")
+
+	err = c.do(http.MethodGet, "/foo", nil, &PingResponse{})
+	assert.ErrorContains(t, err, "Bad request")
+
+	// no agent running
+	_, err = NewAgentClient(filepath.Join(filepath.Dir(sockPath), "missing.sock")).Ping()
+	assert.ErrorContains(t, err, "unable to contact aws-sso agent")
+}
+
+func TestAgentSocket(t *testing.T) {
+	_, sockPath := startTestAgent(t, &testProvider{})
+
+	fi, err := os.Stat(sockPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, fi.Mode().Type())
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Dir(sockPath))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0700), fi.Mode().Perm())
+
+	// only one agent per socket
+	_, err = NewAgentServer(sockPath, &testProvider{})
+	assert.ErrorContains(t, err, "already listening")
+}
+
+func TestAgentStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	sockPath := filepath.Join(dir, "agent.sock")
+
+	// leave a socket behind which nobody is listening on
+	l, err := net.Listen("unix", sockPath)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+	_, err = os.Stat(sockPath)
+	require.NoError(t, err)
+
+	a, err := NewAgentServer(sockPath, &testProvider{})
+	require.NoError(t, err)
+	go func() { _ = a.Serve() }()
+
+	_, err = NewAgentClient(sockPath).Ping()
+	assert.NoError(t, err)
+
+	// Close cleans up our socket
+	assert.NoError(t, a.Close())
+	_, err = os.Stat(sockPath)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,108 @@
+package agent
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// the host is ignored since we always dial our Unix socket
+	AGENT_URL = "http://aws-sso-agent"
+
+	// how long we wait for the agent.  Generous because the agent may need
+	// to fetch the credentials from AWS SSO
+	CLIENT_TIMEOUT = 60 * time.Second
+)
+
+type AgentClient struct {
+	sockPath string
+	client   *http.Client
+}
+
+// NewAgentClient returns a client for the agent listening on sockPath
+func NewAgentClient(sockPath string) *AgentClient {
+	dialer := net.Dialer{}
+	return &AgentClient{
+		sockPath: sockPath,
+		client: &http.Client{
+			Timeout: CLIENT_TIMEOUT,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return dialer.DialContext(ctx, "unix", sockPath)
+				},
+			},
+		},
+	}
+}
+
+// Ping checks that the agent is running
+func (c *AgentClient) Ping() (*PingResponse, error) {
+	ping := PingResponse{}
+	if err := c.do(http.MethodGet, PING_ROUTE, nil, &ping); err != nil {
+		return nil, err
+	}
+	return &ping, nil
+}
+
+// GetCredentials requests the credentials for a role from the agent
+func (c *AgentClient) GetCredentials(req CredentialsRequest) (*CredentialsResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := CredentialsResponse{}
+	if err := c.do(http.MethodPost, CREDENTIALS_ROUTE, bytes.NewReader(body), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do makes our request and decodes the JSON response into out
+func (c *AgentClient) do(method, route string, body io.Reader, out any) error {
+	req, err := http.NewRequest(method, AGENT_URL+route, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", CHARSET_JSON)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to contact aws-sso agent via %s: %w", c.sockPath, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg := Message{}
+		if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil || msg.Message == "" {
+			return fmt.Errorf("aws-sso agent returned HTTP %d", resp.StatusCode)
+		}
+		return fmt.Errorf("aws-sso agent: %s", msg.Message)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,162 @@
+package agent
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+)
+
+// CredentialsProvider looks up the credentials requested by our clients
+type CredentialsProvider interface {
+	// SSOName returns the name of the AWS SSO instance we serve
+	SSOName() string
+	GetCredentials(req CredentialsRequest) (*CredentialsResponse, error)
+}
+
+type AgentServer struct {
+	sockPath string
+	listener net.Listener
+	server   http.Server
+	provider CredentialsProvider
+}
+
+// NewAgentServer creates a new agent listening on the Unix socket sockPath which
+// is only accessible by the current user
+func NewAgentServer(sockPath string, provider CredentialsProvider) (*AgentServer, error) {
+	l, err := listen(sockPath)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &AgentServer{
+		sockPath: sockPath,
+		listener: l,
+		provider: provider,
+	}
+
+	router := http.NewServeMux()
+	router.HandleFunc(PING_ROUTE, a.ping)
+	router.HandleFunc(CREDENTIALS_ROUTE, a.credentials)
+	a.server.Handler = router
+	a.server.ReadHeaderTimeout = 5 * time.Second
+
+	return a, nil
+}
+
+// listen creates our socket, replacing any stale socket left behind by an
+// agent which didn't shut down cleanly
+func listen(sockPath string) (net.Listener, error) {
+	if err := os.MkdirAll(filepath.Dir(sockPath), 0700); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Lstat(sockPath); err == nil {
+		if _, err := NewAgentClient(sockPath).Ping(); err == nil {
+			return nil, fmt.Errorf("an agent is already listening on %s", sockPath)
+		}
+		log.Debug("Removing stale agent socket", "socket", sockPath)
+		if err := os.Remove(sockPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return fileutils.ListenUnix(sockPath, 0600)
+}
+
+// SocketPath returns the path of our Unix socket
+func (a *AgentServer) SocketPath() string {
+	return a.sockPath
+}
+
+// Serve handles requests until Close() is called
+func (a *AgentServer) Serve() error {
+	return a.server.Serve(a.listener)
+}
+
+// Close shuts down the agent and removes the socket
+func (a *AgentServer) Close() error {
+	return a.server.Close()
+}
+
+func (a *AgentServer) ping(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != PING_ROUTE || r.Method != http.MethodGet {
+		writeMessage(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	jsonResponse(w, PingResponse{
+		SSO: a.provider.SSOName(),
+		Pid: os.Getpid(),
+	})
+}
+
+func (a *AgentServer) credentials(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeMessage(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	req := CredentialsRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeMessage(w, fmt.Sprintf("Invalid request: %s", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	if req.SSO != "" && req.SSO != a.provider.SSOName() {
+		writeMessage(w, fmt.Sprintf("agent is serving the %s AWS SSO instance, not %s", a.provider.SSOName(), req.SSO), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	resp, err := a.provider.GetCredentials(req)
+	if err != nil {
+		log.Error("Unable to get credentials", "error", err.Error())
+		writeMessage(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	log.Info("served credentials", "arn", resp.Creds.RoleArn(), "time", time.Since(start))
+	jsonResponse(w, resp)
+}
+
+// jsonResponse returns a JSON blob as a result
+func jsonResponse(w http.ResponseWriter, jdata any) {
+	w.Header().Set("Content-Type", CHARSET_JSON)
+	if err := json.NewEncoder(w).Encode(jdata); err != nil {
+		log.Error(err.Error())
+		writeMessage(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeMessage returns a JSON message to the caller with the appropriate HTTP Status Code
+func writeMessage(w http.ResponseWriter, msg string, statusCode int) {
+	w.Header().Set("Content-Type", CHARSET_JSON)
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(Message{
+		Code:    statusCode,
+		Message: msg,
+	})
+}
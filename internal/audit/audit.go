@@ -0,0 +1,301 @@
+package audit
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+const (
+	AUDIT_LOCK_TIMEOUT = 5 * time.Second
+	DEFAULT_MAX_SIZE   = 10 // MB
+	DEFAULT_MAX_FILES  = 5
+
+	// longest line we will read back from the audit log
+	maxLineSize = 1024 * 1024
+)
+
+// Action is the type of audit event
+type Action string
+
+const (
+	Credentials    Action = "credentials"     // role credentials returned to an aws-sso command
+	EcsCredentials Action = "ecs-credentials" // role credentials returned by the ECS Server
+	Console        Action = "console"         // AWS Console URL generated
+	Login          Action = "login"           // authenticated to AWS SSO
+	Logout         Action = "logout"          // logged out of AWS SSO
+)
+
+var Actions = []Action{Credentials, EcsCredentials, Console, Login, Logout}
+
+// Event is a single line of the audit log.  Never put secrets in here!
+type Event struct {
+	Time      time.Time `json:"time"`
+	Action    Action    `json:"action"`
+	Command   string    `json:"command,omitempty"` // aws-sso command which generated the event
+	Pid       int       `json:"pid"`
+	ParentPid int       `json:"ppid"`
+	SSO       string    `json:"sso,omitempty"`
+	Arn       string    `json:"arn,omitempty"`
+	AccountId int64     `json:"accountId,omitempty"`
+	RoleName  string    `json:"roleName,omitempty"`
+	Profile   string    `json:"profile,omitempty"`
+	Source    string    `json:"source,omitempty"` // cache or aws
+	Expires   string    `json:"expires,omitempty"`
+	Region    string    `json:"region,omitempty"`
+	Remote    string    `json:"remote,omitempty"` // ECS Server client address
+	Slot      string    `json:"slot,omitempty"`   // ECS Server slot
+	Error     string    `json:"error,omitempty"`
+}
+
+// Logger appends Events as JSON lines to the audit log, rotating it once it
+// grows larger than maxSize.  Multiple aws-sso processes may share the same
+// file, so writes hold an advisory lock.  Log and SetCommand are safe to call
+// on a nil *Logger which makes auditing a no-op.
+type Logger struct {
+	file     string
+	maxSize  int64
+	maxFiles int
+	command  string
+	lock     sync.Mutex
+}
+
+// NewLogger returns a Logger for the given file which is rotated once it is
+// larger than maxSize MB, keeping maxFiles previous files.
+func NewLogger(file string, maxSize int64, maxFiles int) *Logger {
+	if maxSize <= 0 {
+		maxSize = DEFAULT_MAX_SIZE
+	}
+	if maxFiles < 0 {
+		maxFiles = 0
+	}
+	return &Logger{
+		file:     file,
+		maxSize:  maxSize * 1024 * 1024,
+		maxFiles: maxFiles,
+	}
+}
+
+// File returns the path to the current audit log
+func (l *Logger) File() string {
+	return l.file
+}
+
+// LockFile returns the path of the advisory lock file for our audit log
+func (l *Logger) LockFile() string {
+	return l.file + ".lock"
+}
+
+// SetCommand sets the default Command for all future Events
+func (l *Logger) SetCommand(command string) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.command = command
+}
+
+// Log appends the event to the audit log.  The Time, Pid, ParentPid and
+// Command are filled in if they are not set.
+func (l *Logger) Log(e Event) error {
+	if l == nil {
+		return nil
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.Pid == 0 {
+		e.Pid = os.Getpid()
+		e.ParentPid = os.Getppid()
+	}
+	if e.Command == "" {
+		e.Command = l.command
+	}
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if err = fileutils.EnsureDirExists(l.file); err != nil {
+		return err
+	}
+
+	err = storage.WithFlock(context.Background(), l.LockFile(), AUDIT_LOCK_TIMEOUT, func() error {
+		if info, err := os.Stat(l.file); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > l.maxSize {
+			if err := l.rotate(); err != nil {
+				return err
+			}
+		}
+
+		f, err := os.OpenFile(l.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		if _, err = f.Write(line); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("unable to write audit log %s: %w", l.file, err)
+	}
+	return nil
+}
+
+// rotatedFile returns the path of the nth previous audit log
+func (l *Logger) rotatedFile(n int) string {
+	return fmt.Sprintf("%s.%d", l.file, n)
+}
+
+// rotate renames the current audit log to <file>.1, <file>.1 to <file>.2, etc.
+// and removes the oldest so at most maxFiles previous files are kept
+func (l *Logger) rotate() error {
+	if l.maxFiles == 0 {
+		return os.Remove(l.file)
+	}
+
+	if err := os.Remove(l.rotatedFile(l.maxFiles)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for i := l.maxFiles - 1; i > 0; i-- {
+		if err := os.Rename(l.rotatedFile(i), l.rotatedFile(i+1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	log.Debug("rotating audit log", "file", l.file)
+	return os.Rename(l.file, l.rotatedFile(1))
+}
+
+// Files returns all of the audit logs which exist, oldest first
+func (l *Logger) Files() []string {
+	files := []string{}
+	for i := l.maxFiles; i > 0; i-- {
+		if _, err := os.Stat(l.rotatedFile(i)); err == nil {
+			files = append(files, l.rotatedFile(i))
+		}
+	}
+	if _, err := os.Stat(l.file); err == nil {
+		files = append(files, l.file)
+	}
+	return files
+}
+
+// Filter selects which Events are returned by Query.  Empty values match everything.
+type Filter struct {
+	Role    string // RoleName, Arn or Profile
+	Command string
+	Action  Action
+	Since   time.Time
+	Until   time.Time
+}
+
+// Match returns true if the event matches our filter
+func (f Filter) Match(e Event) bool {
+	if f.Role != "" && !strings.EqualFold(f.Role, e.RoleName) &&
+		!strings.EqualFold(f.Role, e.Arn) && !strings.EqualFold(f.Role, e.Profile) {
+		return false
+	}
+	if f.Command != "" && f.Command != e.Command {
+		return false
+	}
+	if f.Action != "" && f.Action != e.Action {
+		return false
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns all of the Events in the audit logs which match the filter, oldest first
+func (l *Logger) Query(f Filter) ([]Event, error) {
+	events := []Event{}
+	for _, file := range l.Files() {
+		err := readFile(file, func(e Event) {
+			if f.Match(e) {
+				events = append(events, e)
+			}
+		})
+		if err != nil {
+			return events, err
+		}
+	}
+	return events, nil
+}
+
+// readFile calls fn for each Event in the given audit log
+func readFile(file string, fn func(Event)) error {
+	f, err := os.Open(file) // nolint:gosec
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		e := Event{}
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			log.Warn("skipping invalid audit log entry", "file", file, "line", lineNum, "error", err.Error())
+			continue
+		}
+		fn(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read %s: %w", file, err)
+	}
+	return nil
+}
+
+// NewAction returns the Action for the given string
+func NewAction(action string) (Action, error) {
+	for _, a := range Actions {
+		if string(a) == action {
+			return a, nil
+		}
+	}
+	return "", fmt.Errorf("invalid audit action: %s", action)
+}
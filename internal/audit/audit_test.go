@@ -0,0 +1,190 @@
+package audit
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNilLogger(t *testing.T) {
+	var l *Logger
+	l.SetCommand("exec")
+	assert.NoError(t, l.Log(Event{Action: Login}))
+}
+
+func TestLog(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "subdir", "audit.jsonl")
+	l := NewLogger(file, 0, DEFAULT_MAX_FILES)
+	assert.Equal(t, file, l.File())
+	l.SetCommand("exec")
+
+	require.NoError(t, l.Log(Event{Action: Credentials, RoleName: "FooBar", AccountId: 123456789012}))
+	require.NoError(t, l.Log(Event{Action: Login, SSO: "Default", Command: "login"}))
+
+	info, err := os.Stat(file)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	b, err := os.ReadFile(file)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	require.Len(t, lines, 2)
+
+	e := Event{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, Credentials, e.Action)
+	assert.Equal(t, "exec", e.Command)
+	assert.Equal(t, "FooBar", e.RoleName)
+	assert.Equal(t, os.Getpid(), e.Pid)
+	assert.Equal(t, os.Getppid(), e.ParentPid)
+	assert.WithinDuration(t, time.Now(), e.Time, time.Minute)
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, "login", e.Command) // not overridden
+	assert.NotContains(t, lines[1], "roleName")
+}
+
+func TestLogConcurrent(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// separate loggers simulate separate processes
+			l := NewLogger(file, 0, DEFAULT_MAX_FILES)
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, l.Log(Event{Action: Credentials}))
+			}
+		}()
+	}
+	wg.Wait()
+
+	events, err := NewLogger(file, 0, DEFAULT_MAX_FILES).Query(Filter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 100)
+}
+
+func TestRotate(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	l := NewLogger(file, 1, 2)
+	l.maxSize = 300 // bytes; each event is ~100
+
+	for i := 0; i < 10; i++ {
+		require.NoError(t, l.Log(Event{Action: Credentials, AccountId: int64(i)}))
+	}
+
+	files := l.Files()
+	assert.Equal(t, []string{file + ".2", file + ".1", file}, files)
+	for _, f := range files {
+		info, err := os.Stat(f)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), l.maxSize)
+	}
+	_, err := os.Stat(file + ".3")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// oldest first and the oldest events were dropped
+	events, err := l.Query(Filter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, events)
+	assert.Less(t, len(events), 10)
+	first := 10 - int64(len(events))
+	for i, e := range events {
+		assert.Equal(t, first+int64(i), e.AccountId)
+	}
+
+	// no rotated files
+	l = NewLogger(filepath.Join(t.TempDir(), "audit.jsonl"), 1, 0)
+	l.maxSize = 300
+	for i := 0; i < 5; i++ {
+		require.NoError(t, l.Log(Event{Action: Credentials, AccountId: int64(i)}))
+	}
+	assert.Equal(t, []string{l.File()}, l.Files())
+}
+
+func TestQuery(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "audit.jsonl")
+	l := NewLogger(file, 0, DEFAULT_MAX_FILES)
+	now := time.Now()
+
+	events := []Event{
+		{Time: now.Add(-3 * time.Hour), Action: Login, Command: "login", SSO: "Default"},
+		{Time: now.Add(-2 * time.Hour), Action: Credentials, Command: "exec", RoleName: "FooBar",
+			Arn: "arn:aws:iam::123456789012:role/FooBar", Profile: "Foo:FooBar"},
+		{Time: now.Add(-1 * time.Hour), Action: Console, Command: "console", RoleName: "Admin"},
+		{Time: now, Action: EcsCredentials, Command: "ecs server", RoleName: "FooBar", Slot: "default"},
+	}
+	for _, e := range events {
+		require.NoError(t, l.Log(e))
+	}
+
+	// invalid lines are skipped
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString("not json\n\n")
+	require.NoError(t, err)
+	f.Close()
+
+	tests := []struct {
+		filter Filter
+		count  int
+	}{
+		{Filter{}, 4},
+		{Filter{Role: "foobar"}, 2},
+		{Filter{Role: "arn:aws:iam::123456789012:role/FooBar"}, 1},
+		{Filter{Role: "Foo:FooBar"}, 1},
+		{Filter{Command: "exec"}, 1},
+		{Filter{Action: EcsCredentials}, 1},
+		{Filter{Since: now.Add(-90 * time.Minute)}, 2},
+		{Filter{Until: now.Add(-90 * time.Minute)}, 2},
+		{Filter{Since: now.Add(-150 * time.Minute), Until: now.Add(-30 * time.Minute)}, 2},
+		{Filter{Role: "FooBar", Since: now.Add(-time.Minute)}, 1},
+		{Filter{Role: "Missing"}, 0},
+	}
+	for i, test := range tests {
+		events, err := l.Query(test.filter)
+		require.NoError(t, err)
+		assert.Len(t, events, test.count, "test %d", i)
+	}
+
+	// no audit log yet
+	events, err = NewLogger(filepath.Join(t.TempDir(), "missing.jsonl"), 0, 0).Query(Filter{})
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+}
+
+func TestNewAction(t *testing.T) {
+	for _, a := range Actions {
+		action, err := NewAction(string(a))
+		assert.NoError(t, err)
+		assert.Equal(t, a, action)
+	}
+	_, err := NewAction("foo")
+	assert.Error(t, err)
+}
@@ -100,6 +100,13 @@ func (h *SSOOIDCHandler) QueueCreateToken(r OIDCTokenResponse) {
 	h.tokenQ = append(h.tokenQ, queueItem{status: http.StatusOK, body: r})
 }
 
+// QueueCreateTokenError enqueues an error response for CreateToken.
+func (h *SSOOIDCHandler) QueueCreateTokenError(status int, msg string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokenQ = append(h.tokenQ, queueItem{status: status, body: msg})
+}
+
 func (h *SSOOIDCHandler) handleRegisterClient(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
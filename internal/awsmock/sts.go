@@ -24,6 +24,7 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 )
@@ -70,8 +71,16 @@ type stsResponseMetadata struct {
 
 // STSHandler handles the STS AssumeRole endpoint (POST /).
 type STSHandler struct {
-	mu      sync.Mutex
-	assumeQ []queueItem
+	mu       sync.Mutex
+	assumeQ  []queueItem
+	requests []url.Values
+}
+
+// AssumeRoleRequests returns the form parameters of every AssumeRole request received, in order.
+func (h *STSHandler) AssumeRoleRequests() []url.Values {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]url.Values(nil), h.requests...)
 }
 
 // QueueAssumeRole enqueues a successful AssumeRole response.
@@ -94,7 +103,13 @@ func (h *STSHandler) handleSTS(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+
 	h.mu.Lock()
+	h.requests = append(h.requests, r.PostForm)
 	item, found := dequeue(&h.assumeQ)
 	h.mu.Unlock()
 
@@ -8,6 +8,27 @@ import (
 
 const MAX_AWS_ACCOUNTID = 999999999999
 
+const (
+	PARTITION_AWS        = "aws"
+	PARTITION_AWS_CN     = "aws-cn"
+	PARTITION_AWS_US_GOV = "aws-us-gov"
+	PARTITION_AWS_EUSC   = "aws-eusc"
+)
+
+// GetPartition returns the AWS partition for the given region
+func GetPartition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "cn-"):
+		return PARTITION_AWS_CN
+	case strings.HasPrefix(region, "us-gov-"):
+		return PARTITION_AWS_US_GOV
+	case strings.HasPrefix(region, "eusc-"):
+		return PARTITION_AWS_EUSC
+	default:
+		return PARTITION_AWS
+	}
+}
+
 // ParseRoleARN parses an ARN representing a role in long or short format
 func ParseRoleARN(arn string) (int64, string, error) {
 	s := strings.Split(arn, ":")
@@ -18,7 +39,7 @@ func ParseRoleARN(arn string) (int64, string, error) {
 		accountid = s[0]
 		role = s[1]
 	case 6:
-		// long format for arn:aws:iam::XXXXXXXXXX:role/YYYYYYYY
+		// long format for arn:<partition>:iam::XXXXXXXXXX:role/YYYYYYYY
 		accountid = s[4]
 		s = strings.Split(s[5], "/")
 		if len(s) != 2 {
@@ -44,33 +65,49 @@ func ParseUserARN(arn string) (int64, string, error) {
 	return ParseRoleARN(arn)
 }
 
-// MakeRoleARN create an IAM Role ARN using an int64 for the account
-func MakeRoleARN(account int64, name string) string {
+// IsUserARN returns true if the ARN is for an IAM User instead of an IAM Role
+func IsUserARN(arn string) bool {
+	s := strings.Split(arn, ":")
+	return len(s) == 6 && strings.HasPrefix(s[5], "user/")
+}
+
+// MakeRoleARN create an IAM Role ARN using an int64 for the account.
+// An empty partition defaults to `aws`.
+func MakeRoleARN(partition string, account int64, name string) string {
 	a, err := AccountIdToString(account)
 	if err != nil {
 		panic(fmt.Sprintf("unable to MakeRoleARN: %s", err.Error()))
 	}
-	return fmt.Sprintf("arn:aws:iam::%s:role/%s", a, name)
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", defaultPartition(partition), a, name)
 }
 
-// MakeUserARN create an IAM User ARN using an int64 for the account
-func MakeUserARN(account int64, name string) string {
+// MakeUserARN create an IAM User ARN using an int64 for the account.
+// An empty partition defaults to `aws`.
+func MakeUserARN(partition string, account int64, name string) string {
 	a, err := AccountIdToString(account)
 	if err != nil {
 		panic(fmt.Sprintf("unable to MakeUserARN: %s", err.Error()))
 	}
-	return fmt.Sprintf("arn:aws:iam::%s:user/%s", a, name)
+	return fmt.Sprintf("arn:%s:iam::%s:user/%s", defaultPartition(partition), a, name)
 }
 
-// MakeRoleARNs creates an IAM Role ARN using a string for the account and role
-func MakeRoleARNs(account, name string) string {
+// MakeRoleARNs creates an IAM Role ARN using a string for the account and role.
+// An empty partition defaults to `aws`.
+func MakeRoleARNs(partition, account, name string) string {
 	x, err := AccountIdToInt64(account)
 	if err != nil {
 		panic(fmt.Sprintf("unable to MakeRoleARNs: %s", err.Error()))
 	}
 
 	a, _ := AccountIdToString(x)
-	return fmt.Sprintf("arn:aws:iam::%s:role/%s", a, name)
+	return fmt.Sprintf("arn:%s:iam::%s:role/%s", defaultPartition(partition), a, name)
+}
+
+func defaultPartition(partition string) string {
+	if partition == "" {
+		return PARTITION_AWS
+	}
+	return partition
 }
 
 // AccountIdToString returns a string version of AWS AccountID with leading zeroes
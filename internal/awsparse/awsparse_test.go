@@ -43,6 +43,17 @@ func TestParseRoleARN(t *testing.T) {
 	_, _, err = ParseRoleARN("arn:aws:iam::-000000011111:role/Foo")
 	assert.Error(t, err)
 
+	// non-commercial partitions
+	a, r, err = ParseRoleARN("arn:aws-us-gov:iam::000000011111:role/Foo")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11111), a)
+	assert.Equal(t, "Foo", r)
+
+	a, r, err = ParseRoleARN("arn:aws-cn:iam::000000011111:role/Foo")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11111), a)
+	assert.Equal(t, "Foo", r)
+
 	// ParseUserARN is just ParseRoleARN...
 	a, r, err = ParseUserARN("arn:aws:iam::22222:user/Foo")
 	assert.NoError(t, err)
@@ -50,35 +61,67 @@ func TestParseRoleARN(t *testing.T) {
 	assert.Equal(t, "Foo", r)
 }
 
+func TestIsUserARN(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, IsUserARN("arn:aws:iam::000000011111:user/Foo"))
+	assert.True(t, IsUserARN("arn:aws-cn:iam::000000011111:user/Foo"))
+	assert.False(t, IsUserARN("arn:aws:iam::000000011111:role/Foo"))
+	assert.False(t, IsUserARN("000000011111:Foo"))
+	assert.False(t, IsUserARN("user/Foo"))
+	assert.False(t, IsUserARN(""))
+}
+
 func TestMakeRoleARN(t *testing.T) {
 	t.Parallel()
 
-	assert.Equal(t, "arn:aws:iam::000000011111:role/Foo", MakeRoleARN(11111, "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARN(711111, "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000000000:role/", MakeRoleARN(0, ""))
+	assert.Equal(t, "arn:aws:iam::000000011111:role/Foo", MakeRoleARN("", 11111, "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARN("", 711111, "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000000000:role/", MakeRoleARN("", 0, ""))
+
+	assert.Equal(t, "arn:aws:iam::000000011111:role/Foo", MakeRoleARN("aws", 11111, "Foo"))
+	assert.Equal(t, "arn:aws-us-gov:iam::000000011111:role/Foo", MakeRoleARN("aws-us-gov", 11111, "Foo"))
+	assert.Equal(t, "arn:aws-cn:iam::000000011111:role/Foo", MakeRoleARN("aws-cn", 11111, "Foo"))
 
-	assert.Panics(t, func() { MakeRoleARN(-1, "foo") })
+	assert.Panics(t, func() { MakeRoleARN("", -1, "foo") })
 }
 
 func TestMakeUserARN(t *testing.T) {
 	t.Parallel()
 
-	assert.Equal(t, "arn:aws:iam::000000011111:user/Foo", MakeUserARN(11111, "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000711111:user/Foo", MakeUserARN(711111, "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000000000:user/", MakeUserARN(0, ""))
+	assert.Equal(t, "arn:aws:iam::000000011111:user/Foo", MakeUserARN("", 11111, "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000711111:user/Foo", MakeUserARN("", 711111, "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000000000:user/", MakeUserARN("", 0, ""))
 
-	assert.Panics(t, func() { MakeUserARN(-1, "foo") })
+	assert.Equal(t, "arn:aws-eusc:iam::000000011111:user/Foo", MakeUserARN("aws-eusc", 11111, "Foo"))
+
+	assert.Panics(t, func() { MakeUserARN("", -1, "foo") })
 }
 
 func TestMakeRoleARNs(t *testing.T) {
 	t.Parallel()
 
-	assert.Equal(t, "arn:aws:iam::000000011111:role/Foo", MakeRoleARNs("11111", "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARNs("711111", "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARNs("000711111", "Foo"))
-	assert.Equal(t, "arn:aws:iam::000000000000:role/", MakeRoleARNs("0", ""))
+	assert.Equal(t, "arn:aws:iam::000000011111:role/Foo", MakeRoleARNs("", "11111", "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARNs("", "711111", "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000711111:role/Foo", MakeRoleARNs("", "000711111", "Foo"))
+	assert.Equal(t, "arn:aws:iam::000000000000:role/", MakeRoleARNs("", "0", ""))
+
+	assert.Equal(t, "arn:aws-us-gov:iam::000000711111:role/Foo", MakeRoleARNs("aws-us-gov", "711111", "Foo"))
+
+	assert.Panics(t, func() { MakeRoleARNs("", "asdfasfdo", "foo") })
+}
+
+func TestGetPartition(t *testing.T) {
+	t.Parallel()
 
-	assert.Panics(t, func() { MakeRoleARNs("asdfasfdo", "foo") })
+	assert.Equal(t, PARTITION_AWS, GetPartition("us-east-1"))
+	assert.Equal(t, PARTITION_AWS, GetPartition("eu-west-1"))
+	assert.Equal(t, PARTITION_AWS, GetPartition(""))
+	assert.Equal(t, PARTITION_AWS_CN, GetPartition("cn-north-1"))
+	assert.Equal(t, PARTITION_AWS_CN, GetPartition("cn-northwest-1"))
+	assert.Equal(t, PARTITION_AWS_US_GOV, GetPartition("us-gov-west-1"))
+	assert.Equal(t, PARTITION_AWS_US_GOV, GetPartition("us-gov-east-1"))
+	assert.Equal(t, PARTITION_AWS_EUSC, GetPartition("eusc-de-east-1"))
 }
 
 func TestAccountToString(t *testing.T) {
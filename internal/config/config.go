@@ -30,6 +30,8 @@ const (
 	CONFIG_FILE         = "%s/config.yaml"
 	JSON_STORE_FILE     = "%s/store.json"
 	INSECURE_CACHE_FILE = "%s/cache.json"
+	AGENT_SOCK_FILE     = "%s/agent.sock"
+	AUDIT_LOG_FILE      = "%s/audit.jsonl"
 )
 
 // ConfigDir returns the path to the config directory
@@ -73,3 +75,13 @@ func JsonStoreFile(expand bool) string {
 func InsecureCacheFile(expand bool) string {
 	return fmt.Sprintf(INSECURE_CACHE_FILE, ConfigDir(expand))
 }
+
+// AgentSockFile returns the path to the aws-sso agent Unix socket
+func AgentSockFile(expand bool) string {
+	return fmt.Sprintf(AGENT_SOCK_FILE, ConfigDir(expand))
+}
+
+// AuditLogFile returns the path to the audit log
+func AuditLogFile(expand bool) string {
+	return fmt.Sprintf(AUDIT_LOG_FILE, ConfigDir(expand))
+}
@@ -92,6 +92,48 @@ func TestInsecureCacheFile(t *testing.T) {
 	assert.Equal(t, "~/.aws-sso/cache.json", InsecureCacheFile(false))
 }
 
+func TestAgentSockFile(t *testing.T) {
+	tempHome, err := os.MkdirTemp("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempHome)
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", xdg)
+	os.Unsetenv("XDG_CONFIG_HOME")
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	err = os.Setenv("HOME", tempHome)
+	assert.NoError(t, err)
+
+	assert.Equal(t, tempHome+"/.config/aws-sso/agent.sock", AgentSockFile(true))
+	assert.Equal(t, "~/.config/aws-sso/agent.sock", AgentSockFile(false))
+	_ = os.MkdirAll(fmt.Sprintf("%s/.aws-sso", tempHome), 0755)
+	assert.Equal(t, tempHome+"/.aws-sso/agent.sock", AgentSockFile(true))
+	assert.Equal(t, "~/.aws-sso/agent.sock", AgentSockFile(false))
+}
+
+func TestAuditLogFile(t *testing.T) {
+	tempHome, err := os.MkdirTemp("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tempHome)
+
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	defer os.Setenv("XDG_CONFIG_HOME", xdg)
+	os.Unsetenv("XDG_CONFIG_HOME")
+
+	home := os.Getenv("HOME")
+	defer os.Setenv("HOME", home)
+	err = os.Setenv("HOME", tempHome)
+	assert.NoError(t, err)
+
+	assert.Equal(t, tempHome+"/.config/aws-sso/audit.jsonl", AuditLogFile(true))
+	assert.Equal(t, "~/.config/aws-sso/audit.jsonl", AuditLogFile(false))
+	_ = os.MkdirAll(fmt.Sprintf("%s/.aws-sso", tempHome), 0755)
+	assert.Equal(t, tempHome+"/.aws-sso/audit.jsonl", AuditLogFile(true))
+	assert.Equal(t, "~/.aws-sso/audit.jsonl", AuditLogFile(false))
+}
+
 func TestXDGConfigDir(t *testing.T) {
 	tempHome, err := os.MkdirTemp("", "")
 	assert.NoError(t, err)
@@ -0,0 +1,171 @@
+package ecs
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2022 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+)
+
+const (
+	CA_COMMON_NAME     = "aws-sso ECS Server CA"
+	SERVER_COMMON_NAME = "aws-sso ECS Server"
+	CA_LIFETIME        = 10 * 365 * 24 * time.Hour
+	DOCKER_HOST_NAME   = "host.docker.internal"
+)
+
+// DefaultServerHosts are the SANs included in every generated ECS Server certificate
+var DefaultServerHosts = []string{"localhost", "127.0.0.1", "::1", DOCKER_HOST_NAME}
+
+// GenerateCA creates a new self-signed CA certificate and private key in PEM format
+// which is valid for the given lifetime
+func GenerateCA(lifetime time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, err := newCertTemplate(CA_COMMON_NAME, lifetime)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.MaxPathLenZero = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertAndKey(der, key)
+}
+
+// GenerateServerCert creates a new ECS Server certificate and private key in PEM
+// format for the given host names and IP addresses, signed by the CA
+func GenerateServerCert(caCert, caKey []byte, hosts []string, lifetime time.Duration) ([]byte, []byte, error) {
+	ca, err := ParseCertificate(caCert)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA certificate: %w", err)
+	}
+	signer, err := parsePrivateKey(caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA private key: %w", err)
+	}
+
+	if ca.NotAfter.Before(time.Now().Add(lifetime)) {
+		return nil, nil, fmt.Errorf("CA certificate expires before the server certificate: %s", ca.NotAfter.Format(time.RFC3339))
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template, err := newCertTemplate(SERVER_COMMON_NAME, lifetime)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.KeyUsage = x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, key.Public(), signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return encodeCertAndKey(der, key)
+}
+
+// ParseCertificate returns the first certificate in the PEM data
+func ParseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// CertificateExpiresWithin returns true if the first certificate in the PEM data
+// expires within the given duration
+func CertificateExpiresWithin(certPEM []byte, d time.Duration) (bool, error) {
+	cert, err := ParseCertificate(certPEM)
+	if err != nil {
+		return false, err
+	}
+	return cert.NotAfter.Before(time.Now().Add(d)), nil
+}
+
+// newCertTemplate returns the fields common to all of our certificates
+func newCertTemplate(commonName string, lifetime time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    now.Add(-5 * time.Minute), // allow for clock skew
+		NotAfter:     now.Add(lifetime),
+	}, nil
+}
+
+// encodeCertAndKey returns the PEM encoded certificate and PKCS#8 private key
+func encodeCertAndKey(der []byte, key *ecdsa.PrivateKey) ([]byte, []byte, error) {
+	keyDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM, nil
+}
+
+// parsePrivateKey parses a PKCS#8 PEM private key
+func parsePrivateKey(keyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type")
+	}
+	return signer, nil
+}
@@ -0,0 +1,80 @@
+package ecs
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateCerts(t *testing.T) {
+	caCert, caKey, err := GenerateCA(CA_LIFETIME)
+	require.NoError(t, err)
+
+	ca, err := ParseCertificate(caCert)
+	require.NoError(t, err)
+	assert.True(t, ca.IsCA)
+	assert.Equal(t, CA_COMMON_NAME, ca.Subject.CommonName)
+
+	hosts := append(append([]string{}, DefaultServerHosts...), "ecs.example.com")
+	cert, key, err := GenerateServerCert(caCert, caKey, hosts, 24*time.Hour)
+	require.NoError(t, err)
+	assert.Contains(t, string(key), "BEGIN PRIVATE KEY")
+
+	server, err := ParseCertificate(cert)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"localhost", DOCKER_HOST_NAME, "ecs.example.com"}, server.DNSNames)
+	assert.Len(t, server.IPAddresses, 2)
+	assert.True(t, server.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+
+	// server cert is signed by our CA for every host
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	for _, host := range hosts {
+		_, err = server.Verify(x509.VerifyOptions{DNSName: host, Roots: pool})
+		assert.NoError(t, err, host)
+	}
+
+	// but not some other CA
+	otherCert, _, err := GenerateCA(CA_LIFETIME)
+	require.NoError(t, err)
+	other, err := ParseCertificate(otherCert)
+	require.NoError(t, err)
+	pool = x509.NewCertPool()
+	pool.AddCert(other)
+	_, err = server.Verify(x509.VerifyOptions{DNSName: "localhost", Roots: pool})
+	assert.Error(t, err)
+}
+
+func TestGenerateServerCertErrors(t *testing.T) {
+	caCert, caKey, err := GenerateCA(time.Hour)
+	require.NoError(t, err)
+
+	_, _, err = GenerateServerCert(caCert, caKey, DefaultServerHosts, 24*time.Hour)
+	assert.ErrorContains(t, err, "CA certificate expires before")
+
+	_, _, err = GenerateServerCert(caKey, caKey, DefaultServerHosts, time.Minute)
+	assert.ErrorContains(t, err, "invalid CA certificate")
+
+	_, _, err = GenerateServerCert(caCert, caCert, DefaultServerHosts, time.Minute)
+	assert.ErrorContains(t, err, "invalid CA private key")
+}
+
+func TestCertificateExpiresWithin(t *testing.T) {
+	cert, _, err := GenerateCA(48 * time.Hour)
+	require.NoError(t, err)
+
+	expires, err := CertificateExpiresWithin(cert, 24*time.Hour)
+	assert.NoError(t, err)
+	assert.False(t, expires)
+
+	expires, err = CertificateExpiresWithin(cert, 72*time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, expires)
+
+	_, err = CertificateExpiresWithin([]byte("foobar"), time.Hour)
+	assert.Error(t, err)
+}
@@ -20,17 +20,18 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
@@ -50,50 +51,79 @@ type ECSClient struct {
 	loadSlotUrl string
 	profileUrl  string
 	listUrl     string
+	eventsUrl   string
 	client      *http.Client
 }
 
+// NewECSClient returns a client for the ECS Server at server which is either
+// a host:port or unix:///path/to/socket
 func NewECSClient(server, authToken, certChain string) *ECSClient {
 	var client = &http.Client{}
 	var proto = "http"
+	var host = server
 	var err error
 
-	if certChain != "" {
-		proto = "https"
-		client, err = NewHTTPClient(certChain)
-		if err != nil {
-			panic(fmt.Sprintf("unable to load SSL certificate: %s", err.Error()))
-		}
-	}
-
 	if authToken == "" {
 		log.Warn("no auth token provided, ECS server communication will be unauthenticated")
 	}
-	if certChain == "" {
-		log.Warn("no SSL cert provided, ECS server communication will be unencrypted")
-	}
 
-	hostPort := strings.Split(server, ":")
-	if len(hostPort) != 2 || hostPort[0] == "" || hostPort[1] == "" {
-		panic(fmt.Sprintf("invalid --server address: %s", server))
-	}
+	if path, ok := strings.CutPrefix(server, ecs.UNIX_SOCKET_PREFIX); ok {
+		if path == "" {
+			panic(fmt.Sprintf("invalid --server socket: %s", server))
+		}
+		// SSL/TLS is not used over the socket; the file permissions restrict access
+		client = NewUnixHTTPClient(path)
+		host = "localhost"
+	} else {
+		if certChain != "" {
+			proto = "https"
+			client, err = NewHTTPClient(certChain)
+			if err != nil {
+				panic(fmt.Sprintf("unable to load SSL certificate: %s", err.Error()))
+			}
+		} else {
+			log.Warn("no SSL cert provided, ECS server communication will be unencrypted")
+		}
 
-	port, err := strconv.Atoi(hostPort[1])
-	if err != nil || port < 1 || port > 65535 {
-		panic(fmt.Sprintf("invalid --server port: %s", hostPort[1]))
+		hostPort := strings.Split(server, ":")
+		if len(hostPort) != 2 || hostPort[0] == "" || hostPort[1] == "" {
+			panic(fmt.Sprintf("invalid --server address: %s", server))
+		}
+
+		port, err := strconv.Atoi(hostPort[1])
+		if err != nil || port < 1 || port > 65535 {
+			panic(fmt.Sprintf("invalid --server port: %s", hostPort[1]))
+		}
 	}
 
 	return &ECSClient{
 		server:      server,
 		client:      client,
 		authToken:   authToken,
-		loadUrl:     fmt.Sprintf("%s://%s/", proto, server),
-		loadSlotUrl: fmt.Sprintf("%s://%s%s", proto, server, ecs.SLOT_ROUTE),
-		profileUrl:  fmt.Sprintf("%s://%s%s", proto, server, ecs.PROFILE_ROUTE),
-		listUrl:     fmt.Sprintf("%s://%s%s", proto, server, ecs.SLOT_ROUTE),
+		loadUrl:     fmt.Sprintf("%s://%s/", proto, host),
+		loadSlotUrl: fmt.Sprintf("%s://%s%s", proto, host, ecs.SLOT_ROUTE),
+		profileUrl:  fmt.Sprintf("%s://%s%s", proto, host, ecs.PROFILE_ROUTE),
+		listUrl:     fmt.Sprintf("%s://%s%s", proto, host, ecs.SLOT_ROUTE),
+		eventsUrl:   fmt.Sprintf("%s://%s%s", proto, host, ecs.EVENTS_ROUTE),
 	}
 }
 
+// SetClientCert configures the client certificate presented to an ECS Server
+// which requires mutual TLS
+func (c *ECSClient) SetClientCert(certChain, privateKey string) error {
+	tr, ok := c.client.Transport.(*http.Transport)
+	if !ok || tr.TLSClientConfig == nil {
+		return fmt.Errorf("client certificates require SSL/TLS")
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certChain), []byte(privateKey))
+	if err != nil {
+		return fmt.Errorf("unable to load client certificate: %w", err)
+	}
+	tr.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	return nil
+}
+
 func (c *ECSClient) LoadUrl(profile string) string {
 	if profile == "" {
 		return c.loadUrl
@@ -109,6 +139,10 @@ func (c *ECSClient) ListUrl() string {
 	return c.listUrl
 }
 
+func (c *ECSClient) EventsUrl() string {
+	return c.eventsUrl
+}
+
 func (c *ECSClient) newRequest(method, url string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
@@ -122,11 +156,14 @@ func (c *ECSClient) newRequest(method, url string, body io.Reader) (*http.Reques
 	return req, nil
 }
 
-func (c *ECSClient) SubmitCreds(creds *storage.RoleCredentials, profile string, slotted bool) error {
-	log.Debug("loading in slot", "profile", profile, "slot", slotted)
+// SubmitCreds loads the credentials into the ECS Server.  ssoName identifies the
+// SSO instance the role belongs to so the server can re-fetch the credentials.
+func (c *ECSClient) SubmitCreds(creds *storage.RoleCredentials, profile, ssoName string, slotted bool) error {
+	log.Debug("loading in slot", "profile", profile, "sso", ssoName, "slot", slotted)
 	cr := ecs.ECSClientRequest{
 		Creds:       creds,
 		ProfileName: profile,
+		SSOName:     ssoName,
 	}
 	j, _ := json.Marshal(cr)
 
@@ -165,7 +202,7 @@ func (c *ECSClient) GetProfile() (ecs.ListProfilesResponse, error) {
 	if err = json.Unmarshal(body, &lpr); err != nil {
 		return lpr, err
 	}
-	log.Debug("response", "body", spew.Sdump(lpr))
+	log.Debug("response", "body", logger.Sdump(lpr))
 
 	return lpr, nil
 }
@@ -192,7 +229,7 @@ func (c *ECSClient) ListProfiles() ([]ecs.ListProfilesResponse, error) {
 	if err = json.Unmarshal(body, &lpr); err != nil {
 		return lpr, err
 	}
-	log.Debug("response", "body", spew.Sdump(lpr))
+	log.Debug("response", "body", logger.Sdump(lpr))
 
 	return lpr, nil
 }
@@ -207,6 +244,37 @@ func (c *ECSClient) Delete(profile string) error {
 	return checkDoResponse(resp)
 }
 
+// WatchEvents streams the slot events from the ECS Server and calls fn for each
+// one until ctx is cancelled, the server closes the stream or fn returns an error
+func (c *ECSClient) WatchEvents(ctx context.Context, fn func(ecs.SlotEvent) error) error {
+	req, _ := c.newRequest(http.MethodGet, c.EventsUrl(), nil)
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", ecs.EVENTS_CONTENT_TYPE)
+
+	resp, err := c.client.Do(req) // nolint:gosec
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if err := checkDoResponse(resp); err != nil {
+		return err
+	}
+
+	err = ecs.ReadSlotEvents(resp.Body, fn)
+	if ctx.Err() != nil {
+		// we were asked to stop
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("ECS Server closed the event stream")
+}
+
 func checkDoResponse(resp *http.Response) error {
 	if resp.StatusCode < 200 || resp.StatusCode > 200 {
 		return fmt.Errorf("ECS Server HTTP error: %s", resp.Status)
@@ -237,3 +305,15 @@ func NewHTTPClient(certChain string) (*http.Client, error) {
 	tr := &http.Transport{TLSClientConfig: config}
 	return &http.Client{Transport: tr}, nil
 }
+
+// NewUnixHTTPClient creates a new HTTP client which connects to the
+// Unix domain socket at path for every request
+func NewUnixHTTPClient(path string) *http.Client {
+	tr := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}
+	return &http.Client{Transport: tr}
+}
@@ -19,6 +19,7 @@ package client
  */
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -77,6 +78,41 @@ func TestNewEcsClientFail(t *testing.T) {
 	assert.Panics(t, func() { NewECSClient("localhost:0", "token", "") })
 
 	assert.Panics(t, func() { NewECSClient("localhost:65536", "token", "") })
+
+	assert.Panics(t, func() { NewECSClient("unix://", "token", "") })
+}
+
+func TestNewECSClientUnixSocket(t *testing.T) {
+	t.Parallel()
+
+	c := NewECSClient("unix:///tmp/aws-sso.sock", "token", "")
+	assert.Equal(t, "unix:///tmp/aws-sso.sock", c.server)
+	assert.Equal(t, "http://localhost/", c.LoadUrl(""))
+	assert.Equal(t, "http://localhost/slot", c.ListUrl())
+	assert.Equal(t, "http://localhost/profile", c.ProfileUrl())
+
+	// cert chain is ignored for sockets
+	c = NewECSClient("unix:///tmp/aws-sso.sock", "token", "foobar")
+	assert.Equal(t, "http://localhost/", c.LoadUrl(""))
+}
+
+func TestECSClientSetClientCert(t *testing.T) {
+	t.Parallel()
+
+	certChain, err := os.ReadFile("../server/testdata/localhost.crt")
+	assert.NoError(t, err)
+	clientCert, err := os.ReadFile("../server/testdata/client.crt")
+	assert.NoError(t, err)
+	clientKey, err := os.ReadFile("../server/testdata/client.key")
+	assert.NoError(t, err)
+
+	c := NewECSClient("localhost:4144", "token", "")
+	assert.ErrorContains(t, c.SetClientCert(string(clientCert), string(clientKey)), "require SSL/TLS")
+
+	c = NewECSClient("localhost:4144", "token", string(certChain))
+	assert.Error(t, c.SetClientCert(string(clientCert), string(clientCert)))
+	assert.NoError(t, c.SetClientCert(string(clientCert), string(clientKey)))
+	assert.Len(t, c.client.Transport.(*http.Transport).TLSClientConfig.Certificates, 1)
 }
 
 func TestECSClientLoadUrl(t *testing.T) {
@@ -106,6 +142,57 @@ func TestECSClientListUrl(t *testing.T) {
 	assert.Equal(t, "http://localhost:4144/slot", c.ListUrl())
 }
 
+func TestECSClientEventsUrl(t *testing.T) {
+	t.Parallel()
+
+	c := NewECSClient("localhost:4144", "token", "")
+	assert.NotNil(t, c)
+	assert.Equal(t, "http://localhost:4144/events", c.EventsUrl())
+}
+
+func TestECSClientWatchEvents(t *testing.T) {
+	t.Parallel()
+
+	// create mocked http server which sends two events and closes the stream
+	ts := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				w.Header().Set("Content-Type", ecs.EVENTS_CONTENT_TYPE)
+				fmt.Fprint(w, ": connected\n\n")
+				_ = ecs.WriteSlotEvent(w, ecs.SlotEvent{Type: ecs.EVENT_LOADED, Slot: "FooBar"})
+				_ = ecs.WriteSlotEvent(w, ecs.SlotEvent{Type: ecs.EVENT_UNLOADED, Slot: "FooBar"})
+			},
+		),
+	)
+	defer ts.Close()
+
+	c := NewECSClient("localhost:4144", "token", "")
+	c.eventsUrl = ts.URL
+
+	events := []ecs.SlotEventType{}
+	err := c.WatchEvents(context.Background(), func(e ecs.SlotEvent) error {
+		events = append(events, e.Type)
+		return nil
+	})
+	assert.ErrorContains(t, err, "closed the event stream")
+	assert.Equal(t, []ecs.SlotEventType{ecs.EVENT_LOADED, ecs.EVENT_UNLOADED}, events)
+
+	// auth error
+	ts2 := httptest.NewServer(
+		http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				ecs.WriteMessage(w, "Invalid authorization token", http.StatusForbidden)
+			},
+		),
+	)
+	defer ts2.Close()
+
+	c.eventsUrl = ts2.URL
+	err = c.WatchEvents(context.Background(), func(e ecs.SlotEvent) error { return nil })
+	assert.ErrorContains(t, err, "403")
+}
+
 func TestECSClientNewRequest(t *testing.T) {
 	t.Parallel()
 
@@ -159,19 +246,19 @@ func TestECSClientSubmitCredsPass(t *testing.T) {
 		Expiration:      1234567890,
 	}
 
-	err := c.SubmitCreds(&creds, "myprofile", false)
+	err := c.SubmitCreds(&creds, "myprofile", "Default", false)
 	assert.NoError(t, err)
 
 	creds.RoleName = "role2"
-	err = c.SubmitCreds(&creds, "myotherprofile", true)
+	err = c.SubmitCreds(&creds, "myotherprofile", "Default", true)
 	assert.NoError(t, err)
 
 	c.loadUrl = "http://localhost:4144"
-	err = c.SubmitCreds(&creds, "myprofile", false)
+	err = c.SubmitCreds(&creds, "myprofile", "Default", false)
 	assert.Error(t, err)
 
 	c.loadSlotUrl = "http://localhost:4144"
-	err = c.SubmitCreds(&creds, "myprofile", true)
+	err = c.SubmitCreds(&creds, "myprofile", "Default", true)
 	assert.Error(t, err)
 }
 
@@ -203,7 +290,7 @@ func TestECSClientSubmitCredsFail(t *testing.T) {
 		Expiration:      1234567890,
 	}
 
-	err := c.SubmitCreds(creds, "myprofile", false)
+	err := c.SubmitCreds(creds, "myprofile", "Default", false)
 	assert.Error(t, err)
 }
 
@@ -30,6 +30,7 @@ import (
 type ECSClientRequest struct {
 	Creds       *storage.RoleCredentials `json:"Creds"`
 	ProfileName string                   `json:"ProfileName"`
+	SSOName     string                   `json:"SSOName,omitempty"` // SSO instance the role belongs to
 }
 
 func (cr *ECSClientRequest) Validate() error {
@@ -19,9 +19,11 @@ package ecs
  */
 
 const (
-	SLOT_ROUTE        = "/slot"        // put/get/delete
-	PROFILE_ROUTE     = "/profile"     // get name of default creds
-	DEFAULT_ROUTE     = "/"            // put/get/delete: default credentials
-	HEALTHCHECK_ROUTE = "/healthcheck" // get: liveness/readiness probe (no auth required)
-	CHARSET_JSON      = "application/json; charset=utf-8"
+	SLOT_ROUTE         = "/slot"        // put/get/delete
+	PROFILE_ROUTE      = "/profile"     // get name of default creds
+	DEFAULT_ROUTE      = "/"            // put/get/delete: default credentials
+	HEALTHCHECK_ROUTE  = "/healthcheck" // get: liveness/readiness probe (no auth required)
+	EVENTS_ROUTE       = "/events"      // get: server-sent event stream of slot changes
+	CHARSET_JSON       = "application/json; charset=utf-8"
+	UNIX_SOCKET_PREFIX = "unix://" // ECS Server address prefix for a Unix domain socket
 )
@@ -0,0 +1,113 @@
+package ecs
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2022 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// EVENTS_CONTENT_TYPE is the Content-Type of the server-sent event stream
+const EVENTS_CONTENT_TYPE = "text/event-stream"
+
+// SlotEventType is the type of change to a slot in the ECS Server
+type SlotEventType string
+
+const (
+	EVENT_LOADED    SlotEventType = "loaded"    // credentials were loaded into the slot
+	EVENT_UNLOADED  SlotEventType = "unloaded"  // credentials were removed from the slot
+	EVENT_REFRESHED SlotEventType = "refreshed" // credentials were re-fetched before they expired
+	EVENT_EXPIRING  SlotEventType = "expiring"  // credentials are about to expire
+	EVENT_EXPIRED   SlotEventType = "expired"   // credentials have expired
+)
+
+// SlotEvent is sent via the ECS Server event stream when a slot changes
+type SlotEvent struct {
+	Type        SlotEventType `json:"Type"`
+	Time        int64         `json:"Time"`
+	Slot        string        `json:"Slot"` // empty for the default credentials
+	ProfileName string        `json:"ProfileName,omitempty"`
+	AccountId   string        `json:"AccountId,omitempty"`
+	RoleName    string        `json:"RoleName,omitempty"`
+	Expiration  int64         `json:"Expiration,omitempty"`
+}
+
+// NewSlotEvent returns the event for the credentials in the slot.  cr may be nil.
+func NewSlotEvent(eventType SlotEventType, slot string, cr *ECSClientRequest, now time.Time) SlotEvent {
+	e := SlotEvent{
+		Type: eventType,
+		Time: now.Unix(),
+		Slot: slot,
+	}
+	if cr != nil {
+		e.ProfileName = cr.ProfileName
+		if cr.Creds != nil {
+			e.AccountId = cr.Creds.AccountIdStr()
+			e.RoleName = cr.Creds.RoleName
+			e.Expiration = cr.Creds.Expiration / 1000
+		}
+	}
+	return e
+}
+
+// WriteSlotEvent writes the event in the server-sent event format
+func WriteSlotEvent(w io.Writer, e SlotEvent) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return err
+}
+
+// ReadSlotEvents parses the server-sent event stream and calls fn for every
+// event until the stream ends or fn returns an error
+func ReadSlotEvents(r io.Reader, fn func(SlotEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	data := []string{}
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// a blank line ends the event
+			if len(data) == 0 {
+				continue
+			}
+			e := SlotEvent{}
+			if err := json.Unmarshal([]byte(strings.Join(data, "\n")), &e); err != nil {
+				return fmt.Errorf("unable to parse event: %w", err)
+			}
+			data = []string{}
+			if err := fn(e); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+
+		default:
+			// comments and the event field are ignored; the type is in the data
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,96 @@
+package ecs
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+func TestNewSlotEvent(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cr := &ECSClientRequest{
+		ProfileName: "000001234567:FooBar",
+		Creds: &storage.RoleCredentials{
+			AccountId:  1234567,
+			RoleName:   "FooBar",
+			Expiration: 1700003600000,
+		},
+	}
+
+	assert.Equal(t, SlotEvent{
+		Type:        EVENT_LOADED,
+		Time:        1700000000,
+		Slot:        "000001234567:FooBar",
+		ProfileName: "000001234567:FooBar",
+		AccountId:   "000001234567",
+		RoleName:    "FooBar",
+		Expiration:  1700003600,
+	}, NewSlotEvent(EVENT_LOADED, "000001234567:FooBar", cr, now))
+
+	assert.Equal(t, SlotEvent{
+		Type: EVENT_UNLOADED,
+		Time: 1700000000,
+	}, NewSlotEvent(EVENT_UNLOADED, "", nil, now))
+}
+
+func TestReadWriteSlotEvents(t *testing.T) {
+	events := []SlotEvent{
+		{Type: EVENT_LOADED, Time: 1700000000, Slot: "FooBar", ProfileName: "FooBar"},
+		{Type: EVENT_EXPIRED, Time: 1700000001},
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteString(": connected\n\n")
+	for _, e := range events {
+		assert.NoError(t, WriteSlotEvent(buf, e))
+	}
+	assert.Contains(t, buf.String(), "event: loaded\ndata: {")
+	buf.WriteString(": keepalive\n\n")
+
+	read := []SlotEvent{}
+	err := ReadSlotEvents(buf, func(e SlotEvent) error {
+		read = append(read, e)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, events, read)
+
+	// fn can stop reading
+	buf.Reset()
+	for _, e := range events {
+		assert.NoError(t, WriteSlotEvent(buf, e))
+	}
+	cnt := 0
+	err = ReadSlotEvents(buf, func(e SlotEvent) error {
+		cnt++
+		return fmt.Errorf("stop")
+	})
+	assert.ErrorContains(t, err, "stop")
+	assert.Equal(t, 1, cnt)
+
+	err = ReadSlotEvents(strings.NewReader("data: not json\n\n"), func(e SlotEvent) error { return nil })
+	assert.ErrorContains(t, err, "unable to parse event")
+}
@@ -19,6 +19,8 @@ package ecs
  */
 
 import (
+	"crypto/rand"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -31,6 +33,13 @@ const (
 	CONTAINER_NAMED_FILE  = "/app/.aws-sso/mnt/docker-ecs"
 	HOST_MOUNT_POINT_FMT  = "%s/.aws-sso/mnt"
 	HOST_NAMED_FILE_FMT   = "%s/.aws-sso/mnt/docker-ecs"
+
+	// encrypted file SecureStore used by `ecs server --docker --persist` so slots
+	// survive a container restart
+	CONTAINER_STORE_DIR = "/app/.aws-sso/mnt/ecs-server"
+
+	// number of random bytes in a generated bearer token
+	BEARER_TOKEN_BYTES = 32
 )
 
 type ECSFileMode int
@@ -46,6 +55,15 @@ type ECSSecurity struct {
 	BearerToken string `json:"bearerToken"` // nolint:gosec
 }
 
+// NewBearerToken returns a random bearer token for the ECS Server
+func NewBearerToken() (string, error) {
+	b := make([]byte, BEARER_TOKEN_BYTES)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("unable to generate bearer token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
 func SecurityFilePath(mode ECSFileMode) string {
 	switch mode {
 	case READ_ONLY:
@@ -18,6 +18,15 @@ func TestSecurityFilePath(t *testing.T) {
 	assert.Panics(t, func() { SecurityFilePath(WRITE_ONLY) })
 }
 
+func TestNewBearerToken(t *testing.T) {
+	a, err := NewBearerToken()
+	assert.NoError(t, err)
+	b, err := NewBearerToken()
+	assert.NoError(t, err)
+	assert.Len(t, a, 43) // 32 bytes, base64 encoded without padding
+	assert.NotEqual(t, a, b)
+}
+
 func TestOpenSecurityFile(t *testing.T) {
 	tempFile, err := os.CreateTemp("", "security_test")
 	assert.NoError(t, err)
@@ -0,0 +1,53 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"net/http"
+
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+// EnableAudit records every set of credentials returned to a client in the
+// given audit log.  Must be called before Serve().
+func (e *EcsServer) EnableAudit(a *audit.Logger) {
+	e.audit = a
+}
+
+// credentialsVended records that the credentials in the named slot were
+// returned to the client making request r
+func (e *EcsServer) credentialsVended(r *http.Request, slot string, cr *ecs.ECSClientRequest) {
+	e.metrics.vend(slot)
+
+	err := e.audit.Log(audit.Event{
+		Action:    audit.EcsCredentials,
+		SSO:       cr.SSOName,
+		Arn:       cr.Creds.RoleArn(),
+		AccountId: cr.Creds.AccountId,
+		RoleName:  cr.Creds.RoleName,
+		Profile:   cr.ProfileName,
+		Expires:   cr.Creds.ExpireString(),
+		Remote:    r.RemoteAddr,
+		Slot:      slotLabel(slot),
+	})
+	if err != nil {
+		log.Warn("Unable to write audit log", "error", err.Error())
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"golang.org/x/net/nettest"
+)
+
+func TestServerAudit(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	a := audit.NewLogger(filepath.Join(t.TempDir(), "audit.jsonl"), 0, 0)
+	s.EnableAudit(a)
+	require.NoError(t, s.EnableIMDS([]string{"SlotProfile"}))
+	t.Cleanup(s.Close)
+
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+	slot := newRequest(time.Now().Add(1 * time.Hour))
+	slot.ProfileName = "SlotProfile"
+	slot.SSOName = "Default"
+	require.NoError(t, s.PutSlottedCreds(slot))
+	expired := newRequest(time.Now().Add(-1 * time.Hour))
+	expired.ProfileName = "Expired"
+	s.slots.Put(expired.ProfileName, expired)
+
+	go func() {
+		_ = s.Serve()
+	}()
+
+	auth := map[string]string{"Authorization": "Bearer AuthToken"}
+	code, _ := imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.DEFAULT_ROUTE, auth)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.SLOT_ROUTE+"/SlotProfile", auth)
+	assert.Equal(t, http.StatusOK, code)
+
+	// failures are not recorded
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.SLOT_ROUTE+"/Expired", auth)
+	assert.NotEqual(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.SLOT_ROUTE+"/SlotProfile", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// IMDS
+	code, token := imdsRequest(t, http.MethodPut, s.BaseURL()+IMDS_TOKEN_ROUTE,
+		map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	require.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+IMDS_CREDENTIALS_ROUTE+"SlotProfile",
+		map[string]string{IMDS_TOKEN_HEADER: token})
+	assert.Equal(t, http.StatusOK, code)
+
+	events, err := a.Query(audit.Filter{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	assert.Equal(t, audit.EcsCredentials, events[0].Action)
+	assert.Equal(t, "default", events[0].Slot)
+	assert.Equal(t, "1234:FooBar", events[0].Profile)
+	assert.Equal(t, "FooBar", events[0].RoleName)
+	assert.Equal(t, int64(1234), events[0].AccountId)
+	assert.NotEmpty(t, events[0].Remote)
+
+	assert.Equal(t, "SlotProfile", events[1].Slot)
+	assert.Equal(t, "Default", events[1].SSO)
+	assert.Equal(t, "SlotProfile", events[2].Slot)
+
+	for _, e := range events {
+		assert.NotEmpty(t, e.Arn)
+		assert.NotEmpty(t, e.Expires)
+	}
+}
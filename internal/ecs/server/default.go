@@ -50,7 +50,11 @@ func (p DefaultHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (p DefaultHandler) Get(w http.ResponseWriter, r *http.Request) {
 	log.Debug("fetching default creds")
-	ecs.WriteCreds(w, p.ecs.DefaultCreds.Creds)
+	cr := p.ecs.GetDefaultCreds()
+	if !cr.Creds.Expired() {
+		p.ecs.credentialsVended(r, DEFAULT_SLOT, cr)
+	}
+	ecs.WriteCreds(w, cr.Creds)
 }
 
 func (p DefaultHandler) Put(w http.ResponseWriter, r *http.Request) {
@@ -65,18 +69,14 @@ func (p DefaultHandler) Put(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p.ecs.DefaultCreds = creds
+	p.ecs.SetDefaultCreds(creds)
 	ecs.OK(w)
 }
 
 func (p DefaultHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	if p.ecs.DefaultCreds.ProfileName == "" {
+	if err := p.ecs.DeleteDefaultCreds(); err != nil {
 		ecs.Expired(w)
 		return
 	}
-
-	p.ecs.DefaultCreds = &ecs.ECSClientRequest{
-		ProfileName: "",
-	}
 	ecs.OK(w)
 }
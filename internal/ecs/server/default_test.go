@@ -34,12 +34,7 @@ import (
 
 func TestDefaultGet(t *testing.T) {
 	dh := DefaultHandler{
-		ecs: &EcsServer{
-			DefaultCreds: &ecs.ECSClientRequest{
-				ProfileName: "",
-				Creds:       &storage.RoleCredentials{},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
 	ts := httptest.NewServer(&dh)
 	defer ts.Close()
@@ -50,15 +45,17 @@ func TestDefaultGet(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, res.StatusCode)
 
 	soon := time.Now().Add(90 * time.Second)
-	dh.ecs.DefaultCreds.ProfileName = "MyProfile"
-	dh.ecs.DefaultCreds.Creds = &storage.RoleCredentials{
-		RoleName:        "ProfileName",
-		AccountId:       1111111,
-		AccessKeyId:     "AccessKeyId",
-		SecretAccessKey: "SecretAccessKey",
-		SessionToken:    "SessionToken",
-		Expiration:      soon.UnixMilli(),
-	}
+	dh.ecs.SetDefaultCreds(&ecs.ECSClientRequest{
+		ProfileName: "MyProfile",
+		Creds: &storage.RoleCredentials{
+			RoleName:        "ProfileName",
+			AccountId:       1111111,
+			AccessKeyId:     "AccessKeyId",
+			SecretAccessKey: "SecretAccessKey",
+			SessionToken:    "SessionToken",
+			Expiration:      soon.UnixMilli(),
+		},
+	})
 
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
@@ -70,7 +67,7 @@ func TestDefaultGet(t *testing.T) {
 	assert.Equal(t, "AccessKeyId", creds["AccessKeyId"])
 
 	// check expired
-	dh.ecs.DefaultCreds.Creds.Expiration = time.Now().UnixMilli()
+	dh.ecs.GetDefaultCreds().Creds.Expiration = time.Now().UnixMilli()
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusNotFound, res.StatusCode)
@@ -96,12 +93,7 @@ func submitRequest(t *testing.T, url string, cr ecs.ECSClientRequest) (*http.Res
 
 func TestDefaultPut(t *testing.T) {
 	dh := DefaultHandler{
-		ecs: &EcsServer{
-			DefaultCreds: &ecs.ECSClientRequest{
-				ProfileName: "",
-				Creds:       &storage.RoleCredentials{},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
 	ts := httptest.NewServer(&dh)
 	defer ts.Close()
@@ -142,13 +134,12 @@ func TestDefaultPut(t *testing.T) {
 
 func TestDefaultDelete(t *testing.T) {
 	dh := DefaultHandler{
-		ecs: &EcsServer{
-			DefaultCreds: &ecs.ECSClientRequest{
-				ProfileName: "Foo",
-				Creds:       &storage.RoleCredentials{},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
+	dh.ecs.SetDefaultCreds(&ecs.ECSClientRequest{
+		ProfileName: "Foo",
+		Creds:       &storage.RoleCredentials{},
+	})
 	ts := httptest.NewServer(&dh)
 	defer ts.Close()
 
@@ -162,7 +153,7 @@ func TestDefaultDelete(t *testing.T) {
 	resp, err := client.Do(req) // nolint:gosec
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "", dh.ecs.DefaultCreds.ProfileName)
+	assert.Equal(t, "", dh.ecs.GetDefaultCreds().ProfileName)
 
 	// can't delete again
 	resp, err = client.Do(req) // nolint:gosec
@@ -0,0 +1,72 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+const (
+	ENV_CONTAINER_CREDENTIALS_FULL_URI = "AWS_CONTAINER_CREDENTIALS_FULL_URI"
+	ENV_CONTAINER_AUTHORIZATION_TOKEN  = "AWS_CONTAINER_AUTHORIZATION_TOKEN" // nolint:gosec
+)
+
+// NewEphemeralServer returns an EcsServer for a single process which listens on a
+// random localhost port, is protected by a random bearer token and serves creds as
+// the default credentials, re-fetching them via fetcher before they expire.
+func NewEphemeralServer(ctx context.Context, creds *ecs.ECSClientRequest, fetcher CredentialsFetcher) (*EcsServer, error) {
+	if creds.ProfileName == "" {
+		return nil, fmt.Errorf("missing ProfileName")
+	}
+
+	token, err := ecs.NewBearerToken()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := NewEcsServer(ctx, token, l, "", "")
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	e.EnableRefresh(fetcher, 0)
+	e.SetDefaultCreds(creds)
+	return e, nil
+}
+
+// ContainerEnv returns the environment variables which the AWS SDKs use to fetch
+// the default credentials from our server
+func (e *EcsServer) ContainerEnv() map[string]string {
+	env := map[string]string{
+		ENV_CONTAINER_CREDENTIALS_FULL_URI: e.BaseURL() + ecs.DEFAULT_ROUTE,
+	}
+	if e.authToken != "" {
+		env[ENV_CONTAINER_AUTHORIZATION_TOKEN] = "Bearer " + e.authToken
+	}
+	return env
+}
@@ -0,0 +1,93 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEphemeralServer(t *testing.T) {
+	m := &mockFetcher{}
+	creds := newRequest(time.Now().Add(1 * time.Hour))
+	creds.SSOName = "Default"
+
+	s, err := NewEphemeralServer(context.TODO(), creds, m)
+	require.NoError(t, err)
+	defer s.Close()
+
+	go func() {
+		_ = s.Serve()
+	}()
+
+	env := s.ContainerEnv()
+	assert.Equal(t, s.BaseURL()+"/", env[ENV_CONTAINER_CREDENTIALS_FULL_URI])
+	assert.Regexp(t, `^http://127\.0\.0\.1:\d+/$`, env[ENV_CONTAINER_CREDENTIALS_FULL_URI])
+	assert.Regexp(t, `^Bearer [\w-]{43}$`, env[ENV_CONTAINER_AUTHORIZATION_TOKEN])
+
+	get := func(token string) (*http.Response, map[string]string) {
+		req, err := http.NewRequest(http.MethodGet, env[ENV_CONTAINER_CREDENTIALS_FULL_URI], nil)
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		cr := map[string]string{}
+		if res.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&cr))
+		}
+		return res, cr
+	}
+
+	res, _ := get("")
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	res, cr := get(env[ENV_CONTAINER_AUTHORIZATION_TOKEN])
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "AccessKeyId", cr["AccessKeyId"])
+	assert.Equal(t, 0, m.calls)
+
+	// credentials are refreshed before they expire
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Minute)))
+	res, cr = get(env[ENV_CONTAINER_AUTHORIZATION_TOKEN])
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "-FooBar", cr["AccessKeyId"])
+	assert.Equal(t, 1, m.calls)
+
+	// every server has its own token
+	s2, err := NewEphemeralServer(context.TODO(), creds, m)
+	require.NoError(t, err)
+	defer s2.Close()
+	assert.NotEqual(t, env[ENV_CONTAINER_AUTHORIZATION_TOKEN], s2.ContainerEnv()[ENV_CONTAINER_AUTHORIZATION_TOKEN])
+	assert.NotEqual(t, env[ENV_CONTAINER_CREDENTIALS_FULL_URI], s2.ContainerEnv()[ENV_CONTAINER_CREDENTIALS_FULL_URI])
+
+	// requires a profile name to refresh the creds
+	creds.ProfileName = ""
+	_, err = NewEphemeralServer(context.TODO(), creds, m)
+	assert.ErrorContains(t, err, "missing ProfileName")
+}
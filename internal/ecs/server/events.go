@@ -0,0 +1,181 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+const (
+	EVENTS_HEARTBEAT    = 30 * time.Second // keeps idle event streams open through proxies
+	EVENTS_EXPIRY_CHECK = 30 * time.Second // how often we look for expiring credentials
+
+	// events queued per subscriber before we start dropping them
+	eventsBufferSize = 32
+)
+
+// eventBroker fans out slot events to every subscriber of the event stream
+type eventBroker struct {
+	lock        sync.Mutex
+	subscribers map[chan ecs.SlotEvent]bool
+}
+
+func newEventBroker() *eventBroker {
+	return &eventBroker{
+		subscribers: map[chan ecs.SlotEvent]bool{},
+	}
+}
+
+// subscribe returns a channel which receives every published event
+func (b *eventBroker) subscribe() chan ecs.SlotEvent {
+	ch := make(chan ecs.SlotEvent, eventsBufferSize)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.subscribers[ch] = true
+	return ch
+}
+
+// unsubscribe stops sending events to the channel
+func (b *eventBroker) unsubscribe(ch chan ecs.SlotEvent) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subscribers, ch)
+}
+
+// publish sends the event to every subscriber without blocking.  Subscribers
+// which are not keeping up miss the event.
+func (b *eventBroker) publish(e ecs.SlotEvent) {
+	if b == nil {
+		return
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			log.Warn("dropping event for slow subscriber", "type", e.Type, "slot", e.Slot)
+		}
+	}
+}
+
+// publish sends an event for the credentials in the slot to our event stream
+func (e *EcsServer) publish(eventType ecs.SlotEventType, slot string, cr *ecs.ECSClientRequest) {
+	e.events.publish(ecs.NewSlotEvent(eventType, slot, cr, time.Now()))
+}
+
+// watchExpiration checks for expiring credentials every interval until ctx is cancelled
+func (e *EcsServer) watchExpiration(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	notified := map[*ecs.ECSClientRequest]ecs.SlotEventType{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			notified = e.checkExpiration(now, notified)
+		}
+	}
+}
+
+// checkExpiration publishes an event once when the credentials in a slot are
+// about to expire and once more when they have expired.  notified tracks the
+// events already sent; since slots are replaced rather than modified, it is
+// keyed by the request.  Returns the updated notified map.
+func (e *EcsServer) checkExpiration(now time.Time, notified map[*ecs.ECSClientRequest]ecs.SlotEventType) map[*ecs.ECSClientRequest]ecs.SlotEventType {
+	current := map[*ecs.ECSClientRequest]ecs.SlotEventType{}
+	for _, name := range append(e.slots.Names(), DEFAULT_SLOT) {
+		cr, ok := e.slots.Get(name)
+		if !ok || cr.Creds == nil {
+			continue
+		}
+
+		expires := time.UnixMilli(cr.Creds.Expiration)
+		switch {
+		case !now.Before(expires):
+			if notified[cr] != ecs.EVENT_EXPIRED {
+				e.publish(ecs.EVENT_EXPIRED, name, cr)
+			}
+			current[cr] = ecs.EVENT_EXPIRED
+
+		case expires.Sub(now) <= e.expiringWindow():
+			if notified[cr] == "" {
+				e.publish(ecs.EVENT_EXPIRING, name, cr)
+			}
+			current[cr] = ecs.EVENT_EXPIRING
+		}
+	}
+	return current
+}
+
+// EventsHandler streams slot events to the client as server-sent events
+type EventsHandler struct {
+	ecs *EcsServer
+}
+
+func (p EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		log.Error("Invalid request", "url", r.URL.String())
+		ecs.Invalid(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok || p.ecs.events == nil {
+		ecs.InternalServerErrror(w, fmt.Errorf("event stream is not supported"))
+		return
+	}
+
+	ch := p.ecs.events.subscribe()
+	defer p.ecs.events.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", ecs.EVENTS_CONTENT_TYPE)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(EVENTS_HEARTBEAT)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+
+		case e := <-ch:
+			if err := ecs.WriteSlotEvent(w, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
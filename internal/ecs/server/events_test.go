@@ -0,0 +1,208 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs/client"
+	"golang.org/x/net/nettest"
+)
+
+// subscriberCount returns the number of clients watching the event stream
+func subscriberCount(es *EcsServer) int {
+	es.events.lock.Lock()
+	defer es.events.lock.Unlock()
+	return len(es.events.subscribers)
+}
+
+func TestEventBroker(t *testing.T) {
+	var nilBroker *eventBroker
+	assert.NotPanics(t, func() { nilBroker.publish(ecs.SlotEvent{}) })
+
+	b := newEventBroker()
+	a := b.subscribe()
+	c := b.subscribe()
+
+	b.publish(ecs.SlotEvent{Type: ecs.EVENT_LOADED, Slot: "FooBar"})
+	assert.Equal(t, ecs.EVENT_LOADED, (<-a).Type)
+	assert.Equal(t, ecs.EVENT_LOADED, (<-c).Type)
+
+	// slow subscribers miss events rather than blocking
+	for i := 0; i < eventsBufferSize+5; i++ {
+		b.publish(ecs.SlotEvent{Type: ecs.EVENT_REFRESHED})
+	}
+	assert.Len(t, a, eventsBufferSize)
+
+	b.unsubscribe(c)
+	assert.Len(t, b.subscribers, 1)
+}
+
+func TestServerPublishesEvents(t *testing.T) {
+	es := newTestEcsServer()
+	es.events = newEventBroker()
+	ch := es.events.subscribe()
+
+	es.SetDefaultCreds(newRequest(time.Now().Add(time.Hour)))
+	e := <-ch
+	assert.Equal(t, ecs.EVENT_LOADED, e.Type)
+	assert.Equal(t, DEFAULT_SLOT, e.Slot)
+	assert.Equal(t, "1234:FooBar", e.ProfileName)
+
+	require.NoError(t, es.PutSlottedCreds(newRequest(time.Now().Add(time.Hour))))
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_LOADED, e.Type)
+	assert.Equal(t, "1234:FooBar", e.Slot)
+
+	require.NoError(t, es.DeleteSlottedCreds("1234:FooBar"))
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_UNLOADED, e.Type)
+	assert.Equal(t, "1234:FooBar", e.Slot)
+	assert.Equal(t, "FooBar", e.RoleName)
+
+	require.NoError(t, es.DeleteDefaultCreds())
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_UNLOADED, e.Type)
+	assert.Equal(t, DEFAULT_SLOT, e.Slot)
+
+	// failed deletes don't publish
+	assert.Error(t, es.DeleteDefaultCreds())
+	assert.Error(t, es.DeleteSlottedCreds("1234:FooBar"))
+	assert.Empty(t, ch)
+
+	// refresh
+	es.EnableRefresh(&mockFetcher{}, time.Hour)
+	r := newRequest(time.Now().Add(5 * time.Minute))
+	r.SSOName = "Default"
+	es.slots.Put(r.ProfileName, r)
+	_, ok := es.getCreds(r.ProfileName)
+	assert.True(t, ok)
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_REFRESHED, e.Type)
+	assert.Equal(t, "1234:FooBar", e.Slot)
+}
+
+func TestCheckExpiration(t *testing.T) {
+	es := newTestEcsServer()
+	es.events = newEventBroker()
+	ch := es.events.subscribe()
+	now := time.Now()
+
+	es.slots.Put(DEFAULT_SLOT, newRequest(now.Add(time.Hour)))
+	expiring := newRequest(now.Add(5 * time.Minute))
+	expiring.ProfileName = "Expiring"
+	es.slots.Put("Expiring", expiring)
+
+	notified := es.checkExpiration(now, map[*ecs.ECSClientRequest]ecs.SlotEventType{})
+	e := <-ch
+	assert.Equal(t, ecs.EVENT_EXPIRING, e.Type)
+	assert.Equal(t, "Expiring", e.Slot)
+	assert.Empty(t, ch)
+
+	// only once
+	notified = es.checkExpiration(now.Add(time.Minute), notified)
+	assert.Empty(t, ch)
+
+	// and then once when they expire
+	notified = es.checkExpiration(now.Add(6*time.Minute), notified)
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_EXPIRED, e.Type)
+	assert.Equal(t, "Expiring", e.Slot)
+	notified = es.checkExpiration(now.Add(7*time.Minute), notified)
+	assert.Empty(t, ch)
+
+	// new credentials in the slot are tracked separately
+	es.slots.Put("Expiring", newRequest(now.Add(10*time.Minute)))
+	_ = es.checkExpiration(now.Add(7*time.Minute), notified)
+	e = <-ch
+	assert.Equal(t, ecs.EVENT_EXPIRING, e.Type)
+}
+
+func TestEventsHandler(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.AddToken("SlotToken", TokenScope{Slot: "1234:FooBar"}))
+	t.Cleanup(s.Close)
+	go func() {
+		_ = s.Serve()
+	}()
+
+	// needs auth and slot tokens may not watch every slot
+	code, _ := imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.EVENTS_ROUTE, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.EVENTS_ROUTE, map[string]string{"Authorization": "Bearer SlotToken"})
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = imdsRequest(t, http.MethodPut, s.BaseURL()+ecs.EVENTS_ROUTE, map[string]string{"Authorization": "Bearer AuthToken"})
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan ecs.SlotEvent, 10)
+	done := make(chan error)
+	c := client.NewECSClient(l.Addr().String(), "AuthToken", "")
+	go func() {
+		done <- c.WatchEvents(ctx, func(e ecs.SlotEvent) error {
+			events <- e
+			return nil
+		})
+	}()
+	assert.Eventually(t, func() bool { return subscriberCount(s) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	creds := newRequest(time.Now().Add(time.Hour))
+	require.NoError(t, c.SubmitCreds(creds.Creds, creds.ProfileName, "Default", true))
+	require.NoError(t, c.Delete(creds.ProfileName))
+
+	e := <-events
+	assert.Equal(t, ecs.EVENT_LOADED, e.Type)
+	assert.Equal(t, "1234:FooBar", e.Slot)
+	e = <-events
+	assert.Equal(t, ecs.EVENT_UNLOADED, e.Type)
+
+	// stopping the client unsubscribes it
+	cancel()
+	assert.NoError(t, <-done)
+	assert.Eventually(t, func() bool { return subscriberCount(s) == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestWatchEventsServerClosed(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	s, err := NewEcsServer(context.TODO(), "", l, "", "")
+	require.NoError(t, err)
+	go func() {
+		_ = s.Serve()
+	}()
+
+	done := make(chan error)
+	c := client.NewECSClient(l.Addr().String(), "", "")
+	go func() {
+		done <- c.WatchEvents(context.Background(), func(e ecs.SlotEvent) error { return nil })
+	}()
+	assert.Eventually(t, func() bool { return subscriberCount(s) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	s.Close()
+	assert.Error(t, <-done)
+}
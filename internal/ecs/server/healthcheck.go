@@ -63,7 +63,7 @@ func (h HealthCheckHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h HealthCheckHandler) getDefault(w http.ResponseWriter) {
-	creds := h.ecs.DefaultCreds
+	creds := h.ecs.GetDefaultCreds()
 	if creds.ProfileName == "" {
 		writeHealthCheck(w, healthCheckResponse{Status: "no credentials loaded"}, http.StatusServiceUnavailable)
 		return
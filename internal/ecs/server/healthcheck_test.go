@@ -34,12 +34,7 @@ import (
 )
 
 func newHealthCheckServer() *EcsServer {
-	return &EcsServer{
-		DefaultCreds: &ecs.ECSClientRequest{
-			Creds: &storage.RoleCredentials{},
-		},
-		slottedCreds: map[string]*ecs.ECSClientRequest{},
-	}
+	return newTestEcsServer()
 }
 
 func TestHealthCheckDefault(t *testing.T) {
@@ -59,7 +54,7 @@ func TestHealthCheckDefault(t *testing.T) {
 
 	// valid credentials loaded
 	soon := time.Now().Add(90 * time.Second)
-	h.ecs.DefaultCreds = &ecs.ECSClientRequest{
+	h.ecs.SetDefaultCreds(&ecs.ECSClientRequest{
 		ProfileName: "123456789012:MyRole",
 		Creds: &storage.RoleCredentials{
 			RoleName:        "MyRole",
@@ -69,7 +64,7 @@ func TestHealthCheckDefault(t *testing.T) {
 			SessionToken:    "ST",
 			Expiration:      soon.UnixMilli(),
 		},
-	}
+	})
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, res.StatusCode)
@@ -80,7 +75,7 @@ func TestHealthCheckDefault(t *testing.T) {
 	assert.NotEmpty(t, resp.Expires)
 
 	// expired credentials
-	h.ecs.DefaultCreds.Creds.Expiration = time.Now().Add(-5 * time.Second).UnixMilli()
+	h.ecs.GetDefaultCreds().Creds.Expiration = time.Now().Add(-5 * time.Second).UnixMilli()
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
@@ -133,7 +128,7 @@ func TestHealthCheckSlot(t *testing.T) {
 	assert.NotEmpty(t, resp.Expires)
 
 	// expired creds in slot
-	h.ecs.slottedCreds[profileName].Creds.Expiration = time.Now().Add(-5 * time.Second).UnixMilli()
+	getSlot(t, h.ecs, profileName).Creds.Expiration = time.Now().Add(-5 * time.Second).UnixMilli()
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
@@ -0,0 +1,254 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+const (
+	IMDS_ROUTE             = "/latest/"
+	IMDS_TOKEN_ROUTE       = "/latest/api/token"                           // put: IMDSv2 session token
+	IMDS_CREDENTIALS_ROUTE = "/latest/meta-data/iam/security-credentials/" // get: list roles & role creds
+
+	IMDS_TOKEN_HEADER     = "X-aws-ec2-metadata-token"             // nolint:gosec
+	IMDS_TOKEN_TTL_HEADER = "X-aws-ec2-metadata-token-ttl-seconds" // nolint:gosec
+
+	IMDS_MIN_TOKEN_TTL = 1
+	IMDS_MAX_TOKEN_TTL = 21600 // 6 hours, same as EC2
+
+	imdsTokenBytes = 32
+)
+
+// imdsTokens tracks the IMDSv2 session tokens we have handed out
+type imdsTokens struct {
+	lock   sync.Mutex
+	tokens map[string]time.Time // token => expiration
+}
+
+// issue returns a new token which is valid for ttl
+func (t *imdsTokens) issue(ttl time.Duration) (string, error) {
+	b := make([]byte, imdsTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	if t.tokens == nil {
+		t.tokens = map[string]time.Time{}
+	}
+	for k, expires := range t.tokens {
+		if now.After(expires) {
+			delete(t.tokens, k)
+		}
+	}
+	t.tokens[token] = now.Add(ttl)
+	return token, nil
+}
+
+// valid returns true if the token was issued by us and has not expired
+func (t *imdsTokens) valid(token string) bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	expires, ok := t.tokens[token]
+	return ok && time.Now().Before(expires)
+}
+
+// imdsCredentialsResponse is the EC2 IMDS format for role credentials
+type imdsCredentialsResponse struct {
+	Code            string
+	LastUpdated     string
+	Type            string
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+	Expiration      string
+}
+
+// EnableIMDS configures the server to also emulate the IMDSv2 credential endpoints
+// of the EC2 Instance Metadata Service.  These do not use our HTTP Auth bearer token
+// since IMDS clients can't send it, so are only allowed on a loopback or Unix socket
+// listener and only serve the default credentials plus the named slots.
+// Must be called before Serve().
+func (e *EcsServer) EnableIMDS(slots []string) error {
+	if !isLocalListener(e.listener) {
+		return fmt.Errorf("IMDS requires a loopback or Unix socket listener, not %s", e.listener.Addr().String())
+	}
+	e.imds = true
+	e.imdsSlots = map[string]bool{}
+	for _, slot := range slots {
+		e.imdsSlots[slot] = true
+	}
+	return nil
+}
+
+// isLocalListener returns true if only processes on this host can connect to l
+func isLocalListener(l net.Listener) bool {
+	switch addr := l.Addr().(type) {
+	case *net.UnixAddr:
+		return true
+	case *net.TCPAddr:
+		return addr.IP.IsLoopback()
+	}
+	return false
+}
+
+// IMDSHandler emulates the IMDSv2 credential endpoints.  The default credentials
+// are listed first, so are the ones used by the AWS SDKs, followed by the allowed slots.
+type IMDSHandler struct {
+	ecs *EcsServer
+}
+
+func (h IMDSHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.ecs.imds {
+		ecs.Unavailable(w)
+		return
+	}
+
+	if r.URL.Path == IMDS_TOKEN_ROUTE {
+		if r.Method != http.MethodPut {
+			ecs.WriteMessage(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.putToken(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		ecs.WriteMessage(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// IMDSv1 is not supported
+	if !h.ecs.imdsTokens.valid(r.Header.Get(IMDS_TOKEN_HEADER)) {
+		ecs.WriteMessage(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, IMDS_CREDENTIALS_ROUTE) {
+		ecs.Unavailable(w)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, IMDS_CREDENTIALS_ROUTE)
+	if name == "" {
+		h.listRoles(w)
+		return
+	}
+	h.getRole(w, r, name)
+}
+
+// putToken issues a new session token, just like EC2
+func (h IMDSHandler) putToken(w http.ResponseWriter, r *http.Request) {
+	// EC2 rejects proxied requests to prevent SSRF attacks from stealing creds
+	if r.Header.Get("X-Forwarded-For") != "" {
+		ecs.WriteMessage(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	ttl, err := strconv.Atoi(r.Header.Get(IMDS_TOKEN_TTL_HEADER))
+	if err != nil || ttl < IMDS_MIN_TOKEN_TTL || ttl > IMDS_MAX_TOKEN_TTL {
+		ecs.WriteMessage(w, fmt.Sprintf("%s must be between %d and %d",
+			IMDS_TOKEN_TTL_HEADER, IMDS_MIN_TOKEN_TTL, IMDS_MAX_TOKEN_TTL), http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.ecs.imdsTokens.issue(time.Duration(ttl) * time.Second)
+	if err != nil {
+		ecs.WriteMessage(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set(IMDS_TOKEN_TTL_HEADER, strconv.Itoa(ttl))
+	_, _ = w.Write([]byte(token))
+}
+
+// listRoles returns the profile names of the credentials IMDS may serve, one per line
+func (h IMDSHandler) listRoles(w http.ResponseWriter) {
+	names := []string{}
+	if cr := h.ecs.GetDefaultCreds(); cr.ProfileName != "" {
+		names = append(names, cr.ProfileName)
+	}
+	for _, p := range h.ecs.ListSlottedCreds() {
+		if h.ecs.imdsSlots[p.ProfileName] {
+			names = append(names, p.ProfileName)
+		}
+	}
+
+	if len(names) == 0 {
+		ecs.Unavailable(w)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(strings.Join(names, "\n")))
+}
+
+// getRole returns the credentials for the default profile or allowed slot with the given name
+func (h IMDSHandler) getRole(w http.ResponseWriter, r *http.Request, name string) {
+	cr := h.ecs.GetDefaultCreds()
+	slot := DEFAULT_SLOT
+	if cr.ProfileName != name {
+		// slots can be restricted to scoped bearer tokens, so IMDS must not be a way around them
+		if !h.ecs.imdsSlots[name] {
+			ecs.Unavailable(w)
+			return
+		}
+		slot = name
+		var err error
+		if cr, err = h.ecs.GetSlottedCreds(name); err != nil {
+			ecs.Unavailable(w)
+			return
+		}
+	}
+
+	if cr.Creds.Expired() {
+		ecs.Expired(w)
+		return
+	}
+	h.ecs.credentialsVended(r, slot, cr)
+
+	resp := imdsCredentialsResponse{
+		Code:            "Success",
+		LastUpdated:     time.Now().UTC().Format(time.RFC3339),
+		Type:            "AWS-HMAC",
+		AccessKeyId:     cr.Creds.AccessKeyId,
+		SecretAccessKey: cr.Creds.SecretAccessKey,
+		Token:           cr.Creds.SessionToken,
+		Expiration:      time.Unix(cr.Creds.ExpireEpoch(), 0).UTC().Format(time.RFC3339),
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,265 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials/ec2rolecreds"
+	"github.com/aws/aws-sdk-go-v2/feature/ec2/imds"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/nettest"
+)
+
+// newTestIMDSServer returns a running EcsServer with IMDS enabled and the
+// default credentials and a slot loaded
+func newTestIMDSServer(t *testing.T) *EcsServer {
+	t.Helper()
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.EnableIMDS([]string{"SlotProfile"}))
+	t.Cleanup(s.Close)
+
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+	slot := newRequest(time.Now().Add(1 * time.Hour))
+	slot.ProfileName = "SlotProfile"
+	slot.Creds.AccessKeyId = "SlotAccessKeyId"
+	require.NoError(t, s.PutSlottedCreds(slot))
+
+	go func() {
+		_ = s.Serve()
+	}()
+	return s
+}
+
+func imdsRequest(t *testing.T, method, url string, headers map[string]string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	return res.StatusCode, string(body)
+}
+
+func TestIMDSWithSDK(t *testing.T) {
+	s := newTestIMDSServer(t)
+	client := imds.New(imds.Options{
+		Endpoint:       s.BaseURL(),
+		EnableFallback: aws.FalseTernary, // IMDSv2 only
+	})
+
+	// same provider the AWS SDK uses on EC2
+	provider := ec2rolecreds.New(func(o *ec2rolecreds.Options) {
+		o.Client = client
+	})
+	creds, err := provider.Retrieve(context.TODO())
+	require.NoError(t, err)
+	assert.Equal(t, "AccessKeyId", creds.AccessKeyID)
+	assert.Equal(t, "SecretAccessKey", creds.SecretAccessKey)
+	assert.Equal(t, "SessionToken", creds.SessionToken)
+	assert.True(t, creds.CanExpire)
+	assert.WithinDuration(t, time.Now().Add(1*time.Hour), creds.Expires, 5*time.Second)
+
+	out, err := client.GetMetadata(context.TODO(), &imds.GetMetadataInput{
+		Path: "iam/security-credentials/",
+	})
+	require.NoError(t, err)
+	defer out.Content.Close()
+	body, err := io.ReadAll(out.Content)
+	require.NoError(t, err)
+	assert.Equal(t, "1234:FooBar\nSlotProfile", string(body))
+
+	out, err = client.GetMetadata(context.TODO(), &imds.GetMetadataInput{
+		Path: "iam/security-credentials/SlotProfile",
+	})
+	require.NoError(t, err)
+	defer out.Content.Close()
+	body, err = io.ReadAll(out.Content)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"AccessKeyId":"SlotAccessKeyId"`)
+	assert.Contains(t, string(body), `"Code":"Success"`)
+
+	_, err = client.GetMetadata(context.TODO(), &imds.GetMetadataInput{
+		Path: "iam/security-credentials/Missing",
+	})
+	assert.Error(t, err)
+}
+
+func TestIMDSToken(t *testing.T) {
+	s := newTestIMDSServer(t)
+	tokenURL := s.BaseURL() + IMDS_TOKEN_ROUTE
+	credsURL := s.BaseURL() + IMDS_CREDENTIALS_ROUTE
+
+	// the TTL header is required and must be valid
+	code, _ := imdsRequest(t, http.MethodPut, tokenURL, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	for _, ttl := range []string{"0", "21601", "-1", "foo"} {
+		code, _ = imdsRequest(t, http.MethodPut, tokenURL, map[string]string{IMDS_TOKEN_TTL_HEADER: ttl})
+		assert.Equal(t, http.StatusBadRequest, code, ttl)
+	}
+
+	// proxied requests are rejected
+	code, _ = imdsRequest(t, http.MethodPut, tokenURL, map[string]string{
+		IMDS_TOKEN_TTL_HEADER: "60",
+		"X-Forwarded-For":     "10.0.0.1",
+	})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = imdsRequest(t, http.MethodGet, tokenURL, map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	code, token := imdsRequest(t, http.MethodPut, tokenURL, map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.NotEmpty(t, token)
+
+	// IMDSv1 & invalid tokens are rejected
+	code, _ = imdsRequest(t, http.MethodGet, credsURL, nil)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, _ = imdsRequest(t, http.MethodGet, credsURL, map[string]string{IMDS_TOKEN_HEADER: "invalid"})
+	assert.Equal(t, http.StatusUnauthorized, code)
+
+	// our ECS bearer token isn't required
+	code, body := imdsRequest(t, http.MethodGet, credsURL, map[string]string{IMDS_TOKEN_HEADER: token})
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, strings.HasPrefix(body, "1234:FooBar\n"))
+
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+"/latest/meta-data/placement/region",
+		map[string]string{IMDS_TOKEN_HEADER: token})
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = imdsRequest(t, http.MethodPost, credsURL, map[string]string{IMDS_TOKEN_HEADER: token})
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+
+	// but the ECS routes still are
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+"/", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestIMDSTokenExpires(t *testing.T) {
+	tokens := imdsTokens{}
+	token, err := tokens.issue(1 * time.Millisecond)
+	require.NoError(t, err)
+	valid, err := tokens.issue(1 * time.Hour)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, valid)
+
+	time.Sleep(5 * time.Millisecond)
+	assert.False(t, tokens.valid(token))
+	assert.True(t, tokens.valid(valid))
+	assert.False(t, tokens.valid(""))
+
+	// expired tokens are purged
+	_, err = tokens.issue(1 * time.Hour)
+	require.NoError(t, err)
+	assert.Len(t, tokens.tokens, 2)
+}
+
+func TestIMDSDisabled(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	s, err := NewEcsServer(context.TODO(), "", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+	go func() {
+		_ = s.Serve()
+	}()
+
+	code, _ := imdsRequest(t, http.MethodPut, fmt.Sprintf("http://%s%s", l.Addr(), IMDS_TOKEN_ROUTE),
+		map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestIMDSExpiredCreds(t *testing.T) {
+	s := newTestIMDSServer(t)
+	s.SetDefaultCreds(newRequest(time.Now().Add(-1 * time.Minute)))
+
+	_, token := imdsRequest(t, http.MethodPut, s.BaseURL()+IMDS_TOKEN_ROUTE, map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	code, _ := imdsRequest(t, http.MethodGet, s.BaseURL()+IMDS_CREDENTIALS_ROUTE+"1234:FooBar",
+		map[string]string{IMDS_TOKEN_HEADER: token})
+	assert.NotEqual(t, http.StatusOK, code)
+}
+
+func TestIMDSRequiresLocalListener(t *testing.T) {
+	l, err := net.Listen("tcp", "0.0.0.0:0")
+	require.NoError(t, err)
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+	assert.ErrorContains(t, s.EnableIMDS(nil), "loopback or Unix socket")
+
+	l, err = NewUnixListener(filepath.Join(t.TempDir(), "ecs.sock"))
+	require.NoError(t, err)
+	s, err = NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+	assert.NoError(t, s.EnableIMDS(nil))
+}
+
+func TestIMDSScopedSlots(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	require.NoError(t, s.AddToken("SlotToken", TokenScope{Slot: "SlotProfile"}))
+	require.NoError(t, s.EnableIMDS([]string{"SlotProfile"}))
+	t.Cleanup(s.Close)
+
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+	for _, name := range []string{"SlotProfile", "OtherProfile"} {
+		slot := newRequest(time.Now().Add(1 * time.Hour))
+		slot.ProfileName = name
+		require.NoError(t, s.PutSlottedCreds(slot))
+	}
+	go func() {
+		_ = s.Serve()
+	}()
+
+	_, token := imdsRequest(t, http.MethodPut, s.BaseURL()+IMDS_TOKEN_ROUTE, map[string]string{IMDS_TOKEN_TTL_HEADER: "60"})
+	headers := map[string]string{IMDS_TOKEN_HEADER: token}
+
+	// OtherProfile is only readable with the default or an admin bearer token
+	code, body := imdsRequest(t, http.MethodGet, s.BaseURL()+IMDS_CREDENTIALS_ROUTE, headers)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "1234:FooBar\nSlotProfile", body)
+
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+IMDS_CREDENTIALS_ROUTE+"OtherProfile", headers)
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+IMDS_CREDENTIALS_ROUTE+"SlotProfile", headers)
+	assert.Equal(t, http.StatusOK, code)
+}
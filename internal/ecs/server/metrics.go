@@ -0,0 +1,239 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+const (
+	METRICS_ROUTE        = "/metrics"
+	METRICS_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+	// label used for the default slot since its name is empty
+	METRICS_DEFAULT_SLOT = "default"
+)
+
+type requestMetric struct {
+	route string
+	code  int
+}
+
+// serverMetrics tracks the counters exposed via the /metrics endpoint.
+// All methods are safe to call on a nil *serverMetrics.
+type serverMetrics struct {
+	lock          sync.Mutex
+	requests      map[requestMetric]uint64
+	vends         map[string]uint64
+	refreshErrors map[string]uint64
+	authFailures  uint64
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests:      map[requestMetric]uint64{},
+		vends:         map[string]uint64{},
+		refreshErrors: map[string]uint64{},
+	}
+}
+
+// request counts an HTTP request for the given URL path and status code
+func (m *serverMetrics) request(path string, code int) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.requests[requestMetric{route: metricsRoute(path), code: code}]++
+}
+
+// vend counts the credentials in the named slot being returned to a client
+func (m *serverMetrics) vend(slot string) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.vends[slotLabel(slot)]++
+}
+
+// refreshError counts a failure to refresh the credentials in the named slot
+func (m *serverMetrics) refreshError(slot string) {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.refreshErrors[slotLabel(slot)]++
+}
+
+// authFailure counts a request with an invalid authorization token
+func (m *serverMetrics) authFailure() {
+	if m == nil {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.authFailures++
+}
+
+// metricsRoute maps the URL path to a route name so the number of label
+// values is bounded regardless of what clients request
+func metricsRoute(path string) string {
+	switch {
+	case path == ecs.DEFAULT_ROUTE:
+		return "default"
+	case path == ecs.SLOT_ROUTE || strings.HasPrefix(path, ecs.SLOT_ROUTE+"/"):
+		return "slot"
+	case path == ecs.PROFILE_ROUTE:
+		return "profile"
+	case path == ecs.EVENTS_ROUTE:
+		return "events"
+	case path == ecs.HEALTHCHECK_ROUTE || strings.HasPrefix(path, ecs.HEALTHCHECK_ROUTE+"/"):
+		return "healthcheck"
+	case strings.HasPrefix(path, IMDS_ROUTE):
+		return "imds"
+	case path == METRICS_ROUTE:
+		return "metrics"
+	}
+	return "other"
+}
+
+func slotLabel(slot string) string {
+	if slot == DEFAULT_SLOT {
+		return METRICS_DEFAULT_SLOT
+	}
+	return slot
+}
+
+// EnableMetrics configures the server to expose Prometheus metrics at /metrics.
+// If authToken is set, scrapers must provide it as a bearer token; it is
+// independent of the token used for the credential routes.  Must be called before Serve().
+func (e *EcsServer) EnableMetrics(authToken string) {
+	if e.metrics == nil {
+		e.metrics = newServerMetrics()
+	}
+	e.metricsEnabled = true
+	e.metricsAuth = ""
+	if authToken != "" {
+		e.metricsAuth = "Bearer " + authToken
+	}
+}
+
+// withMetrics counts every request by route and status code
+func (e *EcsServer) withMetrics(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w2 := &loggingMiddlewareResponseWriter{w, http.StatusOK}
+		handler.ServeHTTP(w2, r)
+		e.metrics.request(r.URL.Path, w2.Code)
+	})
+}
+
+// MetricsHandler serves our metrics in the Prometheus text exposition format
+type MetricsHandler struct {
+	ecs *EcsServer
+}
+
+func (h MetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.ecs.metricsEnabled {
+		ecs.WriteMessage(w, "Metrics are not enabled", http.StatusNotFound)
+		return
+	}
+	if r.Header.Get("Authorization") != h.ecs.metricsAuth {
+		h.ecs.metrics.authFailure()
+		ecs.WriteMessage(w, "Invalid authorization token", http.StatusForbidden)
+		return
+	}
+	if r.Method != http.MethodGet {
+		ecs.WriteMessage(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", METRICS_CONTENT_TYPE)
+	h.ecs.writeMetrics(w)
+}
+
+// writeMetrics writes all of our metrics to w
+func (e *EcsServer) writeMetrics(w io.Writer) {
+	m := e.metrics
+	m.lock.Lock()
+	requests := make([]requestMetric, 0, len(m.requests))
+	for k := range m.requests {
+		requests = append(requests, k)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].route != requests[j].route {
+			return requests[i].route < requests[j].route
+		}
+		return requests[i].code < requests[j].code
+	})
+
+	writeMetricHeader(w, "aws_sso_ecs_http_requests_total", "counter", "HTTP requests by route and status code")
+	for _, k := range requests {
+		fmt.Fprintf(w, "aws_sso_ecs_http_requests_total{route=%s,code=\"%d\"} %d\n",
+			strconv.Quote(k.route), k.code, m.requests[k])
+	}
+
+	writeMetricHeader(w, "aws_sso_ecs_credentials_vended_total", "counter", "Credentials returned to clients by slot")
+	writeSlotCounters(w, "aws_sso_ecs_credentials_vended_total", m.vends)
+
+	writeMetricHeader(w, "aws_sso_ecs_refresh_errors_total", "counter", "Failures to refresh credentials by slot")
+	writeSlotCounters(w, "aws_sso_ecs_refresh_errors_total", m.refreshErrors)
+
+	writeMetricHeader(w, "aws_sso_ecs_auth_failures_total", "counter", "Requests with an invalid authorization token")
+	fmt.Fprintf(w, "aws_sso_ecs_auth_failures_total %d\n", m.authFailures)
+	m.lock.Unlock()
+
+	// read the slots directly so scraping never triggers a refresh
+	names := append([]string{DEFAULT_SLOT}, e.slots.Names()...)
+	sort.Strings(names)
+	writeMetricHeader(w, "aws_sso_ecs_credentials_expiry_seconds", "gauge", "Seconds until the credentials in each slot expire")
+	for _, name := range names {
+		cr, ok := e.slots.Get(name)
+		if !ok || cr.Creds == nil {
+			continue
+		}
+		expires := time.Until(time.UnixMilli(cr.Creds.Expiration)).Seconds()
+		fmt.Fprintf(w, "aws_sso_ecs_credentials_expiry_seconds{slot=%s,profile=%s} %.0f\n",
+			strconv.Quote(slotLabel(name)), strconv.Quote(cr.ProfileName), expires)
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSlotCounters(w io.Writer, name string, counters map[string]uint64) {
+	slots := make([]string, 0, len(counters))
+	for k := range counters {
+		slots = append(slots, k)
+	}
+	sort.Strings(slots)
+	for _, slot := range slots {
+		fmt.Fprintf(w, "%s{slot=%s} %d\n", name, strconv.Quote(slot), counters[slot])
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"golang.org/x/net/nettest"
+)
+
+// newTestMetricsServer returns a running EcsServer with the default credentials
+// and a slot loaded.  If enable is true, metrics are enabled with metricsToken.
+func newTestMetricsServer(t *testing.T, enable bool, metricsToken string) *EcsServer {
+	t.Helper()
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.TODO(), "AuthToken", l, "", "")
+	require.NoError(t, err)
+	if enable {
+		s.EnableMetrics(metricsToken)
+	}
+	t.Cleanup(s.Close)
+
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+	slot := newRequest(time.Now().Add(1 * time.Hour))
+	slot.ProfileName = "SlotProfile"
+	require.NoError(t, s.PutSlottedCreds(slot))
+
+	go func() {
+		_ = s.Serve()
+	}()
+	return s
+}
+
+func TestMetricsRoute(t *testing.T) {
+	tests := map[string]string{
+		"/":                       "default",
+		"/slot":                   "slot",
+		"/slot/SlotProfile":       "slot",
+		"/profile":                "profile",
+		"/events":                 "events",
+		"/healthcheck":            "healthcheck",
+		"/healthcheck/foo":        "healthcheck",
+		"/latest/api/token":       "imds",
+		"/metrics":                "metrics",
+		"/slotted":                "other",
+		"/does/not/exist":         "other",
+		"/latest-but-not-imds":    "other",
+		"/metrics/does-not-exist": "other",
+	}
+	for path, route := range tests {
+		assert.Equal(t, route, metricsRoute(path), path)
+	}
+}
+
+func TestServerMetricsNil(t *testing.T) {
+	// servers created without NewEcsServer() have no metrics
+	var m *serverMetrics
+	assert.NotPanics(t, func() {
+		m.request("/", http.StatusOK)
+		m.vend(DEFAULT_SLOT)
+		m.refreshError("foo")
+		m.authFailure()
+	})
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	s := newTestMetricsServer(t, false, "")
+	code, _ := imdsRequest(t, http.MethodGet, s.BaseURL()+METRICS_ROUTE, nil)
+	assert.Equal(t, http.StatusNotFound, code)
+}
+
+func TestMetricsAuth(t *testing.T) {
+	s := newTestMetricsServer(t, true, "MetricsToken")
+	url := s.BaseURL() + METRICS_ROUTE
+
+	code, _ := imdsRequest(t, http.MethodGet, url, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	// the credential token does not grant access to metrics
+	code, _ = imdsRequest(t, http.MethodGet, url, map[string]string{"Authorization": "Bearer AuthToken"})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, body := imdsRequest(t, http.MethodGet, url, map[string]string{"Authorization": "Bearer MetricsToken"})
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "aws_sso_ecs_auth_failures_total 2\n")
+
+	// and the metrics token does not grant access to credentials
+	code, _ = imdsRequest(t, http.MethodGet, s.BaseURL()+ecs.DEFAULT_ROUTE, map[string]string{"Authorization": "Bearer MetricsToken"})
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, _ = imdsRequest(t, http.MethodPut, url, map[string]string{"Authorization": "Bearer MetricsToken"})
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	s := newTestMetricsServer(t, true, "")
+	auth := map[string]string{"Authorization": "Bearer AuthToken"}
+	base := s.BaseURL()
+
+	code, _ := imdsRequest(t, http.MethodGet, base+ecs.DEFAULT_ROUTE, auth)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.DEFAULT_ROUTE, auth)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile", auth)
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/Missing", auth)
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.DEFAULT_ROUTE, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+
+	code, body := imdsRequest(t, http.MethodGet, base+METRICS_ROUTE, nil)
+	assert.Equal(t, http.StatusOK, code)
+
+	for _, line := range []string{
+		"# TYPE aws_sso_ecs_http_requests_total counter\n",
+		"aws_sso_ecs_http_requests_total{route=\"default\",code=\"200\"} 2\n",
+		"aws_sso_ecs_http_requests_total{route=\"default\",code=\"403\"} 1\n",
+		"aws_sso_ecs_http_requests_total{route=\"slot\",code=\"200\"} 1\n",
+		"aws_sso_ecs_http_requests_total{route=\"slot\",code=\"404\"} 1\n",
+		"aws_sso_ecs_credentials_vended_total{slot=\"default\"} 2\n",
+		"aws_sso_ecs_credentials_vended_total{slot=\"SlotProfile\"} 1\n",
+		"aws_sso_ecs_auth_failures_total 1\n",
+		"# TYPE aws_sso_ecs_credentials_expiry_seconds gauge\n",
+		"aws_sso_ecs_credentials_expiry_seconds{slot=\"default\",profile=\"1234:FooBar\"} ",
+		"aws_sso_ecs_credentials_expiry_seconds{slot=\"SlotProfile\",profile=\"SlotProfile\"} ",
+	} {
+		assert.Contains(t, body, line)
+	}
+
+	// the scrape itself is counted on the next one
+	_, body = imdsRequest(t, http.MethodGet, base+METRICS_ROUTE, nil)
+	assert.Contains(t, body, "aws_sso_ecs_http_requests_total{route=\"metrics\",code=\"200\"} 1\n")
+}
+
+func TestMetricsRefreshErrors(t *testing.T) {
+	es := newTestEcsServer()
+	es.EnableMetrics("")
+	es.EnableRefresh(&mockFetcher{err: fmt.Errorf("boom")}, time.Hour)
+
+	r := newRequest(time.Now().Add(5 * time.Minute))
+	r.SSOName = "Default"
+	es.slots.Put(r.ProfileName, r)
+
+	_, ok := es.getCreds(r.ProfileName)
+	assert.True(t, ok)
+	// skip past the failure backoff so we retry
+	es.refreshFailed[r.ProfileName] = time.Now().Add(-REFRESH_FAILURE_BACKOFF)
+	_, ok = es.getCreds(r.ProfileName)
+	assert.True(t, ok)
+
+	w := &strings.Builder{}
+	es.writeMetrics(w)
+	assert.Contains(t, w.String(), "aws_sso_ecs_refresh_errors_total{slot=\"1234:FooBar\"} 2\n")
+	assert.Contains(t, w.String(), "aws_sso_ecs_credentials_expiry_seconds{slot=\"1234:FooBar\",profile=\"1234:FooBar\"} 300\n")
+}
@@ -0,0 +1,45 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+)
+
+// RequireClientCerts enables mutual TLS: every client must present a certificate
+// signed by one of the CA certificates in caCerts.  Must be called before Serve().
+func (e *EcsServer) RequireClientCerts(caCerts string) error {
+	if !e.sslEnabled() {
+		return fmt.Errorf("client certificates require SSL/TLS")
+	}
+
+	pool := x509.NewCertPool()
+	if ok := pool.AppendCertsFromPEM([]byte(caCerts)); !ok {
+		return fmt.Errorf("unable to load client CA certificate")
+	}
+
+	e.server.TLSConfig = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		MinVersion: tls.VersionTLS12,
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs/client"
+	"golang.org/x/net/nettest"
+)
+
+func TestRequireClientCerts(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	privateKey, err := os.ReadFile("./testdata/localhost.key")
+	require.NoError(t, err)
+	certChain, err := os.ReadFile("./testdata/localhost.crt")
+	require.NoError(t, err)
+	caCert, err := os.ReadFile("./testdata/client-ca.crt")
+	require.NoError(t, err)
+	clientCert, err := os.ReadFile("./testdata/client.crt")
+	require.NoError(t, err)
+	clientKey, err := os.ReadFile("./testdata/client.key")
+	require.NoError(t, err)
+
+	// mTLS requires SSL
+	s, err := NewEcsServer(context.TODO(), "token", l, "", "")
+	require.NoError(t, err)
+	assert.ErrorContains(t, s.RequireClientCerts(string(caCert)), "require SSL/TLS")
+
+	s, err = NewEcsServer(context.TODO(), "token", l, string(privateKey), string(certChain))
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Error(t, s.RequireClientCerts("foobar"))
+	require.NoError(t, s.RequireClientCerts(string(caCert)))
+
+	go func() {
+		_ = s.Serve()
+	}()
+
+	// no client cert
+	c := client.NewECSClient(l.Addr().String(), "token", string(certChain))
+	_, err = c.ListProfiles()
+	assert.Error(t, err)
+
+	// client cert signed by a different CA
+	require.NoError(t, c.SetClientCert(string(certChain), string(privateKey)))
+	_, err = c.ListProfiles()
+	assert.Error(t, err)
+
+	require.NoError(t, c.SetClientCert(string(clientCert), string(clientKey)))
+	profiles, err := c.ListProfiles()
+	assert.NoError(t, err)
+	assert.Empty(t, profiles)
+}
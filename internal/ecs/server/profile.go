@@ -42,15 +42,16 @@ func (p ProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (p ProfileHandler) Get(w http.ResponseWriter, r *http.Request) {
 	// get the details of the default profile
 	log.Debug("fetching default profile")
-	if p.ecs.DefaultCreds.ProfileName == "" {
+	creds := p.ecs.GetDefaultCreds()
+	if creds.ProfileName == "" {
 		ecs.Unavailable(w)
 		return
 	}
 
-	if p.ecs.DefaultCreds.Creds.Expired() {
+	if creds.Creds.Expired() {
 		ecs.Expired(w)
 		return
 	}
 
-	ecs.WriteListProfileResponse(w, ecs.NewListProfileRepsonse(p.ecs.DefaultCreds))
+	ecs.WriteListProfileResponse(w, ecs.NewListProfileRepsonse(creds))
 }
@@ -33,12 +33,7 @@ import (
 
 func TestProfileGet(t *testing.T) {
 	ph := ProfileHandler{
-		ecs: &EcsServer{
-			DefaultCreds: &ecs.ECSClientRequest{
-				ProfileName: "",
-				Creds:       &storage.RoleCredentials{},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
 	ts := httptest.NewServer(&ph)
 	defer ts.Close()
@@ -53,15 +48,17 @@ func TestProfileGet(t *testing.T) {
 	assert.Equal(t, fmt.Sprintf("%d", http.StatusNotFound), msg.Code)
 
 	soon := time.Now().Add(90 * time.Second)
-	ph.ecs.DefaultCreds.ProfileName = "000001111111:ProfileName"
-	ph.ecs.DefaultCreds.Creds = &storage.RoleCredentials{
-		RoleName:        "ProfileName",
-		AccountId:       1111111,
-		AccessKeyId:     "AccessKeyId",
-		SecretAccessKey: "SecretAccessKey",
-		SessionToken:    "SessionToken",
-		Expiration:      soon.UnixMilli(),
-	}
+	ph.ecs.SetDefaultCreds(&ecs.ECSClientRequest{
+		ProfileName: "000001111111:ProfileName",
+		Creds: &storage.RoleCredentials{
+			RoleName:        "ProfileName",
+			AccountId:       1111111,
+			AccessKeyId:     "AccessKeyId",
+			SecretAccessKey: "SecretAccessKey",
+			SessionToken:    "SessionToken",
+			Expiration:      soon.UnixMilli(),
+		},
+	})
 
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
@@ -71,7 +68,7 @@ func TestProfileGet(t *testing.T) {
 	assert.Equal(t, "000001111111:ProfileName", lpr.ProfileName)
 	assert.Equal(t, "ProfileName", lpr.RoleName)
 
-	ph.ecs.DefaultCreds.Creds.Expiration = time.Now().UnixMilli()
+	ph.ecs.GetDefaultCreds().Creds.Expiration = time.Now().UnixMilli()
 	res, err = http.Get(url) //nolint
 	assert.NoError(t, err)
 	err = json.NewDecoder(res.Body).Decode(&msg)
@@ -0,0 +1,134 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// DEFAULT_REFRESH_WINDOW is how long before expiration we re-fetch credentials.
+// Matches the advisory refresh window used by the AWS SDKs so clients never
+// cache credentials which are about to expire.
+const DEFAULT_REFRESH_WINDOW = 15 * time.Minute
+
+// REFRESH_FAILURE_BACKOFF is how long we wait after a failed refresh before
+// trying to refresh the same slot again.  Until then, the existing credentials
+// are returned so an SSO outage doesn't turn every request into an AWS call.
+const REFRESH_FAILURE_BACKOFF = 30 * time.Second
+
+// CredentialsFetcher retrieves a fresh set of credentials for the given role
+// in the named AWS SSO instance
+type CredentialsFetcher interface {
+	GetRoleCredentials(ssoName string, accountId int64, role string) (*storage.RoleCredentials, error)
+}
+
+// EnableRefresh configures the server to re-fetch credentials via fetcher when
+// they are within window of expiring.  A window <= 0 uses DEFAULT_REFRESH_WINDOW.
+// Must be called before Serve().
+func (e *EcsServer) EnableRefresh(fetcher CredentialsFetcher, window time.Duration) {
+	if window <= 0 {
+		window = DEFAULT_REFRESH_WINDOW
+	}
+	e.fetcher = fetcher
+	e.refreshWindow = window
+	e.refreshFailed = map[string]time.Time{}
+}
+
+// needsRefresh returns true if refresh is enabled, the credentials in the named
+// slot are about to expire and we are not backing off from a failed refresh
+func (e *EcsServer) needsRefresh(name string, cr *ecs.ECSClientRequest) bool {
+	if e.fetcher == nil || cr.ProfileName == "" || cr.Creds == nil {
+		return false
+	}
+	expires := time.UnixMilli(cr.Creds.Expiration)
+	if time.Until(expires) > e.refreshWindow {
+		return false
+	}
+
+	e.failedLock.Lock()
+	defer e.failedLock.Unlock()
+	if failed, ok := e.refreshFailed[name]; ok {
+		if time.Since(failed) < REFRESH_FAILURE_BACKOFF {
+			return false
+		}
+		delete(e.refreshFailed, name)
+	}
+	return true
+}
+
+// setRefreshFailed records that refreshing the named slot just failed
+func (e *EcsServer) setRefreshFailed(name string) {
+	e.failedLock.Lock()
+	defer e.failedLock.Unlock()
+	e.refreshFailed[name] = time.Now()
+}
+
+// expiringWindow returns how long before expiration credentials are considered to be expiring
+func (e *EcsServer) expiringWindow() time.Duration {
+	if e.refreshWindow > 0 {
+		return e.refreshWindow
+	}
+	return DEFAULT_REFRESH_WINDOW
+}
+
+// getCreds returns the credentials in the named slot, re-fetching them first if
+// refresh is enabled and they are about to expire.  If the refresh fails, the
+// existing credentials are returned and the slot isn't retried for
+// REFRESH_FAILURE_BACKOFF.
+func (e *EcsServer) getCreds(name string) (*ecs.ECSClientRequest, bool) {
+	cr, ok := e.slots.Get(name)
+	if !ok || !e.needsRefresh(name, cr) {
+		return cr, ok
+	}
+
+	// only one refresh at a time so concurrent requests don't all hit AWS
+	e.refreshLock.Lock()
+	defer e.refreshLock.Unlock()
+
+	// another request may have refreshed or removed the slot while we waited
+	cr, ok = e.slots.Get(name)
+	if !ok || !e.needsRefresh(name, cr) {
+		return cr, ok
+	}
+
+	log.Info("refreshing credentials", "profile", cr.ProfileName, "sso", cr.SSOName,
+		"accountId", cr.Creds.AccountId, "role", cr.Creds.RoleName)
+	creds, err := e.fetcher.GetRoleCredentials(cr.SSOName, cr.Creds.AccountId, cr.Creds.RoleName)
+	if err != nil {
+		log.Error("unable to refresh credentials", "profile", cr.ProfileName, "error", err.Error())
+		e.metrics.refreshError(name)
+		e.setRefreshFailed(name)
+		return cr, true
+	}
+
+	updated := &ecs.ECSClientRequest{
+		ProfileName: cr.ProfileName,
+		SSOName:     cr.SSOName,
+		Creds:       creds,
+	}
+	if !e.slots.Replace(name, cr, updated) {
+		// slot was changed via the API during our refresh; that wins
+		return e.slots.Get(name)
+	}
+	e.publish(ecs.EVENT_REFRESHED, name, updated)
+	return updated, true
+}
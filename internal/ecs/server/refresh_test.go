@@ -0,0 +1,158 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+type mockFetcher struct {
+	calls int
+	err   error
+}
+
+func (m *mockFetcher) GetRoleCredentials(ssoName string, accountId int64, role string) (*storage.RoleCredentials, error) {
+	m.calls++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &storage.RoleCredentials{
+		AccountId:       accountId,
+		RoleName:        role,
+		AccessKeyId:     fmt.Sprintf("%s-%s", ssoName, role),
+		SecretAccessKey: "NewSecretAccessKey",
+		SessionToken:    "NewSessionToken",
+		Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+	}, nil
+}
+
+func TestRefreshCreds(t *testing.T) {
+	es := newTestEcsServer()
+	put := func(expires time.Time) *ecs.ECSClientRequest {
+		r := newRequest(expires)
+		r.SSOName = "Default"
+		es.slots.Put(r.ProfileName, r)
+		return r
+	}
+
+	// disabled by default
+	put(time.Now().Add(5 * time.Minute))
+	cr, err := es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "AccessKeyId", cr.Creds.AccessKeyId)
+
+	m := &mockFetcher{}
+	es.EnableRefresh(m, 0)
+	assert.Equal(t, DEFAULT_REFRESH_WINDOW, es.refreshWindow)
+
+	// outside of the window
+	put(time.Now().Add(1 * time.Hour))
+	cr, err = es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "AccessKeyId", cr.Creds.AccessKeyId)
+	assert.Equal(t, 0, m.calls)
+
+	// inside of the window
+	orig := put(time.Now().Add(5 * time.Minute))
+	cr, err = es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "Default-FooBar", cr.Creds.AccessKeyId)
+	assert.Equal(t, "1234:FooBar", cr.ProfileName)
+	assert.Equal(t, "Default", cr.SSOName)
+	assert.Equal(t, "AccessKeyId", orig.Creds.AccessKeyId, "stored requests are never modified")
+	assert.Equal(t, 1, m.calls)
+
+	// already refreshed
+	cr, err = es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "Default-FooBar", cr.Creds.AccessKeyId)
+	assert.Equal(t, 1, m.calls)
+
+	// errors return the existing creds
+	m.err = fmt.Errorf("refresh token expired")
+	put(time.Now().Add(-5 * time.Minute))
+	cr, err = es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "AccessKeyId", cr.Creds.AccessKeyId)
+	assert.True(t, cr.Creds.Expired())
+	assert.Equal(t, 2, m.calls)
+
+	// nothing loaded
+	assert.Equal(t, "", es.GetDefaultCreds().ProfileName)
+	assert.Equal(t, 2, m.calls)
+}
+
+func TestRefreshFailureBackoff(t *testing.T) {
+	es := newTestEcsServer()
+	m := &mockFetcher{err: fmt.Errorf("sso is down")}
+	es.EnableRefresh(m, 0)
+
+	r := newRequest(time.Now().Add(-5 * time.Minute))
+	r.SSOName = "Default"
+	es.slots.Put(r.ProfileName, r)
+
+	cr, err := es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "AccessKeyId", cr.Creds.AccessKeyId)
+	assert.Equal(t, 1, m.calls)
+
+	// within the backoff we don't try again
+	for i := 0; i < 3; i++ {
+		cr, err = es.GetSlottedCreds("1234:FooBar")
+		assert.NoError(t, err)
+		assert.Equal(t, "AccessKeyId", cr.Creds.AccessKeyId)
+	}
+	assert.Equal(t, 1, m.calls)
+
+	// other slots are not affected
+	r2 := newRequest(time.Now().Add(-5 * time.Minute))
+	r2.ProfileName = "5678:FooBar"
+	r2.SSOName = "Default"
+	es.slots.Put(r2.ProfileName, r2)
+	_, err = es.GetSlottedCreds("5678:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m.calls)
+
+	// after the backoff we retry
+	m.err = nil
+	es.refreshFailed["1234:FooBar"] = time.Now().Add(-REFRESH_FAILURE_BACKOFF)
+	cr, err = es.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "Default-FooBar", cr.Creds.AccessKeyId)
+	assert.Equal(t, 3, m.calls)
+	assert.NotContains(t, es.refreshFailed, "1234:FooBar")
+}
+
+func TestRefreshListSlottedCreds(t *testing.T) {
+	es := newTestEcsServer()
+	es.EnableRefresh(&mockFetcher{}, 10*time.Minute)
+
+	// expired creds are refreshed rather than skipped
+	es.slots.Put("1234:FooBar", newRequest(time.Now().Add(-5*time.Second)))
+	list := es.ListSlottedCreds()
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, "1234:FooBar", list[0].ProfileName)
+	assert.False(t, getSlot(t, es, "1234:FooBar").Creds.Expired())
+}
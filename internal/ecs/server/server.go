@@ -25,8 +25,11 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	// "github.com/davecgh/go-spew/spew"
+	"github.com/synfinatic/aws-sso-cli/internal/audit"
 	"github.com/synfinatic/aws-sso-cli/internal/ecs"
 	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
@@ -40,13 +43,31 @@ func init() {
 }
 
 type EcsServer struct {
-	listener     net.Listener
-	authToken    string
-	server       http.Server
-	DefaultCreds *ecs.ECSClientRequest
-	slottedCreds map[string]*ecs.ECSClientRequest
-	privateKey   string
-	certChain    string
+	listener   net.Listener
+	authToken  string
+	tokens     map[string]TokenScope // additional scoped tokens; key is the Authorization header
+	server     http.Server
+	slots      *SlotRegistry
+	privateKey string
+	certChain  string
+	// optional auto-refresh of credentials before they expire
+	fetcher       CredentialsFetcher
+	refreshWindow time.Duration
+	refreshLock   sync.Mutex
+	refreshFailed map[string]time.Time // slot name => time of the last failed refresh
+	failedLock    sync.Mutex
+	// optional EC2 IMDSv2 emulation
+	imds       bool
+	imdsSlots  map[string]bool // slots which IMDS may serve in addition to the default
+	imdsTokens imdsTokens
+	// Prometheus metrics; the endpoint is optional & has its own auth token
+	metrics        *serverMetrics
+	metricsEnabled bool
+	metricsAuth    string
+	// optional audit log of credentials returned to clients
+	audit *audit.Logger
+	// subscribers to our event stream of slot changes
+	events *eventBroker
 }
 
 type ExpiredCredentials struct{}
@@ -58,14 +79,13 @@ func (e *ExpiredCredentials) Error() string {
 // NewEcsServer creates a new ECS Server
 func NewEcsServer(ctx context.Context, authToken string, listen net.Listener, privateKey, certChain string) (*EcsServer, error) {
 	e := &EcsServer{
-		listener:  listen,
-		authToken: authToken,
-		DefaultCreds: &ecs.ECSClientRequest{
-			Creds: &storage.RoleCredentials{},
-		},
-		slottedCreds: map[string]*ecs.ECSClientRequest{},
-		privateKey:   privateKey,
-		certChain:    certChain,
+		listener:   listen,
+		authToken:  authToken,
+		slots:      NewSlotRegistry(ctx, nil),
+		privateKey: privateKey,
+		certChain:  certChain,
+		metrics:    newServerMetrics(),
+		events:     newEventBroker(),
 	}
 
 	// inner router: all auth-protected credential routes
@@ -76,6 +96,9 @@ func NewEcsServer(ctx context.Context, authToken string, listen net.Listener, pr
 	innerRouter.Handle(fmt.Sprintf("%s/", ecs.SLOT_ROUTE), SlottedHandler{
 		ecs: e,
 	})
+	innerRouter.Handle(ecs.EVENTS_ROUTE, EventsHandler{
+		ecs: e,
+	})
 	innerRouter.Handle(ecs.PROFILE_ROUTE, ProfileHandler{
 		ecs: e,
 	})
@@ -88,31 +111,76 @@ func NewEcsServer(ctx context.Context, authToken string, listen net.Listener, pr
 	}
 
 	healthHandler := HealthCheckHandler{ecs: e}
+	imdsHandler := IMDSHandler{ecs: e}
 
 	// outer router: healthcheck bypasses auth; all other routes require auth
 	outerRouter := http.NewServeMux()
 	outerRouter.Handle(ecs.HEALTHCHECK_ROUTE, healthHandler)
 	outerRouter.Handle(fmt.Sprintf("%s/", ecs.HEALTHCHECK_ROUTE), healthHandler)
-	outerRouter.Handle(ecs.DEFAULT_ROUTE, WithAuthorizationCheck(authTokenHeader, innerRouter.ServeHTTP))
-	e.server.Handler = withLogging(outerRouter)
+	outerRouter.Handle(IMDS_ROUTE, imdsHandler)               // uses IMDSv2 tokens instead of our authToken
+	outerRouter.Handle(METRICS_ROUTE, MetricsHandler{ecs: e}) // uses the metrics token instead of our authToken
+	outerRouter.Handle(ecs.DEFAULT_ROUTE, e.withAuthorizationCheck(authTokenHeader, innerRouter.ServeHTTP))
+	e.server.Handler = withLogging(e.withMetrics(outerRouter))
 
 	return e, nil
 }
 
+// EnablePersistence saves all slots in the SecureStorage so they survive a
+// restart and restores any previously saved slots.  The SecureStorage is not
+// safe for concurrent use, so storeLock must also be held by anything else which
+// uses it while the server is running, such as the CredentialsFetcher.
+// Must be called before Serve().
+func (e *EcsServer) EnablePersistence(store storage.SecureStorage, storeLock *sync.Mutex) {
+	cnt := e.slots.SetStore(store, storeLock)
+	log.Info("restored persisted slots", "count", cnt)
+}
+
+// GetDefaultCreds returns the default credentials.  If none are loaded, the
+// ProfileName will be empty.
+func (e *EcsServer) GetDefaultCreds() *ecs.ECSClientRequest {
+	cr, ok := e.getCreds(DEFAULT_SLOT)
+	if !ok {
+		return &ecs.ECSClientRequest{
+			Creds: &storage.RoleCredentials{},
+		}
+	}
+	return cr
+}
+
+// SetDefaultCreds loads the default credentials
+func (e *EcsServer) SetDefaultCreds(creds *ecs.ECSClientRequest) {
+	e.slots.Put(DEFAULT_SLOT, creds)
+	e.publish(ecs.EVENT_LOADED, DEFAULT_SLOT, creds)
+}
+
+// DeleteDefaultCreds removes the default credentials
+func (e *EcsServer) DeleteDefaultCreds() error {
+	cr, ok := e.slots.Remove(DEFAULT_SLOT)
+	if !ok {
+		return fmt.Errorf("no default credentials loaded")
+	}
+	e.publish(ecs.EVENT_UNLOADED, DEFAULT_SLOT, cr)
+	return nil
+}
+
 // deleteCreds removes our slotted credentials from the cache
 func (e *EcsServer) DeleteSlottedCreds(profile string) error {
-	if _, ok := e.slottedCreds[profile]; ok {
-		delete(e.slottedCreds, profile)
-		return nil
+	if profile == DEFAULT_SLOT {
+		return fmt.Errorf("%s is not found", profile)
 	}
-	return fmt.Errorf("%s is not found", profile)
+	cr, ok := e.slots.Remove(profile)
+	if !ok {
+		return fmt.Errorf("%s is not found", profile)
+	}
+	e.publish(ecs.EVENT_UNLOADED, profile, cr)
+	return nil
 }
 
 // getCreds fetches the named profile from the cache.
 func (e *EcsServer) GetSlottedCreds(profile string) (*ecs.ECSClientRequest, error) {
 	log.Debug("fetching creds", "profile", profile)
-	c, ok := e.slottedCreds[profile]
-	if !ok {
+	c, ok := e.getCreds(profile)
+	if profile == DEFAULT_SLOT || !ok {
 		return c, fmt.Errorf("%s is not found", profile)
 	}
 	return c, nil
@@ -123,8 +191,12 @@ func (e *EcsServer) PutSlottedCreds(creds *ecs.ECSClientRequest) error {
 	if creds.Creds.Expired() {
 		return fmt.Errorf("expired creds")
 	}
+	if creds.ProfileName == DEFAULT_SLOT {
+		return fmt.Errorf("missing ProfileName")
+	}
 
-	e.slottedCreds[creds.ProfileName] = creds
+	e.slots.Put(creds.ProfileName, creds)
+	e.publish(ecs.EVENT_LOADED, creds.ProfileName, creds)
 	return nil
 }
 
@@ -132,7 +204,12 @@ func (e *EcsServer) PutSlottedCreds(creds *ecs.ECSClientRequest) error {
 func (e *EcsServer) ListSlottedCreds() []ecs.ListProfilesResponse {
 	resp := []ecs.ListProfilesResponse{}
 
-	for _, cr := range e.slottedCreds {
+	for _, name := range e.slots.Names() {
+		cr, ok := e.getCreds(name)
+		if !ok {
+			// deleted since we got the list of names
+			continue
+		}
 		if cr.Creds.Expired() {
 			log.Error("Skipping expired creds", "profile", cr.ProfileName)
 			continue
@@ -144,10 +221,18 @@ func (e *EcsServer) ListSlottedCreds() []ecs.ListProfilesResponse {
 	return resp
 }
 
+// sslEnabled returns if we have an SSL/TLS private key and certificate
+func (e *EcsServer) sslEnabled() bool {
+	return e.privateKey != "" && e.certChain != ""
+}
+
 // BaseURL returns our the base URL for all requests
 func (e *EcsServer) BaseURL() string {
+	if e.listener.Addr().Network() == "unix" {
+		return ecs.UNIX_SOCKET_PREFIX + e.listener.Addr().String()
+	}
 	proto := "http"
-	if e.privateKey != "" && e.certChain != "" {
+	if e.sslEnabled() {
 		proto = "https"
 	}
 	return fmt.Sprintf("%s://%s", proto, e.listener.Addr().String())
@@ -155,7 +240,12 @@ func (e *EcsServer) BaseURL() string {
 
 // Serve starts the sever and blocks
 func (e *EcsServer) Serve() error {
-	if e.privateKey != "" && e.certChain != "" {
+	// notify our event stream subscribers about expiring credentials
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go e.watchExpiration(ctx, EVENTS_EXPIRY_CHECK)
+
+	if e.sslEnabled() {
 		// Go sucks... have to pass the key and cert as _files_ not strings.  Why???
 		dname, err := os.MkdirTemp("", "aws-sso")
 		if err != nil {
@@ -190,6 +280,36 @@ func WithAuthorizationCheck(authToken string, next http.HandlerFunc) http.Handle
 	}
 }
 
+// withAuthorizationCheck is WithAuthorizationCheck which also accepts our scoped
+// tokens and counts failures in our metrics
+func (e *EcsServer) withAuthorizationCheck(authToken string, next http.HandlerFunc) http.HandlerFunc {
+	check := WithAuthorizationCheck(authToken, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		if scope, ok := e.tokens[auth]; ok {
+			if !scope.allows(r) {
+				e.metrics.authFailure()
+				ecs.WriteMessage(w, "Token is not authorized for this request", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// with only scoped tokens, requests without a token must still be rejected
+		if auth == "" && len(e.tokens) > 0 {
+			e.metrics.authFailure()
+			ecs.WriteMessage(w, "Invalid authorization token", http.StatusForbidden)
+			return
+		}
+
+		if auth != authToken {
+			e.metrics.authFailure()
+		}
+		check(w, r)
+	}
+}
+
 func (e *EcsServer) Close() {
 	e.server.Close()
 }
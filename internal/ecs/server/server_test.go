@@ -48,12 +48,25 @@ func newRequest(now time.Time) *ecs.ECSClientRequest {
 	}
 }
 
+// newTestEcsServer returns an EcsServer with an empty, non-persistent SlotRegistry
+func newTestEcsServer() *EcsServer {
+	return &EcsServer{
+		slots: NewSlotRegistry(context.Background(), nil),
+	}
+}
+
+// getSlot returns the request stored in the slot without any refresh
+func getSlot(t *testing.T, es *EcsServer, name string) *ecs.ECSClientRequest {
+	t.Helper()
+	cr, ok := es.slots.Get(name)
+	assert.True(t, ok)
+	return cr
+}
+
 func TestSlottedCreds(t *testing.T) {
 	now := time.Now().Add(95 * time.Second)
 	r := newRequest(now)
-	es := &EcsServer{
-		slottedCreds: map[string]*ecs.ECSClientRequest{},
-	}
+	es := newTestEcsServer()
 
 	// Add creds
 	err := es.PutSlottedCreds(r)
@@ -84,12 +97,12 @@ func TestSlottedCreds(t *testing.T) {
 	assert.Equal(t, r.ProfileName, list[0].ProfileName)
 
 	// manually add expired creds which should not be listed
-	es.slottedCreds["Expired"] = &ecs.ECSClientRequest{
+	es.slots.Put("Expired", &ecs.ECSClientRequest{
 		ProfileName: "expired",
 		Creds: &storage.RoleCredentials{
 			Expiration: now.UnixMilli(),
 		},
-	}
+	})
 	list = es.ListSlottedCreds()
 	assert.Equal(t, 1, len(list))
 
@@ -0,0 +1,178 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sync"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// DEFAULT_SLOT is the name of the slot which holds the default credentials
+const DEFAULT_SLOT = ""
+
+// SlotRegistry is a concurrency-safe registry of the credentials loaded into
+// the ECS Server.  Entries are never modified once stored; updates replace
+// them.  If a SecureStorage is configured, every change is persisted so the
+// slots survive a restart of the server.
+type SlotRegistry struct {
+	ctx   context.Context
+	lock  sync.RWMutex
+	slots map[string]*ecs.ECSClientRequest
+	// persistence happens outside of lock so a slow SecureStorage doesn't block
+	// readers.  storeLock serializes our access to store with anyone else using it.
+	store     storage.SecureStorage
+	storeLock *sync.Mutex
+}
+
+// NewSlotRegistry returns a new SlotRegistry.  store is optional and enables persistence.
+func NewSlotRegistry(ctx context.Context, store storage.SecureStorage) *SlotRegistry {
+	return &SlotRegistry{
+		ctx:       ctx,
+		slots:     map[string]*ecs.ECSClientRequest{},
+		store:     store,
+		storeLock: &sync.Mutex{},
+	}
+}
+
+// SetStore enables persistence via the SecureStorage and loads any previously
+// saved slots.  storeLock must be held by anything else which uses the store while
+// the registry is in use.  Must be called before the registry is shared between
+// goroutines.  Returns the number of slots restored.
+func (r *SlotRegistry) SetStore(store storage.SecureStorage, storeLock *sync.Mutex) int {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.store = store
+	r.storeLock = storeLock
+	cnt := 0
+	for _, name := range store.ListEcsSlots() {
+		slot := storage.EcsSlot{}
+		if err := store.GetEcsSlot(name, &slot); err != nil {
+			log.Warn("unable to restore ECS slot", "slot", name, "error", err.Error())
+			continue
+		}
+		creds := slot.Creds
+		r.slots[name] = &ecs.ECSClientRequest{
+			ProfileName: slot.ProfileName,
+			SSOName:     slot.SSOName,
+			Creds:       &creds,
+		}
+		cnt++
+	}
+	return cnt
+}
+
+// Get returns the request stored in the named slot
+func (r *SlotRegistry) Get(name string) (*ecs.ECSClientRequest, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	cr, ok := r.slots[name]
+	return cr, ok
+}
+
+// Put stores the request in the named slot
+func (r *SlotRegistry) Put(name string, cr *ecs.ECSClientRequest) {
+	r.lock.Lock()
+	r.slots[name] = cr
+	r.lock.Unlock()
+	r.persist(name)
+}
+
+// Replace stores cr in the named slot only if the slot still holds old.
+// Returns false if the slot has been changed or removed in the meantime.
+func (r *SlotRegistry) Replace(name string, old, cr *ecs.ECSClientRequest) bool {
+	r.lock.Lock()
+	if cur, ok := r.slots[name]; !ok || cur != old {
+		r.lock.Unlock()
+		return false
+	}
+	r.slots[name] = cr
+	r.lock.Unlock()
+	r.persist(name)
+	return true
+}
+
+// Delete removes the named slot.  Returns false if the slot does not exist.
+func (r *SlotRegistry) Delete(name string) bool {
+	_, ok := r.Remove(name)
+	return ok
+}
+
+// Remove removes the named slot and returns the request it held.  Returns
+// false if the slot does not exist.
+func (r *SlotRegistry) Remove(name string) (*ecs.ECSClientRequest, bool) {
+	r.lock.Lock()
+	cr, ok := r.slots[name]
+	if !ok {
+		r.lock.Unlock()
+		return nil, false
+	}
+	delete(r.slots, name)
+	r.lock.Unlock()
+	r.persist(name)
+	return cr, true
+}
+
+// Names returns the names of all the slots other than the default
+func (r *SlotRegistry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	ret := make([]string, 0, len(r.slots))
+	for name := range r.slots {
+		if name != DEFAULT_SLOT {
+			ret = append(ret, name)
+		}
+	}
+	return ret
+}
+
+// persist saves the current contents of the named slot or deletes it if the slot
+// no longer exists.  Must be called without the registry lock held.  As the slot is
+// re-read while holding storeLock, concurrent changes can't be persisted out of order.
+func (r *SlotRegistry) persist(name string) {
+	if r.store == nil {
+		return
+	}
+	r.storeLock.Lock()
+	defer r.storeLock.Unlock()
+
+	cr, ok := r.Get(name)
+	if !ok {
+		if err := r.store.DeleteEcsSlot(r.ctx, name); err != nil {
+			log.Warn("unable to delete persisted ECS slot", "slot", name, "error", err.Error())
+		}
+		return
+	}
+	if cr.Creds == nil {
+		return
+	}
+	slot := storage.EcsSlot{
+		ProfileName: cr.ProfileName,
+		SSOName:     cr.SSOName,
+		Creds:       *cr.Creds,
+	}
+	if err := r.store.SaveEcsSlot(r.ctx, name, slot); err != nil {
+		log.Warn("unable to persist ECS slot", "slot", name, "error", err.Error())
+	}
+}
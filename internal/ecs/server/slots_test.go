@@ -0,0 +1,254 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"golang.org/x/net/nettest"
+)
+
+func TestSlotRegistry(t *testing.T) {
+	r := NewSlotRegistry(context.Background(), nil)
+
+	_, ok := r.Get("1234:FooBar")
+	assert.False(t, ok)
+
+	req := newRequest(time.Now().Add(1 * time.Hour))
+	r.Put(req.ProfileName, req)
+	r.Put(DEFAULT_SLOT, req)
+
+	cr, ok := r.Get("1234:FooBar")
+	assert.True(t, ok)
+	assert.Same(t, req, cr)
+
+	// the default slot is not listed
+	assert.Equal(t, []string{"1234:FooBar"}, r.Names())
+
+	// Replace only succeeds if the slot has not changed
+	req2 := newRequest(time.Now().Add(2 * time.Hour))
+	assert.True(t, r.Replace("1234:FooBar", req, req2))
+	assert.False(t, r.Replace("1234:FooBar", req, req))
+	assert.False(t, r.Replace("Missing", nil, req))
+	cr, _ = r.Get("1234:FooBar")
+	assert.Same(t, req2, cr)
+
+	assert.True(t, r.Delete("1234:FooBar"))
+	assert.False(t, r.Delete("1234:FooBar"))
+	assert.Empty(t, r.Names())
+}
+
+func TestSlotRegistryPersistence(t *testing.T) {
+	ctx := context.Background()
+	storeFile := filepath.Join(t.TempDir(), "store.json")
+	store, err := storage.OpenJsonStore(ctx, storeFile)
+	require.NoError(t, err)
+
+	es := newTestEcsServer()
+	es.EnablePersistence(store, &sync.Mutex{})
+
+	foo := newRequest(time.Now().Add(1 * time.Hour))
+	foo.SSOName = "Default"
+	assert.NoError(t, es.PutSlottedCreds(foo))
+	bar := newRequest(time.Now().Add(1 * time.Hour))
+	bar.ProfileName = "1234:Bar"
+	assert.NoError(t, es.PutSlottedCreds(bar))
+	assert.NoError(t, es.DeleteSlottedCreds("1234:Bar"))
+	es.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+
+	// "restart" the server with a freshly opened store
+	store, err = storage.OpenJsonStore(ctx, storeFile)
+	require.NoError(t, err)
+	es2 := newTestEcsServer()
+	es2.EnablePersistence(store, &sync.Mutex{})
+
+	cr, err := es2.GetSlottedCreds("1234:FooBar")
+	assert.NoError(t, err)
+	assert.Equal(t, "Default", cr.SSOName)
+	assert.Equal(t, *foo.Creds, *cr.Creds)
+
+	_, err = es2.GetSlottedCreds("1234:Bar")
+	assert.Error(t, err)
+
+	assert.Equal(t, "1234:FooBar", es2.GetDefaultCreds().ProfileName)
+	assert.Equal(t, 1, len(es2.ListSlottedCreds()))
+
+	// deleting the default is persisted too
+	assert.NoError(t, es2.DeleteDefaultCreds())
+	assert.Equal(t, []string{"1234:FooBar"}, store.ListEcsSlots())
+}
+
+// exclusiveStore fails the test if it is used by more than one goroutine at a time
+type exclusiveStore struct {
+	storage.SecureStorage
+	t     *testing.T
+	inUse atomic.Bool
+}
+
+func (s *exclusiveStore) enter() {
+	if !s.inUse.CompareAndSwap(false, true) {
+		s.t.Error("concurrent use of the SecureStorage")
+	}
+	time.Sleep(100 * time.Microsecond) // widen the window for overlapping calls
+}
+
+func (s *exclusiveStore) SaveEcsSlot(ctx context.Context, name string, slot storage.EcsSlot) error {
+	s.enter()
+	defer s.inUse.Store(false)
+	return s.SecureStorage.SaveEcsSlot(ctx, name, slot)
+}
+
+func (s *exclusiveStore) DeleteEcsSlot(ctx context.Context, name string) error {
+	s.enter()
+	defer s.inUse.Store(false)
+	return s.SecureStorage.DeleteEcsSlot(ctx, name)
+}
+
+func (s *exclusiveStore) SaveRoleCredentials(ctx context.Context, arn string, creds storage.RoleCredentials) error {
+	s.enter()
+	defer s.inUse.Store(false)
+	return s.SecureStorage.SaveRoleCredentials(ctx, arn, creds)
+}
+
+// countingFetcher caches the credentials it returns in the store like the real
+// fetcher does, so shares the store lock with the server
+type countingFetcher struct {
+	calls     atomic.Int64
+	store     storage.SecureStorage
+	storeLock *sync.Mutex
+}
+
+func (c *countingFetcher) GetRoleCredentials(ssoName string, accountId int64, role string) (*storage.RoleCredentials, error) {
+	c.calls.Add(1)
+	creds := storage.RoleCredentials{
+		AccountId:       accountId,
+		RoleName:        role,
+		AccessKeyId:     "RefreshedAccessKeyId",
+		SecretAccessKey: "RefreshedSecretAccessKey",
+		SessionToken:    "RefreshedSessionToken",
+		Expiration:      time.Now().Add(1 * time.Hour).UnixMilli(),
+	}
+	c.storeLock.Lock()
+	defer c.storeLock.Unlock()
+	if err := c.store.SaveRoleCredentials(context.Background(), creds.RoleArn(), creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+// TestSlotRegistryConcurrent hammers the server with parallel PUT/GET/DELETE
+// requests with both persistence and auto-refresh writing to the same store.
+// Run with -race to detect unsafe access.
+func TestSlotRegistryConcurrent(t *testing.T) {
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.Background(), "", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+
+	ctx := context.Background()
+	jstore, err := storage.OpenJsonStore(ctx, filepath.Join(t.TempDir(), "store.json"))
+	require.NoError(t, err)
+	store := &exclusiveStore{SecureStorage: jstore, t: t}
+	storeLock := &sync.Mutex{}
+	s.EnablePersistence(store, storeLock)
+
+	// every slot is about to expire, so every GET races a refresh
+	fetcher := &countingFetcher{store: store, storeLock: storeLock}
+	s.EnableRefresh(fetcher, 10*time.Minute)
+
+	go func() { _ = s.Serve() }()
+	baseURL := s.BaseURL()
+
+	client := &http.Client{}
+	do := func(method, url string, body []byte) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+		if !assert.NoError(t, err) {
+			return
+		}
+		req.Header.Set("Content-Type", ecs.CHARSET_JSON)
+		resp, err := client.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	const workers = 8
+	const loops = 25
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < loops; i++ {
+				slot := fmt.Sprintf("%d:Role%d", w%3, i%4)
+				cr := newRequest(time.Now().Add(5 * time.Minute))
+				cr.ProfileName = slot
+				j, err := json.Marshal(cr)
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				do(http.MethodPut, fmt.Sprintf("%s%s/%s", baseURL, ecs.SLOT_ROUTE, slot), j)
+				do(http.MethodPut, baseURL+ecs.DEFAULT_ROUTE, j)
+				do(http.MethodGet, fmt.Sprintf("%s%s/%s", baseURL, ecs.SLOT_ROUTE, slot), nil)
+				do(http.MethodGet, baseURL+ecs.SLOT_ROUTE, nil)
+				do(http.MethodGet, baseURL+ecs.DEFAULT_ROUTE, nil)
+				do(http.MethodGet, baseURL+ecs.PROFILE_ROUTE, nil)
+				do(http.MethodGet, fmt.Sprintf("%s%s/slot/%s", baseURL, ecs.HEALTHCHECK_ROUTE, slot), nil)
+				if i%3 == 0 {
+					do(http.MethodDelete, fmt.Sprintf("%s%s/%s", baseURL, ecs.SLOT_ROUTE, slot), nil)
+					do(http.MethodDelete, baseURL+ecs.DEFAULT_ROUTE, nil)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	assert.Greater(t, fetcher.calls.Load(), int64(0))
+
+	// whatever is left must be consistent with the persisted copy
+	for _, name := range s.slots.Names() {
+		cr, ok := s.slots.Get(name)
+		assert.True(t, ok)
+		slot := storage.EcsSlot{}
+		assert.NoError(t, store.GetEcsSlot(name, &slot))
+		assert.Equal(t, *cr.Creds, slot.Creds)
+	}
+	// and nothing which was deleted is left behind
+	for _, name := range store.ListEcsSlots() {
+		_, ok := s.slots.Get(name)
+		assert.True(t, ok, name)
+	}
+	assert.NotEmpty(t, store.ListRoleCredentials())
+}
@@ -57,6 +57,9 @@ func (p *SlottedHandler) Get(w http.ResponseWriter, r *http.Request) {
 			ecs.Unavailable(w)
 			return
 		}
+		if !creds.Creds.Expired() {
+			p.ecs.credentialsVended(r, profile, creds)
+		}
 		ecs.WriteCreds(w, creds.Creds)
 	}
 }
@@ -36,22 +36,19 @@ import (
 func TestSlottedGet(t *testing.T) {
 	soon := time.Now().Add(90 * time.Second)
 	sh := SlottedHandler{
-		ecs: &EcsServer{
-			slottedCreds: map[string]*ecs.ECSClientRequest{
-				"Example": {
-					ProfileName: "Example",
-					Creds: &storage.RoleCredentials{
-						RoleName:        "ProfileName",
-						AccountId:       1111111,
-						AccessKeyId:     "AccessKeyId",
-						SecretAccessKey: "SecretAccessKey",
-						SessionToken:    "SessionToken",
-						Expiration:      soon.UnixMilli(),
-					},
-				},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
+	sh.ecs.slots.Put("Example", &ecs.ECSClientRequest{
+		ProfileName: "Example",
+		Creds: &storage.RoleCredentials{
+			RoleName:        "ProfileName",
+			AccountId:       1111111,
+			AccessKeyId:     "AccessKeyId",
+			SecretAccessKey: "SecretAccessKey",
+			SessionToken:    "SessionToken",
+			Expiration:      soon.UnixMilli(),
+		},
+	})
 	ts := httptest.NewServer(&sh)
 	defer ts.Close()
 
@@ -97,9 +94,7 @@ func submitSlotRequest(t *testing.T, url string, cr ecs.ECSClientRequest) (*http
 
 func TestSlottedPut(t *testing.T) {
 	sh := SlottedHandler{
-		ecs: &EcsServer{
-			slottedCreds: map[string]*ecs.ECSClientRequest{},
-		},
+		ecs: newTestEcsServer(),
 	}
 	ts := httptest.NewServer(&sh)
 	defer ts.Close()
@@ -138,15 +133,12 @@ func TestSlottedPut(t *testing.T) {
 
 func TestSlottedDelete(t *testing.T) {
 	sh := SlottedHandler{
-		ecs: &EcsServer{
-			slottedCreds: map[string]*ecs.ECSClientRequest{
-				"Foo": {
-					ProfileName: "Foo",
-					Creds:       &storage.RoleCredentials{},
-				},
-			},
-		},
+		ecs: newTestEcsServer(),
 	}
+	sh.ecs.slots.Put("Foo", &ecs.ECSClientRequest{
+		ProfileName: "Foo",
+		Creds:       &storage.RoleCredentials{},
+	})
 	ts := httptest.NewServer(&sh)
 	defer ts.Close()
 
@@ -160,7 +152,7 @@ func TestSlottedDelete(t *testing.T) {
 	resp, err := client.Do(req) // nolint:gosec
 	assert.NoError(t, err)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	_, ok := sh.ecs.slottedCreds["Foo"]
+	_, ok := sh.ecs.slots.Get("Foo")
 	assert.False(t, ok)
 
 	// can't delete again
@@ -15,3 +15,27 @@ openssl req -x509 -out localhost.crt -keyout localhost.key -days 3065 \
   -newkey rsa:2048 -nodes -sha256 -subj '/CN=localhost' -extensions EXT -config config.ssl
 
 rm config.ssl
+
+# CA and client certificate for testing mutual TLS
+cat <<-EOF > config.ssl
+[dn]
+CN=aws-sso client
+[req]
+distinguished_name = dn
+[CA]
+basicConstraints=critical,CA:TRUE
+keyUsage=critical,keyCertSign,cRLSign
+[CLIENT]
+keyUsage=digitalSignature
+extendedKeyUsage=clientAuth
+EOF
+
+openssl req -x509 -out client-ca.crt -keyout client-ca.key -days 3065 \
+  -newkey rsa:2048 -nodes -sha256 -subj '/CN=aws-sso test client CA' -extensions CA -config config.ssl
+
+openssl req -out client.csr -keyout client.key \
+  -newkey rsa:2048 -nodes -sha256 -subj '/CN=aws-sso client' -config config.ssl
+openssl x509 -req -in client.csr -out client.crt -days 3065 -sha256 \
+  -CA client-ca.crt -CAkey client-ca.key -CAcreateserial -extensions CLIENT -extfile config.ssl
+
+rm config.ssl client.csr client-ca.srl
@@ -0,0 +1,64 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+)
+
+// TokenScope limits what a bearer token is allowed to access
+type TokenScope struct {
+	Admin bool   // load, unload and read the credentials in every slot
+	Slot  string // otherwise only read the credentials in this slot
+}
+
+// allows returns if the scope permits the request
+func (s TokenScope) allows(r *http.Request) bool {
+	if s.Admin {
+		return true
+	}
+	return r.Method == http.MethodGet &&
+		strings.HasPrefix(r.URL.Path, ecs.SLOT_ROUTE+"/") &&
+		GetProfileName(r.URL) == s.Slot
+}
+
+// AddToken accepts an additional bearer token which is limited to the given
+// scope.  Must be called before Serve().
+func (e *EcsServer) AddToken(token string, scope TokenScope) error {
+	if token == "" {
+		return fmt.Errorf("token must not be empty")
+	}
+	if !scope.Admin && scope.Slot == "" {
+		return fmt.Errorf("token must be an admin token or be limited to a slot")
+	}
+	if e.tokens == nil {
+		e.tokens = map[string]TokenScope{}
+	}
+	e.tokens["Bearer "+token] = scope
+	return nil
+}
+
+// AuthEnabled returns if clients must send a bearer token
+func (e *EcsServer) AuthEnabled() bool {
+	return e.authToken != "" || len(e.tokens) > 0
+}
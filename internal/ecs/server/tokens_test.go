@@ -0,0 +1,171 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"golang.org/x/net/nettest"
+)
+
+// newTestTokenServer returns a running EcsServer with the default credentials
+// and the SlotProfile & OtherProfile slots loaded
+func newTestTokenServer(t *testing.T, authToken string, tokens map[string]TokenScope) *EcsServer {
+	t.Helper()
+	l, err := nettest.NewLocalListener("tcp")
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.TODO(), authToken, l, "", "")
+	require.NoError(t, err)
+	for token, scope := range tokens {
+		require.NoError(t, s.AddToken(token, scope))
+	}
+	t.Cleanup(s.Close)
+
+	s.SetDefaultCreds(newRequest(time.Now().Add(1 * time.Hour)))
+	for _, name := range []string{"SlotProfile", "OtherProfile"} {
+		slot := newRequest(time.Now().Add(1 * time.Hour))
+		slot.ProfileName = name
+		require.NoError(t, s.PutSlottedCreds(slot))
+	}
+
+	go func() {
+		_ = s.Serve()
+	}()
+	return s
+}
+
+func TestAddToken(t *testing.T) {
+	es := newTestEcsServer()
+	assert.False(t, es.AuthEnabled())
+
+	assert.Error(t, es.AddToken("", TokenScope{Admin: true}))
+	assert.Error(t, es.AddToken("token", TokenScope{}))
+	assert.False(t, es.AuthEnabled())
+
+	assert.NoError(t, es.AddToken("token", TokenScope{Slot: "SlotProfile"}))
+	assert.True(t, es.AuthEnabled())
+	assert.Equal(t, TokenScope{Slot: "SlotProfile"}, es.tokens["Bearer token"])
+}
+
+func TestTokenScopeAllows(t *testing.T) {
+	admin := TokenScope{Admin: true}
+	slot := TokenScope{Slot: "1234:FooBar"}
+
+	tests := []struct {
+		method string
+		path   string
+		slot   bool
+	}{
+		{http.MethodGet, "/slot/1234:FooBar", true},
+		{http.MethodGet, "/slot/1234%3AFooBar", true},
+		{http.MethodPut, "/slot/1234:FooBar", false},
+		{http.MethodDelete, "/slot/1234:FooBar", false},
+		{http.MethodGet, "/slot/Other", false},
+		{http.MethodGet, "/slot", false},
+		{http.MethodGet, "/slot/", false},
+		{http.MethodGet, "/", false},
+		{http.MethodPut, "/", false},
+		{http.MethodGet, "/profile", false},
+	}
+	for _, test := range tests {
+		r, err := http.NewRequest(test.method, "http://localhost"+test.path, nil)
+		require.NoError(t, err)
+		assert.True(t, admin.allows(r), "%s %s", test.method, test.path)
+		assert.Equal(t, test.slot, slot.allows(r), "%s %s", test.method, test.path)
+	}
+}
+
+func TestServerScopedTokens(t *testing.T) {
+	s := newTestTokenServer(t, "AuthToken", map[string]TokenScope{
+		"AdminToken": {Admin: true},
+		"SlotToken":  {Slot: "SlotProfile"},
+	})
+	base := s.BaseURL()
+	bearer := func(token string) map[string]string {
+		return map[string]string{"Authorization": "Bearer " + token}
+	}
+
+	// the default token still has full access
+	code, _ := imdsRequest(t, http.MethodGet, base+ecs.DEFAULT_ROUTE, bearer("AuthToken"))
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE, bearer("AuthToken"))
+	assert.Equal(t, http.StatusOK, code)
+
+	// as do admin tokens
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/OtherProfile", bearer("AdminToken"))
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = imdsRequest(t, http.MethodDelete, base+ecs.SLOT_ROUTE+"/OtherProfile", bearer("AdminToken"))
+	assert.Equal(t, http.StatusOK, code)
+
+	// slot tokens can only read their own slot
+	code, body := imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile", bearer("SlotToken"))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "AccessKeyId")
+
+	for _, test := range []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, ecs.DEFAULT_ROUTE},
+		{http.MethodGet, ecs.PROFILE_ROUTE},
+		{http.MethodGet, ecs.SLOT_ROUTE},
+		{http.MethodGet, ecs.SLOT_ROUTE + "/OtherProfile"},
+		{http.MethodDelete, ecs.SLOT_ROUTE + "/SlotProfile"},
+	} {
+		code, body = imdsRequest(t, test.method, base+test.path, bearer("SlotToken"))
+		assert.Equal(t, http.StatusForbidden, code, "%s %s", test.method, test.path)
+		assert.Contains(t, body, "Token is not authorized for this request")
+	}
+
+	// the slot was not deleted
+	_, err := s.GetSlottedCreds("SlotProfile")
+	assert.NoError(t, err)
+
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile", bearer("WrongToken"))
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+}
+
+func TestServerOnlyScopedTokens(t *testing.T) {
+	// without a default token, requests still need one of the scoped tokens
+	s := newTestTokenServer(t, "", map[string]TokenScope{
+		"SlotToken": {Slot: "SlotProfile"},
+	})
+	base := s.BaseURL()
+
+	code, _ := imdsRequest(t, http.MethodGet, base+ecs.DEFAULT_ROUTE, nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile", nil)
+	assert.Equal(t, http.StatusForbidden, code)
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.SLOT_ROUTE+"/SlotProfile",
+		map[string]string{"Authorization": "Bearer SlotToken"})
+	assert.Equal(t, http.StatusOK, code)
+
+	// the healthcheck never needs a token
+	code, _ = imdsRequest(t, http.MethodGet, base+ecs.HEALTHCHECK_ROUTE, nil)
+	assert.Equal(t, http.StatusOK, code)
+}
@@ -0,0 +1,46 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+)
+
+// UNIX_SOCKET_MODE only allows the owner to connect to the socket
+const UNIX_SOCKET_MODE os.FileMode = 0600
+
+// NewUnixListener listens on a Unix domain socket at the given path which is
+// only accessible to the current user.  A stale socket left behind by a
+// previous ECS Server is removed, but any other file is left alone.
+func NewUnixListener(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unable to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	return fileutils.ListenUnix(path, UNIX_SOCKET_MODE)
+}
@@ -0,0 +1,91 @@
+package server
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs"
+	"github.com/synfinatic/aws-sso-cli/internal/ecs/client"
+)
+
+func TestNewUnixListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ecs.sock")
+
+	l, err := NewUnixListener(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, UNIX_SOCKET_MODE, info.Mode().Perm())
+	l.Close()
+
+	// a stale socket is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	require.NoError(t, err)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	l, err = NewUnixListener(path)
+	require.NoError(t, err)
+	l.Close()
+
+	// never remove other files
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, []byte("data"), 0600))
+	_, err = NewUnixListener(file)
+	assert.ErrorContains(t, err, "is not a socket")
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ecs.sock")
+	l, err := NewUnixListener(path)
+	require.NoError(t, err)
+
+	s, err := NewEcsServer(context.TODO(), "token", l, "", "")
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Equal(t, ecs.UNIX_SOCKET_PREFIX+path, s.BaseURL())
+
+	go func() {
+		_ = s.Serve()
+	}()
+
+	c := client.NewECSClient(s.BaseURL(), "token", "")
+	req := newRequest(time.Now().Add(time.Hour))
+	require.NoError(t, c.SubmitCreds(req.Creds, req.ProfileName, "", true))
+
+	profiles, err := c.ListProfiles()
+	require.NoError(t, err)
+	require.Len(t, profiles, 1)
+	assert.Equal(t, req.ProfileName, profiles[0].ProfileName)
+
+	// bearer token is still required
+	c = client.NewECSClient(s.BaseURL(), "", "")
+	_, err = c.ListProfiles()
+	assert.Error(t, err)
+}
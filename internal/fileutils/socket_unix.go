@@ -0,0 +1,81 @@
+//go:build !windows
+
+package fileutils
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// ListenUnix listens on a Unix domain socket at the given path with the given
+// permissions.  The socket is created in a private directory and only linked
+// into place once its permissions are set, so nobody else can connect to it in
+// the meantime.  Like net.Listen, this fails if the path already exists.
+func ListenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	// MkdirTemp creates the directory with 0700
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".sock")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	tmpPath := filepath.Join(dir, "s")
+	l, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// we remove the socket at path instead
+	l.SetUnlinkOnClose(false)
+
+	if err = os.Chmod(tmpPath, mode); err == nil {
+		err = os.Link(tmpPath, path)
+	}
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+
+	return &unixListener{
+		UnixListener: l,
+		addr:         &net.UnixAddr{Name: path, Net: "unix"},
+	}, nil
+}
+
+// unixListener is a net.UnixListener whose socket was moved to addr
+type unixListener struct {
+	*net.UnixListener
+	addr *net.UnixAddr
+}
+
+// Addr returns the address of our socket
+func (l *unixListener) Addr() net.Addr {
+	return l.addr
+}
+
+// Close stops listening and removes our socket
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	if rmErr := os.Remove(l.addr.Name); err == nil && !os.IsNotExist(rmErr) {
+		err = rmErr
+	}
+	return err
+}
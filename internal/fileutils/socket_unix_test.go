@@ -0,0 +1,56 @@
+//go:build !windows
+
+package fileutils
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Chmod(dir, 0755))
+	path := filepath.Join(dir, "test.sock")
+
+	l, err := ListenUnix(path, 0600)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket, info.Mode().Type())
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	assert.Equal(t, path, l.Addr().String())
+	assert.Equal(t, "unix", l.Addr().Network())
+
+	// our private directory is gone
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// we can connect via path
+	go func() {
+		if c, err := l.Accept(); err == nil {
+			c.Close()
+		}
+	}()
+	c, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	c.Close()
+
+	// never replace an existing file
+	_, err = ListenUnix(path, 0600)
+	assert.Error(t, err)
+
+	// closing removes the socket
+	require.NoError(t, l.Close())
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
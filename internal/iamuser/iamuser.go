@@ -0,0 +1,212 @@
+package iamuser
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/synfinatic/aws-sso-cli/internal/awsendpoint"
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+const SESSION_DURATION = 3600 // seconds for sts:GetSessionToken credentials
+
+// how long we wait for a new access key to be usable
+var accessKeyTimeout = 30 * time.Second
+var accessKeyInterval = 2 * time.Second
+
+// Client makes STS and IAM API calls using the StaticCredentials of an IAM User
+type Client struct {
+	region   string
+	endpoint string
+}
+
+// NewClient returns a new Client for the given region.  endpoint overrides
+// the STS and IAM endpoints and should be empty except for testing.
+func NewClient(region, endpoint string) *Client {
+	return &Client{
+		region:   region,
+		endpoint: endpoint,
+	}
+}
+
+// Identify returns the StaticCredentials for the given access key after
+// verifying it belongs to an IAM User via sts:GetCallerIdentity
+func (c *Client) Identify(ctx context.Context, accessKeyId, secretAccessKey string) (storage.StaticCredentials, error) {
+	creds := storage.StaticCredentials{
+		AccessKeyId:     accessKeyId,
+		SecretAccessKey: secretAccessKey,
+	}
+
+	stsSession, err := c.sts(ctx, creds)
+	if err != nil {
+		return creds, err
+	}
+
+	output, err := stsSession.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return creds, err
+	}
+	log.Debug("sts.GetCallerIdentity", "arn", aws.ToString(output.Arn))
+
+	arn := aws.ToString(output.Arn)
+	if !awsparse.IsUserARN(arn) {
+		return creds, fmt.Errorf("%s is not an IAM User", arn)
+	}
+
+	s := strings.Split(arn, ":")
+	creds.Partition = s[1]
+	if creds.AccountId, err = awsparse.AccountIdToInt64(s[4]); err != nil {
+		return creds, err
+	}
+	// drop any IAM path from the user name
+	creds.UserName = s[5][strings.LastIndex(s[5], "/")+1:]
+	return creds, nil
+}
+
+// SessionCredentials returns temporary credentials for the IAM User via sts:GetSessionToken
+func (c *Client) SessionCredentials(ctx context.Context, creds storage.StaticCredentials) (storage.RoleCredentials, error) {
+	stsSession, err := c.sts(ctx, creds)
+	if err != nil {
+		return storage.RoleCredentials{}, err
+	}
+
+	input := sts.GetSessionTokenInput{
+		DurationSeconds: aws.Int32(SESSION_DURATION),
+	}
+	output, err := stsSession.GetSessionToken(ctx, &input)
+	if err != nil {
+		return storage.RoleCredentials{}, err
+	}
+	log.Debug("sts.GetSessionToken", "accessKeyId", aws.ToString(output.Credentials.AccessKeyId),
+		"expiration", aws.ToTime(output.Credentials.Expiration))
+
+	return storage.RoleCredentials{
+		AccountId:       creds.AccountId,
+		RoleName:        creds.UserName,
+		AccessKeyId:     aws.ToString(output.Credentials.AccessKeyId),
+		SecretAccessKey: aws.ToString(output.Credentials.SecretAccessKey),
+		SessionToken:    aws.ToString(output.Credentials.SessionToken),
+		Expiration:      aws.ToTime(output.Credentials.Expiration).UnixMilli(),
+		Partition:       creds.Partition,
+		IAMUser:         true,
+	}, nil
+}
+
+// CreateAccessKey creates a new access key for the IAM User and returns a
+// copy of creds using it once it is usable.  The existing access key is
+// left alone; see DeleteAccessKey.
+func (c *Client) CreateAccessKey(ctx context.Context, creds storage.StaticCredentials) (storage.StaticCredentials, error) {
+	iamSession, err := c.iam(ctx, creds)
+	if err != nil {
+		return creds, err
+	}
+
+	output, err := iamSession.CreateAccessKey(ctx, &iam.CreateAccessKeyInput{
+		UserName: aws.String(creds.UserName),
+	})
+	if err != nil {
+		return creds, err
+	}
+	log.Debug("iam.CreateAccessKey", "accessKeyId", aws.ToString(output.AccessKey.AccessKeyId))
+
+	newCreds := creds
+	newCreds.AccessKeyId = aws.ToString(output.AccessKey.AccessKeyId)
+	newCreds.SecretAccessKey = aws.ToString(output.AccessKey.SecretAccessKey)
+
+	// IAM is eventually consistent, so new keys may not work right away
+	deadline := time.Now().Add(accessKeyTimeout)
+	for {
+		if _, err = c.Identify(ctx, newCreds.AccessKeyId, newCreds.SecretAccessKey); err == nil {
+			return newCreds, nil
+		}
+		if time.Now().After(deadline) || ctx.Err() != nil {
+			break
+		}
+		log.Debug("waiting for new access key", "accessKeyId", newCreds.AccessKeyId, "error", err.Error())
+		time.Sleep(accessKeyInterval)
+	}
+
+	// don't leave behind a key nobody knows about
+	if delErr := c.DeleteAccessKey(ctx, creds, newCreds.AccessKeyId); delErr != nil {
+		log.Warn("Unable to delete new access key", "accessKeyId", newCreds.AccessKeyId, "error", delErr.Error())
+	}
+	return creds, fmt.Errorf("new access key %s is not usable: %w", newCreds.AccessKeyId, err)
+}
+
+// DeleteAccessKey deletes the given access key of the IAM User using creds
+func (c *Client) DeleteAccessKey(ctx context.Context, creds storage.StaticCredentials, accessKeyId string) error {
+	iamSession, err := c.iam(ctx, creds)
+	if err != nil {
+		return err
+	}
+
+	_, err = iamSession.DeleteAccessKey(ctx, &iam.DeleteAccessKeyInput{
+		AccessKeyId: aws.String(accessKeyId),
+		UserName:    aws.String(creds.UserName),
+	})
+	return err
+}
+
+// config returns an aws.Config using the given StaticCredentials
+func (c *Client) config(ctx context.Context, creds storage.StaticCredentials) (aws.Config, error) {
+	return awsconfig.LoadDefaultConfig(ctx,
+		awsconfig.WithRegion(c.region),
+		awsconfig.WithCredentialsProvider(
+			credentials.NewStaticCredentialsProvider(creds.AccessKeyId, creds.SecretAccessKey, ""),
+		),
+	)
+}
+
+func (c *Client) sts(ctx context.Context, creds storage.StaticCredentials) (*sts.Client, error) {
+	cfg, err := c.config(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	return sts.NewFromConfig(cfg, func(o *sts.Options) {
+		if c.endpoint != "" {
+			o.BaseEndpoint = aws.String(c.endpoint)
+		}
+		o.EndpointOptions.UseFIPSEndpoint = awsendpoint.FipsEndpointState()
+		o.EndpointOptions.UseDualStackEndpoint = awsendpoint.DualStackEndpointState()
+	}), nil
+}
+
+func (c *Client) iam(ctx context.Context, creds storage.StaticCredentials) (*iam.Client, error) {
+	cfg, err := c.config(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+	return iam.NewFromConfig(cfg, func(o *iam.Options) {
+		if c.endpoint != "" {
+			o.BaseEndpoint = aws.String(c.endpoint)
+		}
+		o.EndpointOptions.UseFIPSEndpoint = awsendpoint.FipsEndpointState()
+		o.EndpointOptions.UseDualStackEndpoint = awsendpoint.DualStackEndpointState()
+	}), nil
+}
@@ -0,0 +1,245 @@
+package iamuser
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+const getCallerIdentityXML = `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>%s</Arn>
+    <UserId>AIDATEST</UserId>
+    <Account>123456789012</Account>
+  </GetCallerIdentityResult>
+  <ResponseMetadata><RequestId>test-req-id</RequestId></ResponseMetadata>
+</GetCallerIdentityResponse>`
+
+const getSessionTokenXML = `<GetSessionTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetSessionTokenResult>
+    <Credentials>
+      <AccessKeyId>ASIASESSION</AccessKeyId>
+      <SecretAccessKey>session-secret</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </GetSessionTokenResult>
+  <ResponseMetadata><RequestId>test-req-id</RequestId></ResponseMetadata>
+</GetSessionTokenResponse>`
+
+const createAccessKeyXML = `<CreateAccessKeyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <CreateAccessKeyResult>
+    <AccessKey>
+      <UserName>Alice</UserName>
+      <AccessKeyId>AKIANEW</AccessKeyId>
+      <Status>Active</Status>
+      <SecretAccessKey>new-secret</SecretAccessKey>
+      <CreateDate>2026-01-01T00:00:00Z</CreateDate>
+    </AccessKey>
+  </CreateAccessKeyResult>
+  <ResponseMetadata><RequestId>test-req-id</RequestId></ResponseMetadata>
+</CreateAccessKeyResponse>`
+
+const deleteAccessKeyXML = `<DeleteAccessKeyResponse xmlns="https://iam.amazonaws.com/doc/2010-05-08/">
+  <ResponseMetadata><RequestId>test-req-id</RequestId></ResponseMetadata>
+</DeleteAccessKeyResponse>`
+
+const errorXML = `<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>bad key</Message></Error><RequestId>test-req-id</RequestId></ErrorResponse>`
+
+// mockAWS is a fake STS & IAM endpoint which only accepts requests signed
+// with the active access keys
+type mockAWS struct {
+	lock    sync.Mutex
+	arn     string
+	keys    map[string]bool // access key id => active
+	calls   []string        // Action:AccessKeyId
+	deleted []string
+}
+
+func newMockAWS(t *testing.T, arn string, keys ...string) (*mockAWS, *httptest.Server) {
+	t.Helper()
+	m := &mockAWS{
+		arn:  arn,
+		keys: map[string]bool{},
+	}
+	for _, k := range keys {
+		m.keys[k] = true
+	}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	return m, srv
+}
+
+func (m *mockAWS) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	_ = r.ParseForm()
+	action := r.Form.Get("Action")
+	keyId := ""
+	auth := r.Header.Get("Authorization")
+	if i := strings.Index(auth, "Credential="); i >= 0 {
+		keyId = strings.Split(auth[i+len("Credential="):], "/")[0]
+	}
+	m.calls = append(m.calls, action+":"+keyId)
+
+	w.Header().Set("Content-Type", "text/xml")
+	if !m.keys[keyId] {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, errorXML)
+		return
+	}
+
+	switch action {
+	case "GetCallerIdentity":
+		fmt.Fprintf(w, getCallerIdentityXML, m.arn)
+	case "GetSessionToken":
+		fmt.Fprint(w, getSessionTokenXML)
+	case "CreateAccessKey":
+		m.keys["AKIANEW"] = true
+		fmt.Fprint(w, createAccessKeyXML)
+	case "DeleteAccessKey":
+		delete(m.keys, r.Form.Get("AccessKeyId"))
+		m.deleted = append(m.deleted, r.Form.Get("AccessKeyId"))
+		fmt.Fprint(w, deleteAccessKeyXML)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, errorXML)
+	}
+}
+
+func TestIdentify(t *testing.T) {
+	_, srv := newMockAWS(t, "arn:aws-us-gov:iam::123456789012:user/admins/Alice", "AKIAOLD")
+	c := NewClient("us-gov-west-1", srv.URL)
+
+	creds, err := c.Identify(context.Background(), "AKIAOLD", "old-secret")
+	require.NoError(t, err)
+	assert.Equal(t, storage.StaticCredentials{
+		UserName:        "Alice",
+		AccountId:       123456789012,
+		AccessKeyId:     "AKIAOLD",
+		SecretAccessKey: "old-secret",
+		Partition:       "aws-us-gov",
+	}, creds)
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:user/Alice", creds.UserArn())
+
+	_, err = c.Identify(context.Background(), "AKIABAD", "bad-secret")
+	assert.Error(t, err)
+
+	// not an IAM User
+	_, srv = newMockAWS(t, "arn:aws:sts::123456789012:assumed-role/Foo/bar", "AKIAOLD")
+	c = NewClient("us-east-1", srv.URL)
+	_, err = c.Identify(context.Background(), "AKIAOLD", "old-secret")
+	assert.ErrorContains(t, err, "is not an IAM User")
+}
+
+func TestSessionCredentials(t *testing.T) {
+	_, srv := newMockAWS(t, "arn:aws:iam::123456789012:user/Alice", "AKIAOLD")
+	c := NewClient("us-east-1", srv.URL)
+
+	static := storage.StaticCredentials{
+		UserName:        "Alice",
+		AccountId:       123456789012,
+		AccessKeyId:     "AKIAOLD",
+		SecretAccessKey: "old-secret",
+	}
+	creds, err := c.SessionCredentials(context.Background(), static)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIASESSION", creds.AccessKeyId)
+	assert.Equal(t, "session-secret", creds.SecretAccessKey)
+	assert.Equal(t, "session-token", creds.SessionToken)
+	assert.Equal(t, "Alice", creds.RoleName)
+	assert.Equal(t, int64(123456789012), creds.AccountId)
+	assert.Equal(t, time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli(), creds.Expiration)
+	assert.True(t, creds.IAMUser)
+	assert.Equal(t, "arn:aws:iam::123456789012:user/Alice", creds.RoleArn())
+
+	static.AccessKeyId = "AKIABAD"
+	_, err = c.SessionCredentials(context.Background(), static)
+	assert.Error(t, err)
+}
+
+func TestRotateAccessKey(t *testing.T) {
+	m, srv := newMockAWS(t, "arn:aws:iam::123456789012:user/Alice", "AKIAOLD")
+	c := NewClient("us-east-1", srv.URL)
+
+	old := storage.StaticCredentials{
+		Profile:         "alice",
+		UserName:        "Alice",
+		AccountId:       123456789012,
+		AccessKeyId:     "AKIAOLD",
+		SecretAccessKey: "old-secret",
+		Tags:            map[string]string{"Team": "ops"},
+	}
+	creds, err := c.CreateAccessKey(context.Background(), old)
+	require.NoError(t, err)
+	assert.Equal(t, "AKIANEW", creds.AccessKeyId)
+	assert.Equal(t, "new-secret", creds.SecretAccessKey)
+	assert.Equal(t, "alice", creds.Profile)
+	assert.Equal(t, old.Tags, creds.Tags)
+	assert.Equal(t, []string{"CreateAccessKey:AKIAOLD", "GetCallerIdentity:AKIANEW"}, m.calls)
+
+	require.NoError(t, c.DeleteAccessKey(context.Background(), creds, old.AccessKeyId))
+	assert.Equal(t, []string{"AKIAOLD"}, m.deleted)
+	assert.Equal(t, map[string]bool{"AKIANEW": true}, m.keys)
+
+	_, err = c.Identify(context.Background(), old.AccessKeyId, old.SecretAccessKey)
+	assert.Error(t, err)
+}
+
+func TestRotateAccessKeyNotUsable(t *testing.T) {
+	oldTimeout, oldInterval := accessKeyTimeout, accessKeyInterval
+	defer func() { accessKeyTimeout, accessKeyInterval = oldTimeout, oldInterval }()
+	accessKeyTimeout = 50 * time.Millisecond
+	accessKeyInterval = 10 * time.Millisecond
+
+	m, srv := newMockAWS(t, "arn:aws:iam::123456789012:user/Alice", "AKIAOLD")
+	c := NewClient("us-east-1", srv.URL)
+
+	// the new key never becomes active
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.ServeHTTP(w, r)
+		m.lock.Lock()
+		m.keys["AKIANEW"] = false
+		m.lock.Unlock()
+	})
+
+	old := storage.StaticCredentials{
+		UserName:        "Alice",
+		AccountId:       123456789012,
+		AccessKeyId:     "AKIAOLD",
+		SecretAccessKey: "old-secret",
+	}
+	creds, err := c.CreateAccessKey(context.Background(), old)
+	assert.ErrorContains(t, err, "AKIANEW is not usable")
+	assert.Equal(t, old, creds)
+	// and we cleaned up after ourselves
+	assert.Equal(t, []string{"AKIANEW"}, m.deleted)
+}
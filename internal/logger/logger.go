@@ -18,13 +18,13 @@ func init() {
 	color := isatty.IsTerminal(w.Fd())
 
 	CreateLogger = flexlog.NewConsole
-	logger = flexlog.NewLogger(CreateLogger, w, false, defaultLevel, color)
+	logger = WithRedaction(flexlog.NewLogger(CreateLogger, w, false, defaultLevel, color))
 
 	slog.SetDefault(logger.GetLogger())
 }
 
 func SetLogger(l flexlog.FlexLogger) {
-	logger = l
+	logger = WithRedaction(l)
 }
 
 func GetLogger() flexlog.FlexLogger {
@@ -50,6 +50,6 @@ func SwitchLogger(name string) {
 	}
 
 	// switch the logger
-	logger = flexlog.NewLogger(CreateLogger, logger.Writer(), logger.AddSource(), logger.Level(), logger.Color())
+	logger = WithRedaction(flexlog.NewLogger(CreateLogger, logger.Writer(), logger.AddSource(), logger.Level(), logger.Color()))
 	slog.SetDefault(logger.GetLogger())
 }
@@ -0,0 +1,293 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/davecgh/go-spew/spew"
+	"github.com/synfinatic/flexlog"
+)
+
+const (
+	REDACTED = "********"
+
+	// how deep we walk nested values looking for secrets
+	maxRedactDepth = 10
+)
+
+// secretFields are the (lower case) struct field, map key and log attribute
+// names which hold secrets.  This covers our storage.RoleCredentials,
+// storage.CreateTokenResponse, storage.RegisterClientData and the AWS SDK
+// sso, ssooidc and sts credential structs.
+var secretFields = map[string]bool{
+	"accesstoken":     true,
+	"clientsecret":    true,
+	"codeverifier":    true,
+	"devicecode":      true,
+	"idtoken":         true,
+	"refreshtoken":    true,
+	"secretaccesskey": true,
+	"sessiontoken":    true,
+	"signintoken":     true,
+}
+
+// secretParams are the additional (lower case) URL query parameters which
+// hold secrets, such as the credentials passed to the AWS federation endpoint
+var secretParams = map[string]bool{
+	"session": true,
+}
+
+// secretEnvVars are the environment variables which hold secrets
+var secretEnvVars = map[string]bool{
+	"AWS_CONTAINER_AUTHORIZATION_TOKEN": true,
+	"AWS_SECRET_ACCESS_KEY":             true,
+	"AWS_SECURITY_TOKEN":                true,
+	"AWS_SESSION_TOKEN":                 true,
+	"AWS_SSO_ECS_METRICS_TOKEN":         true,
+	"AWS_SSO_ECS_PERSIST_PASSWORD":      true,
+	"AWS_SSO_FILE_PASSWORD":             true,
+	"AWS_SSO_OP_SERVICE_ACCOUNT_TOKEN":  true,
+	"AWS_SSO_STATIC_SECRET_ACCESS_KEY":  true,
+}
+
+// isSecret returns if the given field/key name holds a secret
+func isSecret(name string) bool {
+	name = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+	return secretFields[name]
+}
+
+// Redact returns a copy of v with the value of any secret fields masked.
+// v itself is never modified.
+func Redact(v any) any {
+	if v == nil {
+		return nil
+	}
+	return redactValue(reflect.ValueOf(v), 0).Interface()
+}
+
+// Sdump is a drop in replacement for spew.Sdump which masks any secrets
+func Sdump(v any) string {
+	return spew.Sdump(Redact(v))
+}
+
+// RedactURL returns u with the values of any secret query parameters masked
+func RedactURL(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return REDACTED
+	}
+	q := parsed.Query()
+	for k := range q {
+		if isSecret(k) || secretParams[strings.ToLower(k)] {
+			q.Set(k, REDACTED)
+		}
+	}
+	parsed.RawQuery = q.Encode()
+	return parsed.String()
+}
+
+// RedactEnv returns value, or masks it if the environment variable name holds a secret
+func RedactEnv(name, value string) string {
+	if secretEnvVars[strings.ToUpper(name)] || isSecret(name) {
+		return REDACTED
+	}
+	return value
+}
+
+func redactValue(v reflect.Value, depth int) reflect.Value {
+	if depth > maxRedactDepth {
+		return v
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		p := reflect.New(v.Type().Elem())
+		p.Elem().Set(redactValue(v.Elem(), depth+1))
+		return p
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(redactValue(v.Elem(), depth+1))
+		return out
+
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v) // copies the unexported fields which we can't walk
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if isSecret(f.Name) {
+				maskValue(out.Field(i))
+				continue
+			}
+			out.Field(i).Set(redactValue(v.Field(i), depth+1))
+		}
+		return out
+
+	case reflect.Slice:
+		if v.IsNil() || !mayHoldSecrets(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactValue(v.Index(i), depth+1))
+		}
+		return out
+
+	case reflect.Array:
+		if !mayHoldSecrets(v.Type().Elem()) {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(redactValue(v.Index(i), depth+1))
+		}
+		return out
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			val := iter.Value()
+			if iter.Key().Kind() == reflect.String && isSecret(iter.Key().String()) {
+				masked := reflect.New(val.Type()).Elem()
+				masked.Set(val)
+				maskValue(masked)
+				out.SetMapIndex(iter.Key(), masked)
+				continue
+			}
+			out.SetMapIndex(iter.Key(), redactValue(val, depth+1))
+		}
+		return out
+	}
+	return v
+}
+
+// mayHoldSecrets returns false for types which we know can't contain a secret field
+func mayHoldSecrets(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128, reflect.String:
+		return false
+	}
+	return true
+}
+
+// maskValue replaces the secret held by v.  Empty values are left as is so
+// it is still obvious in the logs when a field was not set.
+func maskValue(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.Len() > 0 {
+			v.SetString(REDACTED)
+		}
+
+	case reflect.Pointer:
+		if !v.IsNil() && v.Type().Elem().Kind() == reflect.String {
+			p := reflect.New(v.Type().Elem())
+			p.Elem().SetString(REDACTED)
+			v.Set(p)
+		}
+
+	case reflect.Slice:
+		if v.Len() > 0 && v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(REDACTED))
+		}
+
+	case reflect.Interface:
+		if !v.IsNil() && v.Elem().Kind() == reflect.String {
+			v.Set(reflect.ValueOf(REDACTED))
+		}
+	}
+}
+
+// redactAttr masks any secrets in the given log attribute
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		if isSecret(a.Key) && a.Value.String() != "" {
+			a.Value = slog.StringValue(REDACTED)
+		}
+
+	case slog.KindGroup:
+		attrs := a.Value.Group()
+		redacted := make([]slog.Attr, 0, len(attrs))
+		for _, ga := range attrs {
+			redacted = append(redacted, redactAttr(ga))
+		}
+		a.Value = slog.GroupValue(redacted...)
+
+	case slog.KindAny:
+		if isSecret(a.Key) {
+			a.Value = slog.StringValue(REDACTED)
+		} else {
+			a.Value = slog.AnyValue(Redact(a.Value.Any()))
+		}
+	}
+	return a
+}
+
+// RedactHandler is a slog.Handler which masks any secrets before passing
+// the record on to the wrapped handler
+type RedactHandler struct {
+	handler slog.Handler
+}
+
+// NewRedactHandler returns a RedactHandler wrapping h
+func NewRedactHandler(h slog.Handler) *RedactHandler {
+	return &RedactHandler{
+		handler: h,
+	}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.handler.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		redacted = append(redacted, redactAttr(a))
+	}
+	return NewRedactHandler(h.handler.WithAttrs(redacted))
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return NewRedactHandler(h.handler.WithGroup(name))
+}
+
+// WithRedaction updates the given logger so that secrets are always masked
+// and returns it
+func WithRedaction(l flexlog.FlexLogger) flexlog.FlexLogger {
+	if _, ok := l.Handler().(*RedactHandler); !ok {
+		l.SetLogger(slog.New(NewRedactHandler(l.Handler())))
+	}
+	return l
+}
@@ -145,6 +145,7 @@ func (p *Predictor) newPredictor(s *sso.Settings, c *ssocache.Cache) *Predictor
 		"open-url-in-container",
 		"print",
 		"printurl",
+		"qrcode",
 	}
 	return p
 }
@@ -86,7 +86,7 @@ func TestCompletions(t *testing.T) {
 
 	c = p.UrlActionComplete()
 	assert.NotNil(t, c)
-	assert.Equal(t, 8, len(c.Predict(args)))
+	assert.Equal(t, 9, len(c.Predict(args)))
 }
 
 func TestSupportedListField(t *testing.T) {
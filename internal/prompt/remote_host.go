@@ -19,7 +19,9 @@ package prompt
  */
 
 import (
+	"fmt"
 	"os"
+	"strings"
 )
 
 // IsRemoteHost returns if we are running on a remote host environment
@@ -30,3 +32,30 @@ func IsRemoteHost() bool {
 	_, inWSLSession := os.LookupEnv("WSL_DISTRO_NAME")
 	return inSSHSession || inWSLSession
 }
+
+// SSHForwardCommand returns the `ssh -L` command to run on the user's local
+// machine to forward localPort to remoteAddress:remotePort on this host.  The
+// host and port come from $SSH_CONNECTION if we are in an SSH session.
+func SSHForwardCommand(localPort int, remoteAddress string, remotePort int) string {
+	host, sshPort := "", "22"
+	// SSH_CONNECTION is "<client ip> <client port> <server ip> <server port>"
+	if fields := strings.Fields(os.Getenv("SSH_CONNECTION")); len(fields) == 4 {
+		host, sshPort = fields[2], fields[3]
+	}
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	if user := os.Getenv("USER"); user != "" {
+		host = user + "@" + host
+	}
+
+	if strings.Contains(remoteAddress, ":") {
+		remoteAddress = "[" + remoteAddress + "]" // IPv6
+	}
+
+	cmd := fmt.Sprintf("ssh -N -L %d:%s:%d", localPort, remoteAddress, remotePort)
+	if sshPort != "22" {
+		cmd += " -p " + sshPort
+	}
+	return cmd + " " + host
+}
@@ -71,3 +71,22 @@ func TestIsRemoteHost(t *testing.T) {
 	assert.NoError(t, os.Unsetenv("WSL_DISTRO_NAME"))
 	assert.False(t, IsRemoteHost())
 }
+
+func TestSSHForwardCommand(t *testing.T) {
+	t.Setenv("USER", "alice")
+	t.Setenv("SSH_CONNECTION", "10.0.0.5 51234 10.0.0.10 22")
+	assert.Equal(t, "ssh -N -L 8250:127.0.0.1:8250 alice@10.0.0.10",
+		SSHForwardCommand(8250, "127.0.0.1", 8250))
+
+	t.Setenv("SSH_CONNECTION", "10.0.0.5 51234 10.0.0.10 2222")
+	assert.Equal(t, "ssh -N -L 8250:[::1]:9000 -p 2222 alice@10.0.0.10",
+		SSHForwardCommand(8250, "::1", 9000))
+
+	// not in an SSH session
+	unsetEnvWithCleanup(t, "SSH_CONNECTION")
+	unsetEnvWithCleanup(t, "USER")
+	host, err := os.Hostname()
+	assert.NoError(t, err)
+	assert.Equal(t, "ssh -N -L 8250:127.0.0.1:8250 "+host,
+		SSHForwardCommand(8250, "127.0.0.1", 8250))
+}
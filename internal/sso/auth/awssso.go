@@ -33,11 +33,14 @@ import (
 	awssso "github.com/aws/aws-sdk-go-v2/service/sso"
 	ssotypes "github.com/aws/aws-sdk-go-v2/service/sso/types"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
-	"github.com/davecgh/go-spew/spew"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/synfinatic/aws-sso-cli/internal/awsendpoint"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/iamuser"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
 )
@@ -56,6 +59,9 @@ type SsoAPI interface {
 	Logout(context.Context, *awssso.LogoutInput, ...func(*awssso.Options)) (*awssso.LogoutOutput, error)
 }
 
+// MfaTokenFunc returns the current TOTP code of the given MFA device
+type MfaTokenFunc func(mfaSerial string) (string, error)
+
 type AWSSSO struct {
 	key              string // key in the settings file that names us
 	sso              SsoAPI
@@ -78,6 +84,7 @@ type AWSSSO struct {
 	browser          string                          // cache for future calls
 	urlExecCommand   []string                        // cache for future calls
 	authenticateLock sync.RWMutex                    // lock for reauthenticate()
+	mfaToken         MfaTokenFunc                    // how we get TOTP codes for roles with an MfaSerial
 }
 
 func NewAWSSSO(s *ssoconfig.SSOConfig, store storage.SecureStorage) *AWSSSO {
@@ -272,7 +279,7 @@ func (as *AWSSSO) makeRoleInfo(account ssoconfig.AccountInfo, i int, r ssotypes.
 	as.Roles[account.AccountId] = append(as.Roles[account.AccountId], ssoconfig.RoleInfo{
 		Id:           i,
 		AccountId:    aws.ToString(r.AccountId),
-		Arn:          awsparse.MakeRoleARN(aId, aws.ToString(r.RoleName)),
+		Arn:          awsparse.MakeRoleARN(as.Partition(), aId, aws.ToString(r.RoleName)),
 		RoleName:     aws.ToString(r.RoleName),
 		AccountName:  account.AccountName,
 		EmailAddress: account.EmailAddress,
@@ -326,15 +333,82 @@ func (as *AWSSSO) GetAccounts() ([]ssoconfig.AccountInfo, error) {
 	return as.Accounts, nil
 }
 
+// SetMfaTokenFunc sets how we get the TOTP code for roles with an MfaSerial
+func (as *AWSSSO) SetMfaTokenFunc(f MfaTokenFunc) {
+	as.mfaToken = f
+}
+
+// Partition returns the AWS partition of our AWS SSO instance
+func (as *AWSSSO) Partition() string {
+	return awsparse.GetPartition(as.SsoRegion)
+}
+
 // GetRoleCredentials recursively does any sts:AssumeRole calls as necessary for role-chaining
 // through `Via` and returns the final set of RoleCredentials for the requested role
 func (as *AWSSSO) GetRoleCredentials(accountId int64, role string) (storage.RoleCredentials, error) {
-	return as.getRoleCredentials(accountId, role, map[string]bool{})
+	return as.roleCredentials(accountId, role, false)
+}
+
+// RefreshRoleCredentials is like GetRoleCredentials, but always calls AWS for
+// the requested role instead of reusing its MFA session
+func (as *AWSSSO) RefreshRoleCredentials(accountId int64, role string) (storage.RoleCredentials, error) {
+	return as.roleCredentials(accountId, role, true)
+}
+
+func (as *AWSSSO) roleCredentials(accountId int64, role string, refresh bool) (storage.RoleCredentials, error) {
+	// catch any broken role chains before making any API calls
+	arn := awsparse.MakeRoleARN(as.Partition(), accountId, role)
+	if _, err := roles.NewChainResolver(as.SSOConfig, nil).Resolve(arn); err != nil {
+		return storage.RoleCredentials{}, err
+	}
+	return as.getRoleCredentials(accountId, role, map[string]bool{}, refresh)
+}
+
+// GetCredentials returns the credentials for the given IAM Role or IAM User ARN
+func (as *AWSSSO) GetCredentials(arn string) (storage.RoleCredentials, error) {
+	return as.getCredentials(arn, false)
+}
+
+// RefreshCredentials is like GetCredentials, but always calls AWS for the
+// requested role instead of reusing its MFA session
+func (as *AWSSSO) RefreshCredentials(arn string) (storage.RoleCredentials, error) {
+	return as.getCredentials(arn, true)
+}
+
+func (as *AWSSSO) getCredentials(arn string, refresh bool) (storage.RoleCredentials, error) {
+	if awsparse.IsUserARN(arn) {
+		return as.GetUserCredentials(arn)
+	}
+	accountId, role, err := awsparse.ParseRoleARN(arn)
+	if err != nil {
+		return storage.RoleCredentials{}, err
+	}
+	return as.roleCredentials(accountId, role, refresh)
+}
+
+// GetUserCredentials returns temporary credentials via sts:GetSessionToken for the
+// IAM User with the given ARN using the StaticCredentials in our SecureStore
+func (as *AWSSSO) GetUserCredentials(arn string) (storage.RoleCredentials, error) {
+	static, err := as.staticCredentials(arn)
+	if err != nil {
+		return storage.RoleCredentials{}, err
+	}
+	return iamuser.NewClient(as.SsoRegion, as.stsEndpoint).SessionCredentials(context.TODO(), static)
+}
+
+// staticCredentials returns the StaticCredentials for the given IAM User ARN
+func (as *AWSSSO) staticCredentials(arn string) (storage.StaticCredentials, error) {
+	static := storage.StaticCredentials{}
+	if err := as.store.GetStaticCredentials(arn, &static); err != nil {
+		return static, fmt.Errorf("unable to load static credentials for %s: %w", arn, err)
+	}
+	return static, nil
 }
 
 // getRoleCredentials is the recursive implementation of GetRoleCredentials. chainMap tracks visited
-// role ARNs in the current call chain to detect loops.
-func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[string]bool) (storage.RoleCredentials, error) {
+// role ARNs in the current call chain to detect loops.  If refresh is true, the MFA session of
+// the requested role is not reused.
+func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[string]bool, refresh bool) (storage.RoleCredentials, error) {
 	aId, err := awsparse.AccountIdToString(accountId)
 	if err != nil {
 		return storage.RoleCredentials{}, err
@@ -360,7 +434,7 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 		if err != nil {
 			return storage.RoleCredentials{}, err
 		}
-		log.Debug("sso.GetRoleCredentials", "output", spew.Sdump(output))
+		log.Debug("sso.GetRoleCredentials", "output", logger.Sdump(output))
 
 		ret := storage.RoleCredentials{
 			AccountId:       accountId,
@@ -369,6 +443,7 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 			SecretAccessKey: aws.ToString(output.RoleCredentials.SecretAccessKey),
 			SessionToken:    aws.ToString(output.RoleCredentials.SessionToken),
 			Expiration:      output.RoleCredentials.Expiration,
+			Partition:       as.Partition(),
 		}
 
 		return ret, nil
@@ -376,26 +451,48 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 
 	// Detect loops
 	chainMap[configRole.ARN] = true
-	for k := range chainMap {
-		if k == configRole.Via {
-			panic(fmt.Sprintf("Detected role chain loop!  Getting %s via %s", configRole.ARN, configRole.Via))
+	if chainMap[configRole.Via] {
+		return storage.RoleCredentials{}, fmt.Errorf("%w: getting %s via %s", roles.ErrChainLoop, configRole.ARN, configRole.Via)
+	}
+
+	roleArn := awsparse.MakeRoleARN(as.Partition(), accountId, role)
+	if configRole.MfaSerial != "" && !refresh {
+		// reuse our MFA session so we only prompt for a new code once it expires
+		if creds, ok := as.mfaSessionCredentials(configRole.MfaSerial, roleArn); ok {
+			return creds, nil
 		}
-		chainMap[k] = true
 	}
 
 	// Need to recursively call sts:AssumeRole in order to retrieve the STS creds for
 	// the requested role
 	// role has a Via
 	log.Debug("Calling AssumeRole", "role", fmt.Sprintf("%s:%s", aId, role), "via", configRole.Via)
-	viaAccountId, viaRole, err := awsparse.ParseRoleARN(configRole.Via)
-	if err != nil {
-		return storage.RoleCredentials{}, fmt.Errorf("invalid Via %s: %s", configRole.Via, err.Error())
-	}
+	var creds storage.RoleCredentials
+	if awsparse.IsUserARN(configRole.Via) {
+		// end of the chain is an IAM User with StaticCredentials
+		static, err := as.staticCredentials(configRole.Via)
+		if err != nil {
+			return storage.RoleCredentials{}, err
+		}
+		creds = storage.RoleCredentials{
+			AccountId:       static.AccountId,
+			RoleName:        static.UserName,
+			AccessKeyId:     static.AccessKeyId,
+			SecretAccessKey: static.SecretAccessKey,
+			Partition:       static.Partition,
+			IAMUser:         true,
+		}
+	} else {
+		viaAccountId, viaRole, err := awsparse.ParseRoleARN(configRole.Via)
+		if err != nil {
+			return storage.RoleCredentials{}, fmt.Errorf("invalid Via %s: %s", configRole.Via, err.Error())
+		}
 
-	// recurse
-	creds, err := as.getRoleCredentials(viaAccountId, viaRole, chainMap)
-	if err != nil {
-		return storage.RoleCredentials{}, err
+		// recurse
+		creds, err = as.getRoleCredentials(viaAccountId, viaRole, chainMap, false)
+		if err != nil {
+			return storage.RoleCredentials{}, err
+		}
 	}
 
 	cfgCreds := credentials.NewStaticCredentialsProvider(
@@ -420,12 +517,37 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 	})
 
 	previousAccount, _ := awsparse.AccountIdToString(creds.AccountId)
-	previousRole := fmt.Sprintf("%s@%s", creds.RoleName, previousAccount)
+	sessionName, err := configRole.GetRoleSessionName(ssoconfig.RoleSessionNameData{
+		AccountId:       previousAccount,
+		RoleName:        creds.RoleName,
+		TargetAccountId: aId,
+		TargetRoleName:  role,
+	})
+	if err != nil {
+		return storage.RoleCredentials{}, err
+	}
 
 	input := sts.AssumeRoleInput{
-		// DurationSeconds: aws.Int32(900),
-		RoleArn:         aws.String(awsparse.MakeRoleARN(accountId, role)),
-		RoleSessionName: aws.String(previousRole),
+		RoleArn:         aws.String(roleArn),
+		RoleSessionName: aws.String(sessionName),
+	}
+	if configRole.Duration > 0 {
+		input.DurationSeconds = aws.Int32(configRole.Duration * 60)
+	}
+	for _, k := range configRole.GetSessionTagKeys() {
+		input.Tags = append(input.Tags, ststypes.Tag{
+			Key:   aws.String(k),
+			Value: aws.String(configRole.SessionTags[k]),
+		})
+	}
+	input.TransitiveTagKeys = configRole.TransitiveTagKeys
+	for _, arn := range configRole.PolicyArns {
+		input.PolicyArns = append(input.PolicyArns, ststypes.PolicyDescriptorType{
+			Arn: aws.String(arn),
+		})
+	}
+	if configRole.Policy != "" {
+		input.Policy = aws.String(configRole.Policy)
 	}
 	if configRole.ExternalId != "" {
 		// Optional value: https://docs.aws.amazon.com/sdk-for-go/api/service/sts/#AssumeRoleInput
@@ -434,12 +556,23 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 	if configRole.SourceIdentity != "" {
 		input.SourceIdentity = aws.String(configRole.SourceIdentity)
 	}
+	if configRole.MfaSerial != "" {
+		if as.mfaToken == nil {
+			return storage.RoleCredentials{}, fmt.Errorf("%s requires an MFA token from %s", roleArn, configRole.MfaSerial)
+		}
+		token, err := as.mfaToken(configRole.MfaSerial)
+		if err != nil {
+			return storage.RoleCredentials{}, err
+		}
+		input.SerialNumber = aws.String(configRole.MfaSerial)
+		input.TokenCode = aws.String(token)
+	}
 
 	output, err := stsSession.AssumeRole(context.TODO(), &input)
 	if err != nil {
 		return storage.RoleCredentials{}, err
 	}
-	log.Debug("stsSession.AssumeRole", "output", spew.Sdump(output))
+	log.Debug("stsSession.AssumeRole", "output", logger.Sdump(output))
 	ret := storage.RoleCredentials{
 		AccountId:       accountId,
 		RoleName:        role,
@@ -448,6 +581,50 @@ func (as *AWSSSO) getRoleCredentials(accountId int64, role string, chainMap map[
 		SessionToken:    aws.ToString(output.Credentials.SessionToken),
 		Expiration:      aws.ToTime(output.Credentials.Expiration).UnixMilli(),
 		RoleChaining:    true, // we used AssumeRole to get these creds
+		Partition:       as.Partition(),
+	}
+
+	if configRole.MfaSerial != "" {
+		key := mfaSessionKey(configRole.MfaSerial, roleArn)
+		if err = as.store.SaveRoleCredentials(context.TODO(), key, ret); err != nil {
+			log.Warn("Unable to cache MFA session", "arn", roleArn, "error", err.Error())
+		}
 	}
 	return ret, nil
 }
+
+// mfaSessionKey returns the SecureStore key of our MFA session for the given role.
+// It is separate from the role ARN which callers use to cache the credentials they
+// return, so that a refresh of those doesn't get the same credentials back.
+func mfaSessionKey(mfaSerial, roleArn string) string {
+	return fmt.Sprintf("mfa:%s:%s", mfaSerial, roleArn)
+}
+
+// mfaSessionCredentials returns the unexpired RoleCredentials for the given
+// role ARN which we previously got using MFA
+func (as *AWSSSO) mfaSessionCredentials(mfaSerial, roleArn string) (storage.RoleCredentials, bool) {
+	creds := storage.RoleCredentials{}
+	err := as.store.GetRoleCredentials(mfaSessionKey(mfaSerial, roleArn), &creds)
+	if err != nil || creds.Expired() {
+		return creds, false
+	}
+	log.Debug("Using cached MFA session", "arn", roleArn)
+	return creds, true
+}
+
+// DeleteMfaSessions removes the cached MFA sessions of all the roles in our config
+func (as *AWSSSO) DeleteMfaSessions(ctx context.Context) {
+	for _, role := range as.SSOConfig.GetRoles() {
+		if role.MfaSerial == "" {
+			continue
+		}
+		creds := storage.RoleCredentials{}
+		key := mfaSessionKey(role.MfaSerial, role.ARN)
+		if as.store.GetRoleCredentials(key, &creds) != nil {
+			continue
+		}
+		if err := as.store.DeleteRoleCredentials(ctx, key); err != nil {
+			log.Error("Unable to delete MFA session", "arn", role.ARN, "error", err.Error())
+		}
+	}
+}
@@ -20,15 +20,19 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	awssso "github.com/aws/aws-sdk-go-v2/service/sso"
 	"github.com/synfinatic/aws-sso-cli/internal/prompt"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
@@ -38,6 +42,7 @@ const (
 	DEFAULT_AUTH_COLOR       = "blue"
 	DEFAULT_AUTH_ICON        = "fingerprint"
 	VERIFY_MSG               = "\n\tVerify this code in your browser: %s\n"
+	PKCE_REMOTE_MSG          = "\nRun the following on the computer with your web browser to forward the login callback to this host:\n\n\t%s\n\n"
 	SSO_ACCOUNT_ACCESS_SCOPE = "sso:account:access"
 	awsSSOClientName         = "aws-sso-cli"
 	awsSSOClientType         = "public"
@@ -95,7 +100,7 @@ func (as *AWSSSO) ValidAuthToken(ctx context.Context) bool {
 
 	// Attempt a silent renewal using the stored refresh token before
 	// falling back to a full browser-based re-authentication.
-	if token.RefreshToken != "" && as.tryRefreshToken(ctx, token, clientData) {
+	if token.RefreshToken != "" && as.tryRefreshToken(ctx) {
 		return true
 	}
 	return false
@@ -105,24 +110,51 @@ func (as *AWSSSO) ValidAuthToken(ctx context.Context) bool {
 // the stored refresh token.  It saves the new token and returns true on
 // success, or logs and returns false so the caller can fall back to a full
 // re-authentication flow.
-func (as *AWSSSO) tryRefreshToken(ctx context.Context, expiredToken storage.CreateTokenResponse, clientData storage.RegisterClientData) bool {
+func (as *AWSSSO) tryRefreshToken(ctx context.Context) bool {
 	log.Debug("Attempting silent token refresh", "storeKey", as.StoreKey())
+	as.authenticateLock.Lock()
+	defer as.authenticateLock.Unlock()
+
+	// re-read the token now that we hold the lock, since the keepalive may
+	// have already rotated the refresh token we were handed
+	token, clientData, err := as.refreshableToken()
+	if err != nil {
+		log.Debug("Token refresh failed, falling back to full re-authentication", "error", err.Error())
+		return false
+	}
+	if !token.Expired() {
+		as.tokenLock.Lock()
+		as.Token = token
+		as.tokenLock.Unlock()
+		return true
+	}
+
+	if _, err := as.refreshToken(ctx, token, clientData); err != nil {
+		log.Debug("Token refresh failed, falling back to full re-authentication", "error", err.Error())
+		return false
+	}
+	log.Debug("Token successfully refreshed", "storeKey", as.StoreKey())
+	return true
+}
+
+// refreshToken exchanges the refresh token in the given token for a new
+// AccessToken and saves it.  Callers must hold authenticateLock so that
+// two refreshes never race to use the same refresh token.
+func (as *AWSSSO) refreshToken(ctx context.Context, token storage.CreateTokenResponse, clientData storage.RegisterClientData) (storage.CreateTokenResponse, error) {
 	newToken, err := as.oidcClient.ExchangeRefreshToken(ctx, oidc.ExchangeRefreshTokenInput{
 		ClientID:     clientData.ClientId,
 		ClientSecret: clientData.ClientSecret,
-		RefreshToken: expiredToken.RefreshToken,
+		RefreshToken: token.RefreshToken,
 	})
 	if err != nil {
-		log.Debug("Token refresh failed, falling back to full re-authentication", "error", err.Error())
-		return false
+		return storage.CreateTokenResponse{}, err
 	}
 	if newToken.RefreshToken == "" {
 		// AWS may choose not to return a new refresh token; if so, reuse the old one.
-		newToken.RefreshToken = expiredToken.RefreshToken
+		newToken.RefreshToken = token.RefreshToken
 	}
 	_ = as.saveToken(ctx, newToken)
-	log.Debug("Token successfully refreshed", "storeKey", as.StoreKey())
-	return true
+	return newToken, nil
 }
 
 // Authenticate retrieves an AWS SSO AccessToken from our cache or by
@@ -158,13 +190,13 @@ func (as *AWSSSO) reauthenticate(ctx context.Context) error {
 	}
 	log.Trace("<- reauthenticate()")
 
-	switch as.getAuthWorkflow() {
+	switch as.AuthWorkflow() {
 	case oidc.AuthWorkflowDeviceCode:
 		return as.reauthenticateDeviceCode(ctx)
 	case oidc.AuthWorkflowPKCE:
 		return as.reauthenticatePKCE(ctx)
 	default:
-		log.Fatal("unsupported auth workflow", "workflow", as.getAuthWorkflow())
+		log.Fatal("unsupported auth workflow", "workflow", as.AuthWorkflow())
 	}
 	return nil
 }
@@ -189,7 +221,7 @@ func (as *AWSSSO) registerClient(ctx context.Context, force bool) error {
 		GrantTypes: as.authGrantTypes(),
 		IssuerUrl:  as.StartUrl,
 	}
-	if as.getAuthWorkflow() == oidc.AuthWorkflowPKCE {
+	if as.AuthWorkflow() == oidc.AuthWorkflowPKCE {
 		input.Scopes = []string{SSO_ACCOUNT_ACCESS_SCOPE}
 		input.RedirectUris = []string{as.pkceRedirectURIBase()}
 	}
@@ -293,10 +325,10 @@ func (as *AWSSSO) saveToken(ctx context.Context, token storage.CreateTokenRespon
 	return nil
 }
 
-// getAuthWorkflow returns the AuthWorkflow to use for this AWSSSO instance.
+// AuthWorkflow returns the AuthWorkflow to use for this AWSSSO instance.
 // In remote host sessions (SSH/WSL), we default to device_code when unset; otherwise
 // we default to PKCE when unset.
-func (as *AWSSSO) getAuthWorkflow() oidc.AuthWorkflow {
+func (as *AWSSSO) AuthWorkflow() oidc.AuthWorkflow {
 	if prompt.IsRemoteHost() {
 		if as.SSOConfig == nil || as.SSOConfig.AuthWorkflow == "" {
 			return oidc.AuthWorkflowDeviceCode
@@ -312,14 +344,14 @@ func (as *AWSSSO) getAuthWorkflow() oidc.AuthWorkflow {
 // GrantTypes returns the list of GrantTypes to request in our OIDC client registration, based
 // on the AuthWorkflow.
 func (as *AWSSSO) GrantTypes() []storage.GrantType {
-	log.Debug("GrantTypes()", "authWorkflow", as.getAuthWorkflow())
-	switch as.getAuthWorkflow() {
+	log.Debug("GrantTypes()", "authWorkflow", as.AuthWorkflow())
+	switch as.AuthWorkflow() {
 	case oidc.AuthWorkflowDeviceCode:
 		return []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken}
 	case oidc.AuthWorkflowPKCE:
 		return []storage.GrantType{storage.GrantTypeAuthorizationCode, storage.GrantTypeRefreshToken}
 	default:
-		log.Fatal("unsupported auth workflow", "workflow", as.getAuthWorkflow())
+		log.Fatal("unsupported auth workflow", "workflow", as.AuthWorkflow())
 	}
 	return nil
 }
@@ -390,18 +422,40 @@ func (as *AWSSSO) reauthenticateDeviceCode(ctx context.Context) error {
 	return nil
 }
 
+// pkceRemoteWriter is where we tell the user how to forward the PKCE callback
+var pkceRemoteWriter io.Writer = os.Stderr
+
+// pkceConfig returns the settings for our PKCE callback listener
+func (as *AWSSSO) pkceConfig() ssoconfig.PKCEConfig {
+	if as.SSOConfig == nil {
+		return ssoconfig.PKCEConfig{}
+	}
+	return as.SSOConfig.PKCE
+}
+
 func (as *AWSSSO) reauthenticatePKCE(ctx context.Context) error {
+	cfg := as.pkceConfig()
+
 	// Bind the loopback callback listener up front. RFC 8252 §7.3 recommends any
-	// available port rather than a fixed one to avoid bind conflicts. Keeping the
-	// socket bound before the browser opens lets the kernel queue the redirect if
-	// it arrives before the callback server starts serving, avoiding a
-	// connection-refused race with warm SSO sessions that redirect instantly.
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	// available port rather than a fixed one to avoid bind conflicts, so unless
+	// the user configured a CallbackPort (e.g. for `ssh -L`) we use port 0.
+	// Keeping the socket bound before the browser opens lets the kernel queue
+	// the redirect if it arrives before the callback server starts serving,
+	// avoiding a connection-refused race with warm SSO sessions that redirect
+	// instantly.
+	ln, err := net.Listen("tcp", cfg.ListenAddress())
 	if err != nil {
+		if cfg.CallbackPort != 0 {
+			return fmt.Errorf("listen on %s for pkce callback: %w", cfg.ListenAddress(), err)
+		}
 		return fmt.Errorf("find free port for pkce callback: %w", err)
 	}
-	port := ln.Addr().(*net.TCPAddr).Port
-	redirectURI := fmt.Sprintf("http://127.0.0.1:%d", port)
+	addr := ln.Addr().(*net.TCPAddr)
+	bindHost, _, _ := net.SplitHostPort(cfg.ListenAddress())
+	listenAddr := net.JoinHostPort(bindHost, strconv.Itoa(addr.Port))
+	// the browser always redirects to the loopback address, which may be
+	// forwarded to us by ssh
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d", addr.Port)
 
 	// WaitForPKCECallback takes ownership of the listener and closes it. Close it
 	// here only if we return before handing it off.
@@ -427,14 +481,25 @@ func (as *AWSSSO) reauthenticatePKCE(ctx context.Context) error {
 		return fmt.Errorf("unable to start pkce authorization with AWS SSO: %w", err)
 	}
 
+	var sshCmd string
+	if cfg.Remote {
+		target := addr.IP.String()
+		if addr.IP.IsUnspecified() {
+			target = ssoconfig.DEFAULT_PKCE_CALLBACK_ADDRESS
+		}
+		sshCmd = prompt.SSHForwardCommand(addr.Port, target, addr.Port)
+		fmt.Fprintf(pkceRemoteWriter, PKCE_REMOTE_MSG, sshCmd)
+	}
+
 	urlOpener := uri.NewHandleUrl(as.urlAction, flow.AuthorizationURL, as.browser, as.urlExecCommand)
 	urlOpener.ContainerSettings(as.StoreKey(), DEFAULT_AUTH_COLOR, DEFAULT_AUTH_ICON)
 	if err = urlOpener.Open(); err != nil {
 		return err
 	}
 
-	// Give the user up to 5 minutes to complete the browser login.
-	pkceCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	// Give the user time to complete the browser login.
+	timeout := cfg.GetTimeout()
+	pkceCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	listenerHandedOff = true
@@ -444,6 +509,12 @@ func (as *AWSSSO) reauthenticatePKCE(ctx context.Context) error {
 		Listener:      ln,
 	})
 	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s waiting on %s", timeout, listenAddr)
+			if cfg.Remote {
+				err = fmt.Errorf("%w: is `%s` running?", err, sshCmd)
+			}
+		}
 		return fmt.Errorf("unable to receive pkce callback: %w", err)
 	}
 
@@ -19,6 +19,7 @@ package auth
  */
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
@@ -112,29 +113,29 @@ func TestAuthWorkflowSelection(t *testing.T) {
 	assert.NoError(t, os.Unsetenv("SSH_TTY"))
 
 	as := &AWSSSO{}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowPKCE)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowPKCE)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeAuthorizationCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeAuthorizationCode, storage.GrantTypeRefreshToken})
 
 	as.SSOConfig = &ssoconfig.SSOConfig{AuthWorkflow: oidc.AuthWorkflowDeviceCode}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowDeviceCode)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowDeviceCode)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeDeviceCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken})
 
 	t.Setenv("WSL_DISTRO_NAME", "Ubuntu")
 
 	as = &AWSSSO{}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowDeviceCode)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowDeviceCode)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeDeviceCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken})
 
 	as.SSOConfig = &ssoconfig.SSOConfig{}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowDeviceCode)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowDeviceCode)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeDeviceCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken})
 
 	as.SSOConfig = &ssoconfig.SSOConfig{AuthWorkflow: oidc.AuthWorkflowPKCE}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowPKCE)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowPKCE)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeAuthorizationCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeAuthorizationCode, storage.GrantTypeRefreshToken})
 
@@ -142,15 +143,15 @@ func TestAuthWorkflowSelection(t *testing.T) {
 	t.Setenv("SSH_TTY", "/dev/pts/1")
 
 	as = &AWSSSO{}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowDeviceCode)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowDeviceCode)
 	assert.Equal(t, as.authGrantTypes(), []string{string(storage.GrantTypeDeviceCode), string(storage.GrantTypeRefreshToken)})
 	assert.Equal(t, as.GrantTypes(), []storage.GrantType{storage.GrantTypeDeviceCode, storage.GrantTypeRefreshToken})
 
 	as.SSOConfig = &ssoconfig.SSOConfig{}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowDeviceCode)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowDeviceCode)
 
 	as.SSOConfig = &ssoconfig.SSOConfig{AuthWorkflow: oidc.AuthWorkflowPKCE}
-	assert.Equal(t, as.getAuthWorkflow(), oidc.AuthWorkflowPKCE)
+	assert.Equal(t, as.AuthWorkflow(), oidc.AuthWorkflowPKCE)
 }
 
 func TestAuthenticateSteps(t *testing.T) {
@@ -1123,6 +1124,113 @@ func TestReauthenticatePKCE(t *testing.T) {
 		assert.Contains(t, err.Error(), "callback timed out")
 	})
 
+	t.Run("remote", func(t *testing.T) {
+		tfile, err := os.CreateTemp("", "*storage.json")
+		assert.NoError(t, err)
+		defer os.Remove(tfile.Name())
+
+		jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
+		assert.NoError(t, err)
+
+		// find a free port for our fixed CallbackPort
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		assert.NoError(t, err)
+		port := ln.Addr().(*net.TCPAddr).Port
+		assert.NoError(t, ln.Close())
+
+		t.Setenv("USER", "alice")
+		t.Setenv("SSH_CONNECTION", "10.0.0.5 51234 10.0.0.10 22")
+		buf := new(bytes.Buffer)
+		pkceRemoteWriter = buf
+		defer func() { pkceRemoteWriter = os.Stderr }()
+
+		mock := &mockOIDCClient{
+			startPKCEFlowResult: oidc.PKCEAuthCodeFlow{
+				AuthorizationURL: "https://oidc.us-east-1.amazonaws.com/authorize?client_id=cid",
+				State:            "test-state",
+				CodeVerifier:     "test-verifier",
+			},
+			waitForCallbackErr: context.DeadlineExceeded,
+		}
+
+		as := &AWSSSO{
+			key:        "test",
+			SsoRegion:  "us-east-1",
+			store:      jstore,
+			urlAction:  "print",
+			oidcClient: mock,
+			SSOConfig: &ssoconfig.SSOConfig{
+				AuthWorkflow: oidc.AuthWorkflowPKCE,
+				PKCE: ssoconfig.PKCEConfig{
+					CallbackAddress: "0.0.0.0",
+					CallbackPort:    port,
+					Remote:          true,
+				},
+			},
+		}
+
+		sshCmd := fmt.Sprintf("ssh -N -L %d:127.0.0.1:%d alice@10.0.0.10", port, port)
+		err = as.reauthenticatePKCE(context.Background())
+		assert.ErrorContains(t, err, fmt.Sprintf("unable to receive pkce callback: timed out after 5m0s waiting on 0.0.0.0:%d", port))
+		assert.ErrorContains(t, err, sshCmd)
+		assert.Equal(t, fmt.Sprintf(PKCE_REMOTE_MSG, sshCmd), buf.String())
+
+		// the browser is redirected to the forwarded port on its own loopback
+		if assert.Len(t, mock.waitForCallbackInputs, 1) {
+			in := mock.waitForCallbackInputs[0]
+			assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", port), in.RedirectURI)
+			if assert.NotNil(t, in.Listener) {
+				assert.Equal(t, port, in.Listener.Addr().(*net.TCPAddr).Port)
+			}
+			assert.NoError(t, mock.listenerDialErr)
+		}
+		if assert.Len(t, mock.startPKCEFlowInputs, 1) {
+			assert.Equal(t, fmt.Sprintf("http://127.0.0.1:%d", port), mock.startPKCEFlowInputs[0].RedirectURI)
+		}
+
+		// the port is in use
+		ln, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		assert.NoError(t, err)
+		defer ln.Close()
+		as.SSOConfig.PKCE.CallbackAddress = "127.0.0.1"
+		err = as.reauthenticatePKCE(context.Background())
+		assert.ErrorContains(t, err, fmt.Sprintf("listen on 127.0.0.1:%d for pkce callback", port))
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		tfile, err := os.CreateTemp("", "*storage.json")
+		assert.NoError(t, err)
+		defer os.Remove(tfile.Name())
+
+		jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
+		assert.NoError(t, err)
+
+		mock := &mockOIDCClient{
+			startPKCEFlowResult: oidc.PKCEAuthCodeFlow{
+				AuthorizationURL: "https://oidc.us-east-1.amazonaws.com/authorize?client_id=cid",
+				State:            "test-state",
+				CodeVerifier:     "test-verifier",
+			},
+			waitForCallbackErr: context.DeadlineExceeded,
+		}
+
+		as := &AWSSSO{
+			key:        "test",
+			SsoRegion:  "us-east-1",
+			store:      jstore,
+			urlAction:  "print",
+			oidcClient: mock,
+			SSOConfig: &ssoconfig.SSOConfig{
+				AuthWorkflow: oidc.AuthWorkflowPKCE,
+				PKCE:         ssoconfig.PKCEConfig{Timeout: 2},
+			},
+		}
+
+		err = as.reauthenticatePKCE(context.Background())
+		assert.ErrorContains(t, err, "unable to receive pkce callback: timed out after 2m0s waiting on 127.0.0.1:")
+		assert.NotContains(t, err.Error(), "ssh")
+	})
+
 	t.Run("ExchangePKCEAuthCode error", func(t *testing.T) {
 		tfile, err := os.CreateTemp("", "*storage.json")
 		assert.NoError(t, err)
@@ -1177,6 +1285,8 @@ func TestTryRefreshToken(t *testing.T) {
 
 		jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
 		assert.NoError(t, err)
+		require.NoError(t, jstore.SaveRegisterClientData(context.Background(), "test", clientData))
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), "test", expiredToken))
 
 		newToken := storage.CreateTokenResponse{
 			AccessToken:  "new-access-token",
@@ -1195,7 +1305,7 @@ func TestTryRefreshToken(t *testing.T) {
 			oidcClient: mock,
 		}
 
-		ok := as.tryRefreshToken(context.Background(), expiredToken, clientData)
+		ok := as.tryRefreshToken(context.Background())
 		assert.True(t, ok)
 		assert.Equal(t, "new-access-token", as.Token.AccessToken)
 		assert.Equal(t, "new-refresh-token", as.Token.RefreshToken)
@@ -1222,6 +1332,8 @@ func TestTryRefreshToken(t *testing.T) {
 
 		jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
 		assert.NoError(t, err)
+		require.NoError(t, jstore.SaveRegisterClientData(context.Background(), "test", clientData))
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), "test", expiredToken))
 
 		mock := &mockOIDCClient{
 			exchangeRefreshErr: fmt.Errorf("token expired"),
@@ -1233,11 +1345,44 @@ func TestTryRefreshToken(t *testing.T) {
 			oidcClient: mock,
 		}
 
-		ok := as.tryRefreshToken(context.Background(), expiredToken, clientData)
+		ok := as.tryRefreshToken(context.Background())
 		assert.False(t, ok)
 		// Token in memory should be unchanged (zero value)
 		assert.Equal(t, "", as.Token.AccessToken)
 	})
+
+	t.Run("token already refreshed", func(t *testing.T) {
+		tfile, err := os.CreateTemp("", "*storage.json")
+		assert.NoError(t, err)
+		defer os.Remove(tfile.Name())
+
+		jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
+		assert.NoError(t, err)
+
+		// the keepalive already rotated the token before we got the lock
+		rotated := storage.CreateTokenResponse{
+			AccessToken:  "rotated-access-token",
+			ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+			RefreshToken: "rotated-refresh-token",
+		}
+		require.NoError(t, jstore.SaveRegisterClientData(context.Background(), "test", clientData))
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), "test", rotated))
+
+		mock := &mockOIDCClient{
+			exchangeRefreshErr: fmt.Errorf("refresh token already used"),
+		}
+
+		as := &AWSSSO{
+			key:        "test",
+			store:      jstore,
+			oidcClient: mock,
+		}
+
+		ok := as.tryRefreshToken(context.Background())
+		assert.True(t, ok)
+		assert.Equal(t, "rotated-access-token", as.Token.AccessToken)
+		assert.Empty(t, mock.exchangeRefreshInputs)
+	})
 }
 
 func TestValidAuthTokenRefresh(t *testing.T) {
@@ -19,10 +19,12 @@ package auth
  */
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"strings"
 	"testing"
@@ -34,9 +36,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
+	"github.com/synfinatic/flexlog"
 )
 
 // mock sso
@@ -131,6 +136,14 @@ func TestRoleARN(t *testing.T) {
 	assert.Equal(t, "arn:aws:iam::000001111111:role/FooBar", ri.RoleArn())
 }
 
+func TestAWSSSOPartition(t *testing.T) {
+	as := &AWSSSO{SsoRegion: "us-west-2"}
+	assert.Equal(t, "aws", as.Partition())
+
+	as.SsoRegion = "us-gov-west-1"
+	assert.Equal(t, "aws-us-gov", as.Partition())
+}
+
 func TestGetFieldNameRoleInfo(t *testing.T) {
 	ri := ssoconfig.RoleInfo{
 		AccountId: "1111111",
@@ -736,6 +749,42 @@ func TestGetRoleCredentials(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestGetRoleCredentialsRedactsLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	oldLogger := log
+	log = logger.WithRedaction(flexlog.NewLogger(flexlog.NewJSON, buf, false, flexlog.LevelTrace, false))
+	defer func() { log = oldLogger }()
+
+	as, cleanup := makeChainTestAWSSSOBase(t)
+	defer cleanup()
+
+	as.sso = &mockSsoAPI{
+		Results: []mockSsoAPIResults{
+			{
+				GetRoleCredentials: &awssso.GetRoleCredentialsOutput{
+					RoleCredentials: &ssotypes.RoleCredentials{
+						AccessKeyId:     aws.String("access-key-id"),
+						Expiration:      42,
+						SecretAccessKey: aws.String("secret-access-key"),
+						SessionToken:    aws.String("session-token"),
+					},
+				},
+			},
+		},
+	}
+
+	creds, err := as.GetRoleCredentials(int64(1111111), "FooBar")
+	require.NoError(t, err)
+	assert.Equal(t, "secret-access-key", creds.SecretAccessKey)
+
+	output := buf.String()
+	assert.Contains(t, output, "sso.GetRoleCredentials")
+	assert.Contains(t, output, "access-key-id")
+	for _, secret := range []string{"secret-access-key", "session-token", "access-token", "refresh-token"} {
+		assert.NotContains(t, output, secret)
+	}
+}
+
 func makeChainTestAWSSSOBase(t *testing.T) (*AWSSSO, func()) {
 	t.Helper()
 	tfile, err := os.CreateTemp("", "*storage.json")
@@ -760,6 +809,15 @@ func makeChainTestAWSSSOBase(t *testing.T) (*AWSSSO, func()) {
 							ARN: "arn:aws:iam::000001111111:role/InvalidViaRole",
 							Via: "not-a-valid-arn",
 						},
+						"UserChainRole": {
+							ARN: "arn:aws:iam::000001111111:role/UserChainRole",
+							Via: "arn:aws:iam::000001111111:user/breakglass",
+						},
+						"MfaChainRole": {
+							ARN:       "arn:aws:iam::000001111111:role/MfaChainRole",
+							Via:       "arn:aws:iam::000001111111:user/breakglass",
+							MfaSerial: "arn:aws:iam::000001111111:mfa/breakglass",
+						},
 					},
 				},
 			},
@@ -786,7 +844,7 @@ func TestGetRoleCredentialsInvalidVia(t *testing.T) {
 	assert.Contains(t, err.Error(), "invalid Via")
 }
 
-// TestGetRoleCredentialsLoopDetection verifies that a cycle in the Via chain causes a panic.
+// TestGetRoleCredentialsLoopDetection verifies that a cycle in the Via chain returns an error.
 func TestGetRoleCredentialsLoopDetection(t *testing.T) {
 	as, cleanup := makeChainTestAWSSSOBase(t)
 	defer cleanup()
@@ -795,9 +853,22 @@ func TestGetRoleCredentialsLoopDetection(t *testing.T) {
 	loopMap := map[string]bool{
 		"arn:aws:iam::000001111111:role/BaseRole": true,
 	}
-	assert.Panics(t, func() {
-		_, _ = as.getRoleCredentials(int64(1111111), "ChainRole", loopMap)
-	})
+	_, err := as.getRoleCredentials(int64(1111111), "ChainRole", loopMap, false)
+	assert.ErrorIs(t, err, roles.ErrChainLoop)
+
+	// the resolver catches loops in the config before making any API calls
+	as.SSOConfig.Accounts["000001111111"].Roles["LoopA"] = &ssoconfig.SSORole{
+		ARN: "arn:aws:iam::000001111111:role/LoopA",
+		Via: "arn:aws:iam::000001111111:role/LoopB",
+	}
+	as.SSOConfig.Accounts["000001111111"].Roles["LoopB"] = &ssoconfig.SSORole{
+		ARN: "arn:aws:iam::000001111111:role/LoopB",
+		Via: "arn:aws:iam::000001111111:role/LoopA",
+	}
+	as.sso = &mockSsoAPI{}
+	_, err = as.GetRoleCredentials(int64(1111111), "LoopA")
+	assert.ErrorIs(t, err, roles.ErrChainLoop)
+	assert.ErrorContains(t, err, "LoopA -> arn:aws:iam::000001111111:role/LoopB -> arn:aws:iam::000001111111:role/LoopA")
 }
 
 // TestGetRoleCredentialsViaRecursiveError verifies that an error from the recursive Via call propagates.
@@ -1036,6 +1107,181 @@ func TestGetRoleCredentialsVia(t *testing.T) {
 	})
 }
 
+const stsGetSessionTokenXML = `<GetSessionTokenResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetSessionTokenResult>
+    <Credentials>
+      <AccessKeyId>ASIASESSION</AccessKeyId>
+      <SecretAccessKey>session-secret</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2099-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </GetSessionTokenResult>
+  <ResponseMetadata><RequestId>test-req-id</RequestId></ResponseMetadata>
+</GetSessionTokenResponse>`
+
+// newStaticUserSTS returns a mock STS server which requires requests to be signed
+// by our static access key and records the form of each request
+func newStaticUserSTS(t *testing.T, forms *[]url.Values) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		*forms = append(*forms, r.Form)
+		w.Header().Set("Content-Type", "text/xml")
+		if !strings.Contains(r.Header.Get("Authorization"), "Credential=AKIASTATIC/") {
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `<ErrorResponse><Error><Code>InvalidClientTokenId</Code><Message>bad key</Message></Error></ErrorResponse>`)
+			return
+		}
+		switch r.Form.Get("Action") {
+		case "GetSessionToken":
+			fmt.Fprint(w, stsGetSessionTokenXML)
+		default:
+			fmt.Fprint(w, stsAssumeRoleXML)
+		}
+	}))
+}
+
+func saveStaticUser(t *testing.T, as *AWSSSO) {
+	t.Helper()
+	require.NoError(t, as.store.SaveStaticCredentials(context.Background(),
+		"arn:aws:iam::000001111111:user/breakglass",
+		storage.StaticCredentials{
+			UserName:        "breakglass",
+			AccountId:       1111111,
+			AccessKeyId:     "AKIASTATIC",
+			SecretAccessKey: "static-secret",
+		}))
+}
+
+// TestGetRoleCredentialsViaUser tests role chaining from an IAM User with StaticCredentials
+func TestGetRoleCredentialsViaUser(t *testing.T) {
+	forms := []url.Values{}
+	srv := newStaticUserSTS(t, &forms)
+	defer srv.Close()
+
+	as, cleanup := makeChainTestAWSSSOBase(t)
+	defer cleanup()
+	as.stsEndpoint = srv.URL
+
+	// no static credentials in the SecureStore
+	_, err := as.GetRoleCredentials(int64(1111111), "UserChainRole")
+	assert.ErrorContains(t, err, "unable to load static credentials")
+	assert.Empty(t, forms)
+
+	saveStaticUser(t, as)
+	creds, err := as.GetRoleCredentials(int64(1111111), "UserChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIACHAIN", creds.AccessKeyId)
+	assert.Equal(t, "UserChainRole", creds.RoleName)
+	assert.True(t, creds.RoleChaining)
+	assert.False(t, creds.IAMUser)
+
+	require.Len(t, forms, 1)
+	assert.Equal(t, "AssumeRole", forms[0].Get("Action"))
+	assert.Equal(t, "arn:aws:iam::000001111111:role/UserChainRole", forms[0].Get("RoleArn"))
+	assert.Equal(t, "breakglass@000001111111", forms[0].Get("RoleSessionName"))
+}
+
+// TestGetRoleCredentialsMfa tests role chaining with an MfaSerial and the caching of the MFA session
+func TestGetRoleCredentialsMfa(t *testing.T) {
+	forms := []url.Values{}
+	srv := newStaticUserSTS(t, &forms)
+	defer srv.Close()
+
+	as, cleanup := makeChainTestAWSSSOBase(t)
+	defer cleanup()
+	as.stsEndpoint = srv.URL
+	saveStaticUser(t, as)
+
+	// no way to get a TOTP code
+	_, err := as.GetRoleCredentials(int64(1111111), "MfaChainRole")
+	assert.ErrorContains(t, err, "requires an MFA token from arn:aws:iam::000001111111:mfa/breakglass")
+	assert.Empty(t, forms)
+
+	as.SetMfaTokenFunc(func(mfaSerial string) (string, error) {
+		return "", fmt.Errorf("no token for %s", mfaSerial)
+	})
+	_, err = as.GetRoleCredentials(int64(1111111), "MfaChainRole")
+	assert.ErrorContains(t, err, "no token for arn:aws:iam::000001111111:mfa/breakglass")
+	assert.Empty(t, forms)
+
+	prompts := 0
+	as.SetMfaTokenFunc(func(mfaSerial string) (string, error) {
+		prompts++
+		return "123456", nil
+	})
+	creds, err := as.GetRoleCredentials(int64(1111111), "MfaChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIACHAIN", creds.AccessKeyId)
+	assert.Equal(t, "MfaChainRole", creds.RoleName)
+	assert.Equal(t, 1, prompts)
+
+	require.Len(t, forms, 1)
+	assert.Equal(t, "AssumeRole", forms[0].Get("Action"))
+	assert.Equal(t, "arn:aws:iam::000001111111:mfa/breakglass", forms[0].Get("SerialNumber"))
+	assert.Equal(t, "123456", forms[0].Get("TokenCode"))
+
+	// MFA session is reused until it expires
+	creds, err = as.GetRoleCredentials(int64(1111111), "MfaChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, "AKIACHAIN", creds.AccessKeyId)
+	assert.Equal(t, 1, prompts)
+	assert.Len(t, forms, 1)
+
+	// the MFA session doesn't use the role ARN which our callers cache the creds under
+	arn := "arn:aws:iam::000001111111:role/MfaChainRole"
+	assert.Error(t, as.store.GetRoleCredentials(arn, &storage.RoleCredentials{}))
+
+	// unless we are asked to refresh
+	_, err = as.RefreshRoleCredentials(int64(1111111), "MfaChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, 2, prompts)
+	assert.Len(t, forms, 2)
+
+	creds.Expiration = time.Now().UnixMilli()
+	key := mfaSessionKey("arn:aws:iam::000001111111:mfa/breakglass", arn)
+	require.NoError(t, as.store.SaveRoleCredentials(context.Background(), key, creds))
+	_, err = as.GetRoleCredentials(int64(1111111), "MfaChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, 3, prompts)
+	assert.Len(t, forms, 3)
+
+	as.DeleteMfaSessions(context.Background())
+	assert.Error(t, as.store.GetRoleCredentials(key, &storage.RoleCredentials{}))
+
+	// roles without an MfaSerial never prompt
+	_, err = as.GetRoleCredentials(int64(1111111), "UserChainRole")
+	require.NoError(t, err)
+	assert.Equal(t, 3, prompts)
+	assert.Empty(t, forms[3].Get("SerialNumber"))
+}
+
+func TestGetUserCredentials(t *testing.T) {
+	forms := []url.Values{}
+	srv := newStaticUserSTS(t, &forms)
+	defer srv.Close()
+
+	as, cleanup := makeChainTestAWSSSOBase(t)
+	defer cleanup()
+	as.stsEndpoint = srv.URL
+
+	arn := "arn:aws:iam::000001111111:user/breakglass"
+	_, err := as.GetUserCredentials(arn)
+	assert.Error(t, err)
+
+	saveStaticUser(t, as)
+	creds, err := as.GetUserCredentials(arn)
+	require.NoError(t, err)
+	assert.Equal(t, "ASIASESSION", creds.AccessKeyId)
+	assert.Equal(t, "session-token", creds.SessionToken)
+	assert.Equal(t, "breakglass", creds.RoleName)
+	assert.True(t, creds.IAMUser)
+	assert.Equal(t, arn, creds.RoleArn())
+
+	require.Len(t, forms, 1)
+	assert.Equal(t, "GetSessionToken", forms[0].Get("Action"))
+}
+
 func TestListAccountRolesMaxResultsWithinAPILimit(t *testing.T) {
 	tfile, err := os.CreateTemp("", "*storage.json")
 	assert.NoError(t, err)
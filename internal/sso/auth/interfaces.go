@@ -20,6 +20,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
@@ -31,6 +32,7 @@ type Authenticator interface {
 	Authenticate(ctx context.Context, urlAction uri.Action, browser string) error
 	ValidAuthToken(ctx context.Context) bool
 	Logout(ctx context.Context) error
+	KeepAlive(ctx context.Context, refreshBefore time.Duration) error
 }
 
 // Compile-time assertions that *AWSSSO satisfies both interfaces.
@@ -0,0 +1,164 @@
+package auth
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// DEFAULT_KEEPALIVE_REFRESH is how long before the AccessToken expires that
+// KeepAlive() refreshes it
+const DEFAULT_KEEPALIVE_REFRESH = 15 * time.Minute
+
+// backoff between failed refresh attempts
+var KEEPALIVE_MIN_BACKOFF = 30 * time.Second
+var KEEPALIVE_MAX_BACKOFF = 10 * time.Minute
+
+// KEEPALIVE_MAX_SLEEP limits how long KeepAlive() sleeps between checks of
+// the token.  Timers don't advance while the host is suspended, so we can't
+// simply sleep until the refresh is due.
+var KEEPALIVE_MAX_SLEEP = 5 * time.Minute
+
+// ErrRefreshTokenExpired is returned by KeepAlive() when the refresh token
+// has lapsed and a new login is required
+var ErrRefreshTokenExpired = errors.New("AWS SSO refresh token has expired")
+
+// KeepAlive refreshes the AccessToken via the refresh token before it expires,
+// so that it never lapses while in use.  refreshBefore is how long before the
+// token expires to refresh it.  Failed refreshes are retried with exponential
+// backoff.  KeepAlive returns nil when ctx is cancelled, ErrRefreshTokenExpired
+// if the refresh token is no longer valid, or an error if there is no token
+// which can be refreshed.
+func (as *AWSSSO) KeepAlive(ctx context.Context, refreshBefore time.Duration) error {
+	var backoff time.Duration
+	for {
+		token, _, err := as.refreshableToken()
+		if err != nil {
+			return err
+		}
+
+		wait := time.Until(refreshTime(token, refreshBefore))
+		if wait <= 0 {
+			err = as.keepAliveRefresh(ctx, refreshBefore)
+			switch {
+			case ctx.Err() != nil:
+				return nil
+
+			case err == nil:
+				backoff = 0
+				continue
+
+			case errors.Is(err, ErrRefreshTokenExpired):
+				return err
+			}
+
+			backoff = nextKeepAliveBackoff(backoff)
+			log.Warn("Unable to refresh AWS SSO token, will retry",
+				"storeKey", as.StoreKey(), "retry", backoff.String(), "error", err.Error())
+			wait = backoff
+		}
+
+		if err := sleepContext(ctx, min(wait, KEEPALIVE_MAX_SLEEP)); err != nil {
+			return nil
+		}
+	}
+}
+
+// keepAliveRefresh refreshes our AccessToken unless another process or
+// goroutine has already done so since we last checked
+func (as *AWSSSO) keepAliveRefresh(ctx context.Context, refreshBefore time.Duration) error {
+	as.authenticateLock.Lock()
+	defer as.authenticateLock.Unlock()
+
+	token, clientData, err := as.refreshableToken()
+	if err != nil {
+		return err
+	}
+	if time.Until(refreshTime(token, refreshBefore)) > 0 {
+		return nil
+	}
+
+	log.Debug("Refreshing AWS SSO token", "storeKey", as.StoreKey())
+	newToken, err := as.refreshToken(ctx, token, clientData)
+	if err != nil {
+		if oidc.IsRefreshTokenExpired(err) {
+			return fmt.Errorf("%w for %s.  Please run 'aws-sso login': %s", ErrRefreshTokenExpired, as.StoreKey(), err.Error())
+		}
+		return err
+	}
+
+	log.Info("Refreshed AWS SSO token", "storeKey", as.StoreKey(),
+		"expires", time.Unix(newToken.ExpiresAt, 0).Format("Mon Jan 2 15:04:05 -0700 MST 2006"))
+	return nil
+}
+
+// refreshableToken returns our stored token and client registration, or an
+// error if we don't have a token with a refresh token
+func (as *AWSSSO) refreshableToken() (storage.CreateTokenResponse, storage.RegisterClientData, error) {
+	token := storage.CreateTokenResponse{}
+	clientData := storage.RegisterClientData{}
+
+	if err := as.store.GetCreateTokenResponse(as.StoreKey(), &token); err != nil {
+		return token, clientData, fmt.Errorf("no AWS SSO token for %s.  Please run 'aws-sso login': %w", as.StoreKey(), err)
+	}
+	if token.RefreshToken == "" {
+		return token, clientData, fmt.Errorf("AWS SSO token for %s has no refresh token.  Please run 'aws-sso login --force'", as.StoreKey())
+	}
+	if err := as.store.GetRegisterClientData(as.StoreKey(), &clientData); err != nil {
+		return token, clientData, fmt.Errorf("no AWS SSO client registration for %s.  Please run 'aws-sso login': %w", as.StoreKey(), err)
+	}
+	return token, clientData, nil
+}
+
+// refreshTime returns when the given token should be refreshed.  We never
+// refresh before the token is half way through its lifetime so a refreshBefore
+// longer than the token lifetime doesn't refresh in a tight loop.
+func refreshTime(token storage.CreateTokenResponse, refreshBefore time.Duration) time.Time {
+	if half := time.Duration(token.ExpiresIn) * time.Second / 2; half > 0 && refreshBefore > half {
+		refreshBefore = half
+	}
+	return time.Unix(token.ExpiresAt, 0).Add(-refreshBefore)
+}
+
+// nextKeepAliveBackoff doubles the given backoff within our limits
+func nextKeepAliveBackoff(backoff time.Duration) time.Duration {
+	if backoff < KEEPALIVE_MIN_BACKOFF {
+		return KEEPALIVE_MIN_BACKOFF
+	}
+	return min(backoff*2, KEEPALIVE_MAX_BACKOFF)
+}
+
+// sleepContext sleeps for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	oidctypes "github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// keepAliveOIDCClient returns a different ExchangeRefreshToken result per call
+type keepAliveOIDCClient struct {
+	mockOIDCClient
+	results []keepAliveResult
+}
+
+type keepAliveResult struct {
+	token storage.CreateTokenResponse
+	err   error
+}
+
+func (m *keepAliveOIDCClient) ExchangeRefreshToken(_ context.Context, in oidc.ExchangeRefreshTokenInput) (storage.CreateTokenResponse, error) {
+	m.exchangeRefreshInputs = append(m.exchangeRefreshInputs, in)
+	r := m.results[0]
+	m.results = m.results[1:]
+	return r.token, r.err
+}
+
+func keepAliveSetup(t *testing.T, client oidc.Client) (*AWSSSO, storage.SecureStorage) {
+	tfile, err := os.CreateTemp("", "*storage.json")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = os.Remove(tfile.Name()) // nolint:gosec
+	})
+
+	jstore, err := storage.OpenJsonStore(context.Background(), tfile.Name())
+	require.NoError(t, err)
+
+	as := &AWSSSO{
+		key:        "keepalive",
+		store:      jstore,
+		oidcClient: client,
+	}
+
+	clientData := storage.RegisterClientData{
+		ClientId:              "cid",
+		ClientSecret:          "csecret",
+		ClientSecretExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+	require.NoError(t, jstore.SaveRegisterClientData(context.Background(), as.StoreKey(), clientData))
+	return as, jstore
+}
+
+func TestRefreshTime(t *testing.T) {
+	expires := time.Now().Add(time.Hour).Truncate(time.Second)
+	token := storage.CreateTokenResponse{
+		ExpiresIn: 3600,
+		ExpiresAt: expires.Unix(),
+	}
+	assert.Equal(t, expires.Add(-15*time.Minute), refreshTime(token, 15*time.Minute))
+
+	// never before half way through the token lifetime
+	assert.Equal(t, expires.Add(-30*time.Minute), refreshTime(token, 2*time.Hour))
+
+	// no lifetime, so we can't limit it
+	token.ExpiresIn = 0
+	assert.Equal(t, expires.Add(-2*time.Hour), refreshTime(token, 2*time.Hour))
+}
+
+func TestNextKeepAliveBackoff(t *testing.T) {
+	assert.Equal(t, KEEPALIVE_MIN_BACKOFF, nextKeepAliveBackoff(0))
+	assert.Equal(t, 2*KEEPALIVE_MIN_BACKOFF, nextKeepAliveBackoff(KEEPALIVE_MIN_BACKOFF))
+	assert.Equal(t, KEEPALIVE_MAX_BACKOFF, nextKeepAliveBackoff(KEEPALIVE_MAX_BACKOFF))
+	assert.Equal(t, KEEPALIVE_MAX_BACKOFF, nextKeepAliveBackoff(KEEPALIVE_MAX_BACKOFF-time.Second))
+}
+
+func TestKeepAlive(t *testing.T) {
+	oldBackoff := KEEPALIVE_MIN_BACKOFF
+	KEEPALIVE_MIN_BACKOFF = 10 * time.Millisecond
+	defer func() { KEEPALIVE_MIN_BACKOFF = oldBackoff }()
+
+	t.Run("refreshes until the refresh token expires", func(t *testing.T) {
+		refreshed := storage.CreateTokenResponse{
+			AccessToken:  "refreshed-access-token",
+			ExpiresIn:    3600,
+			ExpiresAt:    time.Now().Add(time.Minute).Unix(), // due again immediately
+			RefreshToken: "new-refresh-token",
+		}
+		client := &keepAliveOIDCClient{
+			results: []keepAliveResult{
+				{err: fmt.Errorf("connection reset by peer")},
+				{token: refreshed},
+				{err: &oidctypes.InvalidGrantException{}},
+			},
+		}
+		as, jstore := keepAliveSetup(t, client)
+
+		token := storage.CreateTokenResponse{
+			AccessToken:  "access-token",
+			ExpiresIn:    3600,
+			ExpiresAt:    time.Now().Add(time.Minute).Unix(),
+			RefreshToken: "stored-refresh-token",
+		}
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), as.StoreKey(), token))
+
+		err := as.KeepAlive(context.Background(), DEFAULT_KEEPALIVE_REFRESH)
+		assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+		assert.Contains(t, err.Error(), "aws-sso login")
+
+		if assert.Len(t, client.exchangeRefreshInputs, 3) {
+			assert.Equal(t, "cid", client.exchangeRefreshInputs[0].ClientID)
+			assert.Equal(t, "csecret", client.exchangeRefreshInputs[0].ClientSecret)
+			assert.Equal(t, "stored-refresh-token", client.exchangeRefreshInputs[0].RefreshToken)
+			assert.Equal(t, "stored-refresh-token", client.exchangeRefreshInputs[1].RefreshToken)
+			assert.Equal(t, "new-refresh-token", client.exchangeRefreshInputs[2].RefreshToken)
+		}
+
+		// the refreshed token was saved
+		got := storage.CreateTokenResponse{}
+		require.NoError(t, jstore.GetCreateTokenResponse(as.StoreKey(), &got))
+		assert.Equal(t, "refreshed-access-token", got.AccessToken)
+		assert.Equal(t, "refreshed-access-token", as.Token.AccessToken)
+	})
+
+	t.Run("waits until the refresh is due", func(t *testing.T) {
+		client := &keepAliveOIDCClient{}
+		as, jstore := keepAliveSetup(t, client)
+
+		token := storage.CreateTokenResponse{
+			AccessToken:  "access-token",
+			ExpiresIn:    3600,
+			ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+			RefreshToken: "stored-refresh-token",
+		}
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), as.StoreKey(), token))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		assert.NoError(t, as.KeepAlive(ctx, DEFAULT_KEEPALIVE_REFRESH))
+		assert.Empty(t, client.exchangeRefreshInputs)
+	})
+
+	t.Run("no token", func(t *testing.T) {
+		as, _ := keepAliveSetup(t, &keepAliveOIDCClient{})
+		err := as.KeepAlive(context.Background(), DEFAULT_KEEPALIVE_REFRESH)
+		assert.ErrorContains(t, err, "no AWS SSO token for keepalive")
+		assert.NotErrorIs(t, err, ErrRefreshTokenExpired)
+	})
+
+	t.Run("no refresh token", func(t *testing.T) {
+		as, jstore := keepAliveSetup(t, &keepAliveOIDCClient{})
+		token := storage.CreateTokenResponse{
+			AccessToken: "access-token",
+			ExpiresAt:   time.Now().Add(time.Hour).Unix(),
+		}
+		require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), as.StoreKey(), token))
+
+		err := as.KeepAlive(context.Background(), DEFAULT_KEEPALIVE_REFRESH)
+		assert.ErrorContains(t, err, "has no refresh token")
+	})
+}
+
+func TestKeepAliveRefreshAlreadyDone(t *testing.T) {
+	client := &keepAliveOIDCClient{}
+	as, jstore := keepAliveSetup(t, client)
+
+	// another process refreshed the token after we decided it was due
+	token := storage.CreateTokenResponse{
+		AccessToken:  "access-token",
+		ExpiresIn:    3600,
+		ExpiresAt:    time.Now().Add(time.Hour).Unix(),
+		RefreshToken: "stored-refresh-token",
+	}
+	require.NoError(t, jstore.SaveCreateTokenResponse(context.Background(), as.StoreKey(), token))
+
+	assert.NoError(t, as.keepAliveRefresh(context.Background(), DEFAULT_KEEPALIVE_REFRESH))
+	assert.Empty(t, client.exchangeRefreshInputs)
+}
@@ -19,18 +19,22 @@ package cache
  */
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
 	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
 const (
-	CACHE_VERSION = 4
+	CACHE_VERSION      = 5
+	CACHE_LOCK_TIMEOUT = 30 * time.Second
 )
 
 // Re-export roles types for backward compatibility with sso/ package consumers
@@ -50,12 +54,13 @@ type SSOCache struct {
 
 // Our Cachefile.  Sub-structs defined in cache.go
 type Cache struct {
-	Version         int64                `json:"Version"`
-	cacheFile       string               // path to the cache file
-	ConfigCreatedAt int64                `json:"ConfigCreatedAt"` // track config.yaml
-	SSO             map[string]*SSOCache `json:"SSO,omitempty"`
-	ssoName         string               // name of SSO that is active
-	refreshed       bool                 // track if we have run Refresh() since this is expensive
+	Version         int64                    `json:"Version"`
+	cacheFile       string                   // path to the cache file
+	ConfigCreatedAt int64                    `json:"ConfigCreatedAt"` // track config.yaml
+	SSO             map[string]*SSOCache     `json:"SSO,omitempty"`
+	ssoName         string                   // name of SSO that is active
+	refreshed       bool                     // track if we have run Refresh() since this is expensive
+	changes         map[string]*cacheChanges // unsaved changes per SSO instance
 }
 
 // GetSSOName returns the name of the active SSO instance.
@@ -173,28 +178,83 @@ func (c *Cache) CacheFile() string {
 	return c.cacheFile
 }
 
-// Save saves our cache to the current file
+// Save saves our cache to the current file.  Other aws-sso processes may
+// be updating the same file, so we hold an advisory lock while merging our
+// changes into what is on disk and atomically replace the file.
 func (c *Cache) Save(updateTime bool) error {
 	c.Version = CACHE_VERSION
 	if updateTime {
 		cache := c.GetSSO()
 		cache.LastUpdate = time.Now().Unix()
+		c.pending(c.ssoName).replaced = true
 	}
-	jbytes, err := json.MarshalIndent(c, "", "  ")
-	if err != nil {
-		return fmt.Errorf("unable to marshal json: %s", err.Error())
-	}
-	err = fileutils.EnsureDirExists(c.CacheFile())
+
+	err := fileutils.EnsureDirExists(c.CacheFile())
 	if err != nil {
 		return fmt.Errorf("unable to create directory for %s: %s", c.CacheFile(), err.Error())
 	}
-	err = os.WriteFile(c.CacheFile(), jbytes, 0600)
+
+	err = storage.WithFlock(context.Background(), c.LockFile(), CACHE_LOCK_TIMEOUT, func() error {
+		if disk, err := readCacheFile(c.CacheFile()); err == nil && disk.Version == CACHE_VERSION {
+			c.merge(disk)
+		}
+
+		jbytes, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			return fmt.Errorf("unable to marshal json: %s", err.Error())
+		}
+		return writeFileAtomic(c.CacheFile(), jbytes)
+	})
 	if err != nil {
-		return fmt.Errorf("unable to write %s: %s", c.CacheFile(), err.Error())
+		return fmt.Errorf("unable to save %s: %w", c.CacheFile(), err)
 	}
+	c.changes = nil
 	return nil
 }
 
+// LockFile returns the path of the advisory lock file for our cache file
+func (c *Cache) LockFile() string {
+	return c.cacheFile + ".lock"
+}
+
+// readCacheFile loads the cache file without any of the OpenCache cleanup
+func readCacheFile(f string) (*Cache, error) {
+	cacheBytes, err := os.ReadFile(f) // nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	c := &Cache{
+		SSO: map[string]*SSOCache{},
+	}
+	if err = json.Unmarshal(cacheBytes, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and then
+// renames it over f so readers never see a partially written file
+func writeFileAtomic(f string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f), filepath.Base(f)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // nolint:errcheck
+
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f)
+}
+
 // NeedsRefresh checks whether the config hash has changed
 func (c *SSOCache) NeedsRefresh(s *ssoconfig.SSOConfig, settings SettingsReader) bool {
 	checkHash := s.GetConfigHash(settings.GetProfileFormat())
@@ -216,6 +276,7 @@ func (c *Cache) PruneSSO(settings SettingsReader) {
 		if !hasSSO {
 			log.Debug("pruning from cache", "SSOName", sso)
 			delete(c.SSO, sso)
+			c.pending(sso).pruned = true
 		}
 	}
 }
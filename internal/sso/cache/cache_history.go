@@ -30,39 +30,59 @@ import (
 // AddHistory adds a role ARN to the History list up to the max number of entries
 // and then removes the History tag from any roles that aren't in our list
 func (c *Cache) AddHistory(s SettingsReader, item string) {
-	// If it's already in the list, remove item
-	c.deleteHistoryItem(item)
-
-	c.GetSSO().History = append([]string{item}, c.GetSSO().History...) // push on top
-	for int64(len(c.GetSSO().History)) > s.GetHistoryLimit() {
-		// remove the oldest entry
-		c.GetSSO().History = c.GetSSO().History[:len(c.GetSSO().History)-1]
-	}
+	cache := c.GetSSO()
+	cache.pushHistory(item, s.GetHistoryLimit())
 
 	// Update our Tags for this new item
 	aId, roleName, _ := awsparse.ParseRoleARN(item)
-	if a, ok := c.GetSSO().Roles.Accounts[aId]; ok {
+	if a, ok := cache.Roles.Accounts[aId]; ok {
 		if r, ok := a.Roles[roleName]; ok {
 			r.Tags["History"] = fmt.Sprintf("%s:%s,%d", a.Alias, roleName, time.Now().Unix())
 		}
 	}
 
-	// remove any history tags not in our list
-	roles := c.GetSSO().Roles.MatchingRolesWithTagKey("History")
+	cache.pruneHistoryTags()
+
+	ch := c.pending(c.ssoName)
+	ch.history = append(ch.history, item)
+	ch.historyLimit = s.GetHistoryLimit()
+}
+
+// pushHistory moves the role ARN to the top of the History list, keeping at
+// most limit entries
+func (sc *SSOCache) pushHistory(item string, limit int64) {
+	// If it's already in the list, remove item
+	for i, value := range sc.History {
+		if item == value {
+			sc.History = append(sc.History[:i], sc.History[i+1:]...)
+			break
+		}
+	}
+
+	sc.History = append([]string{item}, sc.History...) // push on top
+	for int64(len(sc.History)) > limit {
+		// remove the oldest entry
+		sc.History = sc.History[:len(sc.History)-1]
+	}
+}
+
+// pruneHistoryTags removes the History tag from any roles not in our History list
+func (sc *SSOCache) pruneHistoryTags() {
+	roles := sc.Roles.MatchingRolesWithTagKey("History")
 
 	for _, role := range roles {
 		exists := false
-		for _, history := range c.GetSSO().History {
+		for _, history := range sc.History {
 			if history == (*role).Arn {
 				exists = true
 				break
 			}
 		}
 
-		// remove any History tag for roles which don't exist in c.GetSSO().History
+		// remove any History tag for roles which don't exist in History
 		if !exists {
 			aId, roleName, _ := awsparse.ParseRoleARN(role.Arn)
-			delete(c.GetSSO().Roles.Accounts[aId].Roles[roleName].Tags, "History")
+			delete(sc.Roles.Accounts[aId].Roles[roleName].Tags, "History")
 		}
 	}
 }
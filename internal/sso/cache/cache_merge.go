@@ -0,0 +1,166 @@
+package cache
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// cacheChanges tracks what this process changed for a single SSO instance since
+// the cache was last saved so that Save() can replay them on top of any updates
+// written by other aws-sso processes in the meantime.
+type cacheChanges struct {
+	replaced     bool             // Roles were refreshed, so our copy wins
+	pruned       bool             // SSO instance was removed via PruneSSO
+	expireAll    bool             // MarkRolesExpired was called
+	expires      map[string]int64 // role ARN => Expires set via SetRoleExpires
+	history      []string         // role ARNs added via AddHistory, oldest first
+	historyLimit int64
+	staticUsers  []storage.StaticCredentials // IAM Users set via SetStaticUsers, nil if unchanged
+}
+
+// pending returns the changes for the given SSO instance, creating it as necessary
+func (c *Cache) pending(ssoName string) *cacheChanges {
+	if c.changes == nil {
+		c.changes = map[string]*cacheChanges{}
+	}
+	if _, ok := c.changes[ssoName]; !ok {
+		c.changes[ssoName] = &cacheChanges{
+			expires: map[string]int64{},
+			history: []string{},
+		}
+	}
+	return c.changes[ssoName]
+}
+
+// merge updates our cache with the contents of disk, the cache file as
+// currently written by another process, and then re-applies our pending changes.
+func (c *Cache) merge(disk *Cache) {
+	if disk.ConfigCreatedAt > c.ConfigCreatedAt {
+		c.ConfigCreatedAt = disk.ConfigCreatedAt
+	}
+
+	for ssoName, theirs := range disk.SSO {
+		ours, ok := c.SSO[ssoName]
+		ch := c.changes[ssoName]
+
+		switch {
+		case theirs == nil || (ch != nil && ch.pruned):
+			continue
+
+		case !ok || ours == nil:
+			c.SSO[ssoName] = theirs
+			continue
+
+		case ch == nil:
+			// we didn't change anything, so their copy is at least as new as ours
+			*ours = *theirs
+			continue
+
+		case ch.replaced:
+			// keep our refreshed roles, but pick up the role metadata and history
+			// written by other processes since we loaded the cache
+			expires, historyTags := disk.GetExpirationAndHistory(ssoName)
+			c.RestoreMetadata(ssoName, expires, historyTags)
+			ours.History = theirs.History
+
+		default:
+			// their roles are at least as new as ours, but we need our own
+			// History tags before we replace them
+			historyTags := map[string]string{}
+			for _, arn := range ch.history {
+				if tag, ok := ours.historyTag(arn); ok {
+					historyTags[arn] = tag
+				}
+			}
+			*ours = *theirs
+			ours.applyHistoryTags(historyTags)
+		}
+
+		ours.applyChanges(ch)
+	}
+}
+
+// applyChanges replays the given changes on our SSOCache
+func (sc *SSOCache) applyChanges(ch *cacheChanges) {
+	if ch.expireAll {
+		for _, account := range sc.Roles.Accounts {
+			for _, role := range account.Roles {
+				role.Expires = 0
+			}
+		}
+	}
+
+	if ch.staticUsers != nil {
+		sc.setStaticUsers(ch.staticUsers)
+	}
+
+	for arn, expires := range ch.expires {
+		if role := sc.getRole(arn); role != nil {
+			role.Expires = expires
+		}
+	}
+
+	if len(ch.history) > 0 {
+		for _, arn := range ch.history {
+			sc.pushHistory(arn, ch.historyLimit)
+		}
+		sc.pruneHistoryTags()
+	}
+}
+
+// getRole returns the AWSRole for the given ARN or nil if it doesn't exist
+func (sc *SSOCache) getRole(arn string) *AWSRole {
+	if sc.Roles == nil {
+		return nil
+	}
+	aId, roleName, err := awsparse.ParseRoleARN(arn)
+	if err != nil {
+		return nil
+	}
+	if a, ok := sc.Roles.Accounts[aId]; ok {
+		if r, ok := a.Roles[roleName]; ok {
+			return r
+		}
+	}
+	return nil
+}
+
+// historyTag returns the History tag for the given role ARN
+func (sc *SSOCache) historyTag(arn string) (string, bool) {
+	role := sc.getRole(arn)
+	if role == nil {
+		return "", false
+	}
+	tag, ok := role.Tags["History"]
+	return tag, ok
+}
+
+// applyHistoryTags sets the History tag for each of the role ARNs
+func (sc *SSOCache) applyHistoryTags(historyTags map[string]string) {
+	for arn, tag := range historyTags {
+		if role := sc.getRole(arn); role != nil {
+			if role.Tags == nil {
+				role.Tags = map[string]string{}
+			}
+			role.Tags["History"] = tag
+		}
+	}
+}
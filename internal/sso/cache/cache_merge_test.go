@@ -0,0 +1,220 @@
+package cache
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+)
+
+const (
+	MERGE_ROLE_ARN_A = "arn:aws:iam::025823461518:role/AWSPowerUserAccess"
+	MERGE_ROLE_ARN_B = "arn:aws:iam::502470824893:role/AWSReadOnlyAccess"
+)
+
+// newMergeTestCache copies our test cache into a temp dir and returns the path
+func newMergeTestCache(t *testing.T) (string, *mockSettingsReader) {
+	t.Helper()
+	input, err := os.ReadFile(TEST_CACHE_FILE)
+	require.NoError(t, err)
+
+	f := filepath.Join(t.TempDir(), "cache.json")
+	require.NoError(t, os.WriteFile(f, input, 0600))
+
+	settings := &mockSettingsReader{
+		cacheFile:    f,
+		defaultSSO:   "Default",
+		historyLimit: 10,
+		ssoNames:     []string{"Default"},
+	}
+	return f, settings
+}
+
+func openMergeTestCache(t *testing.T, f string, s SettingsReader) *Cache {
+	t.Helper()
+	c, err := OpenCache(f, s)
+	require.NoError(t, err)
+	return c
+}
+
+// roleExpires returns the Expires value for the role in the current SSO instance
+func roleExpires(t *testing.T, c *Cache, arn string) int64 {
+	t.Helper()
+	r := c.GetSSO().getRole(arn)
+	require.NotNil(t, r, arn)
+	return r.Expires
+}
+
+func TestSaveMergesHistory(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+
+	c1 := openMergeTestCache(t, f, settings)
+	c2 := openMergeTestCache(t, f, settings)
+
+	c1.AddHistory(settings, MERGE_ROLE_ARN_A)
+	require.NoError(t, c1.Save(false))
+
+	c2.AddHistory(settings, MERGE_ROLE_ARN_B)
+	require.NoError(t, c2.Save(false))
+
+	c3 := openMergeTestCache(t, f, settings)
+	history := c3.GetSSO().History
+	require.GreaterOrEqual(t, len(history), 2)
+	assert.Equal(t, MERGE_ROLE_ARN_B, history[0])
+	assert.Equal(t, MERGE_ROLE_ARN_A, history[1])
+
+	for _, arn := range []string{MERGE_ROLE_ARN_A, MERGE_ROLE_ARN_B} {
+		r, err := c3.GetRole(arn)
+		require.NoError(t, err)
+		assert.NotEmpty(t, r.Tags["History"], arn)
+	}
+
+	// c2 in memory now reflects both changes as well
+	assert.Equal(t, history, c2.GetSSO().History)
+}
+
+func TestSaveMergesHistoryLimit(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+	settings.historyLimit = 1
+
+	c1 := openMergeTestCache(t, f, settings)
+	c2 := openMergeTestCache(t, f, settings)
+
+	c1.AddHistory(settings, MERGE_ROLE_ARN_A)
+	require.NoError(t, c1.Save(false))
+
+	c2.AddHistory(settings, MERGE_ROLE_ARN_B)
+	require.NoError(t, c2.Save(false))
+
+	c3 := openMergeTestCache(t, f, settings)
+	assert.Equal(t, []string{MERGE_ROLE_ARN_B}, c3.GetSSO().History)
+
+	r, err := c3.GetRole(MERGE_ROLE_ARN_A)
+	require.NoError(t, err)
+	assert.NotContains(t, r.Tags, "History")
+}
+
+func TestSaveMergesExpires(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+
+	c1 := openMergeTestCache(t, f, settings)
+	c2 := openMergeTestCache(t, f, settings)
+
+	require.NoError(t, c1.SetRoleExpires(MERGE_ROLE_ARN_A, 1000))
+	require.NoError(t, c2.SetRoleExpires(MERGE_ROLE_ARN_B, 2000))
+
+	c3 := openMergeTestCache(t, f, settings)
+	assert.Equal(t, int64(1000), roleExpires(t, c3, MERGE_ROLE_ARN_A))
+
+	assert.Equal(t, int64(2000), roleExpires(t, c3, MERGE_ROLE_ARN_B))
+
+	// expiring everything wins over older changes, but not newer ones
+	require.NoError(t, c1.MarkRolesExpired())
+	require.NoError(t, c2.SetRoleExpires(MERGE_ROLE_ARN_B, 3000))
+
+	c3 = openMergeTestCache(t, f, settings)
+	assert.Equal(t, int64(0), roleExpires(t, c3, MERGE_ROLE_ARN_A))
+
+	assert.Equal(t, int64(3000), roleExpires(t, c3, MERGE_ROLE_ARN_B))
+}
+
+func TestSaveKeepsRefreshedRoles(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+
+	c1 := openMergeTestCache(t, f, settings)
+	c2 := openMergeTestCache(t, f, settings)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	require.NoError(t, c1.SetRoleExpires(MERGE_ROLE_ARN_A, expires))
+
+	// c2 "refreshes" its roles, dropping one of the accounts
+	aId, _, err := awsparse.ParseRoleARN(MERGE_ROLE_ARN_B)
+	require.NoError(t, err)
+	delete(c2.GetSSO().Roles.Accounts, aId)
+	require.NoError(t, c2.Save(true))
+
+	c3 := openMergeTestCache(t, f, settings)
+	_, err = c3.GetRole(MERGE_ROLE_ARN_B)
+	assert.Error(t, err)
+
+	// but still has the expires time from c1
+	assert.Equal(t, expires, roleExpires(t, c3, MERGE_ROLE_ARN_A))
+}
+
+func TestSaveCorruptFile(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+	c := openMergeTestCache(t, f, settings)
+
+	require.NoError(t, os.WriteFile(f, []byte(`{"Version": 5, "SSO": {`), 0600))
+	require.NoError(t, c.SetRoleExpires(MERGE_ROLE_ARN_A, 1000))
+
+	c2 := openMergeTestCache(t, f, settings)
+	assert.Equal(t, int64(1000), roleExpires(t, c2, MERGE_ROLE_ARN_A))
+}
+
+func TestSaveConcurrent(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+
+	base := openMergeTestCache(t, f, settings)
+	arns := []string{}
+	for _, r := range base.GetSSO().Roles.GetAllRoles() {
+		arns = append(arns, r.Arn)
+	}
+	require.NotEmpty(t, arns)
+	settings.historyLimit = int64(len(arns))
+
+	var wg sync.WaitGroup
+	for i, arn := range arns {
+		wg.Add(1)
+		go func(i int, arn string) {
+			defer wg.Done()
+			c, err := OpenCache(f, settings)
+			if !assert.NoError(t, err) {
+				return
+			}
+			c.AddHistory(settings, arn)
+			assert.NoError(t, c.SetRoleExpires(arn, int64(1000+i)))
+		}(i, arn)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(f)
+	require.NoError(t, err)
+	assert.True(t, json.Valid(data))
+
+	c := openMergeTestCache(t, f, settings)
+	for i, arn := range arns {
+		assert.Equal(t, int64(1000+i), roleExpires(t, c, arn), arn)
+		assert.Contains(t, c.GetSSO().History, arn)
+	}
+
+	// no temp files left behind
+	matches, err := filepath.Glob(fmt.Sprintf("%s.*.tmp", f))
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
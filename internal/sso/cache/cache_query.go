@@ -34,6 +34,7 @@ func (c *Cache) SetRoleExpires(arn string, expires int64) error {
 
 	cache := c.GetSSO()
 	cache.Roles.Accounts[flat.AccountId].Roles[flat.RoleName].Expires = expires
+	c.pending(c.ssoName).expires[arn] = expires
 	return c.Save(false)
 }
 
@@ -45,6 +46,9 @@ func (c *Cache) MarkRolesExpired() error {
 			(*role).Expires = 0
 		}
 	}
+	ch := c.pending(c.ssoName)
+	ch.expireAll = true
+	ch.expires = map[string]int64{}
 	return c.Save(false)
 }
 
@@ -21,6 +21,7 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
@@ -40,36 +41,139 @@ func (c *Cache) Refresh(sso ssoconfig.RoleProvider, config *ssoconfig.SSOConfig,
 	}
 	c.refreshed = true
 	log.Debug("refreshing SSO cache", "SSOname", ssoName)
+	state := c.startRefresh(config, ssoName, s)
+
+	// load our AWSSSO & Config
+	r, err := c.NewRoles(sso, config, ssoName, threads, s)
+	if err != nil {
+		c.abortRefresh(ssoName, state)
+		return nil, nil, err
+	}
+	added, deleted, err := c.finishRefresh(config, ssoName, state, r)
+	if err != nil {
+		c.abortRefresh(ssoName, state)
+	}
+	return added, deleted, err
+}
+
+// RefreshJob is an AWS SSO instance to update via RefreshAll()
+type RefreshJob struct {
+	SSO     ssoconfig.RoleProvider
+	Config  *ssoconfig.SSOConfig
+	SSOName string
+}
+
+// RefreshResult is the outcome of updating an AWS SSO instance via RefreshAll()
+type RefreshResult struct {
+	Added   []string
+	Deleted []string
+	Err     error
+}
+
+// RefreshAll updates our cached Roles for each of the AWS SSO instances in
+// parallel, but like Refresh() does not save this data!  Returns the result
+// of each job, in order.
+func (c *Cache) RefreshAll(jobs []RefreshJob, threads int, s SettingsReader) []RefreshResult {
+	c.refreshed = true
+
+	// prepare every SSOCache first, so our workers only read the cache
+	states := make([]*refreshState, len(jobs))
+	for i, job := range jobs {
+		log.Debug("refreshing SSO cache", "SSOname", job.SSOName)
+		states[i] = c.startRefresh(job.Config, job.SSOName, s)
+	}
+
+	// talking to AWS is the slow part
+	roles := make([]*Roles, len(jobs))
+	errs := make([]error, len(jobs))
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			roles[i], errs[i] = c.NewRoles(job.SSO, job.Config, job.SSOName, threads, s)
+		}()
+	}
+	wg.Wait()
+
+	now := time.Now().Unix()
+	results := make([]RefreshResult, len(jobs))
+	for i, job := range jobs {
+		if errs[i] != nil {
+			results[i].Err = errs[i]
+			c.abortRefresh(job.SSOName, states[i])
+			continue
+		}
+		results[i].Added, results[i].Deleted, results[i].Err = c.finishRefresh(job.Config, job.SSOName, states[i], roles[i])
+		if results[i].Err != nil {
+			c.abortRefresh(job.SSOName, states[i])
+			continue
+		}
+		c.GetSSOByName(job.SSOName).LastUpdate = now
+	}
+	return results
+}
+
+// refreshState is what we preserve from an SSOCache while refreshing it
+type refreshState struct {
+	expires     map[string]int64
+	historyTags map[string]string
+	oldRoleSet  map[string]struct{}
+	staticUsers map[string]*AWSRole
+	// restored by abortRefresh()
+	roles      *Roles
+	configHash string
+}
+
+// startRefresh saves the state of the given SSOCache we need to preserve
+// and then empties its Roles
+func (c *Cache) startRefresh(config *ssoconfig.SSOConfig, ssoName string, s SettingsReader) *refreshState {
 	cache := c.GetSSOByName(ssoName)
 
-	expires, historyTags := c.GetExpirationAndHistory(ssoName)
+	state := &refreshState{}
+	state.expires, state.historyTags = c.GetExpirationAndHistory(ssoName)
 
 	// zero out our current roles cache entries so they don't get merged
 	oldRoles := cache.Roles.GetAllRoles()
-	oldRoleSet := make(map[string]struct{}, len(oldRoles))
+	state.oldRoleSet = make(map[string]struct{}, len(oldRoles))
 	for _, role := range oldRoles {
-		oldRoleSet[role.Arn] = struct{}{}
+		state.oldRoleSet[role.Arn] = struct{}{}
 	}
 
+	state.staticUsers = cache.staticUsers()
+	state.roles = cache.Roles
+	state.configHash = cache.ConfigHash
+
 	cache.Roles = &Roles{}
 	cache.ConfigHash = config.GetConfigHash(s.GetProfileFormat())
+	return state
+}
 
-	// load our AWSSSO & Config
-	r, err := c.NewRoles(sso, config, ssoName, threads, s)
-	if err != nil {
-		return nil, nil, err
-	}
+// abortRefresh puts back the Roles of the given SSOCache which startRefresh()
+// removed, so a failed refresh leaves the cache as it was
+func (c *Cache) abortRefresh(ssoName string, state *refreshState) {
+	cache := c.GetSSOByName(ssoName)
+	cache.Roles = state.roles
+	cache.ConfigHash = state.configHash
+}
+
+// finishRefresh replaces the Roles of the given SSOCache with r and restores
+// the state saved by startRefresh().  Returns the ARNs of roles added/deleted
+func (c *Cache) finishRefresh(config *ssoconfig.SSOConfig, ssoName string, state *refreshState, r *Roles) ([]string, []string, error) {
+	cache := c.GetSSOByName(ssoName)
 	cache.Roles = r
+	cache.restoreStaticUsers(state.staticUsers)
 
-	added, deleted := c.CalculateDiff(config, oldRoleSet, cache.Roles)
+	added, deleted := c.CalculateDiff(config, state.oldRoleSet, cache.Roles)
 
 	if err := c.RestoreManualRoles(config, ssoName); err != nil {
 		return nil, nil, err
 	}
 
-	c.RestoreMetadata(ssoName, expires, historyTags)
+	c.RestoreMetadata(ssoName, state.expires, state.historyTags)
 
 	c.ConfigCreatedAt = config.CreatedAt()
+	c.pending(ssoName).replaced = true
 	return added, deleted, nil
 }
 
@@ -122,7 +226,7 @@ func (c *Cache) CalculateDiff(config *ssoconfig.SSOConfig, oldRoleSet map[string
 		}
 		for rName, role := range account.Roles {
 			if role.Via != "" {
-				arn := awsparse.MakeRoleARN(accountId, rName)
+				arn := awsparse.MakeRoleARN(config.Partition(), accountId, rName)
 				delete(oldRoleSet, arn)
 			}
 		}
@@ -224,8 +328,14 @@ func (c *Cache) NewRoles(as ssoconfig.RoleProvider, config *ssoconfig.SSOConfig,
 	return &r, nil
 }
 
+// ssoRolesResult is the result of fetching the roles of an account via fetchSSORole()
+type ssoRolesResult struct {
+	roles []ssoconfig.RoleInfo
+	err   error
+}
+
 // fetchSSORole is a goroutine worker that fetches RoleInfo for each AccountInfo received.
-func fetchSSORole(id int, as ssoconfig.RoleProvider, aInfo <-chan ssoconfig.AccountInfo, rInfo chan<- []ssoconfig.RoleInfo) {
+func fetchSSORole(id int, as ssoconfig.RoleProvider, aInfo <-chan ssoconfig.AccountInfo, rInfo chan<- ssoRolesResult) {
 	for {
 		a := <-aInfo
 		if a.AccountId == "" {
@@ -235,9 +345,9 @@ func fetchSSORole(id int, as ssoconfig.RoleProvider, aInfo <-chan ssoconfig.Acco
 		log.Debug("Worker processing", "worker", id, "accountID", a.AccountId)
 		roles, err := as.GetRoles(a)
 		if err != nil {
-			panic(fmt.Sprintf("Unable to get AWS SSO roles: %s", err.Error()))
+			err = fmt.Errorf("unable to get AWS SSO roles for %s: %w", a.AccountId, err)
 		}
-		rInfo <- roles
+		rInfo <- ssoRolesResult{roles: roles, err: err}
 	}
 }
 
@@ -258,7 +368,7 @@ func processSSORoles(roles []ssoconfig.RoleInfo, cache *SSOCache, r *Roles) {
 		}
 
 		r.Accounts[accountId].Roles[role.RoleName] = &AWSRole{
-			Arn: awsparse.MakeRoleARN(accountId, role.RoleName),
+			Arn: awsparse.MakeRoleARN(r.Partition(), accountId, role.RoleName),
 			Tags: map[string]string{
 				"AccountID":    role.AccountId,
 				"AccountAlias": role.AccountName, // AWS SSO calls it `AccountName`
@@ -284,7 +394,7 @@ func processSSORoles(roles []ssoconfig.RoleInfo, cache *SSOCache, r *Roles) {
 // The first account is fetched serially to allow token refresh; remaining
 // accounts are fetched in parallel via a bounded worker pool.
 func (c *Cache) addSSORoles(r *Roles, as ssoconfig.RoleProvider, threads int, s SettingsReader) error {
-	cache := c.GetSSO()
+	cache := c.GetSSOByName(r.SSOName)
 
 	accounts, err := as.GetAccounts()
 	if err != nil {
@@ -316,7 +426,8 @@ func (c *Cache) addSSORoles(r *Roles, as ssoconfig.RoleProvider, threads int, s
 		}
 
 		tasks := make(chan ssoconfig.AccountInfo, len(accounts))
-		results := make(chan []ssoconfig.RoleInfo, len(accounts))
+		// buffered so our workers never block if we give up early on an error
+		results := make(chan ssoRolesResult, len(accounts))
 
 		// feed our workers with our other accounts
 		for _, aInfo := range accounts {
@@ -335,10 +446,13 @@ func (c *Cache) addSSORoles(r *Roles, as ssoconfig.RoleProvider, threads int, s
 
 		for count := 0; count < len(accounts); {
 			select {
-			case roles := <-results:
-				processSSORoles(roles, cache, r)
+			case result := <-results:
+				if result.err != nil {
+					return result.err
+				}
+				processSSORoles(result.roles, cache, r)
 				count++ // increment count only when processing results
-				log.Debug("processed", "accounts", count, "new_roles", len(roles), "total_roles", len(r.GetAllRoles()))
+				log.Debug("processed", "accounts", count, "new_roles", len(result.roles), "total_roles", len(r.GetAllRoles()))
 			case <-ticker.C:
 				log.Warn(fmt.Sprintf("fetching roles for %d accounts, this might take a while...", len(accounts)+1))
 				ticker.Stop() // one-time warning; stop to avoid repeated fires
@@ -397,7 +511,7 @@ func (c *Cache) addConfigRoles(r *Roles, config *ssoconfig.SSOConfig) error {
 				log.DebugContext(ctx, "config.yaml defines a role but you don't have access", "role", roleName)
 				continue
 			}
-			r.Accounts[id].Roles[roleName].Arn = awsparse.MakeRoleARN(id, roleName)
+			r.Accounts[id].Roles[roleName].Arn = awsparse.MakeRoleARN(r.Partition(), id, roleName)
 			r.Accounts[id].Roles[roleName].Profile = role.Profile
 			r.Accounts[id].Roles[roleName].DefaultRegion = r.Accounts[id].DefaultRegion
 			r.Accounts[id].Roles[roleName].Via = role.Via
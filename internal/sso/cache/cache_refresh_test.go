@@ -32,6 +32,7 @@ type mockRoleProvider struct {
 	accountErr error
 	roles      map[string][]ssoconfig.RoleInfo
 	roleErr    error
+	roleErrs   map[string]error // per-AccountId GetRoles() errors
 }
 
 func (m *mockRoleProvider) GetAccounts() ([]ssoconfig.AccountInfo, error) {
@@ -42,6 +43,9 @@ func (m *mockRoleProvider) GetRoles(account ssoconfig.AccountInfo) ([]ssoconfig.
 	if m.roleErr != nil {
 		return nil, m.roleErr
 	}
+	if err := m.roleErrs[account.AccountId]; err != nil {
+		return nil, err
+	}
 	return m.roles[account.AccountId], nil
 }
 
@@ -374,6 +378,26 @@ func (suite *CacheTestSuite) TestCacheRefreshMocked() {
 	assert.NoError(t, err)
 	assert.Empty(t, added)
 	assert.Contains(t, deleted, "arn:aws:iam::000001111111:role/OldRole")
+
+	// --- IAM Users with StaticCredentials are not managed by AWS SSO ---
+	suite.cache.SSO["Default"].Roles = &Roles{
+		Accounts: map[int64]*AWSAccount{
+			2222222: {
+				Roles: map[string]*AWSRole{
+					"alice": {Arn: "arn:aws:iam::000002222222:user/alice", Profile: "alice"},
+				},
+				Tags: map[string]string{},
+			},
+		},
+	}
+	suite.cache.refreshed = false
+
+	added, deleted, err = suite.cache.Refresh(prov, ssoConf, "Default", 1, settings)
+	assert.NoError(t, err)
+	assert.NotContains(t, added, "arn:aws:iam::000002222222:user/alice")
+	assert.Empty(t, deleted)
+	assert.Contains(t, suite.cache.SSO["Default"].Roles.Accounts[1111111].Roles, "ReadOnly")
+	assert.Equal(t, "alice", suite.cache.SSO["Default"].Roles.Accounts[2222222].Roles["alice"].Profile)
 }
 
 func (suite *CacheTestSuite) TestRefreshUsesNamedSSOCache() {
@@ -453,6 +477,98 @@ func (suite *CacheTestSuite) TestRefreshUsesNamedSSOCache() {
 	assert.Contains(t, suite.cache.SSO["Other"].Roles.Accounts[2222222].Roles, "OldOther")
 }
 
+func (suite *CacheTestSuite) TestRefreshAll() {
+	t := suite.T()
+
+	newConf := func() *ssoconfig.SSOConfig {
+		c := &ssoconfig.SSOConfig{
+			SSORegion:     "us-east-1",
+			StartUrl:      "https://testing.awsapps.com/start",
+			DefaultRegion: "us-east-1",
+			Accounts:      map[string]*ssoconfig.SSOAccount{},
+		}
+		c.SetConfigFile(suite.cacheFile)
+		return c
+	}
+
+	settings := &mockSettingsReader{
+		defaultSSO:     "Default",
+		historyLimit:   1,
+		historyMinutes: 90,
+		cacheFile:      suite.cacheFile,
+		profileFormat:  "{{ .AccountIdPad }}:{{ .RoleName }}",
+		ssoNames:       []string{"Default", "Other", "Broken"},
+	}
+
+	origDefault := suite.cache.SSO["Default"]
+	origRefreshed := suite.cache.refreshed
+	defer func() {
+		suite.cache.SSO["Default"] = origDefault
+		delete(suite.cache.SSO, "Other")
+		delete(suite.cache.SSO, "Broken")
+		suite.cache.refreshed = origRefreshed
+	}()
+
+	suite.cache.SSO["Default"] = &SSOCache{
+		name:    "Default",
+		History: []string{},
+		Roles: &Roles{Accounts: map[int64]*AWSAccount{
+			1111111: {
+				Roles: map[string]*AWSRole{
+					"OldDefault": {Arn: "arn:aws:iam::000001111111:role/OldDefault"},
+				},
+				Tags: map[string]string{},
+			},
+		}},
+	}
+	suite.cache.refreshed = false
+
+	provDefault := &mockRoleProvider{
+		accounts: []ssoconfig.AccountInfo{
+			{AccountId: "000001111111", AccountName: "Account-000001111111", EmailAddress: "000001111111@example.com"},
+		},
+		roles: map[string][]ssoconfig.RoleInfo{
+			"000001111111": {
+				{RoleName: "ReadOnly", AccountId: "000001111111", AccountName: "Account-000001111111", EmailAddress: "000001111111@example.com"},
+			},
+		},
+	}
+	provOther := &mockRoleProvider{
+		accounts: []ssoconfig.AccountInfo{
+			{AccountId: "000002222222", AccountName: "Account-000002222222", EmailAddress: "000002222222@example.com"},
+		},
+		roles: map[string][]ssoconfig.RoleInfo{
+			"000002222222": {
+				{RoleName: "Admin", AccountId: "000002222222", AccountName: "Account-000002222222", EmailAddress: "000002222222@example.com"},
+			},
+		},
+	}
+	provBroken := &mockRoleProvider{accountErr: fmt.Errorf("AWS down")}
+
+	results := suite.cache.RefreshAll([]RefreshJob{
+		{SSO: provDefault, Config: newConf(), SSOName: "Default"},
+		{SSO: provOther, Config: newConf(), SSOName: "Other"},
+		{SSO: provBroken, Config: newConf(), SSOName: "Broken"},
+	}, 1, settings)
+	assert.True(t, suite.cache.refreshed)
+
+	assert.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, []string{"arn:aws:iam::000001111111:role/ReadOnly"}, results[0].Added)
+	assert.Equal(t, []string{"arn:aws:iam::000001111111:role/OldDefault"}, results[0].Deleted)
+	assert.Contains(t, suite.cache.SSO["Default"].Roles.Accounts[1111111].Roles, "ReadOnly")
+	assert.NotZero(t, suite.cache.SSO["Default"].LastUpdate)
+
+	assert.NoError(t, results[1].Err)
+	assert.Equal(t, []string{"arn:aws:iam::000002222222:role/Admin"}, results[1].Added)
+	assert.Empty(t, results[1].Deleted)
+	assert.Contains(t, suite.cache.SSO["Other"].Roles.Accounts[2222222].Roles, "Admin")
+	assert.Equal(t, "Other", suite.cache.SSO["Other"].Roles.SSOName)
+
+	assert.ErrorContains(t, results[2].Err, "AWS down")
+	assert.Zero(t, suite.cache.SSO["Broken"].LastUpdate)
+}
+
 // TestRefreshWithManuallyDefinedRoles verifies that manually-defined roles
 // (those with a Via field for role chaining) are not incorrectly reported
 // as deleted on subsequent refresh operations. See issue #1349.
@@ -701,3 +817,104 @@ func (suite *CacheTestSuite) TestRestoreMetadata() {
 	assert.Equal(t, int64(12345678), role.Expires)
 	assert.Equal(t, "2021-01-01", role.Tags["History"])
 }
+
+func (suite *CacheTestSuite) TestRefreshAllRoleError() {
+	t := suite.T()
+
+	newConf := func() *ssoconfig.SSOConfig {
+		c := &ssoconfig.SSOConfig{
+			SSORegion:     "us-east-1",
+			StartUrl:      "https://testing.awsapps.com/start",
+			DefaultRegion: "us-east-1",
+			Accounts:      map[string]*ssoconfig.SSOAccount{},
+		}
+		c.SetConfigFile(suite.cacheFile)
+		return c
+	}
+
+	settings := &mockSettingsReader{
+		defaultSSO:     "Default",
+		historyLimit:   1,
+		historyMinutes: 90,
+		cacheFile:      suite.cacheFile,
+		profileFormat:  "{{ .AccountIdPad }}:{{ .RoleName }}",
+		ssoNames:       []string{"Default", "Broken"},
+	}
+
+	origDefault := suite.cache.SSO["Default"]
+	origRefreshed := suite.cache.refreshed
+	defer func() {
+		suite.cache.SSO["Default"] = origDefault
+		delete(suite.cache.SSO, "Broken")
+		suite.cache.refreshed = origRefreshed
+	}()
+
+	suite.cache.SSO["Default"] = &SSOCache{
+		name:    "Default",
+		History: []string{},
+		Roles:   &Roles{Accounts: map[int64]*AWSAccount{}},
+	}
+	oldRoles := &Roles{
+		SSOName: "Broken",
+		Accounts: map[int64]*AWSAccount{
+			3333333: {
+				Roles: map[string]*AWSRole{
+					"OldBroken": {Arn: "arn:aws:iam::000003333333:role/OldBroken"},
+				},
+				Tags: map[string]string{},
+			},
+		},
+	}
+	suite.cache.SSO["Broken"] = &SSOCache{
+		name:       "Broken",
+		History:    []string{},
+		Roles:      oldRoles,
+		ConfigHash: "old-hash",
+	}
+
+	provDefault := &mockRoleProvider{
+		accounts: []ssoconfig.AccountInfo{
+			{AccountId: "000001111111", AccountName: "Account-000001111111", EmailAddress: "000001111111@example.com"},
+		},
+		roles: map[string][]ssoconfig.RoleInfo{
+			"000001111111": {
+				{RoleName: "ReadOnly", AccountId: "000001111111", AccountName: "Account-000001111111", EmailAddress: "000001111111@example.com"},
+			},
+		},
+	}
+	// the first account is fetched directly, the others by our worker pool
+	provBroken := &mockRoleProvider{
+		accounts: []ssoconfig.AccountInfo{
+			{AccountId: "000003333333", AccountName: "Account-000003333333", EmailAddress: "000003333333@example.com"},
+			{AccountId: "000004444444", AccountName: "Account-000004444444", EmailAddress: "000004444444@example.com"},
+			{AccountId: "000005555555", AccountName: "Account-000005555555", EmailAddress: "000005555555@example.com"},
+		},
+		roles: map[string][]ssoconfig.RoleInfo{
+			"000003333333": {
+				{RoleName: "NewBroken", AccountId: "000003333333", AccountName: "Account-000003333333", EmailAddress: "000003333333@example.com"},
+			},
+		},
+		roleErrs: map[string]error{
+			"000004444444": fmt.Errorf("AWS down"),
+		},
+	}
+
+	var results []RefreshResult
+	assert.NotPanics(t, func() {
+		results = suite.cache.RefreshAll([]RefreshJob{
+			{SSO: provDefault, Config: newConf(), SSOName: "Default"},
+			{SSO: provBroken, Config: newConf(), SSOName: "Broken"},
+		}, 2, settings)
+	})
+
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Err)
+	assert.Contains(t, suite.cache.SSO["Default"].Roles.Accounts[1111111].Roles, "ReadOnly")
+	assert.NotZero(t, suite.cache.SSO["Default"].LastUpdate)
+
+	assert.ErrorContains(t, results[1].Err, "000004444444")
+	assert.ErrorContains(t, results[1].Err, "AWS down")
+	assert.Zero(t, suite.cache.SSO["Broken"].LastUpdate)
+	assert.Same(t, oldRoles, suite.cache.SSO["Broken"].Roles)
+	assert.Equal(t, "old-hash", suite.cache.SSO["Broken"].ConfigHash)
+}
@@ -0,0 +1,141 @@
+package cache
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"reflect"
+
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+// SetStaticUsers updates the IAM Users with StaticCredentials in the cache
+// for the given SSO instance to match users.  Returns true if anything changed.
+func (c *Cache) SetStaticUsers(ssoName string, users []storage.StaticCredentials) bool {
+	if !c.GetSSOByName(ssoName).setStaticUsers(users) {
+		return false
+	}
+	c.pending(ssoName).staticUsers = users
+	return true
+}
+
+// staticUsers returns all the IAM Users in the cache.  Key is the user ARN.
+func (sc *SSOCache) staticUsers() map[string]*AWSRole {
+	ret := map[string]*AWSRole{}
+	if sc.Roles == nil {
+		return ret
+	}
+	for _, account := range sc.Roles.Accounts {
+		for _, role := range account.Roles {
+			if awsparse.IsUserARN(role.Arn) {
+				ret[role.Arn] = role
+			}
+		}
+	}
+	return ret
+}
+
+// setStaticUsers replaces the IAM Users in our roles with users.  Users
+// with the same name as a role in the same account are skipped.
+func (sc *SSOCache) setStaticUsers(users []storage.StaticCredentials) bool {
+	if sc.Roles == nil {
+		sc.Roles = &Roles{}
+	}
+	if sc.Roles.Accounts == nil {
+		sc.Roles.Accounts = map[int64]*AWSAccount{}
+	}
+
+	current := sc.staticUsers()
+	want := map[string]*AWSRole{}
+	for _, user := range users {
+		arn := user.UserArn()
+		if account, ok := sc.Roles.Accounts[user.AccountId]; ok {
+			if role, ok := account.Roles[user.UserName]; ok && !awsparse.IsUserARN(role.Arn) {
+				log.Warn("IAM User has the same name as a role, skipping", "arn", arn, "role", role.Arn)
+				continue
+			}
+		}
+
+		role := &AWSRole{
+			Arn:     arn,
+			Profile: user.Profile,
+			Tags: map[string]string{
+				"IAMUser": user.UserName,
+			},
+		}
+		for k, v := range user.Tags {
+			role.Tags[k] = v
+		}
+
+		// keep our metadata
+		if old, ok := current[arn]; ok {
+			role.Expires = old.Expires
+			if history, ok := old.Tags["History"]; ok {
+				role.Tags["History"] = history
+			}
+		}
+		want[arn] = role
+	}
+
+	if reflect.DeepEqual(current, want) {
+		return false
+	}
+
+	for arn := range current {
+		accountId, userName, _ := awsparse.ParseUserARN(arn)
+		account := sc.Roles.Accounts[accountId]
+		delete(account.Roles, userName)
+		if len(account.Roles) == 0 && account.Alias == "" {
+			// account only exists because of our IAM Users
+			delete(sc.Roles.Accounts, accountId)
+		}
+	}
+
+	for arn, role := range want {
+		accountId, userName, _ := awsparse.ParseUserARN(arn)
+		if _, ok := sc.Roles.Accounts[accountId]; !ok {
+			sc.Roles.Accounts[accountId] = &AWSAccount{
+				Roles: map[string]*AWSRole{},
+				Tags:  map[string]string{},
+			}
+		}
+		sc.Roles.Accounts[accountId].Roles[userName] = role
+	}
+	return true
+}
+
+// restoreStaticUsers adds the given IAM Users back into our roles after a refresh
+func (sc *SSOCache) restoreStaticUsers(users map[string]*AWSRole) {
+	for arn, role := range users {
+		accountId, userName, _ := awsparse.ParseUserARN(arn)
+		account, ok := sc.Roles.Accounts[accountId]
+		if !ok {
+			account = &AWSAccount{
+				Roles: map[string]*AWSRole{},
+				Tags:  map[string]string{},
+			}
+			sc.Roles.Accounts[accountId] = account
+		}
+		if _, ok := account.Roles[userName]; ok {
+			log.Warn("IAM User has the same name as a role, skipping", "arn", arn)
+			continue
+		}
+		account.Roles[userName] = role
+	}
+}
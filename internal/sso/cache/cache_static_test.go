@@ -0,0 +1,133 @@
+package cache
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/synfinatic/aws-sso-cli/internal/storage"
+)
+
+const (
+	STATIC_USER_ARN_A = "arn:aws:iam::502470824893:user/breakglass"
+	STATIC_USER_ARN_B = "arn:aws:iam::000000111111:user/legacy"
+)
+
+func testStaticUsers() []storage.StaticCredentials {
+	return []storage.StaticCredentials{
+		{
+			Profile:         "BreakGlass",
+			UserName:        "breakglass",
+			AccountId:       502470824893,
+			AccessKeyId:     "AKIAEXAMPLE1",
+			SecretAccessKey: "secret1",
+			Tags:            map[string]string{"Team": "ops"},
+		},
+		{
+			UserName:        "legacy",
+			AccountId:       111111,
+			AccessKeyId:     "AKIAEXAMPLE2",
+			SecretAccessKey: "secret2",
+		},
+	}
+}
+
+func TestSetStaticUsers(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+	c := openMergeTestCache(t, f, settings)
+	roleCount := len(c.GetSSO().Roles.GetAllRoles())
+
+	users := testStaticUsers()
+	assert.True(t, c.SetStaticUsers("Default", users))
+	assert.False(t, c.SetStaticUsers("Default", users))
+	assert.Len(t, c.GetSSO().Roles.GetAllRoles(), roleCount+2)
+
+	// user in an account we have via AWS SSO
+	flat, err := c.GetRole(STATIC_USER_ARN_A)
+	require.NoError(t, err)
+	assert.Equal(t, STATIC_USER_ARN_A, flat.Arn)
+	assert.Equal(t, "breakglass", flat.RoleName)
+	assert.Equal(t, "BreakGlass", flat.Profile)
+	assert.Equal(t, "breakglass", flat.Tags["IAMUser"])
+	assert.Equal(t, "ops", flat.Tags["Team"])
+	assert.Equal(t, "502470824893", flat.Tags["AccountID"])
+	assert.NotEmpty(t, flat.AccountAlias)
+
+	// user in an account outside of AWS SSO
+	flat, err = c.GetRole(STATIC_USER_ARN_B)
+	require.NoError(t, err)
+	assert.Equal(t, "legacy", flat.RoleName)
+	assert.Equal(t, "000000111111", flat.Tags["AccountID"])
+
+	// metadata is kept when the users change
+	require.NoError(t, c.SetRoleExpires(STATIC_USER_ARN_A, 4102444800))
+	users[0].Tags["Team"] = "security"
+	assert.True(t, c.SetStaticUsers("Default", users))
+	flat, err = c.GetRole(STATIC_USER_ARN_A)
+	require.NoError(t, err)
+	assert.Equal(t, "security", flat.Tags["Team"])
+	assert.Equal(t, int64(4102444800), flat.ExpiresEpoch)
+
+	// users with the same name as a role are skipped
+	assert.False(t, c.SetStaticUsers("Default", append(users, storage.StaticCredentials{
+		UserName:  "AWSReadOnlyAccess",
+		AccountId: 502470824893,
+	})))
+	flat, err = c.GetRole(MERGE_ROLE_ARN_B)
+	require.NoError(t, err)
+	assert.Equal(t, MERGE_ROLE_ARN_B, flat.Arn)
+
+	// removing the users also removes accounts which only existed for them
+	assert.True(t, c.SetStaticUsers("Default", []storage.StaticCredentials{}))
+	assert.Len(t, c.GetSSO().Roles.GetAllRoles(), roleCount)
+	_, err = c.GetRole(STATIC_USER_ARN_A)
+	assert.Error(t, err)
+	assert.NotContains(t, c.GetSSO().Roles.Accounts, int64(111111))
+	assert.Contains(t, c.GetSSO().Roles.Accounts, int64(502470824893))
+}
+
+func TestSaveMergesStaticUsers(t *testing.T) {
+	f, settings := newMergeTestCache(t)
+
+	c1 := openMergeTestCache(t, f, settings)
+	c2 := openMergeTestCache(t, f, settings)
+
+	c1.AddHistory(settings, MERGE_ROLE_ARN_A)
+	require.NoError(t, c1.Save(false))
+
+	// c2 loaded the cache before c1 saved
+	assert.True(t, c2.SetStaticUsers("Default", testStaticUsers()))
+	require.NoError(t, c2.Save(false))
+
+	c3 := openMergeTestCache(t, f, settings)
+	assert.Equal(t, []string{MERGE_ROLE_ARN_A}, c3.GetSSO().History)
+	_, err := c3.GetRole(STATIC_USER_ARN_A)
+	assert.NoError(t, err)
+	_, err = c3.GetRole(STATIC_USER_ARN_B)
+	assert.NoError(t, err)
+
+	// and another process saving doesn't lose them
+	c1.AddHistory(settings, MERGE_ROLE_ARN_B)
+	require.NoError(t, c1.Save(false))
+	c4 := openMergeTestCache(t, f, settings)
+	_, err = c4.GetRole(STATIC_USER_ARN_A)
+	assert.NoError(t, err)
+}
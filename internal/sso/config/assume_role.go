@@ -0,0 +1,176 @@
+package config
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+)
+
+const (
+	DEFAULT_ROLE_SESSION_NAME = "{{ .RoleName }}@{{ .AccountId }}"
+
+	// sts:AssumeRole limits
+	MIN_ROLE_DURATION     = 15  // minutes
+	MAX_ROLE_DURATION     = 720 // minutes, when Via is an IAM User
+	MAX_CHAINED_DURATION  = 60  // minutes, when Via is a role
+	MAX_SESSION_TAGS      = 50
+	MAX_SESSION_TAG_KEY   = 128
+	MAX_SESSION_TAG_VALUE = 256
+	MAX_POLICY_ARNS       = 10
+	MAX_POLICY_SIZE       = 2048
+)
+
+var (
+	roleSessionNameRegexp = regexp.MustCompile(`^[\w+=,.@-]{2,64}$`)
+	sessionTagRegexp      = regexp.MustCompile(`^[\p{L}\p{Z}\p{N}_.:/=+\-@]*$`)
+)
+
+// RoleSessionNameData is passed to the RoleSessionName template
+type RoleSessionNameData struct {
+	AccountId       string // AccountId of the credentials calling sts:AssumeRole
+	RoleName        string // Role or IAM User of the credentials calling sts:AssumeRole
+	TargetAccountId string // AccountId of the role being assumed
+	TargetRoleName  string // Name of the role being assumed
+}
+
+// Validate checks the sts:AssumeRole parameters of the role
+func (r *SSORole) Validate() error {
+	if r.Via == "" {
+		options := []struct {
+			name string
+			set  bool
+		}{
+			{"Duration", r.Duration != 0},
+			{"SessionTags", len(r.SessionTags) > 0},
+			{"TransitiveTagKeys", len(r.TransitiveTagKeys) > 0},
+			{"PolicyArns", len(r.PolicyArns) > 0},
+			{"Policy", r.Policy != ""},
+			{"RoleSessionName", r.RoleSessionName != ""},
+			{"MfaSerial", r.MfaSerial != ""},
+		}
+		for _, o := range options {
+			if o.set {
+				return fmt.Errorf("%s requires Via", o.name)
+			}
+		}
+		return nil
+	}
+
+	if r.Duration != 0 {
+		if awsparse.IsUserARN(r.Via) {
+			if r.Duration < MIN_ROLE_DURATION || r.Duration > MAX_ROLE_DURATION {
+				return fmt.Errorf("invalid Duration %d.  Must be between %d and %d",
+					r.Duration, MIN_ROLE_DURATION, MAX_ROLE_DURATION)
+			}
+		} else if r.Duration < MIN_ROLE_DURATION || r.Duration > MAX_CHAINED_DURATION {
+			return fmt.Errorf("invalid Duration %d.  Must be between %d and %d because AWS limits role chaining to %d minutes",
+				r.Duration, MIN_ROLE_DURATION, MAX_CHAINED_DURATION, MAX_CHAINED_DURATION)
+		}
+	}
+
+	if len(r.SessionTags) > MAX_SESSION_TAGS {
+		return fmt.Errorf("too many SessionTags: %d.  Must be %d or less", len(r.SessionTags), MAX_SESSION_TAGS)
+	}
+	for k, v := range r.SessionTags {
+		if len(k) == 0 || len(k) > MAX_SESSION_TAG_KEY || !sessionTagRegexp.MatchString(k) {
+			return fmt.Errorf("invalid SessionTags key: %s", k)
+		}
+		if len(v) > MAX_SESSION_TAG_VALUE || !sessionTagRegexp.MatchString(v) {
+			return fmt.Errorf("invalid SessionTags value for %s: %s", k, v)
+		}
+	}
+	for _, k := range r.TransitiveTagKeys {
+		if _, ok := r.SessionTags[k]; !ok {
+			return fmt.Errorf("TransitiveTagKeys %s is not in SessionTags", k)
+		}
+	}
+
+	if len(r.PolicyArns) > MAX_POLICY_ARNS {
+		return fmt.Errorf("too many PolicyArns: %d.  Must be %d or less", len(r.PolicyArns), MAX_POLICY_ARNS)
+	}
+	for _, arn := range r.PolicyArns {
+		s := strings.Split(arn, ":")
+		if len(s) != 6 || s[0] != "arn" || s[2] != "iam" || !strings.HasPrefix(s[5], "policy/") {
+			return fmt.Errorf("invalid PolicyArns: %s", arn)
+		}
+	}
+
+	if r.Policy != "" {
+		buf := new(bytes.Buffer)
+		if err := json.Compact(buf, []byte(r.Policy)); err != nil {
+			return fmt.Errorf("invalid Policy: %s", err.Error())
+		}
+		if buf.Len() > MAX_POLICY_SIZE {
+			return fmt.Errorf("Policy is too large: %d.  Must be %d characters or less", buf.Len(), MAX_POLICY_SIZE)
+		}
+	}
+
+	if _, err := r.GetRoleSessionName(RoleSessionNameData{
+		AccountId:       "123456789012",
+		RoleName:        "Role",
+		TargetAccountId: "123456789012",
+		TargetRoleName:  "Role",
+	}); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetRoleSessionName returns the RoleSessionName for sts:AssumeRole
+func (r *SSORole) GetRoleSessionName(data RoleSessionNameData) (string, error) {
+	format := r.RoleSessionName
+	if format == "" {
+		format = DEFAULT_ROLE_SESSION_NAME
+	}
+
+	templ, err := template.New("role_session_name").Funcs(sprig.TxtFuncMap()).Parse(format)
+	if err != nil {
+		return "", fmt.Errorf("invalid RoleSessionName: %s", err.Error())
+	}
+
+	buf := new(bytes.Buffer)
+	if err := templ.Execute(buf, data); err != nil {
+		return "", fmt.Errorf("unable to generate RoleSessionName: %s", err.Error())
+	}
+
+	name := buf.String()
+	if !roleSessionNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid RoleSessionName %s: must be 2-64 characters of [A-Za-z0-9_+=,.@-]", name)
+	}
+	return name, nil
+}
+
+// GetSessionTagKeys returns the sorted keys of our SessionTags
+func (r *SSORole) GetSessionTagKeys() []string {
+	keys := make([]string, 0, len(r.SessionTags))
+	for k := range r.SessionTags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
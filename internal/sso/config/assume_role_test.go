@@ -0,0 +1,104 @@
+package config
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSORoleValidate(t *testing.T) {
+	via := "arn:aws:iam::123456789012:role/BaseRole"
+	cases := []struct {
+		name string
+		role SSORole
+		err  string
+	}{
+		{"empty", SSORole{}, ""},
+		{"via only", SSORole{Via: via}, ""},
+		{"all options", SSORole{
+			Via:               via,
+			Duration:          60,
+			SessionTags:       map[string]string{"Project": "aws-sso", "Owner": "alice@example.com"},
+			TransitiveTagKeys: []string{"Project"},
+			PolicyArns:        []string{"arn:aws:iam::aws:policy/ReadOnlyAccess"},
+			Policy:            `{"Version": "2012-10-17", "Statement": []}`,
+			RoleSessionName:   "{{ .RoleName }}-{{ .TargetAccountId }}",
+			MfaSerial:         "arn:aws:iam::123456789012:mfa/alice",
+		}, ""},
+		{"duration without via", SSORole{Duration: 60}, "Duration requires Via"},
+		{"mfa without via", SSORole{MfaSerial: "arn:aws:iam::123456789012:mfa/alice"}, "MfaSerial requires Via"},
+		{"duration too short", SSORole{Via: via, Duration: 14}, "invalid Duration 14"},
+		{"duration too long", SSORole{Via: via, Duration: 61}, "AWS limits role chaining to 60 minutes"},
+		{"user duration", SSORole{Via: "arn:aws:iam::123456789012:user/alice", Duration: 720}, ""},
+		{"user duration too long", SSORole{Via: "arn:aws:iam::123456789012:user/alice", Duration: 721}, "invalid Duration 721"},
+		{"bad tag key", SSORole{Via: via, SessionTags: map[string]string{"foo*": "bar"}}, "invalid SessionTags key"},
+		{"long tag value", SSORole{Via: via, SessionTags: map[string]string{"foo": strings.Repeat("x", 257)}}, "invalid SessionTags value"},
+		{"transitive not a tag", SSORole{Via: via, TransitiveTagKeys: []string{"Project"}}, "TransitiveTagKeys Project is not in SessionTags"},
+		{"bad policy arn", SSORole{Via: via, PolicyArns: []string{"arn:aws:iam::aws:role/Foo"}}, "invalid PolicyArns"},
+		{"too many policy arns", SSORole{Via: via, PolicyArns: make([]string, 11)}, "too many PolicyArns"},
+		{"bad policy", SSORole{Via: via, Policy: "{"}, "invalid Policy"},
+		{"large policy", SSORole{Via: via, Policy: `{"Sid": "` + strings.Repeat("x", 2048) + `"}`}, "Policy is too large"},
+		{"bad template", SSORole{Via: via, RoleSessionName: "{{ .RoleName"}, "invalid RoleSessionName"},
+		{"bad template field", SSORole{Via: via, RoleSessionName: "{{ .Foo }}"}, "unable to generate RoleSessionName"},
+		{"bad session name", SSORole{Via: via, RoleSessionName: "{{ .RoleName }} {{ .AccountId }}"}, "invalid RoleSessionName"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.role.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestGetRoleSessionName(t *testing.T) {
+	data := RoleSessionNameData{
+		AccountId:       "000001111111",
+		RoleName:        "BaseRole",
+		TargetAccountId: "123456789012",
+		TargetRoleName:  "TargetRole",
+	}
+
+	r := SSORole{}
+	name, err := r.GetRoleSessionName(data)
+	require.NoError(t, err)
+	assert.Equal(t, "BaseRole@000001111111", name)
+
+	r.RoleSessionName = "{{ .TargetRoleName | lower }}.{{ .RoleName }}"
+	name, err = r.GetRoleSessionName(data)
+	require.NoError(t, err)
+	assert.Equal(t, "targetrole.BaseRole", name)
+
+	data.RoleName = strings.Repeat("x", 64)
+	_, err = r.GetRoleSessionName(data)
+	assert.ErrorContains(t, err, "must be 2-64 characters")
+}
+
+func TestGetSessionTagKeys(t *testing.T) {
+	r := SSORole{SessionTags: map[string]string{"b": "2", "a": "1", "c": "3"}}
+	assert.Equal(t, []string{"a", "b", "c"}, r.GetSessionTagKeys())
+	assert.Empty(t, (&SSORole{}).GetSessionTagKeys())
+}
@@ -41,6 +41,7 @@ type SSOConfigSettings struct {
 	Browser        string
 	UrlExecCommand []string
 	AuthWorkflow   oidc.AuthWorkflow
+	PKCE           PKCEConfig
 	CacheRefresh   int64
 	ConfigFile     string
 }
@@ -64,6 +65,7 @@ type SSOConfig struct {
 	Browser        string            `koanf:"-" yaml:"-"`
 	UrlExecCommand []string          `koanf:"-" yaml:"-"`
 	AuthWorkflow   oidc.AuthWorkflow `koanf:"-" yaml:"-"`
+	PKCE           PKCEConfig        `koanf:"-" yaml:"-"`
 	CacheRefresh   int64             `koanf:"-" yaml:"-"`
 	configFile     string            // path to parent config file
 }
@@ -85,6 +87,15 @@ type SSORole struct {
 	Via            string            `koanf:"Via" yaml:"Via,omitempty"`
 	ExternalId     string            `koanf:"ExternalId" yaml:"ExternalId,omitempty"`
 	SourceIdentity string            `koanf:"SourceIdentity" yaml:"SourceIdentity,omitempty"`
+	MfaSerial      string            `koanf:"MfaSerial" yaml:"MfaSerial,omitempty"`
+
+	// sts:AssumeRole parameters for roles with a Via
+	Duration          int32             `koanf:"Duration" yaml:"Duration,omitempty"` // minutes
+	SessionTags       map[string]string `koanf:"SessionTags" yaml:"SessionTags,omitempty"`
+	TransitiveTagKeys []string          `koanf:"TransitiveTagKeys" yaml:"TransitiveTagKeys,omitempty"`
+	PolicyArns        []string          `koanf:"PolicyArns" yaml:"PolicyArns,omitempty"`
+	Policy            string            `koanf:"Policy" yaml:"Policy,omitempty"`
+	RoleSessionName   string            `koanf:"RoleSessionName" yaml:"RoleSessionName,omitempty"`
 }
 
 // GetKey returns the key used to identify this SSOConfig in Settings.SSO.
@@ -92,6 +103,11 @@ func (c *SSOConfig) GetKey() string {
 	return c.key
 }
 
+// Partition returns the AWS partition for this SSO instance based on the SSORegion
+func (c *SSOConfig) Partition() string {
+	return awsparse.GetPartition(c.SSORegion)
+}
+
 // SetKey sets the key for this SSOConfig instance (used by Settings.LoadSettings).
 func (c *SSOConfig) SetKey(k string) {
 	c.key = k
@@ -122,6 +138,7 @@ func (c *SSOConfig) Refresh(params SSOConfigSettings) {
 	c.Browser = params.Browser
 	c.UrlExecCommand = params.UrlExecCommand
 	c.AuthWorkflow = params.AuthWorkflow
+	c.PKCE = params.PKCE
 	c.CacheRefresh = params.CacheRefresh
 	c.configFile = params.ConfigFile
 
@@ -151,7 +168,7 @@ func (c *SSOConfig) Refresh(params SSOConfigSettings) {
 				log.Debug("Refreshing role", "accountId", id, "roleName", roleName)
 			}
 			r.SetParentAccount(a)
-			r.ARN = awsparse.MakeRoleARNs(id, roleName)
+			r.ARN = awsparse.MakeRoleARNs(c.Partition(), id, roleName)
 		}
 	}
 }
@@ -89,6 +89,7 @@ func TestRefresh(t *testing.T) {
 		UrlAction:  uri.Open,
 		MaxBackoff: 60,
 		MaxRetry:   3,
+		PKCE:       PKCEConfig{CallbackPort: 8250},
 	}
 
 	c := &SSOConfig{
@@ -112,6 +113,7 @@ func TestRefresh(t *testing.T) {
 	assert.Equal(t, c.AuthUrlAction, uri.Open)
 	assert.Equal(t, c.MaxBackoff, 60)
 	assert.Equal(t, c.MaxRetry, 3)
+	assert.Equal(t, 8250, c.PKCE.CallbackPort)
 
 	c.Accounts["123456789012"].Roles = map[string]*SSORole{}
 	c.Accounts["123456789012"].Roles["FooBar"] = nil
@@ -127,6 +129,20 @@ func TestRefresh(t *testing.T) {
 	assert.Contains(t, c.Accounts, "123456789012")
 	assert.Contains(t, c.Accounts, "023456789012")
 	assert.Contains(t, c.Accounts, "033456789012")
+
+	// roles in other partitions get the correct ARN
+	c.SSORegion = "us-gov-west-1"
+	c.Accounts["123456789012"].Roles["FooBar"] = nil
+	c.Refresh(params)
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:role/FooBar", c.Accounts["123456789012"].Roles["FooBar"].ARN)
+}
+
+func TestSSOConfigPartition(t *testing.T) {
+	c := &SSOConfig{SSORegion: "us-east-1"}
+	assert.Equal(t, "aws", c.Partition())
+
+	c.SSORegion = "cn-north-1"
+	assert.Equal(t, "aws-cn", c.Partition())
 }
 
 func TestGetRole(t *testing.T) {
@@ -310,6 +326,9 @@ func TestSSORoleGetAllTags(t *testing.T) {
 func TestRoleInfoRoleArn(t *testing.T) {
 	ri := RoleInfo{AccountId: "123456789012", RoleName: "MyRole"}
 	assert.Equal(t, "arn:aws:iam::123456789012:role/MyRole", ri.RoleArn())
+
+	ri.SSORegion = "us-gov-east-1"
+	assert.Equal(t, "arn:aws-us-gov:iam::123456789012:role/MyRole", ri.RoleArn())
 }
 
 func TestRoleInfoGetAccountId64(t *testing.T) {
@@ -0,0 +1,77 @@
+package config
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	DEFAULT_PKCE_CALLBACK_ADDRESS = "127.0.0.1"
+	DEFAULT_PKCE_TIMEOUT          = 5 // minutes
+)
+
+// PKCEConfig holds the settings for the PKCE callback listener
+type PKCEConfig struct {
+	CallbackAddress string `koanf:"CallbackAddress" yaml:"CallbackAddress,omitempty"` // default: 127.0.0.1
+	CallbackPort    int    `koanf:"CallbackPort" yaml:"CallbackPort,omitempty"`       // default: random
+	Remote          bool   `koanf:"Remote" yaml:"Remote,omitempty"`                   // forward the callback over `ssh -L`
+	Timeout         int    `koanf:"Timeout" yaml:"Timeout,omitempty"`                 // minutes to wait for the callback
+}
+
+// Validate returns an error if the PKCEConfig is invalid
+func (p PKCEConfig) Validate() error {
+	if p.CallbackAddress != "" && net.ParseIP(p.CallbackAddress) == nil {
+		return fmt.Errorf("invalid CallbackAddress %s: must be an IP address", p.CallbackAddress)
+	}
+
+	if p.CallbackPort < 0 || p.CallbackPort > 65535 {
+		return fmt.Errorf("invalid CallbackPort %d: must be between 0 and 65535 (0 = random)", p.CallbackPort)
+	}
+
+	if p.Remote && p.CallbackPort == 0 {
+		return fmt.Errorf("a CallbackPort is required with Remote")
+	}
+
+	if p.Timeout < 0 {
+		return fmt.Errorf("invalid Timeout %d: must be >= 0", p.Timeout)
+	}
+	return nil
+}
+
+// ListenAddress returns the host:port our callback listener binds to.  A port
+// of 0 lets the kernel pick any available port.
+func (p PKCEConfig) ListenAddress() string {
+	address := p.CallbackAddress
+	if address == "" {
+		address = DEFAULT_PKCE_CALLBACK_ADDRESS
+	}
+	return net.JoinHostPort(address, strconv.Itoa(p.CallbackPort))
+}
+
+// GetTimeout returns how long to wait for the browser to deliver the callback
+func (p PKCEConfig) GetTimeout() time.Duration {
+	if p.Timeout == 0 {
+		return DEFAULT_PKCE_TIMEOUT * time.Minute
+	}
+	return time.Duration(p.Timeout) * time.Minute
+}
@@ -0,0 +1,49 @@
+package config
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPKCEConfigValidate(t *testing.T) {
+	assert.NoError(t, PKCEConfig{}.Validate())
+	assert.NoError(t, PKCEConfig{CallbackAddress: "0.0.0.0", CallbackPort: 8250, Remote: true, Timeout: 10}.Validate())
+	assert.NoError(t, PKCEConfig{CallbackAddress: "::1"}.Validate())
+
+	assert.ErrorContains(t, PKCEConfig{CallbackAddress: "localhost"}.Validate(), "invalid CallbackAddress")
+	assert.ErrorContains(t, PKCEConfig{CallbackPort: -1}.Validate(), "invalid CallbackPort")
+	assert.ErrorContains(t, PKCEConfig{CallbackPort: 65536}.Validate(), "invalid CallbackPort")
+	assert.ErrorContains(t, PKCEConfig{Remote: true}.Validate(), "CallbackPort is required")
+	assert.ErrorContains(t, PKCEConfig{Timeout: -1}.Validate(), "invalid Timeout")
+}
+
+func TestPKCEConfigListenAddress(t *testing.T) {
+	assert.Equal(t, "127.0.0.1:0", PKCEConfig{}.ListenAddress())
+	assert.Equal(t, "0.0.0.0:8250", PKCEConfig{CallbackAddress: "0.0.0.0", CallbackPort: 8250}.ListenAddress())
+	assert.Equal(t, "[::1]:8250", PKCEConfig{CallbackAddress: "::1", CallbackPort: 8250}.ListenAddress())
+}
+
+func TestPKCEConfigGetTimeout(t *testing.T) {
+	assert.Equal(t, 5*time.Minute, PKCEConfig{}.GetTimeout())
+	assert.Equal(t, 15*time.Minute, PKCEConfig{Timeout: 15}.GetTimeout())
+}
@@ -49,7 +49,7 @@ func (ri RoleInfo) GetHeader(fieldName string) (string, error) {
 
 func (ri RoleInfo) RoleArn() string {
 	a, _ := strconv.ParseInt(ri.AccountId, 10, 64)
-	return awsparse.MakeRoleARN(a, ri.RoleName)
+	return awsparse.MakeRoleARN(awsparse.GetPartition(ri.SSORegion), a, ri.RoleName)
 }
 
 func (ri RoleInfo) GetAccountId64() int64 {
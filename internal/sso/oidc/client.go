@@ -20,11 +20,13 @@ package oidc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	oidctypes "github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
 	"github.com/synfinatic/aws-sso-cli/internal/storage"
 )
 
@@ -122,3 +124,13 @@ func (c *AWSClient) ExchangeRefreshToken(ctx context.Context, in ExchangeRefresh
 		RefreshToken: in.RefreshToken,
 	})
 }
+
+// IsRefreshTokenExpired returns true if the error returned by ExchangeRefreshToken
+// means the refresh token can no longer be used and the user must login again.
+// An expired client registration invalidates its refresh tokens as well.
+func IsRefreshTokenExpired(err error) bool {
+	var ige *oidctypes.InvalidGrantException
+	var ete *oidctypes.ExpiredTokenException
+	var ice *oidctypes.InvalidClientException
+	return errors.As(err, &ige) || errors.As(err, &ete) || errors.As(err, &ice)
+}
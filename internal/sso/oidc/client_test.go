@@ -3,11 +3,13 @@ package oidc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ssooidc"
+	oidctypes "github.com/aws/aws-sdk-go-v2/service/ssooidc/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -235,3 +237,14 @@ func TestExchangeRefreshToken(t *testing.T) {
 		assert.Contains(t, err.Error(), "expired_token")
 	})
 }
+
+func TestIsRefreshTokenExpired(t *testing.T) {
+	assert.True(t, IsRefreshTokenExpired(&oidctypes.InvalidGrantException{}))
+	assert.True(t, IsRefreshTokenExpired(&oidctypes.ExpiredTokenException{}))
+	assert.True(t, IsRefreshTokenExpired(&oidctypes.InvalidClientException{}))
+	assert.True(t, IsRefreshTokenExpired(fmt.Errorf("createToken: %w", &oidctypes.InvalidGrantException{})))
+
+	assert.False(t, IsRefreshTokenExpired(nil))
+	assert.False(t, IsRefreshTokenExpired(&oidctypes.SlowDownException{}))
+	assert.False(t, IsRefreshTokenExpired(errors.New("connection reset by peer")))
+}
@@ -0,0 +1,200 @@
+package roles
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+)
+
+// How we get the credentials for each hop in a role chain
+const (
+	CredentialSourceSSO        = "AWS SSO"
+	CredentialSourceIAMUser    = "IAM User"
+	CredentialSourceAssumeRole = "sts:AssumeRole"
+)
+
+var (
+	ErrChainLoop   = errors.New("role chain loop")
+	ErrInvalidVia  = errors.New("invalid Via")
+	ErrMissingHop  = errors.New("missing role chain hop")
+	ErrUnreachable = errors.New("unreachable role")
+)
+
+// ChainHop is a single step in a role chain
+type ChainHop struct {
+	Arn              string
+	CredentialSource string
+	Via              string             // ARN whose credentials are used to assume this role
+	Role             *ssoconfig.SSORole // nil if the role is not in our config
+}
+
+// ExternalId returns the ExternalId used to assume the role, if any
+func (h ChainHop) ExternalId() string {
+	if h.Role == nil {
+		return ""
+	}
+	return h.Role.ExternalId
+}
+
+// SourceIdentity returns the SourceIdentity used to assume the role, if any
+func (h ChainHop) SourceIdentity() string {
+	if h.Role == nil {
+		return ""
+	}
+	return h.Role.SourceIdentity
+}
+
+// ChainSource reports which ARNs we can get credentials for without sts:AssumeRole
+type ChainSource interface {
+	HasSSORole(arn string) bool
+	HasIAMUser(arn string) bool
+}
+
+// ChainResolver resolves the `Via` graph of the roles in an SSOConfig
+type ChainResolver struct {
+	config *ssoconfig.SSOConfig
+	source ChainSource
+}
+
+// NewChainResolver returns a ChainResolver for the given config.  If source is nil,
+// any role without a Via is assumed to be provided by AWS SSO and every IAM User
+// is assumed to have StaticCredentials.
+func NewChainResolver(config *ssoconfig.SSOConfig, source ChainSource) *ChainResolver {
+	return &ChainResolver{
+		config: config,
+		source: source,
+	}
+}
+
+// Resolve returns the hops needed to get credentials for the given role or
+// IAM User ARN, starting with the hop which provides the initial credentials
+func (cr *ChainResolver) Resolve(arn string) ([]ChainHop, error) {
+	hops := []ChainHop{}
+	path := []string{}
+	current := arn
+
+	for {
+		for _, p := range path {
+			if p == current {
+				return nil, fmt.Errorf("%w: %s", ErrChainLoop, strings.Join(append(path, current), " -> "))
+			}
+		}
+		path = append(path, current)
+
+		hop, err := cr.hop(current)
+		if err != nil {
+			if current != arn && errors.Is(err, ErrMissingHop) {
+				return nil, fmt.Errorf("%w %s: %w", ErrUnreachable, arn, err)
+			}
+			return nil, err
+		}
+		hops = append([]ChainHop{hop}, hops...) // prepend
+
+		if hop.Via == "" {
+			return hops, nil
+		}
+		current = hop.Via
+	}
+}
+
+// hop returns the ChainHop for the given ARN
+func (cr *ChainResolver) hop(arn string) (ChainHop, error) {
+	if awsparse.IsUserARN(arn) {
+		if cr.source != nil && !cr.source.HasIAMUser(arn) {
+			return ChainHop{}, fmt.Errorf("%w: %s has no StaticCredentials", ErrMissingHop, arn)
+		}
+		return ChainHop{Arn: arn, CredentialSource: CredentialSourceIAMUser}, nil
+	}
+
+	accountId, roleName, err := awsparse.ParseRoleARN(arn)
+	if err != nil || !strings.HasPrefix(arn, "arn:") {
+		return ChainHop{}, fmt.Errorf("%w: %s is not an IAM Role or IAM User ARN", ErrInvalidVia, arn)
+	}
+
+	hop := ChainHop{
+		Arn:              arn,
+		CredentialSource: CredentialSourceSSO,
+	}
+	if role, err := cr.config.GetRole(accountId, roleName); err == nil {
+		hop.Role = role
+		hop.Via = role.Via
+	}
+
+	if hop.Via != "" {
+		hop.CredentialSource = CredentialSourceAssumeRole
+	} else if cr.source != nil && !cr.source.HasSSORole(arn) {
+		return ChainHop{}, fmt.Errorf("%w: %s is not an AWS SSO role", ErrMissingHop, arn)
+	}
+	return hop, nil
+}
+
+// Validate resolves the role chain of every role in our config with a Via and
+// returns all the errors found
+func (cr *ChainResolver) Validate() error {
+	return errors.Join(cr.Errors()...)
+}
+
+// Errors resolves the role chain of every role in our config with a Via and
+// returns a list of the errors found
+func (cr *ChainResolver) Errors() []error {
+	arns := []string{}
+	for _, r := range cr.config.GetRoles() {
+		if r.Via != "" {
+			arns = append(arns, r.ARN)
+		}
+	}
+	sort.Strings(arns)
+
+	errs := []error{}
+	for _, arn := range arns {
+		if _, err := cr.Resolve(arn); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// HasSSORole returns true if the given role ARN was provided by AWS SSO
+func (r *Roles) HasSSORole(arn string) bool {
+	accountId, roleName, err := awsparse.ParseRoleARN(arn)
+	if err != nil {
+		return false
+	}
+	flat, err := r.GetRole(accountId, roleName)
+	return err == nil && flat.Via == "" && !awsparse.IsUserARN(flat.Arn)
+}
+
+// HasIAMUser returns true if we have StaticCredentials for the given IAM User ARN
+func (r *Roles) HasIAMUser(arn string) bool {
+	if !awsparse.IsUserARN(arn) {
+		return false
+	}
+	accountId, userName, err := awsparse.ParseUserARN(arn)
+	if err != nil {
+		return false
+	}
+	flat, err := r.GetRole(accountId, userName)
+	return err == nil && flat.Arn == arn
+}
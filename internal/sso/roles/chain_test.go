@@ -0,0 +1,165 @@
+package roles
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
+)
+
+const (
+	chainDevAdmin  = "arn:aws:iam::111111111111:role/DevAdmin"
+	chainProdAdmin = "arn:aws:iam::222222222222:role/ProdAdmin"
+	chainAudit     = "arn:aws:iam::222222222222:role/Audit"
+	chainBreakglas = "arn:aws:iam::111111111111:user/breakglass"
+)
+
+// testChainConfig returns an SSOConfig with the given roles, keyed by ARN
+func testChainConfig(t *testing.T, roles map[string]*ssoconfig.SSORole) *ssoconfig.SSOConfig {
+	t.Helper()
+	c := &ssoconfig.SSOConfig{
+		SSORegion: "us-east-1",
+		Accounts:  map[string]*ssoconfig.SSOAccount{},
+	}
+	for arn, r := range roles {
+		r.ARN = arn
+		accountId := r.GetAccountId()
+		if _, ok := c.Accounts[accountId]; !ok {
+			c.Accounts[accountId] = &ssoconfig.SSOAccount{Roles: map[string]*ssoconfig.SSORole{}}
+		}
+		c.Accounts[accountId].Roles[r.GetRoleName()] = r
+	}
+	return c
+}
+
+// testChainSource returns the testRolesFixture() with an IAM User
+func testChainSource() *Roles {
+	r := testRolesFixture()
+	r.Accounts[111111111111].Roles["breakglass"] = &AWSRole{Arn: chainBreakglas}
+	return r
+}
+
+func TestChainResolverResolve(t *testing.T) {
+	c := testChainConfig(t, map[string]*ssoconfig.SSORole{
+		chainProdAdmin: {Via: chainDevAdmin, ExternalId: "ext-id", SourceIdentity: "alice"},
+		chainAudit:     {Via: chainProdAdmin},
+	})
+
+	for _, source := range []ChainSource{nil, testChainSource()} {
+		cr := NewChainResolver(c, source)
+
+		hops, err := cr.Resolve(chainDevAdmin)
+		require.NoError(t, err)
+		require.Len(t, hops, 1)
+		assert.Equal(t, CredentialSourceSSO, hops[0].CredentialSource)
+		assert.Nil(t, hops[0].Role)
+
+		hops, err = cr.Resolve(chainAudit)
+		require.NoError(t, err)
+		require.Len(t, hops, 3)
+		assert.Equal(t, []string{chainDevAdmin, chainProdAdmin, chainAudit},
+			[]string{hops[0].Arn, hops[1].Arn, hops[2].Arn})
+		assert.Equal(t, CredentialSourceSSO, hops[0].CredentialSource)
+		assert.Equal(t, CredentialSourceAssumeRole, hops[1].CredentialSource)
+		assert.Equal(t, chainDevAdmin, hops[1].Via)
+		assert.Equal(t, "ext-id", hops[1].ExternalId())
+		assert.Equal(t, "alice", hops[1].SourceIdentity())
+		assert.Equal(t, CredentialSourceAssumeRole, hops[2].CredentialSource)
+		assert.Equal(t, chainProdAdmin, hops[2].Via)
+		assert.Empty(t, hops[2].ExternalId())
+		assert.Empty(t, hops[0].SourceIdentity())
+	}
+}
+
+func TestChainResolverResolveUser(t *testing.T) {
+	c := testChainConfig(t, map[string]*ssoconfig.SSORole{
+		chainProdAdmin: {Via: chainBreakglas},
+	})
+
+	hops, err := NewChainResolver(c, testChainSource()).Resolve(chainProdAdmin)
+	require.NoError(t, err)
+	require.Len(t, hops, 2)
+	assert.Equal(t, chainBreakglas, hops[0].Arn)
+	assert.Equal(t, CredentialSourceIAMUser, hops[0].CredentialSource)
+
+	// no StaticCredentials for the IAM User
+	_, err = NewChainResolver(c, testRolesFixture()).Resolve(chainProdAdmin)
+	assert.ErrorIs(t, err, ErrUnreachable)
+	assert.ErrorIs(t, err, ErrMissingHop)
+	assert.ErrorContains(t, err, "has no StaticCredentials")
+
+	_, err = NewChainResolver(c, testRolesFixture()).Resolve(chainBreakglas)
+	assert.ErrorIs(t, err, ErrMissingHop)
+	assert.False(t, errors.Is(err, ErrUnreachable))
+}
+
+func TestChainResolverErrors(t *testing.T) {
+	missing := "arn:aws:iam::333333333333:role/Missing"
+	loopA := "arn:aws:iam::333333333333:role/LoopA"
+	loopB := "arn:aws:iam::333333333333:role/LoopB"
+	c := testChainConfig(t, map[string]*ssoconfig.SSORole{
+		chainProdAdmin:                          {Via: chainDevAdmin},
+		chainAudit:                              {Via: missing},
+		loopA:                                   {Via: loopB},
+		loopB:                                   {Via: loopA},
+		"arn:aws:iam::333333333333:role/BadVia": {Via: "not-an-arn"},
+	})
+
+	// without a source we can only detect loops & invalid Vias
+	errs := NewChainResolver(c, nil).Errors()
+	require.Len(t, errs, 3)
+	assert.ErrorIs(t, errs[0], ErrInvalidVia)
+	assert.ErrorIs(t, errs[1], ErrChainLoop)
+	assert.ErrorContains(t, errs[1], loopA+" -> "+loopB+" -> "+loopA)
+	assert.ErrorIs(t, errs[2], ErrChainLoop)
+
+	errs = NewChainResolver(c, testChainSource()).Errors()
+	require.Len(t, errs, 4)
+	assert.ErrorIs(t, errs[0], ErrUnreachable)
+	assert.ErrorIs(t, errs[0], ErrMissingHop)
+	assert.ErrorContains(t, errs[0], missing)
+
+	err := NewChainResolver(c, nil).Validate()
+	assert.ErrorIs(t, err, ErrInvalidVia)
+	assert.ErrorIs(t, err, ErrChainLoop)
+
+	c = testChainConfig(t, map[string]*ssoconfig.SSORole{
+		chainProdAdmin: {Via: chainDevAdmin},
+		chainDevAdmin:  {},
+	})
+	assert.NoError(t, NewChainResolver(c, testChainSource()).Validate())
+}
+
+func TestRolesChainSource(t *testing.T) {
+	r := testChainSource()
+
+	assert.True(t, r.HasSSORole(chainDevAdmin))
+	assert.False(t, r.HasSSORole(chainProdAdmin)) // has a Via
+	assert.False(t, r.HasSSORole("arn:aws:iam::111111111111:role/Missing"))
+	assert.False(t, r.HasSSORole(chainBreakglas))
+	assert.False(t, r.HasSSORole("not-an-arn"))
+
+	assert.True(t, r.HasIAMUser(chainBreakglas))
+	assert.False(t, r.HasIAMUser("arn:aws:iam::111111111111:user/missing"))
+	assert.False(t, r.HasIAMUser(chainDevAdmin))
+}
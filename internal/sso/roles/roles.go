@@ -28,8 +28,8 @@ import (
 	"time"
 
 	"github.com/Masterminds/sprig/v3"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/tags"
 	tparse "github.com/synfinatic/aws-sso-cli/internal/timeutils"
 	"github.com/synfinatic/gotable"
@@ -74,6 +74,11 @@ type AWSRole struct {
 	Via           string            `json:"Via,omitempty"`
 }
 
+// Partition returns the AWS partition of our roles based on the SSORegion
+func (r *Roles) Partition() string {
+	return awsparse.GetPartition(r.SSORegion)
+}
+
 // AccountIds returns all the configured AWS SSO AccountIds
 func (r *Roles) AccountIds() []int64 { // nolint: revive
 	ret := []int64{}
@@ -219,7 +224,7 @@ func (r *Roles) GetRoleByProfile(profileName string, s ProfileSettings) (*AWSRol
 			flat, _ := r.GetRole(aId, roleName)
 			pName, err := flat.ProfileName(s)
 			if err != nil {
-				log.Warn("unable to generate Profile", "arn", awsparse.MakeRoleARN(aId, roleName), "error", err.Error())
+				log.Warn("unable to generate Profile", "arn", awsparse.MakeRoleARN(r.Partition(), aId, roleName), "error", err.Error())
 			}
 			if pName == profileName {
 				return flat, nil
@@ -235,7 +240,7 @@ func (r *Roles) GetRoleChain(accountId int64, roleName string) []*AWSRoleFlat {
 
 	f, err := r.GetRole(accountId, roleName)
 	if err != nil {
-		log.Fatal("unable to fetch role", "arn", awsparse.MakeRoleARN(accountId, roleName), "error", err.Error())
+		log.Fatal("unable to fetch role", "arn", awsparse.MakeRoleARN(r.Partition(), accountId, roleName), "error", err.Error())
 	}
 	ret = append(ret, f)
 	for f.Via != "" {
@@ -245,7 +250,7 @@ func (r *Roles) GetRoleChain(accountId int64, roleName string) []*AWSRoleFlat {
 		}
 		f, err = r.GetRole(aId, rName)
 		if err != nil {
-			log.Fatal("unable to get role", "role", awsparse.MakeRoleARN(aId, rName), "error", err.Error())
+			log.Fatal("unable to get role", "role", awsparse.MakeRoleARN(r.Partition(), aId, rName), "error", err.Error())
 		}
 		ret = append([]*AWSRoleFlat{f}, ret...) // prepend
 	}
@@ -389,8 +394,8 @@ func (r *AWSRoleFlat) ProfileName(s ProfileSettings) (string, error) {
 	}
 
 	buf := new(bytes.Buffer)
-	log.Trace("RoleInfo", "dump", spew.Sdump(r))
-	log.Trace("Template", "dump", spew.Sdump(templ))
+	log.Trace("RoleInfo", "dump", logger.Sdump(r))
+	log.Trace("Template", "dump", logger.Sdump(templ))
 	if err := templ.Execute(buf, r); err != nil {
 		return "", fmt.Errorf("unable to generate ProfileName: %s", err.Error())
 	}
@@ -323,6 +323,14 @@ func TestRolesAccountIds(t *testing.T) {
 	assert.Contains(t, ids, int64(222222222222))
 }
 
+func TestRolesPartition(t *testing.T) {
+	r := testRolesFixture()
+	assert.Equal(t, "aws", r.Partition())
+
+	r.SSORegion = "cn-northwest-1"
+	assert.Equal(t, "aws-cn", r.Partition())
+}
+
 func TestRolesGetAllRoles(t *testing.T) {
 	r := testRolesFixture()
 	all := r.GetAllRoles()
@@ -35,10 +35,12 @@ import (
 	"github.com/knadh/koanf/providers/file"
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
 	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/aws-sso-cli/internal/prompt"
 	ssocache "github.com/synfinatic/aws-sso-cli/internal/sso/cache"
 	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
 	"github.com/synfinatic/aws-sso-cli/internal/ui"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
 )
@@ -55,6 +57,7 @@ type Settings struct {
 	OnePassword               OnePasswordConfig               `koanf:"OnePassword" yaml:"OnePassword,omitempty"`
 	DefaultRegion             string                          `koanf:"DefaultRegion" yaml:"DefaultRegion,omitempty"`
 	AuthWorkflow              oidc.AuthWorkflow               `koanf:"AuthWorkflow" yaml:"AuthWorkflow,omitempty"`
+	PKCE                      ssoconfig.PKCEConfig            `koanf:"PKCE" yaml:"PKCE,omitempty"`
 	ConsoleDuration           int32                           `koanf:"ConsoleDuration" yaml:"ConsoleDuration,omitempty"`
 	JsonStore                 string                          `koanf:"JsonStore" yaml:"JsonStore,omitempty"`
 	CacheRefresh              int64                           `koanf:"CacheRefresh" yaml:"CacheRefresh,omitempty"`
@@ -81,6 +84,23 @@ type Settings struct {
 	ConfigVariables           map[string]interface{}          `koanf:"ConfigVariables" yaml:"ConfigVariables,omitempty"`
 	EnvVarTags                []string                        `koanf:"EnvVarTags" yaml:"EnvVarTags,omitempty"`
 	FullTextSearch            bool                            `koanf:"FullTextSearch" yaml:"FullTextSearch"`
+	Audit                     AuditConfig                     `koanf:"Audit" yaml:"Audit,omitempty"`
+}
+
+// UserManagedRegion returns true if the user has set AWS_DEFAULT_REGION/AWS_REGION
+// themselves, in which case we should not override it
+func UserManagedRegion() bool {
+	currentRegion := os.Getenv("AWS_DEFAULT_REGION")
+	if len(currentRegion) == 0 {
+		currentRegion = os.Getenv("AWS_REGION")
+	}
+	ssoManagedRegion := os.Getenv("AWS_SSO_DEFAULT_REGION")
+
+	if len(currentRegion) > 0 && currentRegion != ssoManagedRegion {
+		log.Debug("Will not override current AWS_DEFAULT_REGION/AWS_REGION", "region", currentRegion)
+		return true
+	}
+	return false
 }
 
 // GetDefaultRegion scans the config settings file to pick the most local DefaultRegion from the tree
@@ -96,17 +116,8 @@ func (s *Settings) GetDefaultRegion(id int64, roleName string, noRegion bool, ov
 		log.Fatal("Unable to GetDefaultRegion()", "error", err.Error())
 	}
 
-	if !overwriteEnv {
-		currentRegion := os.Getenv("AWS_DEFAULT_REGION")
-		if len(currentRegion) == 0 {
-			currentRegion = os.Getenv("AWS_REGION")
-		}
-		ssoManagedRegion := os.Getenv("AWS_SSO_DEFAULT_REGION")
-
-		if len(currentRegion) > 0 && currentRegion != ssoManagedRegion {
-			log.Debug("Will not override current AWS_DEFAULT_REGION/AWS_REGION", "region", currentRegion)
-			return ""
-		}
+	if !overwriteEnv && UserManagedRegion() {
+		return ""
 	}
 
 	role := s.DefaultRegion
@@ -136,6 +147,14 @@ type OnePasswordConfig struct {
 	Account  string `koanf:"Account" yaml:"Account,omitempty"`
 }
 
+// AuditConfig holds settings for the audit log of credential access
+type AuditConfig struct {
+	Enabled  bool   `koanf:"Enabled" yaml:"Enabled,omitempty"`
+	File     string `koanf:"File" yaml:"File,omitempty"`
+	MaxSize  int64  `koanf:"MaxSize" yaml:"MaxSize,omitempty"`   // MB before the file is rotated
+	MaxFiles int    `koanf:"MaxFiles" yaml:"MaxFiles,omitempty"` // number of rotated files to keep
+}
+
 var DEFAULT_ACCOUNT_PRIMARY_TAGS []string = []string{
 	"AccountName",
 	"AccountAlias",
@@ -185,7 +204,8 @@ func LoadSettings(configFile, cacheFile string, defaults map[string]interface{},
 	}
 
 	s.setOverrides(override)
-	s.AuthWorkflow = defaultAuthWorkflow(s.AuthWorkflow, konf.Exists("AuthWorkflow"), prompt.IsRemoteHost())
+	// remote PKCE works fine over SSH, so don't fall back to device_code
+	s.AuthWorkflow = defaultAuthWorkflow(s.AuthWorkflow, konf.Exists("AuthWorkflow"), prompt.IsRemoteHost() && !s.PKCE.Remote)
 
 	// set our SSO names
 	for k, v := range s.SSO {
@@ -236,6 +256,9 @@ func LoadSettings(configFile, cacheFile string, defaults map[string]interface{},
 	// load the cache
 	if s.Cache, err = ssocache.OpenCache(s.cacheFile, s); err != nil {
 		log.Info("unable to open cache file", "error", err.Error())
+	} else if err = s.validateRoleChains(); err != nil {
+		// now we can also check for roles which are missing from AWS SSO
+		return s, err
 	}
 
 	return s, nil
@@ -253,6 +276,40 @@ func (s *Settings) Validate() error {
 		return fmt.Errorf("invalid AuthWorkflow: %w", err)
 	}
 
+	if err := s.PKCE.Validate(); err != nil {
+		return fmt.Errorf("invalid PKCE: %w", err)
+	}
+	if s.PKCE.Remote && s.AuthWorkflow != oidc.AuthWorkflowPKCE {
+		return fmt.Errorf("invalid PKCE: Remote requires AuthWorkflow: %s", oidc.AuthWorkflowPKCE)
+	}
+
+	for name, c := range s.SSO {
+		for _, r := range c.GetRoles() {
+			if err := r.Validate(); err != nil {
+				return fmt.Errorf("invalid role %s in SSO %s: %w", r.ARN, name, err)
+			}
+		}
+	}
+
+	return s.validateRoleChains()
+}
+
+// validateRoleChains checks the Via graph of every SSO instance for loops and
+// invalid ARNs.  Once our cache has been loaded, roles which Via a role or IAM
+// User that is in neither our config nor our cache are also errors.
+func (s *Settings) validateRoleChains() error {
+	for name, c := range s.SSO {
+		var source roles.ChainSource
+		if s.Cache != nil {
+			cache, ok := s.Cache.SSO[name]
+			if ok && cache.Roles != nil && len(cache.Roles.Accounts) > 0 {
+				source = cache.Roles
+			}
+		}
+		if err := roles.NewChainResolver(c, source).Validate(); err != nil {
+			return fmt.Errorf("invalid role chain in SSO %s: %w", name, err)
+		}
+	}
 	return nil
 }
 
@@ -352,6 +409,7 @@ func (s *Settings) setOverrides(override OverrideSettings) {
 		s.LogLines = true
 	}
 	log.SetReportCaller(s.LogLines)
+	logger.WithRedaction(log) // SetReportCaller replaces the handler
 
 	// Other overrides from CLI
 	if override.Browser != "" {
@@ -464,6 +522,7 @@ func (s *Settings) ToSSOConfigSettings() ssoconfig.SSOConfigSettings {
 		Browser:        s.Browser,
 		UrlExecCommand: s.UrlExecCommand,
 		AuthWorkflow:   s.AuthWorkflow,
+		PKCE:           s.PKCE,
 		CacheRefresh:   s.CacheRefresh,
 		ConfigFile:     s.configFile,
 	}
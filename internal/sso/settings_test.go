@@ -26,8 +26,11 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
+	ssoconfig "github.com/synfinatic/aws-sso-cli/internal/sso/config"
 	"github.com/synfinatic/aws-sso-cli/internal/sso/oidc"
+	"github.com/synfinatic/aws-sso-cli/internal/sso/roles"
 	"github.com/synfinatic/aws-sso-cli/internal/uri"
 	"github.com/synfinatic/flexlog"
 	testlogger "github.com/synfinatic/flexlog/test"
@@ -114,6 +117,86 @@ func TestConfigProfilesUrlAction(t *testing.T) {
 	assert.Equal(t, uri.ConfigProfilesOpenUrlContainer, settings.ConfigProfilesUrlAction)
 }
 
+func TestLoadSettingsInvalidRole(t *testing.T) {
+	t.Parallel()
+	config := `LogLevel: warn
+SSOConfig:
+  Default:
+    SSORegion: us-east-1
+    StartUrl: https://d-1234567890.awsapps.com/start
+    Accounts:
+      "123456789012":
+        Roles:
+          TargetRole:
+            Via: arn:aws:iam::123456789012:role/BaseRole
+            Duration: 1440
+`
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte(config), 0600))
+
+	_, err := LoadSettings(configFile, TEST_CACHE_FILE, map[string]interface{}{}, OverrideSettings{})
+	assert.ErrorContains(t, err, "invalid role arn:aws:iam::123456789012:role/TargetRole in SSO Default: invalid Duration 1440")
+}
+
+func TestLoadSettingsRoleChainLoop(t *testing.T) {
+	t.Parallel()
+	config := `LogLevel: warn
+SSOConfig:
+  Default:
+    SSORegion: us-east-1
+    StartUrl: https://d-1234567890.awsapps.com/start
+    Accounts:
+      "123456789012":
+        Roles:
+          RoleA:
+            Via: arn:aws:iam::123456789012:role/RoleB
+          RoleB:
+            Via: arn:aws:iam::123456789012:role/RoleA
+`
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(configFile, []byte(config), 0600))
+
+	_, err := LoadSettings(configFile, TEST_CACHE_FILE, map[string]interface{}{}, OverrideSettings{})
+	assert.ErrorContains(t, err, "invalid role chain in SSO Default: role chain loop")
+	assert.ErrorIs(t, err, roles.ErrChainLoop)
+}
+
+func TestLoadSettingsRoleChainMissingHop(t *testing.T) {
+	t.Parallel()
+	config := `LogLevel: warn
+SSOConfig:
+  Default:
+    SSORegion: us-east-1
+    StartUrl: https://d-1234567890.awsapps.com/start
+    Accounts:
+      "025823461518":
+        Roles:
+          RoleA:
+            Via: %s
+`
+	cacheData, err := os.ReadFile(TEST_CACHE_FILE)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	cacheFile := filepath.Join(dir, "cache.json")
+	require.NoError(t, os.WriteFile(cacheFile, cacheData, 0600))
+	configFile := filepath.Join(dir, "config.yaml")
+
+	// Via a role which is in our cache
+	via := "arn:aws:iam::025823461518:role/AWSAdministratorAccess"
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(config, via)), 0600))
+	_, err = LoadSettings(configFile, cacheFile, map[string]interface{}{}, OverrideSettings{})
+	assert.NoError(t, err)
+
+	// Via a role which is in neither our config nor our cache
+	via = "arn:aws:iam::025823461518:role/Missing"
+	require.NoError(t, os.WriteFile(configFile, []byte(fmt.Sprintf(config, via)), 0600))
+	s, err := LoadSettings(configFile, cacheFile, map[string]interface{}{}, OverrideSettings{})
+	assert.ErrorContains(t, err, "invalid role chain in SSO Default")
+	assert.ErrorIs(t, err, roles.ErrMissingHop)
+	assert.ErrorIs(t, err, roles.ErrUnreachable)
+	assert.NotNil(t, s.Cache)
+}
+
 func TestDefaultAuthWorkflow(t *testing.T) {
 	tests := []struct {
 		name               string
@@ -457,6 +540,17 @@ func (suite *SettingsTestSuite) TestValidate() {
 	suite.settings.AuthWorkflow = oldWorkflow
 	assert.NoError(t, suite.settings.Validate())
 
+	oldPKCE := suite.settings.PKCE
+	suite.settings.PKCE = ssoconfig.PKCEConfig{CallbackPort: 70000}
+	assert.ErrorContains(t, suite.settings.Validate(), "invalid PKCE")
+	suite.settings.PKCE = ssoconfig.PKCEConfig{CallbackPort: 8250, Remote: true}
+	suite.settings.AuthWorkflow = oidc.AuthWorkflowDeviceCode
+	assert.ErrorContains(t, suite.settings.Validate(), "Remote requires AuthWorkflow: pkce")
+	suite.settings.AuthWorkflow = oidc.AuthWorkflowPKCE
+	assert.NoError(t, suite.settings.Validate())
+	suite.settings.PKCE = oldPKCE
+	suite.settings.AuthWorkflow = oldWorkflow
+
 	suite.settings.UrlAction = uri.Exec
 	suite.settings.ConfigProfilesUrlAction = uri.ConfigProfilesGrantedContainer
 	assert.Error(t, suite.settings.Validate())
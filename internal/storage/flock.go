@@ -24,6 +24,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/danjacques/gofslock/fslock"
 	"github.com/jpillora/backoff"
 	"github.com/synfinatic/aws-sso-cli/internal/config"
 )
@@ -75,3 +76,14 @@ func FlockBlockerWithCtx(ctx context.Context) func() error {
 		return nil
 	}
 }
+
+// WithFlock runs fn while holding an exclusive advisory lock on lockFile, waiting
+// up to timeout for any other process to release it.
+func WithFlock(ctx context.Context, lockFile string, timeout time.Duration, fn func() error) error {
+	lockCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := fslock.WithBlocking(lockFile, FlockBlockerWithCtx(lockCtx), fn)
+	FlockBlockerReset()
+	return err
+}
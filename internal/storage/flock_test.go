@@ -23,10 +23,14 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/danjacques/gofslock/fslock"
+
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/synfinatic/aws-sso-cli/internal/config"
 )
 
@@ -72,3 +76,53 @@ func TestFlockBlockerWithCtx(t *testing.T) {
 		assert.ErrorIs(t, err, context.DeadlineExceeded)
 	})
 }
+
+func TestWithFlock(t *testing.T) {
+	lockFile := path.Join(t.TempDir(), "test.lock")
+
+	t.Run("serializes callers", func(t *testing.T) {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		active, maxActive := 0, 0
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := WithFlock(context.Background(), lockFile, 5*time.Second, func() error {
+					mu.Lock()
+					active++
+					if active > maxActive {
+						maxActive = active
+					}
+					mu.Unlock()
+					time.Sleep(5 * time.Millisecond)
+					mu.Lock()
+					active--
+					mu.Unlock()
+					return nil
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, maxActive)
+	})
+
+	t.Run("returns fn error", func(t *testing.T) {
+		err := WithFlock(context.Background(), lockFile, time.Second, func() error {
+			return fmt.Errorf("boom")
+		})
+		assert.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("times out when held", func(t *testing.T) {
+		h, err := fslock.Lock(lockFile)
+		require.NoError(t, err)
+		defer h.Unlock() // nolint:errcheck
+
+		err = WithFlock(context.Background(), lockFile, 20*time.Millisecond, func() error {
+			return nil
+		})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -42,6 +42,13 @@ type JsonStore struct {
 	EcsBearerToken      string                         `json:"EcsBearerToken,omitempty"`
 	EcsPrivateKey       string                         `json:"EcsPrivateKey,omitempty"`
 	EcsCertChain        string                         `json:"EcsCertChain,omitempty"`
+	EcsCAKey            string                         `json:"EcsCAKey,omitempty"`
+	EcsCACert           string                         `json:"EcsCACert,omitempty"`
+	EcsClientCA         string                         `json:"EcsClientCA,omitempty"`
+	EcsClientKey        string                         `json:"EcsClientKey,omitempty"`
+	EcsClientCertChain  string                         `json:"EcsClientCertChain,omitempty"`
+	EcsSlots            map[string]EcsSlot             `json:"EcsSlots,omitempty"`
+	EcsTokens           map[string]EcsToken            `json:"EcsTokens,omitempty"`
 }
 
 // OpenJsonStore opens our insecure JSON storage backend
@@ -57,6 +64,13 @@ func OpenJsonStore(ctx context.Context, filename string) (SecureStorage, error)
 		EcsBearerToken:      "",
 		EcsPrivateKey:       "",
 		EcsCertChain:        "",
+		EcsCAKey:            "",
+		EcsCACert:           "",
+		EcsClientCA:         "",
+		EcsClientKey:        "",
+		EcsClientCertChain:  "",
+		EcsSlots:            map[string]EcsSlot{},
+		EcsTokens:           map[string]EcsToken{},
 	}
 
 	lockCtx, cancel := context.WithTimeout(ctx, flockWaitTimeout)
@@ -140,6 +154,11 @@ func (jc *JsonStore) DeleteRegisterClientData(ctx context.Context, key string) e
 	return jc.save(ctx)
 }
 
+// ListRegisterClientData returns the keys of all the RegisterClientData in the json file
+func (jc *JsonStore) ListRegisterClientData() []string {
+	return listKeys(jc.RegisterClient, "")
+}
+
 // SaveCreateTokenResponse stores the token in the json file
 func (jc *JsonStore) SaveCreateTokenResponse(ctx context.Context, key string, token CreateTokenResponse) error {
 	jc.CreateTokenResponse[key] = token
@@ -162,6 +181,11 @@ func (jc *JsonStore) DeleteCreateTokenResponse(ctx context.Context, key string)
 	return jc.save(ctx)
 }
 
+// ListCreateTokenResponses returns the keys of all the CreateTokenResponses in the json file
+func (jc *JsonStore) ListCreateTokenResponses() []string {
+	return listKeys(jc.CreateTokenResponse, "")
+}
+
 // SaveRoleCredentials stores the token in the json file
 func (jc *JsonStore) SaveRoleCredentials(ctx context.Context, arn string, token RoleCredentials) error {
 	jc.RoleCredentials[arn] = token
@@ -184,6 +208,11 @@ func (jc *JsonStore) DeleteRoleCredentials(ctx context.Context, arn string) erro
 	return jc.save(ctx)
 }
 
+// ListRoleCredentials returns the ARNs of all the RoleCredentials in the json file
+func (jc *JsonStore) ListRoleCredentials() []string {
+	return listKeys(jc.RoleCredentials, "")
+}
+
 // SaveStaticCredentials stores the token in the json file
 func (jc *JsonStore) SaveStaticCredentials(ctx context.Context, arn string, creds StaticCredentials) error {
 	jc.StaticCredentials[arn] = creds
@@ -269,3 +298,143 @@ func (jc *JsonStore) DeleteEcsSslKeyPair(ctx context.Context) error {
 	jc.EcsCertChain = ""
 	return jc.save(ctx)
 }
+
+// SaveEcsCAKeyPair stores the ECS Server CA private key and certificate in the json file
+func (jc *JsonStore) SaveEcsCAKeyPair(ctx context.Context, privateKey, cert []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, cert); err != nil {
+		return err
+	}
+	jc.EcsCAKey = string(privateKey)
+	jc.EcsCACert = string(cert)
+	return jc.save(ctx)
+}
+
+// GetEcsCACert retrieves the ECS Server CA certificate from the json file
+func (jc *JsonStore) GetEcsCACert() (string, error) {
+	return jc.EcsCACert, nil
+}
+
+// GetEcsCAKey retrieves the ECS Server CA private key from the json file
+func (jc *JsonStore) GetEcsCAKey() (string, error) {
+	return jc.EcsCAKey, nil
+}
+
+// DeleteEcsCAKeyPair deletes the ECS Server CA private key and certificate from the json file
+func (jc *JsonStore) DeleteEcsCAKeyPair(ctx context.Context) error {
+	jc.EcsCAKey = ""
+	jc.EcsCACert = ""
+	return jc.save(ctx)
+}
+
+// SaveEcsClientCA stores the CA certificate used to verify ECS client certificates in the json file
+func (jc *JsonStore) SaveEcsClientCA(ctx context.Context, caCert []byte) error {
+	if err := ValidateSSLCertificate(caCert); err != nil {
+		return err
+	}
+	jc.EcsClientCA = string(caCert)
+	return jc.save(ctx)
+}
+
+// GetEcsClientCA retrieves the client CA certificate from the json file
+func (jc *JsonStore) GetEcsClientCA() (string, error) {
+	return jc.EcsClientCA, nil
+}
+
+// DeleteEcsClientCA deletes the client CA certificate from the json file
+func (jc *JsonStore) DeleteEcsClientCA(ctx context.Context) error {
+	jc.EcsClientCA = ""
+	return jc.save(ctx)
+}
+
+// SaveEcsClientKeyPair stores the ECS client private key and certificate chain in the json file
+func (jc *JsonStore) SaveEcsClientKeyPair(ctx context.Context, privateKey, certChain []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, certChain); err != nil {
+		return err
+	}
+	jc.EcsClientKey = string(privateKey)
+	jc.EcsClientCertChain = string(certChain)
+	return jc.save(ctx)
+}
+
+// GetEcsClientCert retrieves the ECS client certificate chain from the json file
+func (jc *JsonStore) GetEcsClientCert() (string, error) {
+	return jc.EcsClientCertChain, nil
+}
+
+// GetEcsClientKey retrieves the ECS client private key from the json file
+func (jc *JsonStore) GetEcsClientKey() (string, error) {
+	return jc.EcsClientKey, nil
+}
+
+// DeleteEcsClientKeyPair deletes the ECS client private key and certificate chain from the json file
+func (jc *JsonStore) DeleteEcsClientKeyPair(ctx context.Context) error {
+	jc.EcsClientKey = ""
+	jc.EcsClientCertChain = ""
+	return jc.save(ctx)
+}
+
+// SaveEcsSlot stores the ECS Server slot in the json file
+func (jc *JsonStore) SaveEcsSlot(ctx context.Context, name string, slot EcsSlot) error {
+	if jc.EcsSlots == nil {
+		jc.EcsSlots = map[string]EcsSlot{}
+	}
+	jc.EcsSlots[name] = slot
+	return jc.save(ctx)
+}
+
+// GetEcsSlot retrieves the ECS Server slot from the json file
+func (jc *JsonStore) GetEcsSlot(name string, slot *EcsSlot) error {
+	var ok bool
+	*slot, ok = jc.EcsSlots[name]
+	if !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	return nil
+}
+
+// DeleteEcsSlot deletes the ECS Server slot from the json file
+func (jc *JsonStore) DeleteEcsSlot(ctx context.Context, name string) error {
+	if _, ok := jc.EcsSlots[name]; !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	delete(jc.EcsSlots, name)
+	return jc.save(ctx)
+}
+
+// ListEcsSlots returns the names of all the ECS Server slots in the json file
+func (jc *JsonStore) ListEcsSlots() []string {
+	return listKeys(jc.EcsSlots, "")
+}
+
+// SaveEcsToken stores the named ECS Server bearer token in the json file
+func (jc *JsonStore) SaveEcsToken(ctx context.Context, name string, token EcsToken) error {
+	if jc.EcsTokens == nil {
+		jc.EcsTokens = map[string]EcsToken{}
+	}
+	jc.EcsTokens[name] = token
+	return jc.save(ctx)
+}
+
+// GetEcsToken retrieves the named ECS Server bearer token from the json file
+func (jc *JsonStore) GetEcsToken(name string, token *EcsToken) error {
+	var ok bool
+	*token, ok = jc.EcsTokens[name]
+	if !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	return nil
+}
+
+// DeleteEcsToken deletes the named ECS Server bearer token from the json file
+func (jc *JsonStore) DeleteEcsToken(ctx context.Context, name string) error {
+	if _, ok := jc.EcsTokens[name]; !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	delete(jc.EcsTokens, name)
+	return jc.save(ctx)
+}
+
+// ListEcsTokens returns the names of all the ECS Server bearer tokens in the json file
+func (jc *JsonStore) ListEcsTokens() []string {
+	return listKeys(jc.EcsTokens, "")
+}
@@ -94,6 +94,7 @@ func (s *JsonStoreTestSuite) TestRegisterClientData() {
 	assert.NotNil(t, err)
 
 	key := "us-east-1|https://d-xxxxxxx.awsapps.com/start"
+	assert.Equal(t, []string{key}, s.json.ListRegisterClientData())
 	err = s.json.GetRegisterClientData(key, &rcd)
 	assert.Nil(t, err)
 	rcdTest := RegisterClientData{ // nolint:gosec
@@ -111,6 +112,7 @@ func (s *JsonStoreTestSuite) TestRegisterClientData() {
 
 	err = s.json.DeleteRegisterClientData(context.Background(), key)
 	assert.Nil(t, err)
+	assert.Empty(t, s.json.ListRegisterClientData())
 
 	err = s.json.GetRegisterClientData(key, &rcd)
 	assert.NotNil(t, err)
@@ -124,6 +126,7 @@ func (s *JsonStoreTestSuite) TestRoleCredentials() { //nolint:dupl
 	err := s.json.GetRoleCredentials("foobar", &rc)
 	assert.NotNil(t, err)
 
+	assert.Equal(t, []string{arn}, s.json.ListRoleCredentials())
 	err = s.json.GetRoleCredentials(arn, &rc)
 	assert.Nil(t, err)
 
@@ -143,6 +146,7 @@ func (s *JsonStoreTestSuite) TestRoleCredentials() { //nolint:dupl
 
 	err = s.json.DeleteRoleCredentials(context.Background(), arn)
 	assert.Nil(t, err)
+	assert.Empty(t, s.json.ListRoleCredentials())
 
 	err = s.json.GetRoleCredentials(arn, &rc)
 	assert.NotNil(t, err)
@@ -156,6 +160,7 @@ func (s *JsonStoreTestSuite) TestCreateTokenResponse() { //nolint:dupl
 	err := s.json.GetCreateTokenResponse("foobar", &tr)
 	assert.NotNil(t, err)
 
+	assert.Equal(t, []string{key}, s.json.ListCreateTokenResponses())
 	err = s.json.GetCreateTokenResponse(key, &tr)
 	assert.Nil(t, err)
 
@@ -175,6 +180,7 @@ func (s *JsonStoreTestSuite) TestCreateTokenResponse() { //nolint:dupl
 
 	err = s.json.DeleteCreateTokenResponse(context.Background(), key)
 	assert.Nil(t, err)
+	assert.Empty(t, s.json.ListCreateTokenResponses())
 
 	err = s.json.GetCreateTokenResponse(key, &tr)
 	assert.NotNil(t, err)
@@ -209,6 +215,63 @@ func (s *JsonStoreTestSuite) TestStaticCredentials() {
 	assert.Equal(t, cr2, cr)
 }
 
+func (s *JsonStoreTestSuite) TestEcsSlots() {
+	t := s.T()
+
+	slot := EcsSlot{
+		ProfileName: "123456789012:FooBar",
+		SSOName:     "Default",
+		Creds: RoleCredentials{ // nolint:gosec
+			AccountId:       123456789012,
+			RoleName:        "FooBar",
+			AccessKeyId:     "not a real access key id",
+			SecretAccessKey: "not a real access key",
+			SessionToken:    "not a real session token",
+			Expiration:      1637444478000,
+		},
+	}
+	assert.Empty(t, s.json.ListEcsSlots())
+
+	assert.NoError(t, s.json.SaveEcsSlot(context.Background(), "", slot))
+	assert.NoError(t, s.json.SaveEcsSlot(context.Background(), "FooBar", slot))
+	assert.ElementsMatch(t, []string{"", "FooBar"}, s.json.ListEcsSlots())
+
+	// survives re-opening the store
+	js, err := OpenJsonStore(context.Background(), s.jsonFile)
+	assert.NoError(t, err)
+	slot2 := EcsSlot{}
+	assert.NoError(t, js.GetEcsSlot("FooBar", &slot2))
+	assert.Equal(t, slot, slot2)
+
+	assert.NoError(t, s.json.DeleteEcsSlot(context.Background(), "FooBar"))
+	assert.Equal(t, []string{""}, s.json.ListEcsSlots())
+	assert.Error(t, s.json.GetEcsSlot("FooBar", &slot2))
+	assert.Error(t, s.json.DeleteEcsSlot(context.Background(), "FooBar"))
+}
+
+func (s *JsonStoreTestSuite) TestEcsTokens() {
+	t := s.T()
+
+	token := EcsToken{Token: "not a real token", Slot: "FooBar"}
+	assert.Empty(t, s.json.ListEcsTokens())
+
+	assert.NoError(t, s.json.SaveEcsToken(context.Background(), "container", token))
+	assert.NoError(t, s.json.SaveEcsToken(context.Background(), "admin", EcsToken{Token: "another token", Admin: true}))
+	assert.ElementsMatch(t, []string{"admin", "container"}, s.json.ListEcsTokens())
+
+	// survives re-opening the store
+	js, err := OpenJsonStore(context.Background(), s.jsonFile)
+	assert.NoError(t, err)
+	token2 := EcsToken{}
+	assert.NoError(t, js.GetEcsToken("container", &token2))
+	assert.Equal(t, token, token2)
+
+	assert.NoError(t, s.json.DeleteEcsToken(context.Background(), "container"))
+	assert.Equal(t, []string{"admin"}, s.json.ListEcsTokens())
+	assert.Error(t, s.json.GetEcsToken("container", &token2))
+	assert.Error(t, s.json.DeleteEcsToken(context.Background(), "container"))
+}
+
 func (s *JsonStoreTestSuite) TestEcsBearerToken() {
 	t := s.T()
 
@@ -277,3 +340,100 @@ func (s *JsonStoreTestSuite) TestEcsSslKeyPair() { // nolint: dupl
 	assert.NoError(t, err)
 	assert.Empty(t, key)
 }
+
+func (s *JsonStoreTestSuite) TestEcsCAKeyPair() {
+	t := s.T()
+
+	cert, err := s.json.GetEcsCACert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+
+	certBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.crt")
+	assert.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.key")
+	assert.NoError(t, err)
+
+	assert.Error(t, s.json.SaveEcsCAKeyPair(context.Background(), []byte{}, certBytes))
+	assert.NoError(t, s.json.SaveEcsCAKeyPair(context.Background(), keyBytes, certBytes))
+
+	cert, err = s.json.GetEcsCACert()
+	assert.NoError(t, err)
+	assert.Equal(t, string(certBytes), cert)
+
+	key, err := s.json.GetEcsCAKey()
+	assert.NoError(t, err)
+	assert.Equal(t, string(keyBytes), key)
+
+	assert.NoError(t, s.json.DeleteEcsCAKeyPair(context.Background()))
+	cert, err = s.json.GetEcsCACert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+	key, err = s.json.GetEcsCAKey()
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+}
+
+func (s *JsonStoreTestSuite) TestEcsClientCA() {
+	t := s.T()
+
+	ca, err := s.json.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+
+	caBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.crt")
+	assert.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.key")
+	assert.NoError(t, err)
+
+	assert.Error(t, s.json.SaveEcsClientCA(context.Background(), keyBytes))
+	assert.Error(t, s.json.SaveEcsClientCA(context.Background(), []byte("foobar")))
+	assert.NoError(t, s.json.SaveEcsClientCA(context.Background(), caBytes))
+
+	ca, err = s.json.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Equal(t, string(caBytes), ca)
+
+	assert.NoError(t, s.json.DeleteEcsClientCA(context.Background()))
+	ca, err = s.json.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+}
+
+func (s *JsonStoreTestSuite) TestEcsClientKeyPair() { // nolint: dupl
+	t := s.T()
+
+	cert, err := s.json.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+
+	certBytes, err := os.ReadFile("../ecs/server/testdata/client.crt")
+	assert.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/client.key")
+	assert.NoError(t, err)
+	otherKeyBytes, err := os.ReadFile("../ecs/server/testdata/localhost.key")
+	assert.NoError(t, err)
+
+	// private key is required and must match the cert
+	assert.Error(t, s.json.SaveEcsClientKeyPair(context.Background(), []byte{}, certBytes))
+	assert.Error(t, s.json.SaveEcsClientKeyPair(context.Background(), otherKeyBytes, certBytes))
+	assert.Error(t, s.json.SaveEcsClientKeyPair(context.Background(), keyBytes, keyBytes))
+	assert.NoError(t, s.json.SaveEcsClientKeyPair(context.Background(), keyBytes, certBytes))
+
+	cert, err = s.json.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Equal(t, string(certBytes), cert)
+
+	key, err := s.json.GetEcsClientKey()
+	assert.NoError(t, err)
+	assert.Equal(t, string(keyBytes), key)
+
+	assert.NoError(t, s.json.DeleteEcsClientKeyPair(context.Background()))
+
+	cert, err = s.json.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+
+	key, err = s.json.GetEcsClientKey()
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+}
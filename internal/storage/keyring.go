@@ -64,6 +64,13 @@ type StorageData struct {
 	EcsBearerToken      string
 	EcsPrivateKey       string
 	EcsCertChain        string
+	EcsCAKey            string
+	EcsCACert           string
+	EcsClientCA         string
+	EcsClientKey        string
+	EcsClientCertChain  string
+	EcsSlots            map[string]EcsSlot
+	EcsTokens           map[string]EcsToken
 }
 
 func NewStorageData() StorageData {
@@ -75,6 +82,13 @@ func NewStorageData() StorageData {
 		EcsBearerToken:      "",
 		EcsPrivateKey:       "",
 		EcsCertChain:        "",
+		EcsCAKey:            "",
+		EcsCACert:           "",
+		EcsClientCA:         "",
+		EcsClientKey:        "",
+		EcsClientCertChain:  "",
+		EcsSlots:            map[string]EcsSlot{},
+		EcsTokens:           map[string]EcsToken{},
 	}
 }
 
@@ -396,6 +410,11 @@ func (kr *KeyringStore) DeleteRegisterClientData(ctx context.Context, region str
 	return kr.saveStorageData(ctx)
 }
 
+// ListRegisterClientData returns the keys of all the RegisterClientData in the keyring
+func (kr *KeyringStore) ListRegisterClientData() []string {
+	return listKeys(kr.cache.RegisterClientData, REGISTER_CLIENT_DATA_PREFIX+":")
+}
+
 func (kr *KeyringStore) CreateTokenResponseKey(key string) string {
 	return fmt.Sprintf("%s:%s", CREATE_TOKEN_RESPONSE_PREFIX, key)
 }
@@ -430,6 +449,11 @@ func (kr *KeyringStore) DeleteCreateTokenResponse(ctx context.Context, key strin
 	return kr.saveStorageData(ctx)
 }
 
+// ListCreateTokenResponses returns the keys of all the CreateTokenResponses in the keyring
+func (kr *KeyringStore) ListCreateTokenResponses() []string {
+	return listKeys(kr.cache.CreateTokenResponse, CREATE_TOKEN_RESPONSE_PREFIX+":")
+}
+
 // SaveRoleCredentials stores the token in the arnring
 func (kr *KeyringStore) SaveRoleCredentials(ctx context.Context, arn string, token RoleCredentials) error {
 	kr.cache.RoleCredentials[arn] = token
@@ -456,6 +480,11 @@ func (kr *KeyringStore) DeleteRoleCredentials(ctx context.Context, arn string) e
 	return kr.saveStorageData(ctx)
 }
 
+// ListRoleCredentials returns the ARNs of all the RoleCredentials in the keyring
+func (kr *KeyringStore) ListRoleCredentials() []string {
+	return listKeys(kr.cache.RoleCredentials, "")
+}
+
 // SaveStaticCredentials stores the token in the arnring
 func (kr *KeyringStore) SaveStaticCredentials(ctx context.Context, arn string, creds StaticCredentials) error {
 	kr.cache.StaticCredentials[arn] = creds
@@ -540,3 +569,143 @@ func (kr *KeyringStore) DeleteEcsSslKeyPair(ctx context.Context) error {
 	kr.cache.EcsPrivateKey = ""
 	return kr.saveStorageData(ctx)
 }
+
+// SaveEcsCAKeyPair stores the ECS Server CA private key and certificate in the keyring
+func (kr *KeyringStore) SaveEcsCAKeyPair(ctx context.Context, privateKey, cert []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, cert); err != nil {
+		return err
+	}
+	kr.cache.EcsCAKey = string(privateKey)
+	kr.cache.EcsCACert = string(cert)
+	return kr.saveStorageData(ctx)
+}
+
+// GetEcsCACert retrieves the ECS Server CA certificate from the keyring
+func (kr *KeyringStore) GetEcsCACert() (string, error) {
+	return kr.cache.EcsCACert, nil
+}
+
+// GetEcsCAKey retrieves the ECS Server CA private key from the keyring
+func (kr *KeyringStore) GetEcsCAKey() (string, error) {
+	return kr.cache.EcsCAKey, nil
+}
+
+// DeleteEcsCAKeyPair deletes the ECS Server CA private key and certificate from the keyring
+func (kr *KeyringStore) DeleteEcsCAKeyPair(ctx context.Context) error {
+	kr.cache.EcsCAKey = ""
+	kr.cache.EcsCACert = ""
+	return kr.saveStorageData(ctx)
+}
+
+// SaveEcsClientCA stores the CA certificate used to verify ECS client certificates in the keyring
+func (kr *KeyringStore) SaveEcsClientCA(ctx context.Context, caCert []byte) error {
+	if err := ValidateSSLCertificate(caCert); err != nil {
+		return err
+	}
+	kr.cache.EcsClientCA = string(caCert)
+	return kr.saveStorageData(ctx)
+}
+
+// GetEcsClientCA retrieves the client CA certificate from the keyring
+func (kr *KeyringStore) GetEcsClientCA() (string, error) {
+	return kr.cache.EcsClientCA, nil
+}
+
+// DeleteEcsClientCA deletes the client CA certificate from the keyring
+func (kr *KeyringStore) DeleteEcsClientCA(ctx context.Context) error {
+	kr.cache.EcsClientCA = ""
+	return kr.saveStorageData(ctx)
+}
+
+// SaveEcsClientKeyPair stores the ECS client private key and certificate chain in the keyring
+func (kr *KeyringStore) SaveEcsClientKeyPair(ctx context.Context, privateKey, certChain []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, certChain); err != nil {
+		return err
+	}
+	kr.cache.EcsClientKey = string(privateKey)
+	kr.cache.EcsClientCertChain = string(certChain)
+	return kr.saveStorageData(ctx)
+}
+
+// GetEcsClientCert retrieves the ECS client certificate chain from the keyring
+func (kr *KeyringStore) GetEcsClientCert() (string, error) {
+	return kr.cache.EcsClientCertChain, nil
+}
+
+// GetEcsClientKey retrieves the ECS client private key from the keyring
+func (kr *KeyringStore) GetEcsClientKey() (string, error) {
+	return kr.cache.EcsClientKey, nil
+}
+
+// DeleteEcsClientKeyPair deletes the ECS client private key and certificate chain from the keyring
+func (kr *KeyringStore) DeleteEcsClientKeyPair(ctx context.Context) error {
+	kr.cache.EcsClientKey = ""
+	kr.cache.EcsClientCertChain = ""
+	return kr.saveStorageData(ctx)
+}
+
+// SaveEcsSlot stores the ECS Server slot in the keyring
+func (kr *KeyringStore) SaveEcsSlot(ctx context.Context, name string, slot EcsSlot) error {
+	if kr.cache.EcsSlots == nil {
+		kr.cache.EcsSlots = map[string]EcsSlot{}
+	}
+	kr.cache.EcsSlots[name] = slot
+	return kr.saveStorageData(ctx)
+}
+
+// GetEcsSlot retrieves the ECS Server slot from the keyring
+func (kr *KeyringStore) GetEcsSlot(name string, slot *EcsSlot) error {
+	var ok bool
+	*slot, ok = kr.cache.EcsSlots[name]
+	if !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	return nil
+}
+
+// DeleteEcsSlot deletes the ECS Server slot from the keyring
+func (kr *KeyringStore) DeleteEcsSlot(ctx context.Context, name string) error {
+	if _, ok := kr.cache.EcsSlots[name]; !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	delete(kr.cache.EcsSlots, name)
+	return kr.saveStorageData(ctx)
+}
+
+// ListEcsSlots returns the names of all the ECS Server slots in the keyring
+func (kr *KeyringStore) ListEcsSlots() []string {
+	return listKeys(kr.cache.EcsSlots, "")
+}
+
+// SaveEcsToken stores the named ECS Server bearer token in the keyring
+func (kr *KeyringStore) SaveEcsToken(ctx context.Context, name string, token EcsToken) error {
+	if kr.cache.EcsTokens == nil {
+		kr.cache.EcsTokens = map[string]EcsToken{}
+	}
+	kr.cache.EcsTokens[name] = token
+	return kr.saveStorageData(ctx)
+}
+
+// GetEcsToken retrieves the named ECS Server bearer token from the keyring
+func (kr *KeyringStore) GetEcsToken(name string, token *EcsToken) error {
+	var ok bool
+	*token, ok = kr.cache.EcsTokens[name]
+	if !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	return nil
+}
+
+// DeleteEcsToken deletes the named ECS Server bearer token from the keyring
+func (kr *KeyringStore) DeleteEcsToken(ctx context.Context, name string) error {
+	if _, ok := kr.cache.EcsTokens[name]; !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	delete(kr.cache.EcsTokens, name)
+	return kr.saveStorageData(ctx)
+}
+
+// ListEcsTokens returns the names of all the ECS Server bearer tokens in the keyring
+func (kr *KeyringStore) ListEcsTokens() []string {
+	return listKeys(kr.cache.EcsTokens, "")
+}
@@ -73,6 +73,7 @@ func (suite *KeyringSuite) TestRegisterClientData() {
 	}
 	err := suite.store.SaveRegisterClientData(context.Background(), "foo", rcd)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, suite.store.ListRegisterClientData())
 
 	rcd2 := RegisterClientData{}
 	err = suite.store.GetRegisterClientData("foo", &rcd2)
@@ -81,6 +82,7 @@ func (suite *KeyringSuite) TestRegisterClientData() {
 
 	err = suite.store.DeleteRegisterClientData(context.Background(), "foo")
 	assert.NoError(t, err)
+	assert.Empty(t, suite.store.ListRegisterClientData())
 
 	err = suite.store.GetRegisterClientData("foo", &rcd2)
 	assert.Error(t, err)
@@ -105,6 +107,7 @@ func (suite *KeyringSuite) TestCreateTokenResponse() {
 	}
 	err := suite.store.SaveCreateTokenResponse(context.Background(), "foo", ctr)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, suite.store.ListCreateTokenResponses())
 
 	ctr2 := CreateTokenResponse{}
 	err = suite.store.GetCreateTokenResponse("foo", &ctr2)
@@ -113,6 +116,7 @@ func (suite *KeyringSuite) TestCreateTokenResponse() {
 
 	err = suite.store.DeleteCreateTokenResponse(context.Background(), "foo")
 	assert.NoError(t, err)
+	assert.Empty(t, suite.store.ListCreateTokenResponses())
 
 	err = suite.store.GetCreateTokenResponse("cow", &ctr2)
 	assert.Error(t, err)
@@ -134,6 +138,7 @@ func (suite *KeyringSuite) TestRoleCredentials() {
 	}
 	err := suite.store.SaveRoleCredentials(context.Background(), "foo", rc)
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, suite.store.ListRoleCredentials())
 
 	rc2 := RoleCredentials{}
 	err = suite.store.GetRoleCredentials("foo", &rc2)
@@ -142,6 +147,7 @@ func (suite *KeyringSuite) TestRoleCredentials() {
 
 	err = suite.store.DeleteRoleCredentials(context.Background(), "foo")
 	assert.NoError(t, err)
+	assert.Empty(t, suite.store.ListRoleCredentials())
 
 	err = suite.store.GetRoleCredentials("foo", &rc2)
 	assert.Error(t, err)
@@ -223,6 +229,71 @@ func (suite *KeyringSuite) TestEcsSslKeyPair() { // nolint: dupl
 	assert.Empty(t, key)
 }
 
+func (suite *KeyringSuite) TestEcsClientCA() {
+	t := suite.T()
+
+	ca, err := suite.store.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+
+	caBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.crt")
+	assert.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.key")
+	assert.NoError(t, err)
+
+	assert.Error(t, suite.store.SaveEcsClientCA(context.Background(), keyBytes))
+	assert.Error(t, suite.store.SaveEcsClientCA(context.Background(), []byte("foobar")))
+	assert.NoError(t, suite.store.SaveEcsClientCA(context.Background(), caBytes))
+
+	ca, err = suite.store.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Equal(t, string(caBytes), ca)
+
+	assert.NoError(t, suite.store.DeleteEcsClientCA(context.Background()))
+	ca, err = suite.store.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+}
+
+func (suite *KeyringSuite) TestEcsClientKeyPair() { // nolint: dupl
+	t := suite.T()
+
+	cert, err := suite.store.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+
+	certBytes, err := os.ReadFile("../ecs/server/testdata/client.crt")
+	assert.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/client.key")
+	assert.NoError(t, err)
+	otherKeyBytes, err := os.ReadFile("../ecs/server/testdata/localhost.key")
+	assert.NoError(t, err)
+
+	// private key is required and must match the cert
+	assert.Error(t, suite.store.SaveEcsClientKeyPair(context.Background(), []byte{}, certBytes))
+	assert.Error(t, suite.store.SaveEcsClientKeyPair(context.Background(), otherKeyBytes, certBytes))
+	assert.Error(t, suite.store.SaveEcsClientKeyPair(context.Background(), keyBytes, keyBytes))
+	assert.NoError(t, suite.store.SaveEcsClientKeyPair(context.Background(), keyBytes, certBytes))
+
+	cert, err = suite.store.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Equal(t, string(certBytes), cert)
+
+	key, err := suite.store.GetEcsClientKey()
+	assert.NoError(t, err)
+	assert.Equal(t, string(keyBytes), key)
+
+	assert.NoError(t, suite.store.DeleteEcsClientKeyPair(context.Background()))
+
+	cert, err = suite.store.GetEcsClientCert()
+	assert.NoError(t, err)
+	assert.Empty(t, cert)
+
+	key, err = suite.store.GetEcsClientKey()
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+}
+
 func (suite *KeyringSuite) TestErrorReadKeyring() {
 	t := suite.T()
 	ks := suite.store.(*KeyringStore)
@@ -376,6 +447,55 @@ func (suite *KeyringSuite) TestStaticCredentials() { //nolint:dupl
 	assert.Error(t, suite.store.DeleteStaticCredentials(context.Background(), arn))
 }
 
+func (suite *KeyringSuite) TestEcsSlots() {
+	t := suite.T()
+
+	slot := EcsSlot{
+		ProfileName: "123456789012:FooBar",
+		SSOName:     "Default",
+		Creds: RoleCredentials{ // nolint:gosec
+			AccountId:       123456789012,
+			RoleName:        "FooBar",
+			AccessKeyId:     "not a real access key id",
+			SecretAccessKey: "not a real access key",
+			SessionToken:    "not a real session token",
+			Expiration:      1637444478000,
+		},
+	}
+	assert.Empty(t, suite.store.ListEcsSlots())
+
+	assert.NoError(t, suite.store.SaveEcsSlot(context.Background(), "FooBar", slot))
+	assert.Equal(t, []string{"FooBar"}, suite.store.ListEcsSlots())
+
+	slot2 := EcsSlot{}
+	assert.NoError(t, suite.store.GetEcsSlot("FooBar", &slot2))
+	assert.Equal(t, slot, slot2)
+
+	assert.NoError(t, suite.store.DeleteEcsSlot(context.Background(), "FooBar"))
+	assert.Empty(t, suite.store.ListEcsSlots())
+	assert.Error(t, suite.store.GetEcsSlot("FooBar", &slot2))
+	assert.Error(t, suite.store.DeleteEcsSlot(context.Background(), "FooBar"))
+}
+
+func (suite *KeyringSuite) TestEcsTokens() {
+	t := suite.T()
+
+	token := EcsToken{Token: "not a real token", Slot: "FooBar"}
+	assert.Empty(t, suite.store.ListEcsTokens())
+
+	assert.NoError(t, suite.store.SaveEcsToken(context.Background(), "container", token))
+	assert.Equal(t, []string{"container"}, suite.store.ListEcsTokens())
+
+	token2 := EcsToken{}
+	assert.NoError(t, suite.store.GetEcsToken("container", &token2))
+	assert.Equal(t, token, token2)
+
+	assert.NoError(t, suite.store.DeleteEcsToken(context.Background(), "container"))
+	assert.Empty(t, suite.store.ListEcsTokens())
+	assert.Error(t, suite.store.GetEcsToken("container", &token2))
+	assert.Error(t, suite.store.DeleteEcsToken(context.Background(), "container"))
+}
+
 func TestNewStorageData(t *testing.T) {
 	s := NewStorageData()
 	assert.Empty(t, s.RegisterClientData)
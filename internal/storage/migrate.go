@@ -0,0 +1,455 @@
+package storage
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// MigrateResult counts the records copied between two SecureStorage backends
+type MigrateResult struct {
+	RegisterClientData  int
+	CreateTokenResponse int
+	RoleCredentials     int
+	StaticCredentials   int
+	EcsSlots            int
+	EcsTokens           int
+	EcsBearerToken      bool
+	EcsSslKeyPair       bool
+	EcsCAKeyPair        bool
+	EcsClientCA         bool
+	EcsClientKeyPair    bool
+}
+
+// Total returns the number of records copied
+func (r *MigrateResult) Total() int {
+	total := r.RegisterClientData + r.CreateTokenResponse + r.RoleCredentials + r.StaticCredentials + r.EcsSlots + r.EcsTokens
+	if r.EcsBearerToken {
+		total++
+	}
+	if r.EcsSslKeyPair {
+		total++
+	}
+	if r.EcsCAKeyPair {
+		total++
+	}
+	if r.EcsClientCA {
+		total++
+	}
+	if r.EcsClientKeyPair {
+		total++
+	}
+	return total
+}
+
+// Migrate copies every record in the from SecureStorage to the to SecureStorage,
+// replacing any records with the same key
+func Migrate(ctx context.Context, from, to SecureStorage) (*MigrateResult, error) {
+	r := &MigrateResult{}
+
+	for _, key := range sorted(from.ListRegisterClientData()) {
+		v := RegisterClientData{}
+		if err := from.GetRegisterClientData(key, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveRegisterClientData(ctx, key, v); err != nil {
+			return r, fmt.Errorf("unable to save RegisterClientData %s: %w", key, err)
+		}
+		r.RegisterClientData++
+	}
+
+	for _, key := range sorted(from.ListCreateTokenResponses()) {
+		v := CreateTokenResponse{}
+		if err := from.GetCreateTokenResponse(key, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveCreateTokenResponse(ctx, key, v); err != nil {
+			return r, fmt.Errorf("unable to save CreateTokenResponse %s: %w", key, err)
+		}
+		r.CreateTokenResponse++
+	}
+
+	for _, arn := range sorted(from.ListRoleCredentials()) {
+		v := RoleCredentials{}
+		if err := from.GetRoleCredentials(arn, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveRoleCredentials(ctx, arn, v); err != nil {
+			return r, fmt.Errorf("unable to save RoleCredentials %s: %w", arn, err)
+		}
+		r.RoleCredentials++
+	}
+
+	for _, arn := range sorted(from.ListStaticCredentials()) {
+		v := StaticCredentials{}
+		if err := from.GetStaticCredentials(arn, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveStaticCredentials(ctx, arn, v); err != nil {
+			return r, fmt.Errorf("unable to save StaticCredentials %s: %w", arn, err)
+		}
+		r.StaticCredentials++
+	}
+
+	for _, name := range sorted(from.ListEcsSlots()) {
+		v := EcsSlot{}
+		if err := from.GetEcsSlot(name, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveEcsSlot(ctx, name, v); err != nil {
+			return r, fmt.Errorf("unable to save EcsSlot %s: %w", name, err)
+		}
+		r.EcsSlots++
+	}
+
+	for _, name := range sorted(from.ListEcsTokens()) {
+		v := EcsToken{}
+		if err := from.GetEcsToken(name, &v); err != nil {
+			return r, err
+		}
+		if err := to.SaveEcsToken(ctx, name, v); err != nil {
+			return r, fmt.Errorf("unable to save EcsToken %s: %w", name, err)
+		}
+		r.EcsTokens++
+	}
+
+	token, err := from.GetEcsBearerToken()
+	if err != nil {
+		return r, err
+	}
+	if token != "" {
+		if err := to.SaveEcsBearerToken(ctx, token); err != nil {
+			return r, fmt.Errorf("unable to save ECS bearer token: %w", err)
+		}
+		r.EcsBearerToken = true
+	}
+
+	key, cert, err := getEcsSslKeyPair(from)
+	if err != nil {
+		return r, err
+	}
+	if key != "" || cert != "" {
+		if err := to.SaveEcsSslKeyPair(ctx, []byte(key), []byte(cert)); err != nil {
+			return r, fmt.Errorf("unable to save ECS SSL key pair: %w", err)
+		}
+		r.EcsSslKeyPair = true
+	}
+
+	key, cert, err = getEcsCAKeyPair(from)
+	if err != nil {
+		return r, err
+	}
+	if key != "" || cert != "" {
+		if err := to.SaveEcsCAKeyPair(ctx, []byte(key), []byte(cert)); err != nil {
+			return r, fmt.Errorf("unable to save ECS CA key pair: %w", err)
+		}
+		r.EcsCAKeyPair = true
+	}
+
+	ca, err := from.GetEcsClientCA()
+	if err != nil {
+		return r, err
+	}
+	if ca != "" {
+		if err := to.SaveEcsClientCA(ctx, []byte(ca)); err != nil {
+			return r, fmt.Errorf("unable to save ECS client CA: %w", err)
+		}
+		r.EcsClientCA = true
+	}
+
+	key, cert, err = getEcsClientKeyPair(from)
+	if err != nil {
+		return r, err
+	}
+	if key != "" || cert != "" {
+		if err := to.SaveEcsClientKeyPair(ctx, []byte(key), []byte(cert)); err != nil {
+			return r, fmt.Errorf("unable to save ECS client key pair: %w", err)
+		}
+		r.EcsClientKeyPair = true
+	}
+
+	return r, nil
+}
+
+// VerifyMigration checks that every record in the from SecureStorage exists
+// with the same value in the to SecureStorage
+func VerifyMigration(from, to SecureStorage) error {
+	for _, key := range from.ListRegisterClientData() {
+		a, b := RegisterClientData{}, RegisterClientData{}
+		if err := verifyRecord("RegisterClientData", key, &a, &b,
+			from.GetRegisterClientData(key, &a), to.GetRegisterClientData(key, &b)); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range from.ListCreateTokenResponses() {
+		a, b := CreateTokenResponse{}, CreateTokenResponse{}
+		if err := verifyRecord("CreateTokenResponse", key, &a, &b,
+			from.GetCreateTokenResponse(key, &a), to.GetCreateTokenResponse(key, &b)); err != nil {
+			return err
+		}
+	}
+
+	for _, arn := range from.ListRoleCredentials() {
+		a, b := RoleCredentials{}, RoleCredentials{}
+		if err := verifyRecord("RoleCredentials", arn, &a, &b,
+			from.GetRoleCredentials(arn, &a), to.GetRoleCredentials(arn, &b)); err != nil {
+			return err
+		}
+	}
+
+	for _, arn := range from.ListStaticCredentials() {
+		a, b := StaticCredentials{}, StaticCredentials{}
+		if err := verifyRecord("StaticCredentials", arn, &a, &b,
+			from.GetStaticCredentials(arn, &a), to.GetStaticCredentials(arn, &b)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range from.ListEcsSlots() {
+		a, b := EcsSlot{}, EcsSlot{}
+		if err := verifyRecord("EcsSlot", name, &a, &b,
+			from.GetEcsSlot(name, &a), to.GetEcsSlot(name, &b)); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range from.ListEcsTokens() {
+		a, b := EcsToken{}, EcsToken{}
+		if err := verifyRecord("EcsToken", name, &a, &b,
+			from.GetEcsToken(name, &a), to.GetEcsToken(name, &b)); err != nil {
+			return err
+		}
+	}
+
+	fromToken, err := from.GetEcsBearerToken()
+	if err != nil {
+		return err
+	}
+	toToken, err := to.GetEcsBearerToken()
+	if err != nil {
+		return err
+	}
+	if fromToken != "" && fromToken != toToken {
+		return fmt.Errorf("ECS bearer token does not match")
+	}
+
+	fromKey, fromCert, err := getEcsSslKeyPair(from)
+	if err != nil {
+		return err
+	}
+	toKey, toCert, err := getEcsSslKeyPair(to)
+	if err != nil {
+		return err
+	}
+	if (fromKey != "" || fromCert != "") && (fromKey != toKey || fromCert != toCert) {
+		return fmt.Errorf("ECS SSL key pair does not match")
+	}
+
+	fromKey, fromCert, err = getEcsCAKeyPair(from)
+	if err != nil {
+		return err
+	}
+	toKey, toCert, err = getEcsCAKeyPair(to)
+	if err != nil {
+		return err
+	}
+	if (fromKey != "" || fromCert != "") && (fromKey != toKey || fromCert != toCert) {
+		return fmt.Errorf("ECS CA key pair does not match")
+	}
+
+	fromCA, err := from.GetEcsClientCA()
+	if err != nil {
+		return err
+	}
+	toCA, err := to.GetEcsClientCA()
+	if err != nil {
+		return err
+	}
+	if fromCA != "" && fromCA != toCA {
+		return fmt.Errorf("ECS client CA does not match")
+	}
+
+	fromKey, fromCert, err = getEcsClientKeyPair(from)
+	if err != nil {
+		return err
+	}
+	toKey, toCert, err = getEcsClientKeyPair(to)
+	if err != nil {
+		return err
+	}
+	if (fromKey != "" || fromCert != "") && (fromKey != toKey || fromCert != toCert) {
+		return fmt.Errorf("ECS client key pair does not match")
+	}
+
+	return nil
+}
+
+// Wipe deletes every record in the SecureStorage
+func Wipe(ctx context.Context, store SecureStorage) error {
+	for _, key := range store.ListRegisterClientData() {
+		if err := store.DeleteRegisterClientData(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range store.ListCreateTokenResponses() {
+		if err := store.DeleteCreateTokenResponse(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	for _, arn := range store.ListRoleCredentials() {
+		if err := store.DeleteRoleCredentials(ctx, arn); err != nil {
+			return err
+		}
+	}
+
+	for _, arn := range store.ListStaticCredentials() {
+		if err := store.DeleteStaticCredentials(ctx, arn); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range store.ListEcsSlots() {
+		if err := store.DeleteEcsSlot(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range store.ListEcsTokens() {
+		if err := store.DeleteEcsToken(ctx, name); err != nil {
+			return err
+		}
+	}
+
+	if token, err := store.GetEcsBearerToken(); err != nil {
+		return err
+	} else if token != "" {
+		if err := store.DeleteEcsBearerToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	if key, cert, err := getEcsSslKeyPair(store); err != nil {
+		return err
+	} else if key != "" || cert != "" {
+		if err := store.DeleteEcsSslKeyPair(ctx); err != nil {
+			return err
+		}
+	}
+
+	if key, cert, err := getEcsCAKeyPair(store); err != nil {
+		return err
+	} else if key != "" || cert != "" {
+		if err := store.DeleteEcsCAKeyPair(ctx); err != nil {
+			return err
+		}
+	}
+
+	if ca, err := store.GetEcsClientCA(); err != nil {
+		return err
+	} else if ca != "" {
+		if err := store.DeleteEcsClientCA(ctx); err != nil {
+			return err
+		}
+	}
+
+	if key, cert, err := getEcsClientKeyPair(store); err != nil {
+		return err
+	} else if key != "" || cert != "" {
+		if err := store.DeleteEcsClientKeyPair(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// verifyRecord compares the record read from both SecureStorage backends.
+// Records are compared by their JSON encoding, which is how they are stored.
+func verifyRecord(kind, key string, a, b any, fromErr, toErr error) error {
+	if fromErr != nil {
+		return fromErr
+	}
+	if toErr != nil {
+		return fmt.Errorf("%s %s is missing: %w", kind, key, toErr)
+	}
+
+	aBytes, err := json.Marshal(a)
+	if err != nil {
+		return err
+	}
+	bBytes, err := json.Marshal(b)
+	if err != nil {
+		return err
+	}
+	if string(aBytes) != string(bBytes) {
+		return fmt.Errorf("%s %s does not match", kind, key)
+	}
+	return nil
+}
+
+// getEcsSslKeyPair returns the ECS Server private key and cert chain
+func getEcsSslKeyPair(store SecureStorage) (string, string, error) {
+	key, err := store.GetEcsSslKey()
+	if err != nil {
+		return "", "", err
+	}
+	cert, err := store.GetEcsSslCert()
+	if err != nil {
+		return "", "", err
+	}
+	return key, cert, nil
+}
+
+// getEcsCAKeyPair returns the ECS Server CA private key and cert
+func getEcsCAKeyPair(store SecureStorage) (string, string, error) {
+	key, err := store.GetEcsCAKey()
+	if err != nil {
+		return "", "", err
+	}
+	cert, err := store.GetEcsCACert()
+	if err != nil {
+		return "", "", err
+	}
+	return key, cert, nil
+}
+
+// getEcsClientKeyPair returns the ECS client private key and cert chain
+func getEcsClientKeyPair(store SecureStorage) (string, string, error) {
+	key, err := store.GetEcsClientKey()
+	if err != nil {
+		return "", "", err
+	}
+	cert, err := store.GetEcsClientCert()
+	if err != nil {
+		return "", "", err
+	}
+	return key, cert, nil
+}
+
+// sorted returns keys in a stable order so migrations are repeatable
+func sorted(keys []string) []string {
+	sort.Strings(keys)
+	return keys
+}
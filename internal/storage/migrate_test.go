@@ -0,0 +1,232 @@
+package storage
+
+/*
+ * AWS SSO CLI
+ * Copyright (c) 2021-2026 Aaron Turner  <synfinatic at gmail dot com>
+ *
+ * This program is free software: you can redistribute it
+ * and/or modify it under the terms of the GNU General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or with the authors permission any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <http://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMigrateTestStores returns a populated file keyring and an empty JsonStore
+func newMigrateTestStores(t *testing.T) (SecureStorage, SecureStorage, string) {
+	t.Helper()
+	ctx := context.Background()
+	d := t.TempDir()
+
+	oldFlockFile := flockFile
+	flockFile = path.Join(d, "storage.lock")
+	t.Cleanup(func() { flockFile = oldFlockFile })
+	t.Setenv(ENV_SSO_FILE_PASSWORD, "justapassword")
+
+	c, err := NewKeyringConfig("file", d, "")
+	require.NoError(t, err)
+	from, err := OpenKeyring(ctx, c)
+	require.NoError(t, err)
+
+	require.NoError(t, from.SaveRegisterClientData(ctx, "us-east-1", RegisterClientData{ // nolint:gosec
+		ClientId:              "client-id",
+		ClientSecret:          "client-secret",
+		ClientSecretExpiresAt: 1637723379,
+		GrantTypes:            []GrantType{GrantTypeDeviceCode, GrantTypeRefreshToken},
+	}))
+	require.NoError(t, from.SaveCreateTokenResponse(ctx, "us-east-1|https://d-xxxxxxx.awsapps.com/start", CreateTokenResponse{
+		AccessToken:  "access-token",
+		ExpiresAt:    1637469677,
+		RefreshToken: "refresh-token",
+		TokenType:    "Bearer",
+	}))
+	for _, role := range []string{"FooBar", "HelloWorld"} {
+		require.NoError(t, from.SaveRoleCredentials(ctx, "arn:aws:iam::123456789012:role/"+role, RoleCredentials{ // nolint:gosec
+			RoleName:        role,
+			AccountId:       123456789012,
+			AccessKeyId:     "not a real access key id",
+			SecretAccessKey: "not a real access key",
+			SessionToken:    "not a real session token",
+			Expiration:      1637444478000,
+		}))
+	}
+	require.NoError(t, from.SaveStaticCredentials(ctx, "arn:aws:iam::123456789012:user/foo", StaticCredentials{ // nolint:gosec
+		Profile:         "foo",
+		UserName:        "foo",
+		AccountId:       123456789012,
+		AccessKeyId:     "not a real access key id",
+		SecretAccessKey: "not a real access key",
+	}))
+	require.NoError(t, from.SaveEcsSlot(ctx, "FooBar", EcsSlot{
+		ProfileName: "123456789012:FooBar",
+		SSOName:     "Default",
+		Creds:       RoleCredentials{AccountId: 123456789012, RoleName: "FooBar"},
+	}))
+	require.NoError(t, from.SaveEcsToken(ctx, "container", EcsToken{Token: "container-token", Slot: "FooBar"}))
+	require.NoError(t, from.SaveEcsBearerToken(ctx, "bearer-token"))
+
+	certBytes, err := os.ReadFile("../ecs/server/testdata/localhost.crt")
+	require.NoError(t, err)
+	keyBytes, err := os.ReadFile("../ecs/server/testdata/localhost.key")
+	require.NoError(t, err)
+	require.NoError(t, from.SaveEcsSslKeyPair(ctx, keyBytes, certBytes))
+
+	caBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.crt")
+	require.NoError(t, err)
+	require.NoError(t, from.SaveEcsClientCA(ctx, caBytes))
+	caKeyBytes, err := os.ReadFile("../ecs/server/testdata/client-ca.key")
+	require.NoError(t, err)
+	require.NoError(t, from.SaveEcsCAKeyPair(ctx, caKeyBytes, caBytes))
+	clientCertBytes, err := os.ReadFile("../ecs/server/testdata/client.crt")
+	require.NoError(t, err)
+	clientKeyBytes, err := os.ReadFile("../ecs/server/testdata/client.key")
+	require.NoError(t, err)
+	require.NoError(t, from.SaveEcsClientKeyPair(ctx, clientKeyBytes, clientCertBytes))
+
+	jsonFile := path.Join(d, "store.json")
+	to, err := OpenJsonStore(ctx, jsonFile)
+	require.NoError(t, err)
+
+	return from, to, jsonFile
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	from, to, jsonFile := newMigrateTestStores(t)
+
+	r, err := Migrate(ctx, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, &MigrateResult{
+		RegisterClientData:  1,
+		CreateTokenResponse: 1,
+		RoleCredentials:     2,
+		StaticCredentials:   1,
+		EcsSlots:            1,
+		EcsTokens:           1,
+		EcsBearerToken:      true,
+		EcsSslKeyPair:       true,
+		EcsCAKeyPair:        true,
+		EcsClientCA:         true,
+		EcsClientKeyPair:    true,
+	}, r)
+	assert.Equal(t, 12, r.Total())
+
+	// verify against what was actually written to disk
+	reopened, err := OpenJsonStore(ctx, jsonFile)
+	require.NoError(t, err)
+	assert.NoError(t, VerifyMigration(from, reopened))
+
+	// keys are stored without the keyring prefixes
+	rcd := RegisterClientData{}
+	assert.NoError(t, reopened.GetRegisterClientData("us-east-1", &rcd))
+	assert.Equal(t, "client-secret", rcd.ClientSecret)
+
+	// migrating again is harmless
+	_, err = Migrate(ctx, from, to)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifyMigration(from, to))
+}
+
+func TestVerifyMigration(t *testing.T) {
+	ctx := context.Background()
+	from, to, _ := newMigrateTestStores(t)
+
+	_, err := Migrate(ctx, from, to)
+	require.NoError(t, err)
+
+	rc := RoleCredentials{}
+	require.NoError(t, to.GetRoleCredentials("arn:aws:iam::123456789012:role/FooBar", &rc))
+	rc.SessionToken = "something else"
+	require.NoError(t, to.SaveRoleCredentials(ctx, "arn:aws:iam::123456789012:role/FooBar", rc))
+	assert.ErrorContains(t, VerifyMigration(from, to), "RoleCredentials arn:aws:iam::123456789012:role/FooBar does not match")
+
+	require.NoError(t, to.DeleteRoleCredentials(ctx, "arn:aws:iam::123456789012:role/FooBar"))
+	assert.ErrorContains(t, VerifyMigration(from, to), "RoleCredentials arn:aws:iam::123456789012:role/FooBar is missing")
+
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+	require.NoError(t, to.SaveEcsToken(ctx, "container", EcsToken{Token: "container-token", Slot: "Other"}))
+	assert.ErrorContains(t, VerifyMigration(from, to), "EcsToken container does not match")
+
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+	require.NoError(t, to.SaveEcsBearerToken(ctx, "another-token"))
+	assert.ErrorContains(t, VerifyMigration(from, to), "ECS bearer token does not match")
+
+	require.NoError(t, to.SaveEcsBearerToken(ctx, "bearer-token"))
+	require.NoError(t, to.DeleteEcsCAKeyPair(ctx))
+	assert.ErrorContains(t, VerifyMigration(from, to), "ECS CA key pair does not match")
+
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+	require.NoError(t, to.DeleteEcsClientCA(ctx))
+	assert.ErrorContains(t, VerifyMigration(from, to), "ECS client CA does not match")
+
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+	require.NoError(t, to.DeleteEcsClientKeyPair(ctx))
+	assert.ErrorContains(t, VerifyMigration(from, to), "ECS client key pair does not match")
+
+	_, err = Migrate(ctx, from, to)
+	require.NoError(t, err)
+
+	// extra records in the destination are fine
+	require.NoError(t, to.SaveEcsBearerToken(ctx, "bearer-token"))
+	require.NoError(t, to.SaveEcsSlot(ctx, "Extra", EcsSlot{}))
+	assert.NoError(t, VerifyMigration(from, to))
+}
+
+func TestWipe(t *testing.T) {
+	ctx := context.Background()
+	from, to, _ := newMigrateTestStores(t)
+
+	assert.NoError(t, Wipe(ctx, from))
+	assert.Empty(t, from.ListRegisterClientData())
+	assert.Empty(t, from.ListCreateTokenResponses())
+	assert.Empty(t, from.ListRoleCredentials())
+	assert.Empty(t, from.ListStaticCredentials())
+	assert.Empty(t, from.ListEcsSlots())
+	assert.Empty(t, from.ListEcsTokens())
+	token, err := from.GetEcsBearerToken()
+	assert.NoError(t, err)
+	assert.Empty(t, token)
+	key, cert, err := getEcsSslKeyPair(from)
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+	assert.Empty(t, cert)
+	key, cert, err = getEcsCAKeyPair(from)
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+	assert.Empty(t, cert)
+	ca, err := from.GetEcsClientCA()
+	assert.NoError(t, err)
+	assert.Empty(t, ca)
+	key, cert, err = getEcsClientKeyPair(from)
+	assert.NoError(t, err)
+	assert.Empty(t, key)
+	assert.Empty(t, cert)
+
+	// nothing left to migrate
+	r, err := Migrate(ctx, from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.Total())
+
+	// wiping an empty store is fine
+	assert.NoError(t, Wipe(ctx, to))
+}
@@ -225,6 +225,10 @@ func (op *OnePasswordStore) DeleteRegisterClientData(ctx context.Context, region
 	return op.saveStorageData(ctx)
 }
 
+func (op *OnePasswordStore) ListRegisterClientData() []string {
+	return listKeys(op.cache.RegisterClientData, REGISTER_CLIENT_DATA_PREFIX+":")
+}
+
 func (op *OnePasswordStore) SaveCreateTokenResponse(ctx context.Context, key string, token CreateTokenResponse) error {
 	k := fmt.Sprintf("%s:%s", CREATE_TOKEN_RESPONSE_PREFIX, key)
 	op.cache.CreateTokenResponse[k] = token
@@ -250,6 +254,10 @@ func (op *OnePasswordStore) DeleteCreateTokenResponse(ctx context.Context, key s
 	return op.saveStorageData(ctx)
 }
 
+func (op *OnePasswordStore) ListCreateTokenResponses() []string {
+	return listKeys(op.cache.CreateTokenResponse, CREATE_TOKEN_RESPONSE_PREFIX+":")
+}
+
 func (op *OnePasswordStore) SaveRoleCredentials(ctx context.Context, arn string, token RoleCredentials) error {
 	op.cache.RoleCredentials[arn] = token
 	return op.saveStorageData(ctx)
@@ -272,6 +280,10 @@ func (op *OnePasswordStore) DeleteRoleCredentials(ctx context.Context, arn strin
 	return op.saveStorageData(ctx)
 }
 
+func (op *OnePasswordStore) ListRoleCredentials() []string {
+	return listKeys(op.cache.RoleCredentials, "")
+}
+
 func (op *OnePasswordStore) SaveStaticCredentials(ctx context.Context, arn string, creds StaticCredentials) error {
 	op.cache.StaticCredentials[arn] = creds
 	return op.saveStorageData(ctx)
@@ -341,3 +353,124 @@ func (op *OnePasswordStore) DeleteEcsSslKeyPair(ctx context.Context) error {
 	op.cache.EcsPrivateKey = ""
 	return op.saveStorageData(ctx)
 }
+
+func (op *OnePasswordStore) SaveEcsCAKeyPair(ctx context.Context, privateKey, cert []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, cert); err != nil {
+		return err
+	}
+	op.cache.EcsCAKey = string(privateKey)
+	op.cache.EcsCACert = string(cert)
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) GetEcsCACert() (string, error) {
+	return op.cache.EcsCACert, nil
+}
+
+func (op *OnePasswordStore) GetEcsCAKey() (string, error) {
+	return op.cache.EcsCAKey, nil
+}
+
+func (op *OnePasswordStore) DeleteEcsCAKeyPair(ctx context.Context) error {
+	op.cache.EcsCAKey = ""
+	op.cache.EcsCACert = ""
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) SaveEcsClientCA(ctx context.Context, caCert []byte) error {
+	if err := ValidateSSLCertificate(caCert); err != nil {
+		return err
+	}
+	op.cache.EcsClientCA = string(caCert)
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) GetEcsClientCA() (string, error) {
+	return op.cache.EcsClientCA, nil
+}
+
+func (op *OnePasswordStore) DeleteEcsClientCA(ctx context.Context) error {
+	op.cache.EcsClientCA = ""
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) SaveEcsClientKeyPair(ctx context.Context, privateKey, certChain []byte) error {
+	if err := ValidateSSLKeyPair(privateKey, certChain); err != nil {
+		return err
+	}
+	op.cache.EcsClientKey = string(privateKey)
+	op.cache.EcsClientCertChain = string(certChain)
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) GetEcsClientCert() (string, error) {
+	return op.cache.EcsClientCertChain, nil
+}
+
+func (op *OnePasswordStore) GetEcsClientKey() (string, error) {
+	return op.cache.EcsClientKey, nil
+}
+
+func (op *OnePasswordStore) DeleteEcsClientKeyPair(ctx context.Context) error {
+	op.cache.EcsClientKey = ""
+	op.cache.EcsClientCertChain = ""
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) SaveEcsSlot(ctx context.Context, name string, slot EcsSlot) error {
+	if op.cache.EcsSlots == nil {
+		op.cache.EcsSlots = map[string]EcsSlot{}
+	}
+	op.cache.EcsSlots[name] = slot
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) GetEcsSlot(name string, slot *EcsSlot) error {
+	v, ok := op.cache.EcsSlots[name]
+	if !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	*slot = v
+	return nil
+}
+
+func (op *OnePasswordStore) DeleteEcsSlot(ctx context.Context, name string) error {
+	if _, ok := op.cache.EcsSlots[name]; !ok {
+		return fmt.Errorf("no EcsSlot for %s", name)
+	}
+	delete(op.cache.EcsSlots, name)
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) ListEcsSlots() []string {
+	return listKeys(op.cache.EcsSlots, "")
+}
+
+func (op *OnePasswordStore) SaveEcsToken(ctx context.Context, name string, token EcsToken) error {
+	if op.cache.EcsTokens == nil {
+		op.cache.EcsTokens = map[string]EcsToken{}
+	}
+	op.cache.EcsTokens[name] = token
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) GetEcsToken(name string, token *EcsToken) error {
+	v, ok := op.cache.EcsTokens[name]
+	if !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	*token = v
+	return nil
+}
+
+func (op *OnePasswordStore) DeleteEcsToken(ctx context.Context, name string) error {
+	if _, ok := op.cache.EcsTokens[name]; !ok {
+		return fmt.Errorf("no EcsToken for %s", name)
+	}
+	delete(op.cache.EcsTokens, name)
+	return op.saveStorageData(ctx)
+}
+
+func (op *OnePasswordStore) ListEcsTokens() []string {
+	return listKeys(op.cache.EcsTokens, "")
+}
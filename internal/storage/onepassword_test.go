@@ -155,12 +155,14 @@ func (suite *OnePasswordSuite) TestRegisterClientData() {
 		TokenEndpoint:         "https://example.com/token",
 	}
 	assert.NoError(t, suite.store.SaveRegisterClientData(context.Background(), "us-east-1", rcd))
+	assert.Equal(t, []string{"us-east-1"}, suite.store.ListRegisterClientData())
 
 	rcd2 := RegisterClientData{}
 	assert.NoError(t, suite.store.GetRegisterClientData("us-east-1", &rcd2))
 	assert.Equal(t, rcd, rcd2)
 
 	assert.NoError(t, suite.store.DeleteRegisterClientData(context.Background(), "us-east-1"))
+	assert.Empty(t, suite.store.ListRegisterClientData())
 	assert.Error(t, suite.store.GetRegisterClientData("us-east-1", &rcd2))
 	assert.Error(t, suite.store.DeleteRegisterClientData(context.Background(), "missing"))
 }
@@ -176,12 +178,14 @@ func (suite *OnePasswordSuite) TestCreateTokenResponse() {
 		TokenType:    "Bearer",
 	}
 	assert.NoError(t, suite.store.SaveCreateTokenResponse(context.Background(), "mykey", ctr))
+	assert.Equal(t, []string{"mykey"}, suite.store.ListCreateTokenResponses())
 
 	ctr2 := CreateTokenResponse{}
 	assert.NoError(t, suite.store.GetCreateTokenResponse("mykey", &ctr2))
 	assert.Equal(t, ctr, ctr2)
 
 	assert.NoError(t, suite.store.DeleteCreateTokenResponse(context.Background(), "mykey"))
+	assert.Empty(t, suite.store.ListCreateTokenResponses())
 	assert.Error(t, suite.store.GetCreateTokenResponse("mykey", &ctr2))
 	assert.Error(t, suite.store.DeleteCreateTokenResponse(context.Background(), "missing"))
 }
@@ -199,12 +203,14 @@ func (suite *OnePasswordSuite) TestRoleCredentials() {
 	arn := "arn:aws:iam::123456789012:role/MyRole"
 
 	assert.NoError(t, suite.store.SaveRoleCredentials(context.Background(), arn, rc))
+	assert.Equal(t, []string{arn}, suite.store.ListRoleCredentials())
 
 	rc2 := RoleCredentials{}
 	assert.NoError(t, suite.store.GetRoleCredentials(arn, &rc2))
 	assert.Equal(t, rc, rc2)
 
 	assert.NoError(t, suite.store.DeleteRoleCredentials(context.Background(), arn))
+	assert.Empty(t, suite.store.ListRoleCredentials())
 	assert.Error(t, suite.store.GetRoleCredentials(arn, &rc2))
 	assert.Error(t, suite.store.DeleteRoleCredentials(context.Background(), "arn:aws:iam::000:role/Missing"))
 }
@@ -253,6 +259,52 @@ func (suite *OnePasswordSuite) TestEcsBearerToken() {
 	assert.Empty(t, token)
 }
 
+func (suite *OnePasswordSuite) TestEcsSlots() { //nolint:dupl
+	t := suite.T()
+	slot := EcsSlot{
+		ProfileName: "123456789012:FooBar",
+		SSOName:     "Default",
+		Creds: RoleCredentials{ //nolint:gosec
+			AccountId:       123456789012,
+			RoleName:        "FooBar",
+			AccessKeyId:     "AKID",
+			SecretAccessKey: "SECRET",
+			SessionToken:    "TOKEN",
+		},
+	}
+	assert.Empty(t, suite.store.ListEcsSlots())
+
+	assert.NoError(t, suite.store.SaveEcsSlot(context.Background(), "FooBar", slot))
+	assert.Equal(t, []string{"FooBar"}, suite.store.ListEcsSlots())
+
+	slot2 := EcsSlot{}
+	assert.NoError(t, suite.store.GetEcsSlot("FooBar", &slot2))
+	assert.Equal(t, slot, slot2)
+
+	assert.NoError(t, suite.store.DeleteEcsSlot(context.Background(), "FooBar"))
+	assert.Empty(t, suite.store.ListEcsSlots())
+	assert.Error(t, suite.store.GetEcsSlot("FooBar", &slot2))
+	assert.Error(t, suite.store.DeleteEcsSlot(context.Background(), "FooBar"))
+}
+
+func (suite *OnePasswordSuite) TestEcsTokens() { //nolint:dupl
+	t := suite.T()
+	token := EcsToken{Token: "not a real token", Admin: true}
+	assert.Empty(t, suite.store.ListEcsTokens())
+
+	assert.NoError(t, suite.store.SaveEcsToken(context.Background(), "admin", token))
+	assert.Equal(t, []string{"admin"}, suite.store.ListEcsTokens())
+
+	token2 := EcsToken{}
+	assert.NoError(t, suite.store.GetEcsToken("admin", &token2))
+	assert.Equal(t, token, token2)
+
+	assert.NoError(t, suite.store.DeleteEcsToken(context.Background(), "admin"))
+	assert.Empty(t, suite.store.ListEcsTokens())
+	assert.Error(t, suite.store.GetEcsToken("admin", &token2))
+	assert.Error(t, suite.store.DeleteEcsToken(context.Background(), "admin"))
+}
+
 func (suite *OnePasswordSuite) TestEcsSslKeyPair() { //nolint:dupl
 	t := suite.T()
 
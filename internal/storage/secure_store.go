@@ -28,15 +28,18 @@ type SecureStorage interface {
 	SaveRegisterClientData(ctx context.Context, region string, client RegisterClientData) error
 	GetRegisterClientData(region string, client *RegisterClientData) error
 	DeleteRegisterClientData(ctx context.Context, region string) error
+	ListRegisterClientData() []string
 
 	SaveCreateTokenResponse(ctx context.Context, key string, token CreateTokenResponse) error
 	GetCreateTokenResponse(key string, token *CreateTokenResponse) error
 	DeleteCreateTokenResponse(ctx context.Context, key string) error
+	ListCreateTokenResponses() []string
 
 	// Temporary STS creds
 	SaveRoleCredentials(ctx context.Context, arn string, token RoleCredentials) error
 	GetRoleCredentials(arn string, token *RoleCredentials) error
 	DeleteRoleCredentials(ctx context.Context, arn string) error
+	ListRoleCredentials() []string
 
 	// Static API creds
 	SaveStaticCredentials(ctx context.Context, arn string, creds StaticCredentials) error
@@ -49,9 +52,37 @@ type SecureStorage interface {
 	GetEcsBearerToken() (string, error)
 	DeleteEcsBearerToken(ctx context.Context) error
 
+	// ECS Server named bearer tokens with a limited scope
+	SaveEcsToken(ctx context.Context, name string, token EcsToken) error
+	GetEcsToken(name string, token *EcsToken) error
+	DeleteEcsToken(ctx context.Context, name string) error
+	ListEcsTokens() []string
+
 	// ECS Server SSL Cert
 	SaveEcsSslKeyPair(ctx context.Context, privateKey []byte, certChain []byte) error
 	DeleteEcsSslKeyPair(ctx context.Context) error
 	GetEcsSslCert() (string, error)
 	GetEcsSslKey() (string, error)
+
+	// ECS Server CA generated by 'setup ecs ssl --generate' to sign the SSL cert
+	SaveEcsCAKeyPair(ctx context.Context, privateKey []byte, cert []byte) error
+	GetEcsCACert() (string, error)
+	GetEcsCAKey() (string, error)
+	DeleteEcsCAKeyPair(ctx context.Context) error
+
+	// ECS Server mutual TLS: the CA used to verify client certificates and
+	// the client cert/key presented by our ECS client commands
+	SaveEcsClientCA(ctx context.Context, caCert []byte) error
+	GetEcsClientCA() (string, error)
+	DeleteEcsClientCA(ctx context.Context) error
+	SaveEcsClientKeyPair(ctx context.Context, privateKey []byte, certChain []byte) error
+	GetEcsClientCert() (string, error)
+	GetEcsClientKey() (string, error)
+	DeleteEcsClientKeyPair(ctx context.Context) error
+
+	// ECS Server persisted slots.  The default slot uses an empty name.
+	SaveEcsSlot(ctx context.Context, name string, slot EcsSlot) error
+	GetEcsSlot(name string, slot *EcsSlot) error
+	DeleteEcsSlot(ctx context.Context, name string) error
+	ListEcsSlots() []string
 }
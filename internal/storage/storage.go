@@ -19,11 +19,13 @@ package storage
  */
 
 import (
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/synfinatic/aws-sso-cli/internal/awsparse"
@@ -135,14 +137,19 @@ type RoleCredentials struct { // Cache
 	AccountId       int64  `json:"accountId"`
 	AccessKeyId     string `json:"accessKeyId"`
 	SecretAccessKey string `json:"secretAccessKey"`
-	SessionToken    string `json:"sessionToken"` // nolint:gosec
-	Expiration      int64  `json:"expiration"`   // not in seconds, but millisec
-	RoleChaining    bool   `json:"roleChaining"` // true if we used AssumeRole to get these creds
+	SessionToken    string `json:"sessionToken"`        // nolint:gosec
+	Expiration      int64  `json:"expiration"`          // not in seconds, but millisec
+	RoleChaining    bool   `json:"roleChaining"`        // true if we used AssumeRole to get these creds
+	Partition       string `json:"partition,omitempty"` // AWS partition of the role; empty is `aws`
+	IAMUser         bool   `json:"iamUser,omitempty"`   // true if RoleName is an IAM User with StaticCredentials
 }
 
-// RoleArn returns the ARN for the role
+// RoleArn returns the ARN for the role, or the IAM User
 func (r *RoleCredentials) RoleArn() string {
-	return awsparse.MakeRoleARN(r.AccountId, r.RoleName)
+	if r.IAMUser {
+		return awsparse.MakeUserARN(r.Partition, r.AccountId, r.RoleName)
+	}
+	return awsparse.MakeRoleARN(r.Partition, r.AccountId, r.RoleName)
 }
 
 // ExpireEpoch return seconds since unix epoch when we expire
@@ -198,6 +205,22 @@ func (r *RoleCredentials) Validate() error {
 	return nil
 }
 
+// EcsSlot is a slot of the ECS Server which is persisted across restarts
+type EcsSlot struct {
+	ProfileName string          `json:"ProfileName"`
+	SSOName     string          `json:"SSOName,omitempty"`
+	Creds       RoleCredentials `json:"Creds"`
+}
+
+// EcsToken is a named bearer token for the ECS Server which is limited in
+// what it can access.  Admin tokens can load & unload credentials in every
+// slot, otherwise the token can only read the credentials in Slot.
+type EcsToken struct {
+	Token string `json:"Token"`
+	Admin bool   `json:"Admin,omitempty"`
+	Slot  string `json:"Slot,omitempty"`
+}
+
 type StaticCredentials struct { // Cache and storage
 	Profile         string            `json:"Profile" header:"Profile"`
 	UserName        string            `json:"userName" header:"UserName"`
@@ -205,6 +228,7 @@ type StaticCredentials struct { // Cache and storage
 	AccessKeyId     string            `json:"accessKeyId"`
 	SecretAccessKey string            `json:"secretAccessKey"`
 	Tags            map[string]string `json:"Tags" header:"Tags"`
+	Partition       string            `json:"partition,omitempty"` // AWS partition of the user; empty is `aws`
 }
 
 // GetHeader is required for GenerateTable()
@@ -213,9 +237,9 @@ func (sc StaticCredentials) GetHeader(fieldName string) (string, error) {
 	return gotable.GetHeaderTag(v, fieldName)
 }
 
-// RoleArn returns the ARN for the role
+// UserArn returns the ARN for the IAM User
 func (sc *StaticCredentials) UserArn() string {
-	return awsparse.MakeUserARN(sc.AccountId, sc.UserName)
+	return awsparse.MakeUserARN(sc.Partition, sc.AccountId, sc.UserName)
 }
 
 // AccountIdStr returns our AccountId as a string
@@ -230,6 +254,9 @@ func (sc *StaticCredentials) AccountIdStr() string {
 // ValidateSSLCertificate ensures we have a valid SSL certificate
 func ValidateSSLCertificate(certChain []byte) error {
 	block, _ := pem.Decode(certChain)
+	if block == nil {
+		return fmt.Errorf("certificate chain file is not a valid PEM file")
+	}
 
 	if _, err := x509.ParseCertificate(block.Bytes); err != nil {
 		return fmt.Errorf("certificate chain file is not a valid certificate: %w", err)
@@ -244,9 +271,38 @@ func ValidateSSLPrivateKey(privateKey []byte) error {
 		return nil
 	}
 	block, _ := pem.Decode(privateKey)
+	if block == nil {
+		return fmt.Errorf("private key file is not a valid PEM file")
+	}
 
 	if _, err := x509.ParsePKCS8PrivateKey(block.Bytes); err != nil {
 		return fmt.Errorf("private key file is not a valid private key: %s", err)
 	}
 	return nil
 }
+
+// ValidateSSLKeyPair ensures we have a matching SSL private key and certificate
+func ValidateSSLKeyPair(privateKey, certChain []byte) error {
+	if len(privateKey) == 0 {
+		return fmt.Errorf("missing private key")
+	}
+	if err := ValidateSSLCertificate(certChain); err != nil {
+		return err
+	}
+	if err := ValidateSSLPrivateKey(privateKey); err != nil {
+		return err
+	}
+	if _, err := tls.X509KeyPair(certChain, privateKey); err != nil {
+		return fmt.Errorf("private key does not match the certificate: %w", err)
+	}
+	return nil
+}
+
+// listKeys returns the keys of m with the given prefix removed
+func listKeys[T any](m map[string]T, prefix string) []string {
+	ret := make([]string, 0, len(m))
+	for k := range m {
+		ret = append(ret, strings.TrimPrefix(k, prefix))
+	}
+	return ret
+}
@@ -19,12 +19,14 @@ package storage
  */
 
 import (
+	"bytes"
 	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/synfinatic/aws-sso-cli/internal/logger"
 	"github.com/synfinatic/flexlog"
 	testlogger "github.com/synfinatic/flexlog/test"
 )
@@ -164,6 +166,12 @@ func TestRoleArn(t *testing.T) {
 	}
 	assert.Equal(t, "arn:aws:iam::012344553243:role/foobar", x.RoleArn())
 	assert.Equal(t, "012344553243", x.AccountIdStr())
+
+	x.Partition = "aws-us-gov"
+	assert.Equal(t, "arn:aws-us-gov:iam::012344553243:role/foobar", x.RoleArn())
+
+	x.IAMUser = true
+	assert.Equal(t, "arn:aws-us-gov:iam::012344553243:user/foobar", x.RoleArn())
 }
 
 func TestExpireEpoch(t *testing.T) {
@@ -192,6 +200,9 @@ func TestGetArn(t *testing.T) {
 		AccountId: 123456789012,
 	}
 	assert.Equal(t, "arn:aws:iam::123456789012:user/foobar", x.UserArn())
+
+	x.Partition = "aws-cn"
+	assert.Equal(t, "arn:aws-cn:iam::123456789012:user/foobar", x.UserArn())
 }
 
 func TestGetAccountIdStr(t *testing.T) {
@@ -292,3 +303,51 @@ func TestValidateSSLPrivateKey(t *testing.T) {
 	err = ValidateSSLPrivateKey(key)
 	assert.Error(t, err)
 }
+
+func TestSecretsRedactedInLogs(t *testing.T) {
+	buf := &bytes.Buffer{}
+	l := logger.WithRedaction(flexlog.NewLogger(flexlog.NewJSON, buf, false, flexlog.LevelTrace, false))
+
+	creds := RoleCredentials{
+		RoleName:        "FooBar",
+		AccountId:       123456789012,
+		AccessKeyId:     "ASIAEXAMPLE",
+		SecretAccessKey: "role-secret-access-key",
+		SessionToken:    "role-session-token",
+	}
+	token := CreateTokenResponse{
+		AccessToken:  "sso-access-token",
+		IdToken:      "sso-id-token",
+		RefreshToken: "sso-refresh-token",
+		TokenType:    "Bearer",
+	}
+	client := RegisterClientData{
+		ClientId:     "client-id",
+		ClientSecret: "client-secret",
+	}
+	static := StaticCredentials{
+		Profile:         "static",
+		AccessKeyId:     "AKIAEXAMPLE",
+		SecretAccessKey: "static-secret-access-key",
+	}
+	slot := EcsSlot{ProfileName: "slot", Creds: creds}
+
+	for _, v := range []any{creds, &token, client, static, slot} {
+		l.Debug("struct", "value", v)
+		l.Trace("dump", "value", logger.Sdump(v))
+	}
+
+	output := buf.String()
+	for _, secret := range []string{
+		"role-secret-access-key", "role-session-token", "sso-access-token", "sso-id-token",
+		"sso-refresh-token", "client-secret", "static-secret-access-key",
+	} {
+		assert.NotContains(t, output, secret)
+	}
+	assert.Contains(t, output, "ASIAEXAMPLE")
+	assert.Contains(t, output, "client-id")
+
+	// the originals are unchanged
+	assert.Equal(t, "role-session-token", creds.SessionToken)
+	assert.Equal(t, "sso-access-token", token.AccessToken)
+}
@@ -29,6 +29,7 @@ import (
 
 	"github.com/atotto/clipboard"
 	"github.com/aymanbagabas/go-osc52/v2"
+	"github.com/skip2/go-qrcode"
 	"github.com/skratchdot/open-golang/open"
 	"github.com/synfinatic/aws-sso-cli/internal/fileutils"
 	"github.com/synfinatic/aws-sso-cli/internal/logger"
@@ -97,6 +98,7 @@ const (
 	Exec             Action = "exec"       // Exec comand
 	Open             Action = "open"       // auto-open in default or specified browser
 	OSC52            Action = "ansi-osc52" // copy to terminal via ANSI OSC52
+	QRCode           Action = "qrcode"     // print a QR code & url to stderr
 	GrantedContainer Action = "granted-containers"
 	OpenUrlContainer Action = "open-url-in-container"
 )
@@ -108,7 +110,7 @@ func (u Action) IsContainer() bool {
 // GetConfigProfileAction returns the ConfigProfilesAction for the given Action
 func (u Action) GetConfigProfilesAction() ConfigProfilesAction {
 	switch u {
-	case "print", "printurl", "":
+	case "print", "printurl", "qrcode", "":
 		return ConfigProfilesOpen
 	default:
 		return ConfigProfilesAction(u)
@@ -157,6 +159,7 @@ func NewAction(action string) (Action, error) {
 		"open":                  Open,
 		"print":                 Print,
 		"printurl":              PrintUrl,
+		"qrcode":                QRCode,
 		"granted-containers":    GrantedContainer,
 		"open-url-in-container": OpenUrlContainer,
 	}
@@ -249,6 +252,12 @@ func (h *HandleUrl) Open() error {
 	case PrintUrl:
 		fmt.Fprintf(printWriter, "%s\n", h.Url)
 
+	case QRCode:
+		var qr string
+		if qr, err = QRCodeString(h.Url); err == nil {
+			fmt.Fprintf(printWriter, "%s%s\n%s%s", h.PreMsg, qr, h.Url, h.PostMsg)
+		}
+
 	case Open:
 		switch h.Browser {
 		case "":
@@ -270,6 +279,17 @@ func (h *HandleUrl) Open() error {
 	return err
 }
 
+// QRCodeString renders the url as a QR code using Unicode half blocks so
+// it can be scanned from the terminal by a phone.  The light modules are drawn
+// in the foreground color, so this assumes a dark terminal background.
+func QRCodeString(url string) (string, error) {
+	q, err := qrcode.New(url, qrcode.Low)
+	if err != nil {
+		return "", fmt.Errorf("unable to generate QR code: %s", err.Error())
+	}
+	return q.ToSmallString(false), nil
+}
+
 // selectElement selects a deterministic pseudo-random option given a string
 // as the seed
 func selectElement(seed string, options []string) string {
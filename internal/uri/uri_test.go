@@ -21,16 +21,33 @@ package uri
 import (
 	"bytes"
 	"encoding/base64"
+	"flag"
 	"fmt"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var updateGolden = flag.Bool("update", false, "update the golden files in testdata")
+
+// assertGolden compares output to testdata/<name>.golden
+func assertGolden(t *testing.T, name, output string) {
+	t.Helper()
+	golden := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(golden, []byte(output), 0600))
+	}
+	expected, err := os.ReadFile(golden) // nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), output)
+}
+
 var checkValue string
 var checkBrowser string
 
@@ -103,6 +120,35 @@ func TestHandleUrlPrintUrl(t *testing.T) {
 	assert.Equal(t, "bar\n", printWriter.(*bytes.Buffer).String())
 }
 
+func TestHandleUrlQRCode(t *testing.T) {
+	noCommand := []string{}
+	printWriter = new(bytes.Buffer)
+	defer func() { printWriter = os.Stderr }()
+
+	// device code verification URL
+	h := NewHandleUrl(QRCode, "https://device.sso.us-east-1.amazonaws.com/?user_code=ABCD-EFGH", "browser", noCommand)
+	assert.NotNil(t, h)
+	assert.NoError(t, h.Open())
+	assertGolden(t, "qrcode_device", printWriter.(*bytes.Buffer).String())
+
+	// PKCE authorization URL
+	printWriter = new(bytes.Buffer)
+	h = NewHandleUrl(QRCode, "https://oidc.us-east-1.amazonaws.com/authorize?client_id=abcdefghijklmnopqrstuvwxyz"+
+		"&code_challenge=E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM&code_challenge_method=S256"+
+		"&redirect_uri=http%3A%2F%2F127.0.0.1%3A54321&response_type=code&scopes=sso%3Aaccount%3Aaccess"+
+		"&state=0123456789abcdef", "browser", noCommand)
+	h.PreMsg = "pre\n"
+	h.PostMsg = "\npost\n"
+	assert.NoError(t, h.Open())
+	assertGolden(t, "qrcode_pkce", printWriter.(*bytes.Buffer).String())
+
+	// too big for a QR code
+	printWriter = new(bytes.Buffer)
+	h = NewHandleUrl(QRCode, "https://example.com/?"+string(bytes.Repeat([]byte("a"), 5000)), "browser", noCommand)
+	assert.ErrorContains(t, h.Open(), "unable to generate QR code")
+	assert.Empty(t, printWriter.(*bytes.Buffer).String())
+}
+
 func TestHandleUrl(t *testing.T) {
 	t.Parallel()
 	noCommand := []string{}
@@ -244,6 +290,10 @@ func TestNewAction(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, Clip, a)
 
+	a, err = NewAction("qrcode")
+	assert.NoError(t, err)
+	assert.Equal(t, QRCode, a)
+
 	a, err = NewAction("missing")
 	assert.Error(t, err)
 	assert.Equal(t, Action(Open), a)
@@ -397,6 +447,9 @@ func TestGetConfigProfilesAction(t *testing.T) {
 
 	action = Print
 	assert.Equal(t, ConfigProfilesOpen, action.GetConfigProfilesAction())
+
+	action = QRCode
+	assert.Equal(t, ConfigProfilesOpen, action.GetConfigProfilesAction())
 }
 
 func TestExecWithUrl(t *testing.T) {